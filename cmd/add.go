@@ -10,6 +10,8 @@ import (
 	"github.com/techishthoughts/gitshift/internal/config"
 	"github.com/techishthoughts/gitshift/internal/git"
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/pkg/platform"
 )
 
 // addCmd represents the add command
@@ -24,7 +26,7 @@ Supported Platforms:
 - Bitbucket (coming soon)
 - Custom Git platforms
 
-REQUIRED FIELDS: alias, name, email, github-username
+REQUIRED FIELDS: alias, name, email, github-username (GitHub accounts only)
 The command automatically detects if all required information is provided via flags
 and runs in non-interactive mode. If any required field is missing, it will prompt
 interactively unless --non-interactive is specified.
@@ -38,7 +40,12 @@ Examples:
   gitshift add gitlab-work --name "Work User" --email "work@company.com" --github-username "workuser"
 
   # Self-hosted GitLab
-  gitshift add company --name "Work" --email "work@company.com" --github-username "workuser" --ssh-key "~/.ssh/id_rsa_work"
+  gitshift add company --name "Work" --email "work@company.com" --github-username "workuser" --ssh-key "~/.ssh/id_rsa_work" \
+    --platform gitlab --domain git.internal.corp
+
+  # Any other Git server, with its API endpoint
+  gitshift add internal --name "Work" --email "work@company.com" --platform custom \
+    --domain git.internal.corp --api-endpoint https://git.internal.corp/api/v1
 
   # GitHub Enterprise
   gitshift add enterprise --name "Enterprise User" --email "user@company.com" --github-username "user"
@@ -70,9 +77,27 @@ Examples:
 		setDefault, _ := cmd.Flags().GetBool("default")
 		nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
 		enableGPG, _ := cmd.Flags().GetBool("enable-gpg")
+		platformName, _ := cmd.Flags().GetString("platform")
+		domain, _ := cmd.Flags().GetString("domain")
+		apiEndpoint, _ := cmd.Flags().GetString("api-endpoint")
+
+		if !models.IsKnownPlatform(platformName) {
+			return fmt.Errorf("unknown platform '%s', expected one of: %s", platformName, strings.Join(models.Platforms, ", "))
+		}
+		if platformName == "custom" && domain == "" {
+			return fmt.Errorf("--domain is required for custom platforms")
+		}
+		if apiEndpoint != "" {
+			if err := models.ValidateAPIEndpoint(apiEndpoint); err != nil {
+				return err
+			}
+		}
+
+		// Only GitHub accounts need a username; other platforms can be used over SSH alone
+		usernameRequired := platformName == "github"
 
 		// Check if all required fields are provided via flags
-		allRequiredProvided := (len(args) > 0 || alias != "") && name != "" && email != "" && githubUsername != ""
+		allRequiredProvided := (len(args) > 0 || alias != "") && name != "" && email != "" && (githubUsername != "" || !usernameRequired)
 
 		// Determine if we should run in interactive mode
 		useInteractiveMode := !nonInteractive && !allRequiredProvided
@@ -102,7 +127,7 @@ Examples:
 			}
 		}
 
-		if githubUsername == "" {
+		if githubUsername == "" && usernameRequired {
 			if useInteractiveMode {
 				githubUsername = promptForInput("GitHub username (without @): ")
 			} else {
@@ -133,6 +158,16 @@ Examples:
 						return fmt.Errorf("account creation cancelled")
 					}
 				}
+			} else if usedBy := ssh.NewManager().AccountsUsingKey(cmd.Context(), sshKey, otherAccounts(configManager, alias)); len(usedBy) > 0 {
+				// A copy of another account's key lets either account act as the other
+				fmt.Printf("⚠️  Warning: this SSH key also backs account(s) %s\n", strings.Join(usedBy, ", "))
+				fmt.Println("   The accounts won't be isolated from each other; consider 'gitshift ssh-keygen' for a key of its own.")
+				if useInteractiveMode {
+					confirmation := promptForInput("Continue anyway? [y/N]: ")
+					if confirmation != "y" && confirmation != "Y" && confirmation != "yes" && confirmation != "Yes" {
+						return fmt.Errorf("account creation cancelled")
+					}
+				}
 			}
 		}
 
@@ -141,9 +176,30 @@ Examples:
 
 		// Create the account
 		account := models.NewAccount(alias, name, email, sshKey)
-		account.GitHubUsername = githubUsername
+		if platformName == "github" {
+			account.GitHubUsername = githubUsername
+		} else {
+			account.Platform = platformName
+			account.Username = githubUsername
+		}
+		account.Domain = domain
+		account.APIEndpoint = apiEndpoint
 		account.Description = description
 
+		// Check that a self-hosted API endpoint is reachable (only warn, like the SSH key check)
+		if endpoint := account.GetAPIEndpoint(); endpoint != "" && (apiEndpoint != "" || domain != "") {
+			if err := platform.CheckEndpoint(cmd.Context(), endpoint); err != nil {
+				fmt.Printf("⚠️  Warning: %v\n", err)
+				fmt.Println("   Account will be created, but API features (tokens, key upload) may not work.")
+				if useInteractiveMode {
+					confirmation := promptForInput("Continue anyway? [y/N]: ")
+					if confirmation != "y" && confirmation != "Y" && confirmation != "yes" && confirmation != "Yes" {
+						return fmt.Errorf("account creation cancelled")
+					}
+				}
+			}
+		}
+
 		// Handle GPG signing preference
 		if enableGPG {
 			account.GPGEnabled = true
@@ -155,6 +211,7 @@ Examples:
 		if err := configManager.AddAccount(account); err != nil {
 			return fmt.Errorf("failed to add account: %w", err)
 		}
+		refreshHostAliases(configManager, account)
 
 		// Set as default if requested or if it's the first account
 		if setDefault || len(configManager.GetConfig().Accounts) == 1 {
@@ -162,11 +219,24 @@ Examples:
 				return fmt.Errorf("failed to set as current account: %w", err)
 			}
 		}
+		if setDefault {
+			if err := configManager.SetDefaultAccount(alias); err != nil {
+				return fmt.Errorf("failed to set as default account: %w", err)
+			}
+		}
 
 		fmt.Printf("✅ Successfully added account '%s'\n", alias)
 		fmt.Printf("   Name: %s\n", name)
 		fmt.Printf("   Email: %s\n", email)
-		fmt.Printf("   GitHub: @%s\n", githubUsername)
+		if githubUsername != "" {
+			fmt.Printf("   Username: @%s\n", githubUsername)
+		}
+		if account.GetPlatform() != "github" || domain != "" {
+			fmt.Printf("   Platform: %s (%s)\n", account.GetPlatform(), account.GetDomain())
+		}
+		if apiEndpoint != "" {
+			fmt.Printf("   API: %s\n", apiEndpoint)
+		}
 		if sshKey != "" {
 			fmt.Printf("   SSH Key: %s\n", sshKey)
 		}
@@ -189,6 +259,11 @@ func init() {
 	addCmd.Flags().BoolP("default", "", false, "Set as default account")
 	addCmd.Flags().Bool("non-interactive", false, "Run in non-interactive mode (no prompts)")
 	addCmd.Flags().Bool("enable-gpg", false, "Enable GPG commit signing for this account (requires GPG key)")
+	addCmd.Flags().String("platform", "github", "Git hosting platform: github, gitlab, bitbucket or custom")
+	addCmd.Flags().String("domain", "", "Platform domain for self-hosted installations (e.g. git.internal.corp)")
+	addCmd.Flags().String("api-endpoint", "", "API base URL when it isn't the platform's default (e.g. https://git.internal.corp/api/v4)")
+	_ = addCmd.RegisterFlagCompletionFunc("ssh-key", completeSSHKeyPath)
+	_ = addCmd.RegisterFlagCompletionFunc("platform", completePlatform)
 }
 
 // promptForInput prompts the user for input and returns the trimmed response
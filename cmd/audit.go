@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/audit"
+	"github.com/techishthoughts/gitshift/internal/config"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "📜 Inspect the audit log of account switches",
+	Long: `Every account switch appends the account, the fingerprint of its SSH key and
+the outcome to an audit log in the config directory, along with each SSH key
+loaded into the agent. Entries are chained by hash, so 'audit show' can tell
+when entries were edited, removed or reordered.`,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "📋 Show audit log entries and verify the log",
+	Long: `Show the entries of the audit log, oldest first, and verify its hash chain.
+The command fails when the log was tampered with.
+
+--since takes a duration back from now (24h, 30m), a date (2006-01-02) or an
+RFC 3339 timestamp.`,
+	Example: `  # Everything recorded in the last day
+  gitshift audit show --since 24h
+
+  # Switches to one account since a date, as JSON
+  gitshift audit show --account work --since 2026-01-01 --json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuditShow,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditShowCmd)
+
+	auditShowCmd.Flags().String("since", "", "Only show entries from this far back: a duration, date or RFC 3339 timestamp")
+	auditShowCmd.Flags().String("account", "", "Only show entries for this account")
+	auditShowCmd.Flags().Bool("json", false, "Output the entries as JSON")
+	_ = auditShowCmd.RegisterFlagCompletionFunc("account", completeAccountFlag)
+}
+
+// auditLog returns the audit log for entries recorded by command
+func auditLog(command string) *audit.Log {
+	homeDir, _ := os.UserHomeDir()
+	return audit.NewLog(filepath.Join(config.ConfigDir(homeDir), audit.LogFileName), command)
+}
+
+func runAuditShow(cmd *cobra.Command, args []string) error {
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	account, _ := cmd.Flags().GetString("account")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	var since time.Time
+	if sinceFlag != "" {
+		var err error
+		if since, err = parseSince(sinceFlag, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	log := auditLog("")
+	entries, err := log.Read()
+	if err != nil {
+		return err
+	}
+	// Verify the whole log: filtering first would hide where the chain breaks
+	verifyErr := audit.Verify(entries)
+
+	var shown []audit.Entry
+	for _, entry := range entries {
+		if entry.Time.Before(since) || (account != "" && entry.Account != account) {
+			continue
+		}
+		shown = append(shown, entry)
+	}
+
+	if jsonOutput {
+		if shown == nil {
+			shown = []audit.Entry{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(shown); err != nil {
+			return err
+		}
+	} else {
+		printAuditEntries(log.Path(), shown)
+	}
+
+	var chainErr *audit.ChainError
+	if errors.As(verifyErr, &chainErr) {
+		fmt.Fprintf(os.Stderr, "\n🚨 %v\n", chainErr)
+		return fmt.Errorf("audit log %s failed verification", log.Path())
+	}
+	return verifyErr
+}
+
+// printAuditEntries prints entries one per line in local time
+func printAuditEntries(path string, entries []audit.Entry) {
+	if len(entries) == 0 {
+		fmt.Printf("📭 No audit entries in %s\n", path)
+		return
+	}
+	for _, entry := range entries {
+		icon := "✅"
+		if entry.Outcome != audit.OutcomeSuccess {
+			icon = "❌"
+		}
+		fingerprint := entry.KeyFingerprint
+		if fingerprint == "" {
+			fingerprint = "-"
+		}
+		fmt.Printf("%s %s  %-8s  %-15s  %s  %s\n",
+			icon, entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Event, entry.Account, fingerprint, entry.Command)
+		if entry.Detail != "" {
+			fmt.Printf("    %s\n", entry.Detail)
+		}
+	}
+}
+
+// parseSince parses the --since value relative to now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration (24h), a date (2006-01-02) or an RFC 3339 timestamp", value)
+}
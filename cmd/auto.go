@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/git"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+)
+
+var autoCmd = &cobra.Command{
+	Use:   "auto",
+	Short: "🧭 Switch to the account that matches the repository's remote",
+	Long: `Pick the account for the current repository from its remote URL and switch to it.
+
+The account is chosen in this order:
+1. The account pinned in .gitshift.yaml, in the current directory or the
+   nearest parent that has one, so subprojects of a monorepo can pin their own
+2. The account whose host alias the remote uses (e.g. git@github.com-work:acme/api.git)
+3. The account with the most specific matching rule in match_rules
+
+Match rules are host[/owner[/repo]] glob patterns set per account in the config:
+
+  accounts:
+    work:
+      match_rules:
+        - github.com/acme-*
+        - gitlab.acme.com
+
+A rule with more path segments wins over a shorter one, then the rule with more
+literal characters. When accounts still tie, you choose among them when
+gitshift runs in a terminal; otherwise the default account is chosen, then the
+alphabetically first alias.
+
+To run it automatically after checkouts, call 'gitshift auto' from a
+post-checkout hook, or use 'gitshift auto --check' to only verify the active
+account.`,
+	Example: `  # Switch to the account matching origin
+  gitshift auto
+
+  # Only check that the active account matches (non-zero exit if not)
+  gitshift auto --check
+
+  # Use another remote
+  gitshift auto --remote upstream`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runAuto,
+}
+
+func init() {
+	rootCmd.AddCommand(autoCmd)
+
+	autoCmd.Flags().Bool("check", false, "Only report whether the active account matches; don't switch")
+	autoCmd.Flags().String("remote", "origin", "Remote whose URL selects the account")
+	autoCmd.Flags().Bool("dry-run", false, "Show the SSH config and agent changes without applying them")
+	autoCmd.Flags().Bool("offline", false, "Skip live SSH connection tests (no network access)")
+}
+
+// AutoSelection is the account chosen for a repository and why
+type AutoSelection struct {
+	Alias  string
+	Reason string
+	Tied   []string // aliases that matched as well as the selected one
+}
+
+func runAuto(cmd *cobra.Command, args []string) error {
+	check, _ := cmd.Flags().GetBool("check")
+	remote, _ := cmd.Flags().GetString("remote")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	offline, _ := cmd.Flags().GetBool("offline")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	repoRoot := repoGitValue(cmd.Context(), ".", "rev-parse", "--show-toplevel")
+	if repoRoot == "" {
+		return fmt.Errorf("not inside a Git repository")
+	}
+
+	remoteURL := repoGitValue(cmd.Context(), ".", "remote", "get-url", remote)
+	if remoteURL == "" {
+		return fmt.Errorf("repository has no '%s' remote", remote)
+	}
+
+	selection, err := selectAutoAccount(configManager, ".", remoteURL)
+	if err != nil {
+		return err
+	}
+	if selection == nil {
+		fmt.Printf("🤷 No account matches %s\n", remoteURL)
+		fmt.Printf("💡 Add match_rules to an account, or pin one in %s\n", config.ProjectConfigName)
+		return nil
+	}
+
+	fmt.Printf("🧭 %s → account '%s' (%s)\n", remoteURL, selection.Alias, selection.Reason)
+	if len(selection.Tied) > 0 {
+		fmt.Printf("⚠️  Also matched equally: %s\n", strings.Join(selection.Tied, ", "))
+		if !check && isInteractive() {
+			var candidates []*models.Account
+			for _, alias := range append([]string{selection.Alias}, selection.Tied...) {
+				if account, err := configManager.GetAccount(alias); err == nil {
+					candidates = append(candidates, account)
+				}
+			}
+			account, err := pickAccount("🧭 Which of these accounts is this repository's?", candidates)
+			if err != nil {
+				return err
+			}
+			selection.Alias = account.Alias
+		}
+	}
+
+	current := configManager.GetConfig().CurrentAccount
+	if current == selection.Alias {
+		fmt.Printf("✅ Already using account '%s'\n", current)
+		return nil
+	}
+
+	if check {
+		return fmt.Errorf("active account is '%s' but this repository expects '%s'; run 'gitshift auto' to switch", valueOrNone(current), selection.Alias)
+	}
+
+	return switchToAccount(cmd.Context(), configManager, selection.Alias, SwitchCommandOptions{
+		DryRun:  dryRun,
+		Offline: offline,
+		Command: cmd.CommandPath(),
+	})
+}
+
+// selectAutoAccount chooses the account for a repository: a .gitshift.yaml pin in dir or
+// one of its parents first, then a remote using an account's host alias, then the
+// account's match rules. It returns nil when nothing matches.
+func selectAutoAccount(configManager *config.Manager, dir, remoteURL string) (*AutoSelection, error) {
+	pinned, pinPath, err := configManager.ProjectAccount(dir)
+	if err != nil {
+		return nil, err
+	}
+	if pinned != nil {
+		return &AutoSelection{Alias: pinned.Alias, Reason: "pinned in " + pinPath}, nil
+	}
+
+	host, repoPath, err := git.ParseRemoteURL(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := configManager.ListAccounts()
+	for _, account := range accounts {
+		if host == ssh.HostAlias(account.GetDomain(), account.Alias) {
+			return &AutoSelection{Alias: account.Alias, Reason: "remote uses host alias " + host}, nil
+		}
+	}
+
+	match, tied := models.SelectAccountForRemote(accounts, host, repoPath)
+	if match == nil {
+		return nil, nil
+	}
+
+	selection := &AutoSelection{Alias: match.Account.Alias, Reason: "match rule " + match.Rule}
+	for _, account := range tied {
+		selection.Tied = append(selection.Tied, account.Alias)
+	}
+	return selection, nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <source-alias> <new-alias>",
+	Short: "🧬 Add an account based on an existing one",
+	Long: `Add an account that takes the name, platform, domain, API endpoint,
+description and isolation settings of an existing account.
+
+The new account needs its own email, username and SSH key, so the two accounts
+never act as the same identity: each must differ from the source account's,
+and the SSH key must not be used by any other account. Missing values are
+prompted for on a terminal and required as flags otherwise. Tokens, GPG keys,
+match rules and usage history are not copied.`,
+	Example: `  # Prompt for the email, username and SSH key
+  gitshift clone work work-oss
+
+  # Non-interactive
+  gitshift clone work work-oss --email dev@oss.example.com --username dev-oss \
+    --ssh-key ~/.ssh/id_ed25519_work_oss`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeAccountAlias,
+	SilenceUsage:      true,
+	RunE:              runClone,
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+
+	cloneCmd.Flags().StringP("email", "e", "", "Email of the new account")
+	cloneCmd.Flags().StringP("username", "u", "", "Platform username of the new account (without @)")
+	cloneCmd.Flags().StringP("ssh-key", "k", "", "SSH private key of the new account")
+	_ = cloneCmd.RegisterFlagCompletionFunc("ssh-key", completeSSHKeyPath)
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	sourceAlias, alias := args[0], args[1]
+	opts := config.CloneOptions{}
+	opts.Email, _ = cmd.Flags().GetString("email")
+	opts.Username, _ = cmd.Flags().GetString("username")
+	opts.SSHKeyPath, _ = cmd.Flags().GetString("ssh-key")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	source, err := configManager.GetAccount(sourceAlias)
+	if err != nil {
+		return fmt.Errorf("account '%s' not found", sourceAlias)
+	}
+	if _, err := configManager.GetAccount(alias); err == nil {
+		return fmt.Errorf("account '%s' already exists", alias)
+	}
+
+	needsUsername := source.GetUsername() != "" || source.GetPlatform() == "github"
+	needsSSHKey := source.SSHKeyPath != ""
+	var missing []string
+	if opts.Email == "" {
+		missing = append(missing, "--email")
+	}
+	if opts.Username == "" && needsUsername {
+		missing = append(missing, "--username")
+	}
+	if opts.SSHKeyPath == "" && needsSSHKey {
+		missing = append(missing, "--ssh-key")
+	}
+	if len(missing) > 0 {
+		if !isInteractive() {
+			return fmt.Errorf("%s must be given when not running in a terminal", strings.Join(missing, ", "))
+		}
+		fmt.Printf("🧬 Cloning account '%s' (%s, %s) as '%s'\n", sourceAlias, source.Name, source.GetPlatform(), alias)
+		if opts.Email == "" {
+			opts.Email = promptForInput(fmt.Sprintf("Email (not %s): ", source.Email))
+		}
+		if opts.Username == "" && needsUsername {
+			opts.Username = promptForInput(fmt.Sprintf("Username (not @%s): ", source.GetUsername()))
+		}
+		if opts.SSHKeyPath == "" && needsSSHKey {
+			opts.SSHKeyPath = promptForInput(fmt.Sprintf("SSH key path (not %s): ", source.SSHKeyPath))
+		}
+	}
+
+	if opts.SSHKeyPath != "" && needsSSHKey {
+		// Accounts must not share a key, under any path
+		if users := ssh.NewManager().AccountsUsingKey(cmd.Context(), opts.SSHKeyPath, configManager.ListAccounts()); len(users) > 0 {
+			return fmt.Errorf("SSH key %s is already used by %s; accounts must not share a key", opts.SSHKeyPath, strings.Join(users, ", "))
+		}
+	}
+
+	clone, err := configManager.CloneAccount(sourceAlias, alias, opts)
+	if err != nil {
+		return fmt.Errorf("failed to clone account '%s': %w", sourceAlias, err)
+	}
+
+	refreshHostAliases(configManager, clone)
+
+	fmt.Printf("✅ Added account '%s' based on '%s'\n", clone.Alias, sourceAlias)
+	fmt.Printf("   Name: %s\n", clone.Name)
+	fmt.Printf("   Email: %s\n", clone.Email)
+	if username := clone.GetUsername(); username != "" {
+		fmt.Printf("   Username: @%s\n", username)
+	}
+	fmt.Printf("   Platform: %s (%s)\n", clone.GetPlatform(), clone.GetDomain())
+	if clone.SSHKeyPath != "" {
+		fmt.Printf("   SSH Key: %s\n", clone.SSHKeyPath)
+	} else {
+		fmt.Printf("   💡 Generate an SSH key with: gitshift ssh-keygen %s\n", clone.Alias)
+	}
+	fmt.Printf("   💡 Tokens are not copied; store one with: gitshift token set %s\n", clone.Alias)
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+)
+
+// completionCmd writes the shell completion script
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "🐚 Generate the shell completion script",
+	Long: `Generate the completion script for your shell.
+
+Besides commands and flags, the script completes the aliases of your
+accounts (e.g. 'gitshift switch <TAB>'), the SSH keys in ~/.ssh for --ssh-key
+and the platform names for --platform. Aliases are read from the config each
+time you press TAB, so new accounts complete right away.
+
+Bash (needs the bash-completion package):
+  source <(gitshift completion bash)
+  # permanently, on Linux:
+  gitshift completion bash > /etc/bash_completion.d/gitshift
+  # on macOS:
+  gitshift completion bash > $(brew --prefix)/etc/bash_completion.d/gitshift
+
+Zsh:
+  # enable completion once, if it isn't already
+  echo "autoload -U compinit; compinit" >> ~/.zshrc
+  gitshift completion zsh > "${fpath[1]}/_gitshift"
+
+Fish:
+  gitshift completion fish > ~/.config/fish/completions/gitshift.fish
+
+PowerShell:
+  gitshift completion powershell | Out-String | Invoke-Expression
+  # permanently, add the line above to your $PROFILE`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// accountAliases returns the configured account aliases starting with toComplete, each
+// described by the account's name and email, leaving out the aliases in exclude
+func accountAliases(toComplete string, exclude []string) []string {
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return nil
+	}
+
+	var aliases []string
+	for _, account := range configManager.ListAccounts() {
+		if !strings.HasPrefix(account.Alias, toComplete) || slices.Contains(exclude, account.Alias) {
+			continue
+		}
+		description := account.Name
+		if account.Email != "" {
+			description = fmt.Sprintf("%s <%s>", account.Name, account.Email)
+		}
+		aliases = append(aliases, account.Alias+"\t"+description)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// completeAccountAlias completes the single account alias a command takes
+func completeAccountAlias(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return accountAliases(toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccountAliases completes any number of account aliases, each at most once
+func completeAccountAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return accountAliases(toComplete, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccountFlag completes flags such as --account that name an account
+func completeAccountFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return accountAliases(toComplete, nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSSHKeyPath completes --ssh-key with the private keys in ~/.ssh, i.e. the files
+// that have a .pub next to them. Other paths still complete as files.
+func completeSSHKeyPath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	publicKeys, err := filepath.Glob(filepath.Join(home, ".ssh", "*.pub"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	var keys []string
+	for _, publicKey := range publicKeys {
+		privateKey := strings.TrimSuffix(publicKey, ".pub")
+		if _, err := os.Stat(privateKey); err != nil {
+			continue
+		}
+		for _, candidate := range []string{privateKey, "~/.ssh/" + filepath.Base(privateKey)} {
+			if strings.HasPrefix(candidate, toComplete) {
+				keys = append(keys, candidate)
+				break
+			}
+		}
+	}
+	return keys, cobra.ShellCompDirectiveDefault
+}
+
+// completePlatform completes --platform with the supported platforms
+func completePlatform(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{
+		"github\tgithub.com and GitHub Enterprise",
+		"gitlab\tgitlab.com and self-hosted GitLab",
+		"bitbucket\tbitbucket.org and Bitbucket Data Center",
+		"custom\tany other Git platform",
+	}, cobra.ShellCompDirectiveNoFileComp
+}
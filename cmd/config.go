@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+)
+
+// configCmd groups commands that manage the gitshift configuration file
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "⚙️  Manage the gitshift configuration file",
+	Long: `Manage the gitshift configuration file.
+
+gitshift writes the configuration atomically and keeps the previous version
+as config.yaml.bak next to it, so a bad write can always be rolled back.`,
+}
+
+var configDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "🩺 Validate and repair the gitshift configuration file",
+	Long: `Check the gitshift configuration file for problems:
+- A missing, empty or unparsable file
+- A schema version written by an older or newer gitshift
+- Accounts stored under a different alias than their own
+- Schema problems, with their line: missing names or emails, unknown status,
+  isolation_level or platform values and entries sharing an alias (other
+  commands refuse to load such a config)
+- More than one default account
+- Accounts that fail validation
+- A current account that no longer exists
+
+With --fix, a missing or corrupted file is restored from config.yaml.bak (or
+recreated empty when there is no usable backup), older schema versions are
+migrated and inconsistent account entries are corrected. Of several default
+accounts, the most recently used one stays the default.`,
+	Example: `  # Report problems
+  gitshift config doctor
+
+  # Report and repair what can be repaired automatically
+  gitshift config doctor --fix`,
+	Args: cobra.NoArgs,
+	RunE: runConfigDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configDoctorCmd)
+
+	configDoctorCmd.Flags().Bool("fix", false, "Repair fixable issues")
+}
+
+func runConfigDoctor(cmd *cobra.Command, args []string) error {
+	fix, _ := cmd.Flags().GetBool("fix")
+	configManager := config.NewManager()
+
+	check, err := configManager.CheckConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🩺 Checking %s\n", check.ConfigPath)
+	printConfigIssues(check)
+
+	if !fix || !check.HasFixableIssues() {
+		if check.HasFixableIssues() {
+			fmt.Println("\n💡 Run 'gitshift config doctor --fix' to repair the fixable issues")
+		}
+		if !check.IsValid() {
+			return fmt.Errorf("configuration has %d issue(s)", len(check.Issues))
+		}
+		return nil
+	}
+
+	fmt.Println("\n🔧 Repairing configuration...")
+	check, err = configManager.RepairConfig()
+	if err != nil {
+		return fmt.Errorf("failed to repair configuration: %w", err)
+	}
+	if check.CorruptCopy != "" {
+		fmt.Printf("💾 Kept the config that didn't parse as %s\n", check.CorruptCopy)
+		fmt.Printf("   💡 Fix it and copy it back over %s to recover its accounts\n", check.ConfigPath)
+	}
+	printConfigIssues(check)
+
+	if !check.IsValid() {
+		return fmt.Errorf("configuration still has %d issue(s) that need manual attention", len(check.Issues))
+	}
+	return nil
+}
+
+// printConfigIssues prints the result of a config check
+func printConfigIssues(check *config.ConfigCheck) {
+	if check.IsValid() {
+		fmt.Println("✅ No issues found")
+		return
+	}
+
+	for _, issue := range check.Issues {
+		prefix := ""
+		if issue.Account != "" {
+			prefix = fmt.Sprintf("[%s] ", issue.Account)
+		}
+		marker := "❌"
+		if issue.Fixable {
+			marker = "🔧"
+		}
+		fmt.Printf("  %s %s%s\n", marker, prefix, issue.Message)
+	}
+}
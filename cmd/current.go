@@ -16,14 +16,15 @@ var currentCmd = &cobra.Command{
 	Long: `Display the currently active Git platform account configuration.
 
 This command shows which account is currently active in gitshift, including
-its alias, name, email, and platform.
+its alias, name, email, and platform, when it was last switched to, and
+whether it is the default account (see 'gitshift set-default').
 
 Works with all supported platforms:
 - GitHub (github.com and GitHub Enterprise)
 - GitLab (gitlab.com and self-hosted)
 - Bitbucket (coming soon)
 - Custom Git platforms`,
-	Aliases: []string{"c", "whoami"},
+	Aliases: []string{"c"},
 	RunE:    runCurrentCommand,
 }
 
@@ -37,6 +38,9 @@ func runCurrentCommand(cmd *cobra.Command, args []string) error {
 
 	// Load the configuration
 	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
 
 	// Get the account details
 	account, err := configManager.GetAccount(alias)
@@ -69,6 +73,14 @@ func runCurrentCommand(cmd *cobra.Command, args []string) error {
 	if account.SSHKeyPath != "" {
 		fmt.Printf("🔑 \033[1mSSH Key:\033[0m %s\n", account.SSHKeyPath)
 	}
+	if account.LastUsed != nil {
+		fmt.Printf("🕒 \033[1mLast used:\033[0m %s\n", formatTime(*account.LastUsed))
+	}
+	if account.IsDefault {
+		fmt.Printf("⭐ \033[1mDefault:\033[0m yes\n")
+	} else if defaultAccount, err := configManager.GetDefaultAccount(); err == nil {
+		fmt.Printf("⭐ \033[1mDefault:\033[0m no (the default is '%s')\n", defaultAccount.Alias)
+	}
 	fmt.Println()
 
 	return nil
@@ -0,0 +1,1685 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/audit"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+	"github.com/techishthoughts/gitshift/internal/git"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/switcher"
+	"github.com/techishthoughts/gitshift/internal/token"
+	"github.com/techishthoughts/gitshift/pkg/gh"
+	"github.com/techishthoughts/gitshift/pkg/gitlab"
+	"golang.org/x/term"
+)
+
+// Severity levels for diagnostic issues
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Overall health levels, from best to worst
+const (
+	HealthExcellent = "excellent"
+	HealthGood      = "good"
+	HealthFair      = "fair"
+	HealthPoor      = "poor"
+	HealthCritical  = "critical"
+)
+
+// Exit statuses of diagnose for each overall health; excellent and good exit with 0
+const (
+	ExitHealthFair     = 2
+	ExitHealthPoor     = 3
+	ExitHealthCritical = 4
+)
+
+// healthExitCodes maps the overall health levels that fail diagnose to their exit status
+var healthExitCodes = map[string]int{
+	HealthFair:     ExitHealthFair,
+	HealthPoor:     ExitHealthPoor,
+	HealthCritical: ExitHealthCritical,
+}
+
+// DiagnosticIssue is a single problem found by diagnose
+type DiagnosticIssue struct {
+	Severity   string `json:"severity"`
+	Category   string `json:"category"`
+	Account    string `json:"account,omitempty"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	Fixable    bool   `json:"fixable"`
+
+	// fix repairs the issue; it is set by the check that found it so --fix never
+	// re-derives what is wrong on its own
+	fix func() error
+}
+
+// AccountDiagnostic summarizes the checks run for one account
+type AccountDiagnostic struct {
+	Alias      string `json:"alias"`
+	Platform   string `json:"platform"`
+	Domain     string `json:"domain"`
+	SSHKeyPath string `json:"ssh_key_path,omitempty"`
+	KeyType    string `json:"key_type,omitempty"`
+	KeyBits    int    `json:"key_bits,omitempty"`
+	Current    bool   `json:"current"`
+
+	// TokenBackend is the token store in use; TokenStored is set when it holds a token for
+	// the account that could actually be read back
+	TokenBackend string `json:"token_backend,omitempty"`
+	TokenStored  bool   `json:"token_stored"`
+
+	// SSHKeyRegistered reports whether the SSH key is registered on GitHub or GitLab, nil
+	// when it couldn't be checked (no token, offline or another platform)
+	SSHKeyRegistered *bool `json:"ssh_key_registered,omitempty"`
+
+	// GitHubRateLimit is the API rate limit GitHub reported for the account's token
+	GitHubRateLimit *gh.RateLimit `json:"github_rate_limit,omitempty"`
+
+	Healthy  bool     `json:"healthy"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// SystemHealth describes the tools and services gitshift depends on
+type SystemHealth struct {
+	SSHAvailable    bool   `json:"ssh_available"`
+	SSHVersion      string `json:"ssh_version,omitempty"`
+	GitAvailable    bool   `json:"git_available"`
+	GitVersion      string `json:"git_version,omitempty"`
+	SSHAgentRunning bool   `json:"ssh_agent_running"`
+	SSHAgentStatus  string `json:"ssh_agent_status"`
+	LoadedKeys      int    `json:"loaded_keys"`
+	GPGAvailable    bool   `json:"gpg_available"`
+}
+
+// RepositoryDiagnostic describes the Git setup of the repository given with --repo
+type RepositoryDiagnostic struct {
+	Path           string `json:"path"`
+	RemoteURL      string `json:"remote_url,omitempty"`
+	RemoteHost     string `json:"remote_host,omitempty"`
+	UserName       string `json:"user_name,omitempty"`
+	UserEmail      string `json:"user_email,omitempty"`
+	SSHCommand     string `json:"ssh_command,omitempty"`
+	MatchedAccount string `json:"matched_account,omitempty"`
+}
+
+// DiagnosticResults is the full outcome of a diagnose run
+type DiagnosticResults struct {
+	Timestamp      time.Time             `json:"timestamp"`
+	Version        string                `json:"gitshift_version"`
+	AccountFilter  []string              `json:"account_filter,omitempty"` // the aliases given with --accounts
+	Issues         []DiagnosticIssue     `json:"issues"`
+	Warnings       []DiagnosticIssue     `json:"warnings"`
+	AccountResults []AccountDiagnostic   `json:"account_results"`
+	Repository     *RepositoryDiagnostic `json:"repository,omitempty"`
+	ConfigPath     string                `json:"config_path"`
+	SystemHealth   SystemHealth          `json:"system_health"`
+	OverallHealth  string                `json:"overall_health"`
+
+	// Timings records how long each phase took, keyed by phase name; set with --profile
+	Timings map[string]PhaseTiming `json:"timings,omitempty"`
+}
+
+// PhaseTiming is the wall-clock duration of one diagnose phase
+type PhaseTiming struct {
+	DurationMS int64 `json:"duration_ms"`
+	TimedOut   bool  `json:"timed_out,omitempty"`
+}
+
+// Diagnose phases, in the order they run
+const (
+	PhaseSystem     = "system"
+	PhaseSSH        = "ssh"
+	PhaseGit        = "git"
+	PhaseAccounts   = "accounts"
+	PhaseRepository = "repository"
+)
+
+var diagnosePhases = []string{PhaseSystem, PhaseSSH, PhaseGit, PhaseAccounts, PhaseRepository}
+
+// defaultPhaseTimeout is how long a single diagnose phase may run by default before it is
+// cancelled and reported as timed out
+const defaultPhaseTimeout = 10 * time.Second
+
+// defaultAPIAttempts is how many times diagnose tries an API request that fails with a
+// network or server error before reporting the platform as unreachable
+const defaultAPIAttempts = 3
+
+// addIssue records a problem that needs to be fixed
+func (r *DiagnosticResults) addIssue(severity, category, account, message, suggestion string) {
+	r.Issues = append(r.Issues, DiagnosticIssue{
+		Severity:   severity,
+		Category:   category,
+		Account:    account,
+		Message:    message,
+		Suggestion: suggestion,
+	})
+}
+
+// addFixableIssue records a problem that --fix can repair with fix
+func (r *DiagnosticResults) addFixableIssue(severity, category, account, message, suggestion string, fix func() error) {
+	r.addIssue(severity, category, account, message, suggestion)
+	issue := &r.Issues[len(r.Issues)-1]
+	issue.Fixable = true
+	issue.fix = fix
+}
+
+// addWarning records something that works but is worth a look
+func (r *DiagnosticResults) addWarning(category, account, message, suggestion string) {
+	r.Warnings = append(r.Warnings, DiagnosticIssue{
+		Severity:   SeverityLow,
+		Category:   category,
+		Account:    account,
+		Message:    message,
+		Suggestion: suggestion,
+	})
+}
+
+// computeOverallHealth derives the overall health from the most severe finding
+func (r *DiagnosticResults) computeOverallHealth() string {
+	worst := ""
+	rank := map[string]int{SeverityLow: 1, SeverityMedium: 2, SeverityHigh: 3, SeverityCritical: 4}
+	for _, issue := range r.Issues {
+		if rank[issue.Severity] > rank[worst] {
+			worst = issue.Severity
+		}
+	}
+
+	switch {
+	case worst == SeverityCritical:
+		return HealthCritical
+	case worst == SeverityHigh:
+		return HealthPoor
+	case worst == SeverityMedium || worst == SeverityLow:
+		return HealthFair
+	case len(r.Warnings) > 0:
+		return HealthGood
+	default:
+		return HealthExcellent
+	}
+}
+
+// diagnoseCmd represents the diagnose command
+var diagnoseCmd = &cobra.Command{
+	Use:   "diagnose",
+	Short: "🩺 Diagnose SSH, Git and account configuration problems",
+	Long: `Run a health check of everything gitshift relies on and report problems.
+
+This command checks:
+- System tools: ssh, git, gpg and the SSH agent
+- ~/.ssh/config: permissions, duplicate Host entries, conflicting keys
+- Isolated SSH agents: sockets left behind by agents that are gone (the
+  socket of the current account is never touched)
+- Git configuration: user.name/user.email and core.sshCommand overrides
+- Accounts: SSH key presence, permissions and strength (DSA and RSA keys under
+  3072 bits are flagged), and whether a stored token can be read back
+- Isolation: SSH keys, tokens or GitHub usernames shared between accounts, a
+  global core.sshCommand forcing another account's key, and a shared SSH agent
+  holding the keys of several accounts. With allow_multiple_keys set, several
+  loaded keys are expected; diagnose checks instead that IdentitiesOnly is in
+  effect for every platform the accounts use
+- Platforms: that each GitHub or GitLab account's SSH key is registered on
+  the platform, for accounts with a stored token (skipped with --offline)
+- With --repo: the repository's remote, local identity and core.sshCommand,
+  and which account its remote host alias maps to
+
+Each phase (system, ssh, git, accounts, repository) is cancelled after
+--timeout (10s by default), killing the commands it started, and reported as
+a warning, so a hung ssh, agent or network call can't stall the run. In the
+accounts phase each account has a deadline of its own, so a slow account is
+skipped with a warning while the others are still reported. With --profile,
+the time spent in each phase is printed at the end and included in the JSON
+output as "timings".
+
+With --fix, the issues that can be repaired automatically (SSH config
+duplicates and permissions, SSH key permissions) are listed and you choose
+which fixes to apply; the checks then run again. --yes applies every fix
+without asking and is required when diagnose doesn't run in a terminal.
+Applied fixes are recorded in the audit log (see 'gitshift audit show').
+
+A missing global user.name or user.email is only filled in from an account
+chosen without guessing: the one given with --use-account, else the account
+marked as default, else the current account. Values that are already set are
+never overwritten.
+
+The overall health is one of excellent, good, fair, poor or critical, and
+sets the exit status: 0 for excellent and good, 2 for fair, 3 for poor and 4
+for critical (1 means diagnose itself failed).
+
+--accounts limits the account checks to the named accounts, which is handy
+when many are configured; the system, SSH and Git checks still run, and the
+checks between accounts, such as shared keys or tokens, still compare the
+named accounts with all the others. Naming an account that doesn't exist is
+an error.
+
+--report writes the same JSON, including the gitshift version and the time
+of the run, to a file (mode 600) in addition to the console output.
+
+With --quiet, only issues and warnings are printed, and nothing at all when
+there are none, so CI logs stay clean on success. --quiet doesn't affect
+--json, which always writes the full report to stdout.`,
+	Example: `  # Human-readable report
+  gitshift diagnose
+
+  # Machine-readable report for scripts and monitoring
+  gitshift diagnose --json | jq '.overall_health'
+
+  # Only check two of the accounts
+  gitshift diagnose --accounts work,personal
+
+  # Also check the Git setup of a specific clone
+  gitshift diagnose --repo ~/code/work-project
+
+  # Choose which problems to repair, then report again
+  gitshift diagnose --fix
+
+  # Repair everything without asking, e.g. in a script
+  gitshift diagnose --fix --yes
+
+  # Find out which checks are slow
+  gitshift diagnose --profile
+
+  # Save the results to attach to a bug report
+  gitshift diagnose --report ~/gitshift-report.json
+
+  # In CI: silent when healthy, fail the job on fair or worse
+  gitshift diagnose --quiet --offline`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDiagnose,
+}
+
+func init() {
+	rootCmd.AddCommand(diagnoseCmd)
+
+	diagnoseCmd.Flags().Bool("json", false, "Output the results as JSON")
+	diagnoseCmd.Flags().BoolP("quiet", "q", false, "Only print issues and warnings; the exit status reports the health")
+	diagnoseCmd.Flags().Bool("fix", false, "Offer to fix the issues that can be repaired")
+	diagnoseCmd.Flags().BoolP("yes", "y", false, "With --fix, apply every fix without asking")
+	diagnoseCmd.Flags().String("use-account", "", "With --fix, the account to take a missing global user.name/user.email from")
+	diagnoseCmd.Flags().StringSlice("accounts", nil, "Only diagnose these accounts, e.g. work,personal")
+	diagnoseCmd.Flags().String("repo", "", "Also diagnose the Git repository at this path")
+	diagnoseCmd.Flags().String("report", "", "Also write the full results as JSON to this file, e.g. to attach to a bug report")
+	diagnoseCmd.Flags().Bool("offline", false, "Skip the checks that call the GitHub and GitLab APIs")
+	diagnoseCmd.Flags().Bool("profile", false, "Report how long each phase of the diagnosis took")
+	diagnoseCmd.Flags().Duration("timeout", defaultPhaseTimeout, "Time limit for each phase of the diagnosis")
+	diagnoseCmd.Flags().Int("api-attempts", defaultAPIAttempts, "How many times to try a GitHub or GitLab API request that fails with a network or server error")
+	_ = diagnoseCmd.RegisterFlagCompletionFunc("use-account", completeAccountFlag)
+	_ = diagnoseCmd.RegisterFlagCompletionFunc("accounts", completeAccountFlag)
+}
+
+// DiagnoseCommand runs the diagnostic checks and reports the results
+type DiagnoseCommand struct {
+	configManager *config.Manager
+	sshManager    *ssh.Manager
+	jsonOutput    bool
+	quiet         bool // print only issues and warnings in human output
+	fix           bool
+	yes           bool       // apply every fix without asking
+	useAccount    string     // account --fix takes a missing Git identity from
+	accounts      []string   // aliases the account checks are limited to; all when empty
+	auditLog      *audit.Log // records the fixes applied
+	offline       bool
+	profile       bool
+	timeout       time.Duration // per phase, defaultPhaseTimeout when zero
+	repoPath      string
+	reportPath    string // file the JSON results are also written to, if set
+	apiAttempts   int    // per API request, the client's default when zero
+
+	// platformKeys caches the SSH keys listed per API endpoint and token. It is guarded by
+	// keysMu since an abandoned accounts phase may still be running.
+	keysMu       sync.Mutex
+	platformKeys map[string]*platformKeyList
+}
+
+// platformKeyList is the SSH keys registered on a platform account and, for GitHub, the
+// rate limit reported when listing them
+type platformKeyList struct {
+	keys      []gh.SSHKey
+	rateLimit *gh.RateLimit
+}
+
+func runDiagnose(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	fix, _ := cmd.Flags().GetBool("fix")
+	yes, _ := cmd.Flags().GetBool("yes")
+	useAccount, _ := cmd.Flags().GetString("use-account")
+	accounts, _ := cmd.Flags().GetStringSlice("accounts")
+	repoPath, _ := cmd.Flags().GetString("repo")
+	reportPath, _ := cmd.Flags().GetString("report")
+	offline, _ := cmd.Flags().GetBool("offline")
+	profile, _ := cmd.Flags().GetBool("profile")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout <= 0 {
+		return fmt.Errorf("--timeout must be positive, got %s", timeout)
+	}
+	apiAttempts, _ := cmd.Flags().GetInt("api-attempts")
+	if apiAttempts < 1 {
+		return fmt.Errorf("--api-attempts must be at least 1, got %d", apiAttempts)
+	}
+
+	if repoPath != "" {
+		absPath, err := filepath.Abs(repoPath)
+		if err != nil {
+			return fmt.Errorf("invalid repository path %s: %w", repoPath, err)
+		}
+		output, err := execrunner.CommandContext(cmd.Context(), "git", "-C", absPath, "rev-parse", "--is-inside-work-tree").Output()
+		if err != nil || strings.TrimSpace(string(output)) != "true" {
+			return fmt.Errorf("%s is not a Git working tree", repoPath)
+		}
+		repoPath = absPath
+	}
+
+	diagnose := &DiagnoseCommand{
+		configManager: config.NewManager(),
+		sshManager:    ssh.NewManager(),
+		jsonOutput:    jsonOutput,
+		quiet:         quiet,
+		fix:           fix,
+		yes:           yes,
+		useAccount:    useAccount,
+		accounts:      accounts,
+		auditLog:      auditLog(cmd.CommandPath()),
+		offline:       offline,
+		profile:       profile,
+		timeout:       timeout,
+		repoPath:      repoPath,
+		reportPath:    reportPath,
+		apiAttempts:   apiAttempts,
+	}
+	return diagnose.Run(cmd.Context())
+}
+
+// Run executes all checks, prints the report and returns an *models.ExitError when the
+// overall health is fair or worse, so the exit status reflects it in every output mode
+func (d *DiagnoseCommand) Run(ctx context.Context) error {
+	if err := d.checkAccountFilter(ctx); err != nil {
+		return err
+	}
+	results := d.Diagnose(ctx)
+
+	if d.fix {
+		selected, err := d.selectFixes(results)
+		if err != nil {
+			return err
+		}
+		if d.applyFixes(selected) > 0 {
+			// Re-run the same checks so the report reflects the repaired state
+			results = d.Diagnose(ctx)
+		}
+	}
+
+	if d.reportPath != "" {
+		if err := writeDiagnosticReport(d.reportPath, results); err != nil {
+			return err
+		}
+	}
+
+	if d.jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(results); err != nil {
+			return fmt.Errorf("failed to encode diagnostic results as JSON: %w", err)
+		}
+	} else if d.quiet {
+		printDiagnosticProblems(results)
+	} else {
+		d.printResults(results)
+	}
+
+	if code, failed := healthExitCodes[results.OverallHealth]; failed {
+		return &models.ExitError{
+			Code: code,
+			Err:  fmt.Errorf("overall health is %s: %d issue(s) found", results.OverallHealth, len(results.Issues)),
+		}
+	}
+	return nil
+}
+
+// checkAccountFilter makes sure every alias given with --accounts is configured. A config
+// that fails to load is left for Diagnose to report.
+func (d *DiagnoseCommand) checkAccountFilter(ctx context.Context) error {
+	if len(d.accounts) == 0 {
+		return nil
+	}
+	if err := d.configManager.LoadContext(ctx); err != nil {
+		return nil
+	}
+	for _, alias := range d.accounts {
+		if _, err := d.configManager.GetAccount(alias); err != nil {
+			return fmt.Errorf("--accounts %s: %w; run 'gitshift list' to see them", alias, models.ErrAccountNotFound)
+		}
+	}
+	return nil
+}
+
+// includesAccount reports whether the account checks cover alias
+func (d *DiagnoseCommand) includesAccount(alias string) bool {
+	return len(d.accounts) == 0 || slices.Contains(d.accounts, alias)
+}
+
+// writeDiagnosticReport writes results as JSON to path, creating its directory. The file
+// is only readable by the user since it names accounts, usernames and paths.
+func writeDiagnosticReport(path string, results *DiagnosticResults) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode diagnostic report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write diagnostic report: %w", err)
+	}
+	// An existing file keeps its mode when truncated
+	if err := f.Chmod(0600); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to restrict diagnostic report permissions: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write diagnostic report: %w", err)
+	}
+	return f.Close()
+}
+
+// Diagnose runs every check and returns the collected results
+func (d *DiagnoseCommand) Diagnose(ctx context.Context) *DiagnosticResults {
+	results := &DiagnosticResults{
+		Timestamp:      time.Now(),
+		Version:        version,
+		Issues:         []DiagnosticIssue{},
+		Warnings:       []DiagnosticIssue{},
+		AccountResults: []AccountDiagnostic{},
+		AccountFilter:  d.accounts,
+	}
+
+	if d.profile {
+		results.Timings = make(map[string]PhaseTiming)
+	}
+
+	d.runPhase(ctx, results, PhaseSystem, d.diagnoseSystem)
+	d.runPhase(ctx, results, PhaseSSH, d.diagnoseSSH)
+
+	results.ConfigPath = d.configManager.ConfigFile()
+	if err := d.configManager.LoadContext(ctx); err != nil {
+		results.addIssue(SeverityCritical, "accounts", "", fmt.Sprintf("failed to load gitshift configuration: %v", err), fmt.Sprintf("Check %s", results.ConfigPath))
+	} else {
+		if legacy := d.configManager.MigratedFrom(); legacy != "" {
+			results.addWarning("accounts", "", fmt.Sprintf("Configuration was migrated from %s to %s", legacy, results.ConfigPath), fmt.Sprintf("Remove %s once you no longer need it", legacy))
+		}
+		d.runPhase(ctx, results, PhaseGit, d.diagnoseGit)
+		d.runPhase(ctx, results, PhaseAccounts, d.diagnoseAccounts)
+		if d.repoPath != "" {
+			d.runPhase(ctx, results, PhaseRepository, d.diagnoseRepository)
+		}
+	}
+
+	results.OverallHealth = results.computeOverallHealth()
+	return results
+}
+
+// runPhase runs one diagnose phase under a deadline derived from ctx and records how long
+// it took. Phases run their commands with the context, so cancelling it kills them. The
+// phase writes into its own copy of the results, which is merged back only when it
+// finishes in time, so a phase that is cancelled can't change the report behind its back.
+func (d *DiagnoseCommand) runPhase(ctx context.Context, results *DiagnosticResults, name string, phase func(context.Context, *DiagnosticResults)) {
+	timeout := d.phaseTimeout(name)
+	scratch := results.scratch()
+	start := time.Now()
+	err := runWithDeadline(ctx, timeout, func(ctx context.Context) {
+		phase(ctx, scratch)
+	})
+
+	if err == nil {
+		results.merge(scratch)
+	} else {
+		message := fmt.Sprintf("The %s checks did not finish within %s and were skipped", name, timeout)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			message = fmt.Sprintf("The %s checks were cancelled", name)
+		}
+		results.addWarning(name, "", message, "Raise the limit with --timeout, or run with --profile to see which phase is slow")
+	}
+
+	if results.Timings != nil {
+		results.Timings[name] = PhaseTiming{DurationMS: time.Since(start).Milliseconds(), TimedOut: err != nil}
+	}
+}
+
+// checkTimeout is the deadline of a phase or of one account's checks: --timeout, or
+// defaultPhaseTimeout
+func (d *DiagnoseCommand) checkTimeout() time.Duration {
+	if d.timeout == 0 {
+		return defaultPhaseTimeout
+	}
+	return d.timeout
+}
+
+// phaseTimeout returns the deadline of a phase. Every account checked gets a deadline of
+// its own, so the accounts phase may take one per account plus one for the checks across
+// accounts.
+func (d *DiagnoseCommand) phaseTimeout(name string) time.Duration {
+	timeout := d.checkTimeout()
+	if name != PhaseAccounts {
+		return timeout
+	}
+
+	checked := 0
+	for _, account := range d.configManager.ListAccounts() {
+		if d.includesAccount(account.Alias) {
+			checked++
+		}
+	}
+	return timeout * time.Duration(checked+1)
+}
+
+// runWithDeadline runs fn under a deadline of timeout derived from ctx, and returns the
+// context's error when fn didn't finish in time. Commands fn starts with the context are
+// killed then, but fn itself may keep running, so it must only write to state the caller
+// drops in that case.
+func runWithDeadline(ctx context.Context, timeout time.Duration, fn func(context.Context)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scratch returns empty results for a phase or an account to write into, carrying over
+// what the checks read
+func (r *DiagnosticResults) scratch() *DiagnosticResults {
+	return &DiagnosticResults{
+		ConfigPath:   r.ConfigPath,
+		SystemHealth: r.SystemHealth,
+	}
+}
+
+// merge adds the findings of scratch results that finished in time
+func (r *DiagnosticResults) merge(scratch *DiagnosticResults) {
+	r.Issues = append(r.Issues, scratch.Issues...)
+	r.Warnings = append(r.Warnings, scratch.Warnings...)
+	r.AccountResults = append(r.AccountResults, scratch.AccountResults...)
+	if scratch.Repository != nil {
+		r.Repository = scratch.Repository
+	}
+	r.SystemHealth = scratch.SystemHealth
+}
+
+// progressOutput is where --fix reports progress: stderr in JSON mode so stdout stays
+// valid JSON, stdout otherwise
+func (d *DiagnoseCommand) progressOutput() *os.File {
+	if d.jsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// selectFixes returns the fixable issues to repair. With --yes that is all of them;
+// otherwise the planned fixes are listed and the user picks which to apply. Without a
+// terminal to ask on, --yes is required.
+func (d *DiagnoseCommand) selectFixes(results *DiagnosticResults) ([]DiagnosticIssue, error) {
+	var fixable []DiagnosticIssue
+	for _, issue := range results.Issues {
+		if issue.Fixable && issue.fix != nil {
+			fixable = append(fixable, issue)
+		}
+	}
+	if len(fixable) == 0 || d.yes {
+		return fixable, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("%d fix(es) need confirmation: run diagnose --fix in a terminal, or pass --yes to apply them all", len(fixable))
+	}
+
+	out := d.progressOutput()
+	fmt.Fprintf(out, "🔧 Planned fixes:\n")
+	for i, issue := range fixable {
+		if issue.Account != "" {
+			fmt.Fprintf(out, "  %d. [%s] %s\n", i+1, issue.Account, issue.Message)
+		} else {
+			fmt.Fprintf(out, "  %d. %s\n", i+1, issue.Message)
+		}
+		if issue.Suggestion != "" {
+			fmt.Fprintf(out, "     💡 %s\n", issue.Suggestion)
+		}
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(out, "Apply which fixes? [a]ll, [n]one or numbers (e.g. 1,3): ")
+		input, err := reader.ReadString('\n')
+		if err != nil && strings.TrimSpace(input) == "" {
+			fmt.Fprintln(out)
+			return nil, nil
+		}
+		picked, parseErr := parseFixSelection(input, len(fixable))
+		if parseErr != nil {
+			fmt.Fprintf(out, "❌ %v\n", parseErr)
+			continue
+		}
+		fmt.Fprintln(out)
+
+		selected := make([]DiagnosticIssue, 0, len(picked))
+		for _, i := range picked {
+			selected = append(selected, fixable[i])
+		}
+		return selected, nil
+	}
+}
+
+// parseFixSelection parses the answer to the fix prompt into 0-based indexes of the count
+// planned fixes: "a" or "all" for every fix, "n", "none" or nothing for none, or a list
+// of fix numbers separated by commas or spaces
+func parseFixSelection(input string, count int) ([]int, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	switch input {
+	case "a", "all":
+		all := make([]int, count)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	case "", "n", "none":
+		return nil, nil
+	}
+
+	seen := make(map[int]bool)
+	var picked []int
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > count {
+			return nil, fmt.Errorf("%q is not a fix number between 1 and %d", field, count)
+		}
+		if !seen[n] {
+			seen[n] = true
+			picked = append(picked, n-1)
+		}
+	}
+	sort.Ints(picked)
+	return picked, nil
+}
+
+// applyFixes runs the fix attached to each issue, records it in the audit log and returns
+// how many succeeded. With --quiet only failed fixes are reported.
+func (d *DiagnoseCommand) applyFixes(issues []DiagnosticIssue) int {
+	out := d.progressOutput()
+
+	fixed := 0
+	for _, issue := range issues {
+		err := issue.fix()
+		if d.auditLog != nil {
+			entry := audit.Entry{Event: audit.EventFix, Account: issue.Account, Detail: issue.Message, Outcome: audit.Outcome(err)}
+			if recordErr := d.auditLog.Record(entry); recordErr != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to write audit log: %v\n", recordErr)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(out, "❌ Failed to fix: %s: %v\n", issue.Message, err)
+			continue
+		}
+		fixed++
+		if !d.quiet {
+			fmt.Fprintf(out, "🔧 Fixed: %s\n", issue.Message)
+		}
+	}
+
+	if fixed > 0 && !d.quiet {
+		fmt.Fprintln(out)
+	}
+	return fixed
+}
+
+// diagnoseSystem checks the external tools gitshift shells out to
+func (d *DiagnoseCommand) diagnoseSystem(ctx context.Context, results *DiagnosticResults) {
+	health := &results.SystemHealth
+
+	// ssh -V prints its version on stderr
+	if output, err := execrunner.CommandContext(ctx, "ssh", "-V").CombinedOutput(); err == nil {
+		health.SSHAvailable = true
+		health.SSHVersion = strings.TrimSpace(string(output))
+	} else {
+		results.addIssue(SeverityCritical, "system", "", "ssh is not installed or not in PATH", "Install OpenSSH")
+	}
+
+	if output, err := execrunner.CommandContext(ctx, "git", "--version").Output(); err == nil {
+		health.GitAvailable = true
+		health.GitVersion = strings.TrimSpace(string(output))
+	} else {
+		results.addIssue(SeverityCritical, "system", "", "git is not installed or not in PATH", "Install Git")
+	}
+
+	if _, err := exec.LookPath("gpg"); err == nil {
+		health.GPGAvailable = true
+	}
+
+	status, err := d.sshManager.GetAgentStatus(ctx)
+	health.SSHAgentStatus = status.String()
+	switch status {
+	case ssh.AgentNotRunning:
+		results.addWarning("ssh", "", "SSH agent not detected (SSH_AUTH_SOCK not set)", "Start one with: eval \"$(ssh-agent -s)\"")
+		return
+	case ssh.AgentStale:
+		results.addWarning("ssh", "", fmt.Sprintf("%v; keys can't be loaded", err), "Start a new agent with: eval \"$(ssh-agent -s)\"")
+		return
+	case ssh.AgentUnreachable:
+		results.addWarning("ssh", "", fmt.Sprintf("SSH agent is not responding: %v", err), "Restart the SSH agent")
+		return
+	}
+
+	keys, err := d.sshManager.GetLoadedKeys(ctx)
+	if err != nil {
+		results.addWarning("ssh", "", fmt.Sprintf("SSH agent is not responding: %v", err), "Restart the SSH agent")
+		return
+	}
+	health.SSHAgentRunning = true
+	health.LoadedKeys = len(keys)
+}
+
+// diagnoseSSH validates ~/.ssh/config
+func (d *DiagnoseCommand) diagnoseSSH(ctx context.Context, results *DiagnosticResults) {
+	validation, err := d.sshManager.ValidateConfig()
+	if err != nil {
+		results.addIssue(SeverityHigh, "ssh", "", fmt.Sprintf("failed to validate SSH config: %v", err), "")
+		return
+	}
+
+	if !validation.Exists {
+		results.addWarning("ssh", "", "SSH config does not exist", "Run 'gitshift switch <account>' to create it")
+		return
+	}
+
+	for _, issue := range validation.Issues {
+		message := issue.Message
+		if issue.Line > 0 {
+			message = fmt.Sprintf("%s (line %d)", message, issue.Line)
+		}
+		if issue.Warning {
+			results.addWarning("ssh", "", message, "Merge the blocks in ~/.ssh/config if that isn't what you meant")
+			continue
+		}
+		if !issue.Fixable {
+			results.addIssue(SeverityMedium, "ssh", "", message, "Edit ~/.ssh/config to resolve it")
+			continue
+		}
+		// FixConfig repairs every fixable issue at once and is a no-op when run again
+		results.addFixableIssue(SeverityMedium, "ssh", "", message, "Run 'gitshift diagnose --fix'", func() error {
+			_, err := d.sshManager.FixConfig()
+			return err
+		})
+	}
+}
+
+// diagnoseAgentSockets reports the sockets of isolated agents that are gone, which pile up
+// when agents crash. The socket of the active account and the one in SSH_AUTH_SOCK are
+// never reported, so --fix can't remove the agent in use.
+func (d *DiagnoseCommand) diagnoseAgentSockets(results *DiagnosticResults) {
+	keep := []string{os.Getenv("SSH_AUTH_SOCK")}
+	if current, err := d.configManager.GetCurrentAccount(); err == nil {
+		keep = append(keep, current.GetSSHSocketPath())
+	}
+	dirs := d.configManager.AgentDirs()
+
+	stale, err := ssh.FindStaleAgentSockets(dirs, keep)
+	if err != nil {
+		results.addWarning("ssh", "", fmt.Sprintf("Could not check the isolated agent sockets: %v", err), "")
+		return
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	results.addFixableIssue(SeverityLow, "ssh", "",
+		fmt.Sprintf("%d isolated SSH agent socket(s) have no agent behind them anymore: %s", len(stale), strings.Join(stale, ", ")),
+		"Run 'gitshift diagnose --fix' to remove them",
+		func() error {
+			removed, err := ssh.RemoveStaleAgentSockets(dirs, keep)
+			if len(removed) > 0 && !d.quiet {
+				fmt.Fprintf(d.progressOutput(), "🧹 Removed %d stale agent socket(s)\n", len(removed))
+			}
+			return err
+		})
+}
+
+// diagnoseGit checks the global Git identity and SSH command overrides
+func (d *DiagnoseCommand) diagnoseGit(ctx context.Context, results *DiagnosticResults) {
+	if !results.SystemHealth.GitAvailable {
+		return
+	}
+
+	name := gitConfigValue(ctx, "user.name")
+	email := gitConfigValue(ctx, "user.email")
+	if name == "" || email == "" {
+		d.diagnoseMissingIdentity(results, name, email)
+	}
+
+	if current, err := d.configManager.GetCurrentAccount(); err == nil {
+		if current.Email != "" && email != "" && email != current.Email {
+			results.addIssue(SeverityHigh, "git", current.Alias,
+				fmt.Sprintf("Git user.email is %s but the current account uses %s", email, current.Email),
+				fmt.Sprintf("Run 'gitshift switch %s'", current.Alias))
+		}
+	}
+
+	// A switch writes core.sshCommand to force the account's key; checkCrossAccountLeakage
+	// reports it when it forces another account's key
+	if sshCommand := gitConfigValue(ctx, "core.sshCommand"); sshCommand != "" && switchSSHCommandKey(sshCommand) == "" {
+		results.addWarning("git", "", fmt.Sprintf("core.sshCommand is set globally (%s) and overrides ~/.ssh/config", sshCommand),
+			"Unset it with: git config --global --unset core.sshCommand")
+	}
+}
+
+// diagnoseMissingIdentity reports the global user.name or user.email that is not set. --fix
+// fills in only the missing values, from the account chosen by identityAccount; when no
+// account can be chosen without guessing, the issue can't be fixed until --use-account
+// names one.
+func (d *DiagnoseCommand) diagnoseMissingIdentity(results *DiagnosticResults, name, email string) {
+	const message = "Git user.name or user.email is not set"
+
+	account, reason, err := d.identityAccount()
+	if err != nil {
+		results.addIssue(SeverityHigh, "git", "", message, err.Error())
+		return
+	}
+	if account == nil {
+		results.addIssue(SeverityHigh, "git", "", message,
+			"Run 'gitshift switch <account>', or 'gitshift diagnose --fix --use-account <alias>' to set only the missing values")
+		return
+	}
+
+	values := map[string]string{}
+	if name == "" && account.Name != "" {
+		values["user.name"] = account.Name
+	}
+	if email == "" && account.Email != "" {
+		values["user.email"] = account.Email
+	}
+	if len(values) == 0 {
+		results.addIssue(SeverityHigh, "git", account.Alias, message,
+			fmt.Sprintf("Account '%s' has no name or email to use either; run 'gitshift switch <account>'", account.Alias))
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	results.addFixableIssue(SeverityHigh, "git", account.Alias, message,
+		fmt.Sprintf("--fix sets %s from account '%s' (%s)", strings.Join(keys, " and "), account.Alias, reason),
+		func() error {
+			for _, key := range keys {
+				if err := setGitConfigValue(context.Background(), key, values[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// identityAccount returns the account whose identity --fix may write to the global Git
+// config and why it was chosen: the one named with --use-account, else the default account,
+// else the current one. It returns nil when none of these applies rather than picking one.
+func (d *DiagnoseCommand) identityAccount() (*models.Account, string, error) {
+	if d.useAccount != "" {
+		account, err := d.configManager.GetAccount(d.useAccount)
+		if err != nil {
+			return nil, "", fmt.Errorf("--use-account %s: no such account; run 'gitshift list' to see them", d.useAccount)
+		}
+		return account, "given with --use-account", nil
+	}
+
+	var defaults []*models.Account
+	for _, account := range d.configManager.ListAccounts() {
+		if account.IsDefault {
+			defaults = append(defaults, account)
+		}
+	}
+	if len(defaults) == 1 {
+		return defaults[0], "the default account", nil
+	}
+	if len(defaults) > 1 {
+		return nil, "", nil
+	}
+
+	if current, err := d.configManager.GetCurrentAccount(); err == nil {
+		return current, "the current account", nil
+	}
+	return nil, "", nil
+}
+
+// diagnoseRepository checks the Git setup of the repository given with --repo and works out
+// which account its remote resolves to
+func (d *DiagnoseCommand) diagnoseRepository(ctx context.Context, results *DiagnosticResults) {
+	repo := &RepositoryDiagnostic{
+		Path:       d.repoPath,
+		RemoteURL:  repoGitValue(ctx, d.repoPath, "remote", "get-url", "origin"),
+		UserName:   repoGitValue(ctx, d.repoPath, "config", "--local", "--get", "user.name"),
+		UserEmail:  repoGitValue(ctx, d.repoPath, "config", "--local", "--get", "user.email"),
+		SSHCommand: repoGitValue(ctx, d.repoPath, "config", "--local", "--get", "core.sshCommand"),
+	}
+	results.Repository = repo
+	defer d.diagnoseRepositorySSHCommand(results, repo)
+
+	if repo.RemoteURL == "" {
+		results.addWarning("repository", "", "Repository has no 'origin' remote", "")
+		return
+	}
+	repo.RemoteHost = remoteHost(repo.RemoteURL)
+
+	var bareDomain bool
+	for _, account := range d.configManager.ListAccounts() {
+		if repo.RemoteHost == ssh.HostAlias(account.GetDomain(), account.Alias) {
+			repo.MatchedAccount = account.Alias
+			break
+		}
+		if repo.RemoteHost == account.GetDomain() {
+			bareDomain = true
+		}
+	}
+
+	switch {
+	case repo.MatchedAccount != "":
+		account, _ := d.configManager.GetAccount(repo.MatchedAccount)
+		effectiveEmail := repo.UserEmail
+		if effectiveEmail == "" {
+			effectiveEmail = gitConfigValue(ctx, "user.email")
+		}
+		if account != nil && account.Email != "" && effectiveEmail != "" && effectiveEmail != account.Email {
+			results.addIssue(SeverityHigh, "repository", account.Alias,
+				fmt.Sprintf("Commits in %s will be authored as %s but the remote uses account '%s' (%s)", d.repoPath, effectiveEmail, account.Alias, account.Email),
+				fmt.Sprintf("Run: git -C %s config user.email %s", d.repoPath, account.Email))
+		}
+	case bareDomain:
+		results.addWarning("repository", "", fmt.Sprintf("Remote uses the bare domain %s, so it authenticates as whichever account is active", repo.RemoteHost),
+			"Pin an account by using the <domain>-<alias> host alias in the remote URL")
+	default:
+		results.addWarning("repository", "", fmt.Sprintf("Remote host %s does not match any configured account", repo.RemoteHost), "")
+	}
+}
+
+// diagnoseRepositorySSHCommand checks the repository's core.sshCommand. One written by a
+// switch in the repository is expected, as long as it forces the key of the account the
+// remote uses, or of the current account when the remote doesn't pin one.
+func (d *DiagnoseCommand) diagnoseRepositorySSHCommand(results *DiagnosticResults, repo *RepositoryDiagnostic) {
+	if repo.SSHCommand == "" {
+		return
+	}
+	forced := switchSSHCommandKey(repo.SSHCommand)
+	if forced == "" {
+		results.addWarning("repository", "", fmt.Sprintf("core.sshCommand is set in the repository (%s) and overrides ~/.ssh/config", repo.SSHCommand),
+			fmt.Sprintf("Unset it with: git -C %s config --local --unset core.sshCommand", d.repoPath))
+		return
+	}
+
+	account, err := d.configManager.GetAccount(repo.MatchedAccount)
+	if repo.MatchedAccount == "" || err != nil {
+		if account, err = d.configManager.GetCurrentAccount(); err != nil {
+			return
+		}
+	}
+	if account.SSHKeyPath == "" || forced == pathutil.Expand(account.SSHKeyPath) {
+		return
+	}
+	results.addIssue(SeverityHigh, "repository", account.Alias,
+		fmt.Sprintf("core.sshCommand in the repository forces the key %s, not the key of account '%s'", forced, account.Alias),
+		fmt.Sprintf("Run 'gitshift switch %s' in %s", account.Alias, d.repoPath))
+}
+
+// switchSSHCommandKey returns the key a core.sshCommand written by a switch forces, or ""
+// when the command was set some other way
+func switchSSHCommandKey(command string) string {
+	keyPath := identityFromCommand(command)
+	if keyPath == "" || command != switcher.SSHCommand(keyPath) {
+		return ""
+	}
+	return keyPath
+}
+
+// diagnoseAccounts checks each configured account's SSH key and token
+func (d *DiagnoseCommand) diagnoseAccounts(ctx context.Context, results *DiagnosticResults) {
+	// Runs with the accounts, once the config is loaded, so the current account is known
+	d.diagnoseAgentSockets(results)
+
+	accounts := d.configManager.ListAccounts()
+	if len(accounts) == 0 {
+		results.addWarning("accounts", "", "No accounts configured", "Run 'gitshift add' or 'gitshift discover'")
+		return
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Alias < accounts[j].Alias
+	})
+
+	cfg := d.configManager.GetConfig()
+	if cfg.CurrentAccount == "" {
+		results.addWarning("accounts", "", "No current account set", "Run 'gitshift switch <account>'")
+	}
+	d.diagnoseDefaults(results, accounts)
+
+	store, err := d.configManager.TokenStore()
+	if err != nil {
+		results.addIssue(SeverityHigh, "tokens", "", err.Error(), fmt.Sprintf("Fix token_storage in %s", results.ConfigPath))
+	}
+
+	tokens := make(map[string]string)
+	for _, account := range accounts {
+		if !d.includesAccount(account.Alias) {
+			// Still compared with the selected accounts' tokens by the isolation checks
+			if store != nil {
+				if token, err := store.Get(account.Alias); err == nil && token != "" {
+					tokens[account.Alias] = token
+				}
+			}
+			continue
+		}
+
+		// Each account has its own deadline, so one hung ssh or API call only costs the
+		// results of that account
+		scratch := results.scratch()
+		var accountToken string
+		err := runWithDeadline(ctx, d.checkTimeout(), func(ctx context.Context) {
+			accountToken = d.diagnoseAccountAndToken(ctx, scratch, store, account, cfg.CurrentAccount)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			results.addWarning("accounts", account.Alias,
+				fmt.Sprintf("The checks of account '%s' did not finish within %s and were skipped", account.Alias, d.checkTimeout()),
+				"Raise the limit with --timeout, or run with --offline to skip the platform API")
+			continue
+		}
+		results.merge(scratch)
+		if accountToken != "" {
+			tokens[account.Alias] = accountToken
+		}
+	}
+
+	d.diagnoseDuplicateKeys(ctx, results)
+	d.checkCrossAccountLeakage(ctx, results, accounts, tokens)
+}
+
+// diagnoseAccountAndToken runs the checks of one account, its token and its platform
+// integration, and returns its stored token for the isolation checks
+func (d *DiagnoseCommand) diagnoseAccountAndToken(ctx context.Context, results *DiagnosticResults, store token.TokenStore, account *models.Account, currentAlias string) string {
+	result := d.diagnoseAccount(ctx, results, account, currentAlias)
+	var accountToken string
+	if store != nil {
+		validateTokenConfiguration(results, store, &result)
+		if result.TokenStored {
+			accountToken, _ = store.Get(account.Alias)
+			if !d.offline {
+				d.validatePlatformIntegration(ctx, results, store, account, &result)
+			}
+		}
+	}
+	results.AccountResults = append(results.AccountResults, result)
+	return accountToken
+}
+
+// diagnoseDuplicateKeys reports key files in ~/.ssh that are copies of the same key. Copies
+// used by different accounts defeat their isolation, since either account can
+// authenticate as the other.
+func (d *DiagnoseCommand) diagnoseDuplicateKeys(ctx context.Context, results *DiagnosticResults) {
+	duplicates, err := d.sshManager.DetectDuplicateKeys(ctx)
+	if err != nil {
+		results.addWarning("ssh", "", fmt.Sprintf("Could not look for duplicate SSH keys: %v", err), "")
+		return
+	}
+
+	groups := make([][]string, 0, len(duplicates))
+	for _, paths := range duplicates {
+		groups = append(groups, paths)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+
+	for _, paths := range groups {
+		// Accounts using any of the copies match the first one by fingerprint
+		owners := d.sshManager.AccountsUsingKey(ctx, paths[0], d.configManager.ListAccounts())
+
+		if len(owners) > 1 {
+			results.addIssue(SeverityHigh, "isolation", "",
+				fmt.Sprintf("Accounts %s use copies of the same SSH key (%s)", strings.Join(owners, ", "), strings.Join(paths, ", ")),
+				"Give each account its own SSH key with 'gitshift ssh-keygen <account>'")
+			continue
+		}
+		results.addWarning("ssh", "",
+			fmt.Sprintf("%s are copies of the same SSH key", strings.Join(paths, ", ")),
+			"Keep one and remove the others with 'gitshift ssh-keys delete <path>'")
+	}
+}
+
+// diagnoseDefaults reports accounts that are all marked as default, which makes every
+// choice based on the default account depend on map order
+func (d *DiagnoseCommand) diagnoseDefaults(results *DiagnosticResults, accounts []*models.Account) {
+	var defaults []string
+	var mostRecent *models.Account
+	for _, account := range accounts {
+		if !account.IsDefault {
+			continue
+		}
+		defaults = append(defaults, account.Alias)
+		if mostRecent == nil || (account.LastUsed != nil && (mostRecent.LastUsed == nil || account.LastUsed.After(*mostRecent.LastUsed))) {
+			mostRecent = account
+		}
+	}
+	if len(defaults) < 2 {
+		return
+	}
+
+	results.addFixableIssue(SeverityHigh, "accounts", "",
+		fmt.Sprintf("Accounts %s are all marked as default, so which one counts as the default is arbitrary", strings.Join(defaults, ", ")),
+		fmt.Sprintf("Run 'gitshift diagnose --fix' to keep '%s', the most recently used, as the only default", mostRecent.Alias),
+		func() error {
+			kept, cleared, err := d.configManager.ClearExtraDefaults()
+			if err == nil && len(cleared) > 0 && !d.quiet {
+				fmt.Fprintf(d.progressOutput(), "⭐ '%s' stays the default; cleared %s\n", kept, strings.Join(cleared, ", "))
+			}
+			return err
+		})
+}
+
+// checkCrossAccountLeakage looks for credentials that let one account act as another:
+// SSH keys, tokens or GitHub usernames shared between accounts, a global core.sshCommand
+// that forces a key other than the active account's, and a shared SSH agent holding the
+// keys of several accounts
+func (d *DiagnoseCommand) checkCrossAccountLeakage(ctx context.Context, results *DiagnosticResults, accounts []*models.Account, tokens map[string]string) {
+	for _, issue := range config.FindSharedCredentials(accounts, tokens) {
+		suggestion := "Give each account its own SSH key with 'gitshift ssh-keygen <account>'"
+		switch issue.Kind {
+		case config.SharedToken:
+			suggestion = "Store each account's own token with 'gitshift token set <account>'"
+		case config.SharedGitHubUsername:
+			suggestion = "Remove the duplicate account with 'gitshift remove <account>'"
+		}
+		results.addIssue(SeverityHigh, "isolation", "", issue.Message, suggestion)
+	}
+
+	current, err := d.configManager.GetCurrentAccount()
+	if err != nil {
+		return
+	}
+	owner := func(keyPath string) *models.Account {
+		for _, account := range accounts {
+			if account.SSHKeyPath != "" && pathutil.Expand(account.SSHKeyPath) == pathutil.Expand(keyPath) {
+				return account
+			}
+		}
+		return nil
+	}
+
+	if results.SystemHealth.GitAvailable {
+		if forced := identityFromCommand(gitConfigValue(ctx, "core.sshCommand")); forced != "" && current.SSHKeyPath != "" &&
+			forced != pathutil.Expand(current.SSHKeyPath) {
+			message := fmt.Sprintf("Global core.sshCommand forces the key %s, not the key of the active account '%s'", forced, current.Alias)
+			if other := owner(forced); other != nil {
+				message = fmt.Sprintf("Global core.sshCommand forces the key of account '%s' while '%s' is active", other.Alias, current.Alias)
+			}
+			results.addIssue(SeverityHigh, "isolation", current.Alias, message, fmt.Sprintf("Run 'gitshift switch %s'", current.Alias))
+		}
+	}
+
+	loadedKeys := results.SystemHealth.LoadedKeys
+	if !results.SystemHealth.SSHAgentRunning || loadedKeys < 2 {
+		return
+	}
+	if d.configManager.GetConfig().AllowMultipleKeys {
+		d.checkIdentitiesOnly(ctx, results, accounts, current)
+		return
+	}
+	results.addWarning("ssh", "", fmt.Sprintf("%d keys are loaded in the SSH agent; the wrong one may be offered first", loadedKeys),
+		"Run 'gitshift switch <account>' to load only the account's key. If you need several keys loaded, set allow_multiple_keys: true; "+
+			"ssh then relies on IdentitiesOnly alone to pick the key, so any Host entry without it can offer the wrong account's key")
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	for _, account := range accounts {
+		if account.SSHSocketPath != "" && account.SSHSocketPath == socket {
+			// The account's own isolated agent
+			return
+		}
+	}
+
+	loaded, err := d.sshManager.GetLoadedKeys(ctx)
+	if err != nil {
+		return
+	}
+	fingerprints := make(map[string]bool, len(loaded))
+	for _, key := range loaded {
+		fingerprints[key.Fingerprint] = true
+	}
+
+	var holders []string
+	for _, account := range accounts {
+		if account.SSHKeyPath == "" {
+			continue
+		}
+		if info, err := d.sshManager.ValidateKey(ctx, account.SSHKeyPath); err == nil && fingerprints[info.Fingerprint] {
+			holders = append(holders, account.Alias)
+		}
+	}
+	if len(holders) > 1 {
+		results.addIssue(SeverityMedium, "isolation", "",
+			fmt.Sprintf("The shared SSH agent at %s holds the keys of accounts %s, so any of them may be offered", socket, strings.Join(holders, ", ")),
+			fmt.Sprintf("Run 'gitshift switch %s' to load only the active account's key", current.Alias))
+	}
+}
+
+// checkIdentitiesOnly makes sure ssh only offers the configured key to every platform the
+// accounts use, which is what keeps the accounts apart when allow_multiple_keys leaves
+// several keys in the agent
+func (d *DiagnoseCommand) checkIdentitiesOnly(ctx context.Context, results *DiagnosticResults, accounts []*models.Account, current *models.Account) {
+	domains := make(map[string]bool)
+	for _, account := range accounts {
+		if account.SSHKeyPath != "" {
+			domains[account.GetDomain()] = true
+		}
+	}
+	sorted := make([]string, 0, len(domains))
+	for domain := range domains {
+		sorted = append(sorted, domain)
+	}
+	sort.Strings(sorted)
+
+	for _, domain := range sorted {
+		only, err := d.sshManager.IdentitiesOnly(ctx, domain)
+		if err != nil {
+			results.addWarning("isolation", "", fmt.Sprintf("Could not check IdentitiesOnly for %s: %v", domain, err), "")
+			continue
+		}
+		if !only {
+			results.addIssue(SeverityHigh, "isolation", "",
+				fmt.Sprintf("allow_multiple_keys keeps %d keys in the SSH agent, but IdentitiesOnly is not in effect for %s, so ssh may offer another account's key",
+					results.SystemHealth.LoadedKeys, domain),
+				fmt.Sprintf("Run 'gitshift switch %s' to write the managed Host block, which sets IdentitiesOnly, or add 'IdentitiesOnly yes' to your Host entry for %s",
+					current.Alias, domain))
+		}
+	}
+}
+
+// validateTokenConfiguration records which token backend an account uses and checks that a
+// stored token can actually be retrieved. Accounts without a token are fine: tokens are
+// only needed for API features.
+func validateTokenConfiguration(results *DiagnosticResults, store token.TokenStore, result *AccountDiagnostic) {
+	result.TokenBackend = store.Backend()
+
+	_, err := store.Metadata(result.Alias)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return
+	}
+	if err == nil {
+		_, err = store.Get(result.Alias)
+	}
+	if err != nil {
+		message := fmt.Sprintf("Token in the %s store cannot be retrieved: %v", store.Backend(), err)
+		result.Problems = append(result.Problems, message)
+		result.Healthy = false
+		results.addIssue(SeverityHigh, "tokens", result.Alias, message, fmt.Sprintf("Store it again with 'gitshift token set %s'", result.Alias))
+		return
+	}
+
+	result.TokenStored = true
+}
+
+// listPlatformKeys lists the SSH keys registered on the platform account the token
+// belongs to
+func listPlatformKeys(ctx context.Context, account *models.Account, accessToken string, attempts int) (*platformKeyList, error) {
+	client, err := sshKeyClient(account, accessToken, attempts)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := client.ListSSHKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &platformKeyList{keys: keys}
+	if ghClient, ok := client.(*gh.Client); ok {
+		if rateLimit, ok := ghClient.RateLimitStatus(); ok {
+			list.rateLimit = &rateLimit
+		}
+	}
+	return list, nil
+}
+
+// validatePlatformIntegration checks through the GitHub or GitLab API that the account's
+// SSH public key is registered on its platform account. Key lists are cached per API
+// endpoint and token, so each is fetched once per run even when --fix re-runs the checks.
+func (d *DiagnoseCommand) validatePlatformIntegration(ctx context.Context, results *DiagnosticResults, store token.TokenStore, account *models.Account, result *AccountDiagnostic) {
+	if !supportsKeyAPI(account) || account.SSHKeyPath == "" {
+		return
+	}
+	publicKey, err := os.ReadFile(pathutil.Expand(account.SSHKeyPath) + ".pub")
+	if err != nil {
+		return
+	}
+	accessToken, err := store.Get(account.Alias)
+	if err != nil {
+		return
+	}
+
+	cacheKey := account.GetAPIEndpoint() + "\x00" + accessToken
+	category, name := account.GetPlatform(), platformName(account)
+	d.keysMu.Lock()
+	list, cached := d.platformKeys[cacheKey]
+	d.keysMu.Unlock()
+	if !cached {
+		var err error
+		if list, err = listPlatformKeys(ctx, account, accessToken, d.apiAttempts); err != nil {
+			var rateLimitErr *gh.RateLimitError
+			scope := "read:public_key"
+			if category == "gitlab" {
+				scope = "read_api"
+			}
+			switch {
+			case errors.As(err, &rateLimitErr):
+				results.addWarning(category, account.Alias, fmt.Sprintf("Skipped the GitHub checks: %v", err), "Run diagnose again once the limit has reset")
+			case gh.IsAuthError(err) || gitlab.IsAuthError(err):
+				results.addWarning(category, account.Alias, fmt.Sprintf("%s rejected the token: %v", name, err),
+					fmt.Sprintf("The token is invalid, expired or lacks the %s scope; store a new one with 'gitshift token set %s'", scope, account.Alias))
+			case gh.IsTransientError(err) || gitlab.IsTransientError(err):
+				results.addWarning(category, account.Alias, fmt.Sprintf("Could not reach %s (transient error): %v", name, err),
+					"The token was not checked; run diagnose again when the network or platform recovers, or with --offline")
+			default:
+				results.addWarning(category, account.Alias, fmt.Sprintf("Could not list the SSH keys registered on %s: %v", name, err),
+					fmt.Sprintf("Check the token and its %s scope, or run with --offline", scope))
+			}
+			return
+		}
+		d.keysMu.Lock()
+		if d.platformKeys == nil {
+			d.platformKeys = make(map[string]*platformKeyList)
+		}
+		d.platformKeys[cacheKey] = list
+		d.keysMu.Unlock()
+	}
+
+	result.GitHubRateLimit = list.rateLimit
+	if list.rateLimit != nil && list.rateLimit.Remaining == 0 {
+		results.addWarning(category, account.Alias, "The GitHub API rate limit of this account's token is exhausted",
+			fmt.Sprintf("It resets at %s", time.Unix(int64(list.rateLimit.Reset), 0).Format("15:04:05")))
+	}
+
+	registered, err := gh.FindSSHKeyByFingerprint(list.keys, string(publicKey))
+	if err != nil {
+		return
+	}
+	isRegistered := registered != nil
+	result.SSHKeyRegistered = &isRegistered
+	if !isRegistered {
+		message := fmt.Sprintf("SSH key %s.pub is not registered on %s", account.SSHKeyPath, name)
+		result.Problems = append(result.Problems, message)
+		result.Healthy = false
+		results.addIssue(SeverityHigh, category, account.Alias, message, fmt.Sprintf("Run 'gitshift ssh-keys upload --account %s'", account.Alias))
+	}
+}
+
+// diagnoseAccount checks a single account and records its issues
+func (d *DiagnoseCommand) diagnoseAccount(ctx context.Context, results *DiagnosticResults, account *models.Account, currentAlias string) AccountDiagnostic {
+	result := AccountDiagnostic{
+		Alias:      account.Alias,
+		Platform:   account.GetPlatform(),
+		Domain:     account.GetDomain(),
+		SSHKeyPath: account.SSHKeyPath,
+		Current:    account.Alias == currentAlias,
+	}
+
+	problem := func(severity, message, suggestion string, fix func() error) {
+		result.Problems = append(result.Problems, message)
+		if fix != nil {
+			results.addFixableIssue(severity, "accounts", account.Alias, message, suggestion, fix)
+		} else {
+			results.addIssue(severity, "accounts", account.Alias, message, suggestion)
+		}
+	}
+
+	if account.SSHKeyPath == "" && account.SSHKeyFingerprint != "" {
+		if agentKey, err := d.sshManager.FindAgentKey(ctx, account.SSHKeyFingerprint); err != nil {
+			problem(SeverityHigh, fmt.Sprintf("SSH key: %v", err), "Load it with ssh-add, plug in the hardware key, or connect with the agent forwarded", nil)
+		} else {
+			result.KeyType, result.KeyBits = agentKey.Type, agentKey.Bits
+			if weakness := agentKey.Weakness(); weakness != "" {
+				problem(SeverityHigh, fmt.Sprintf("SSH key %s is weak: %s", agentKey.Fingerprint, weakness), fmt.Sprintf("Regenerate it as ed25519: gitshift ssh-keygen %s --type ed25519 --force", account.Alias), nil)
+			}
+		}
+	} else if account.SSHKeyPath == "" {
+		results.addWarning("accounts", account.Alias, "No SSH key configured", fmt.Sprintf("Run 'gitshift ssh-keygen %s'", account.Alias))
+	} else if info, err := os.Stat(pathutil.Expand(account.SSHKeyPath)); err != nil {
+		message := fmt.Sprintf("SSH key %s does not exist", account.SSHKeyPath)
+		if users := d.sshManager.AccountsUsingKey(ctx, account.SSHKeyPath, d.configManager.ListAccounts()); len(users) > 1 {
+			message += fmt.Sprintf("; key %s is used by accounts [%s]", account.SSHKeyPath, strings.Join(users, ", "))
+		}
+		problem(SeverityHigh, message,
+			fmt.Sprintf("Run 'gitshift ssh-keygen %s --force' to create a new key, or 'gitshift update %s --ssh-key <path>' to use an existing one", account.Alias, account.Alias), nil)
+	} else {
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			keyPath := account.SSHKeyPath
+			problem(SeverityMedium, fmt.Sprintf("SSH key %s has permissions %04o; ssh requires 0600", keyPath, perm), fmt.Sprintf("Run: chmod 600 %s", keyPath), func() error {
+				return os.Chmod(keyPath, 0600)
+			})
+		}
+
+		if keyInfo, err := d.sshManager.ValidateKey(ctx, account.SSHKeyPath); err != nil {
+			problem(SeverityHigh, fmt.Sprintf("SSH key %s is not a valid key: %v", account.SSHKeyPath, err), fmt.Sprintf("Run 'gitshift ssh-keygen %s --force' to replace it", account.Alias), nil)
+		} else {
+			result.KeyType, result.KeyBits = keyInfo.Type, keyInfo.Bits
+			if weakness := keyInfo.Weakness(); weakness != "" {
+				problem(SeverityHigh, fmt.Sprintf("SSH key %s is weak: %s", account.SSHKeyPath, weakness), fmt.Sprintf("Regenerate it as ed25519: gitshift ssh-keygen %s --type ed25519 --force", account.Alias), nil)
+			}
+		}
+	}
+
+	result.Healthy = len(result.Problems) == 0
+	return result
+}
+
+// printResults prints the human-readable report
+func (d *DiagnoseCommand) printResults(results *DiagnosticResults) {
+	health := results.SystemHealth
+
+	fmt.Println("🩺 gitshift diagnostics")
+	fmt.Println("══════════════════════════════════════════════════════")
+
+	fmt.Println("\n🖥️  System")
+	printCheck(health.SSHAvailable, "ssh", health.SSHVersion)
+	printCheck(health.GitAvailable, "git", health.GitVersion)
+	agentDetails := fmt.Sprintf("%d key(s) loaded", health.LoadedKeys)
+	if !health.SSHAgentRunning {
+		agentDetails = health.SSHAgentStatus
+	}
+	printCheck(health.SSHAgentRunning, "ssh-agent", agentDetails)
+	printCheck(health.GPGAvailable, "gpg", "")
+	fmt.Printf("  ⚙️  config: %s\n", results.ConfigPath)
+
+	if len(results.AccountResults) > 0 {
+		if len(results.AccountFilter) > 0 {
+			fmt.Printf("\n👥 Accounts (only %s)\n", strings.Join(results.AccountFilter, ", "))
+		} else {
+			fmt.Println("\n👥 Accounts")
+		}
+		for _, account := range results.AccountResults {
+			marker := " "
+			if account.Current {
+				marker = "*"
+			}
+			details := fmt.Sprintf("%s%s (%s)", marker, account.Alias, account.Domain)
+			if account.TokenStored {
+				details += fmt.Sprintf(" 🔑 %s", account.TokenBackend)
+			}
+			if account.Healthy {
+				fmt.Printf("  ✅ %s\n", details)
+			} else {
+				fmt.Printf("  ❌ %s: %s\n", details, strings.Join(account.Problems, "; "))
+			}
+		}
+	}
+
+	if repo := results.Repository; repo != nil {
+		fmt.Printf("\n📁 Repository: %s\n", repo.Path)
+		fmt.Printf("  Remote:  %s\n", valueOrNone(repo.RemoteURL))
+		fmt.Printf("  Account: %s\n", valueOrNone(repo.MatchedAccount))
+		fmt.Printf("  Local user.name:  %s\n", valueOrNone(repo.UserName))
+		fmt.Printf("  Local user.email: %s\n", valueOrNone(repo.UserEmail))
+		fmt.Printf("  core.sshCommand:  %s\n", valueOrNone(repo.SSHCommand))
+	}
+
+	printDiagnosticProblems(results)
+
+	if len(results.Timings) > 0 {
+		fmt.Println("\n⏱️  Timings")
+		for _, phase := range diagnosePhases {
+			timing, ok := results.Timings[phase]
+			if !ok {
+				continue
+			}
+			line := fmt.Sprintf("  %-12s %6dms", phase, timing.DurationMS)
+			if timing.TimedOut {
+				line += "  (timed out)"
+			}
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println("\n══════════════════════════════════════════════════════")
+	fmt.Printf("%s Overall health: %s\n", healthEmoji(results.OverallHealth), results.OverallHealth)
+}
+
+// printDiagnosticProblems prints the issues and warnings sections, or nothing when there
+// are none
+func printDiagnosticProblems(results *DiagnosticResults) {
+	if len(results.Issues) > 0 {
+		fmt.Printf("\n❌ Issues (%d)\n", len(results.Issues))
+		for _, issue := range results.Issues {
+			printDiagnosticIssue(issue)
+		}
+	}
+
+	if len(results.Warnings) > 0 {
+		fmt.Printf("\n⚠️  Warnings (%d)\n", len(results.Warnings))
+		for _, warning := range results.Warnings {
+			printDiagnosticIssue(warning)
+		}
+	}
+}
+
+// printCheck prints a single pass/fail line of the system section
+func printCheck(ok bool, name, details string) {
+	status := "✅"
+	if !ok {
+		status = "❌"
+	}
+	if details != "" {
+		fmt.Printf("  %s %s: %s\n", status, name, details)
+	} else {
+		fmt.Printf("  %s %s\n", status, name)
+	}
+}
+
+// printDiagnosticIssue prints an issue or warning with its suggestion
+func printDiagnosticIssue(issue DiagnosticIssue) {
+	prefix := fmt.Sprintf("[%s] ", issue.Category)
+	if issue.Account != "" {
+		prefix = fmt.Sprintf("[%s/%s] ", issue.Category, issue.Account)
+	}
+	fmt.Printf("  • %s%s\n", prefix, issue.Message)
+	if issue.Suggestion != "" {
+		fmt.Printf("    💡 %s\n", issue.Suggestion)
+	}
+}
+
+// healthEmoji returns the emoji shown next to an overall health level
+func healthEmoji(health string) string {
+	switch health {
+	case HealthExcellent:
+		return "💚"
+	case HealthGood:
+		return "✅"
+	case HealthFair:
+		return "⚠️ "
+	case HealthPoor:
+		return "🟠"
+	default:
+		return "🚨"
+	}
+}
+
+// valueOrNone returns value, or "(none)" when it is empty
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// repoGitValue runs a git command inside repoPath and returns its trimmed output, or ""
+// when it fails
+func repoGitValue(ctx context.Context, repoPath string, args ...string) string {
+	output, err := execrunner.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// remoteHost extracts the host (or SSH host alias) from a Git remote URL, or "" when the
+// URL can't be parsed
+func remoteHost(remoteURL string) string {
+	host, _, err := git.ParseRemoteURL(remoteURL)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// gitConfigValue reads a global Git config value, returning "" when it is unset
+func gitConfigValue(ctx context.Context, key string) string {
+	output, err := execrunner.CommandContext(ctx, "git", "config", "--global", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// setGitConfigValue sets a global Git config value
+func setGitConfigValue(ctx context.Context, key, value string) error {
+	if err := execrunner.CommandContext(ctx, "git", "config", "--global", key, value).Run(); err != nil {
+		return fmt.Errorf("failed to set global %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cryptossh "golang.org/x/crypto/ssh"
+
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/switcher"
+	"github.com/techishthoughts/gitshift/internal/token"
+)
+
+// writeTestPublicKey writes a fresh ed25519 public key to keyPath.pub and returns it in
+// authorized_keys format
+func writeTestPublicKey(t *testing.T, keyPath string) string {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := cryptossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized := strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(sshPub)))
+	if err := os.WriteFile(keyPath+".pub", []byte(authorized+" work@example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return authorized
+}
+
+func TestValidatePlatformIntegration(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		registerKey    bool
+		tilde          bool // name the key relative to ~
+		wantRegistered *bool
+		wantIssues     int
+		wantWarning    string
+	}{
+		{name: "registered", status: http.StatusOK, registerKey: true, wantRegistered: boolPtr(true)},
+		{name: "not registered", status: http.StatusOK, wantRegistered: boolPtr(false), wantIssues: 1},
+		{name: "home-relative key path", status: http.StatusOK, registerKey: true, tilde: true, wantRegistered: boolPtr(true)},
+		{name: "token rejected", status: http.StatusUnauthorized, wantWarning: "rejected the token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			keyPath := filepath.Join(dir, "id_ed25519_work")
+			publicKey := writeTestPublicKey(t, keyPath)
+
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.URL.Path != "/user/keys" {
+					t.Errorf("path = %s, want /user/keys", r.URL.Path)
+				}
+				if tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, `{"message":"Bad credentials"}`)
+					return
+				}
+				keys := `[{"id":1,"key":"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl","title":"other"}]`
+				if tt.registerKey {
+					keys = fmt.Sprintf(`[{"id":2,"key":%q,"title":"work"}]`, publicKey)
+				}
+				fmt.Fprint(w, keys)
+			}))
+			defer server.Close()
+
+			store := token.NewFileStore(filepath.Join(dir, "tokens"))
+			if err := store.Set("work", "ghp_test"); err != nil {
+				t.Fatal(err)
+			}
+			account := &models.Account{
+				Alias:       "work",
+				Platform:    "github",
+				Domain:      "github.com",
+				APIEndpoint: server.URL,
+				SSHKeyPath:  keyPath,
+			}
+			if tt.tilde {
+				t.Setenv("HOME", dir)
+				account.SSHKeyPath = "~/id_ed25519_work"
+			}
+
+			d := &DiagnoseCommand{apiAttempts: 1}
+			results := &DiagnosticResults{}
+			result := &AccountDiagnostic{Alias: account.Alias, Healthy: true}
+			d.validatePlatformIntegration(context.Background(), results, store, account, result)
+
+			if (result.SSHKeyRegistered == nil) != (tt.wantRegistered == nil) ||
+				(tt.wantRegistered != nil && *result.SSHKeyRegistered != *tt.wantRegistered) {
+				t.Errorf("SSHKeyRegistered = %v, want %v", result.SSHKeyRegistered, tt.wantRegistered)
+			}
+			if len(results.Issues) != tt.wantIssues {
+				t.Errorf("issues = %+v, want %d", results.Issues, tt.wantIssues)
+			}
+			if tt.wantIssues > 0 && result.Healthy {
+				t.Error("account with an unregistered key is still healthy")
+			}
+			if tt.wantWarning != "" && (len(results.Warnings) != 1 || !strings.Contains(results.Warnings[0].Message, tt.wantWarning)) {
+				t.Errorf("warnings = %+v, want one containing %q", results.Warnings, tt.wantWarning)
+			}
+
+			// The key list is cached per endpoint and token
+			if tt.status == http.StatusOK {
+				d.validatePlatformIntegration(context.Background(), &DiagnosticResults{}, store, account, &AccountDiagnostic{Alias: account.Alias})
+				if requests != 1 {
+					t.Errorf("made %d requests for two checks, want 1", requests)
+				}
+			}
+		})
+	}
+}
+
+func TestRunPhase_DropsOnlyTheSlowPhase(t *testing.T) {
+	d := &DiagnoseCommand{timeout: 50 * time.Millisecond}
+	results := &DiagnosticResults{Timings: make(map[string]PhaseTiming)}
+
+	d.runPhase(context.Background(), results, "fast", func(ctx context.Context, r *DiagnosticResults) {
+		r.addIssue(SeverityLow, "fast", "", "found by the fast phase", "")
+	})
+	d.runPhase(context.Background(), results, "slow", func(ctx context.Context, r *DiagnosticResults) {
+		r.addIssue(SeverityLow, "slow", "", "found by the slow phase", "")
+		<-ctx.Done()
+	})
+
+	if len(results.Issues) != 1 || results.Issues[0].Category != "fast" {
+		t.Errorf("issues = %+v, want only the fast phase's", results.Issues)
+	}
+	if len(results.Warnings) != 1 || !strings.Contains(results.Warnings[0].Message, "slow checks did not finish") {
+		t.Errorf("warnings = %+v, want the slow phase reported", results.Warnings)
+	}
+	if !results.Timings["slow"].TimedOut || results.Timings["fast"].TimedOut {
+		t.Errorf("timings = %+v, want only the slow phase timed out", results.Timings)
+	}
+}
+
+// slowKeyRunner answers ssh-keygen -lf for every key, except that the first request for
+// the public key at slow hangs until its context is done
+type slowKeyRunner struct {
+	mu   sync.Mutex
+	slow string
+	hung bool
+}
+
+func (r *slowKeyRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if name != "ssh-keygen" || len(args) != 2 || args[0] != "-lf" {
+		return nil, fmt.Errorf("unexpected command: %s %v", name, args)
+	}
+	r.mu.Lock()
+	hang := args[1] == r.slow && !r.hung
+	r.hung = r.hung || hang
+	r.mu.Unlock()
+	if hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return []byte("256 SHA256:" + filepath.Base(args[1]) + " dev@example.com (ED25519)\n"), nil
+}
+
+func (r *slowKeyRunner) InteractiveCombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.CombinedOutput(ctx, name, args...)
+}
+
+func TestDiagnoseAccounts_SlowAccountKeepsTheOthers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, alias := range []string{"fast", "slow"} {
+		keyPath := filepath.Join(sshDir, "id_ed25519_"+alias)
+		writeTestPublicKey(t, keyPath)
+		if err := os.WriteFile(keyPath, []byte("PRIVATE KEY"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := configManager.AddAccount(models.NewAccount(alias, "Dev", alias+"@example.com", keyPath)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := &DiagnoseCommand{
+		configManager: configManager,
+		sshManager:    ssh.NewManagerWithRunner(&slowKeyRunner{slow: filepath.Join(sshDir, "id_ed25519_slow")}),
+		offline:       true,
+		timeout:       100 * time.Millisecond,
+	}
+	results := &DiagnosticResults{}
+	d.runPhase(context.Background(), results, PhaseAccounts, d.diagnoseAccounts)
+
+	if len(results.AccountResults) != 1 || results.AccountResults[0].Alias != "fast" {
+		t.Fatalf("account results = %+v, want only the fast account", results.AccountResults)
+	}
+	var skipped bool
+	for _, warning := range results.Warnings {
+		if warning.Account == "slow" && strings.Contains(warning.Message, "did not finish") {
+			skipped = true
+		}
+		if warning.Category == PhaseAccounts && warning.Account == "" && strings.Contains(warning.Message, "did not finish") {
+			t.Errorf("the whole accounts phase timed out: %s", warning.Message)
+		}
+	}
+	if !skipped {
+		t.Errorf("warnings = %+v, want the slow account reported", results.Warnings)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestDiagnoseGit_AcceptsTheSwitchSSHCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(home, ".ssh", "id_ed25519_work")
+	if err := configManager.AddAccount(models.NewAccount("work", "Dev", "dev@work.com", keyPath)); err != nil {
+		t.Fatal(err)
+	}
+	if err := configManager.SetCurrentAccount("work"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for key, value := range map[string]string{"user.name": "Dev", "user.email": "dev@work.com"} {
+		if err := setGitConfigValue(ctx, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		sshCommand string
+		wantWarned bool
+	}{
+		{"written by a switch", switcher.SSHCommand(keyPath), false},
+		{"set by hand", "ssh -i " + keyPath + " -v", true},
+		{"without a key", "ssh -o ProxyCommand=none", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setGitConfigValue(ctx, "core.sshCommand", tt.sshCommand); err != nil {
+				t.Fatal(err)
+			}
+			d := &DiagnoseCommand{configManager: configManager}
+			results := &DiagnosticResults{}
+			results.SystemHealth.GitAvailable = true
+			d.diagnoseGit(ctx, results)
+
+			warned := false
+			for _, warning := range results.Warnings {
+				warned = warned || strings.Contains(warning.Message, "core.sshCommand")
+			}
+			if warned != tt.wantWarned || len(results.Issues) != 0 {
+				t.Errorf("diagnoseGit() warnings = %+v, issues = %+v; want core.sshCommand warned: %v", results.Warnings, results.Issues, tt.wantWarned)
+			}
+		})
+	}
+}
+
+func TestDiagnoseRepository_SSHCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	repo := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v\n%s", err, output)
+	}
+	for _, args := range [][]string{
+		{"remote", "add", "origin", "git@github.com-work:acme/app.git"},
+		{"config", "user.email", "dev@work.com"},
+	} {
+		if output, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]string)
+	for _, alias := range []string{"work", "personal"} {
+		keys[alias] = filepath.Join(home, ".ssh", "id_ed25519_"+alias)
+		if err := configManager.AddAccount(models.NewAccount(alias, "Dev", "dev@"+alias+".com", keys[alias])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The remote pins work, which takes precedence over the current account
+	if err := configManager.SetCurrentAccount("personal"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		sshCommand string
+		wantIssue  bool
+		wantWarned bool
+	}{
+		{"written by a switch to the remote's account", switcher.SSHCommand(keys["work"]), false, false},
+		{"written by a switch to another account", switcher.SSHCommand(keys["personal"]), true, false},
+		{"set by hand", "ssh -i " + keys["work"] + " -v", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if output, err := exec.Command("git", "-C", repo, "config", "core.sshCommand", tt.sshCommand).CombinedOutput(); err != nil {
+				t.Fatalf("git config failed: %v\n%s", err, output)
+			}
+			d := &DiagnoseCommand{configManager: configManager, repoPath: repo}
+			results := &DiagnosticResults{}
+			d.diagnoseRepository(context.Background(), results)
+
+			var issue, warned bool
+			for _, i := range results.Issues {
+				issue = issue || strings.Contains(i.Message, "core.sshCommand")
+			}
+			for _, warning := range results.Warnings {
+				warned = warned || strings.Contains(warning.Message, "core.sshCommand")
+			}
+			if issue != tt.wantIssue || warned != tt.wantWarned {
+				t.Errorf("diagnoseRepository() issues = %+v, warnings = %+v; want issue %v, warning %v",
+					results.Issues, results.Warnings, tt.wantIssue, tt.wantWarned)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/techishthoughts/gitshift/internal/config"
 	"github.com/techishthoughts/gitshift/internal/discovery"
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
 )
 
 // discoverCmd represents the discover command
@@ -18,6 +19,7 @@ var discoverCmd = &cobra.Command{
 	Long: `Automatically discover and import existing Git accounts from:
 
 - SSH keys in ~/.ssh/ directory
+- Host blocks in ~/.ssh/config, including files pulled in with Include
 - GPG signing keys from system keyring
 - Matches SSH and GPG keys by email address
 
@@ -35,7 +37,10 @@ Examples:
   gitshift discover --auto-import
 
   # Dry run to see what would be discovered
-  gitshift discover --dry-run`,
+  gitshift discover --dry-run
+
+  # Only consider accounts discovered with high confidence
+  gitshift discover --min-confidence 8`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		configManager := config.NewManager()
 		if err := configManager.Load(); err != nil {
@@ -66,14 +71,23 @@ Examples:
 		}
 
 		// Discover accounts
-		discovery := discovery.NewAccountDiscovery()
+		accountDiscovery := discovery.NewAccountDiscovery()
 		fmt.Println("🔍 Scanning system for existing Git accounts...")
 
-		discovered, err := discovery.ScanExistingAccounts()
+		discovered, err := accountDiscovery.ScanExistingAccounts()
 		if err != nil {
 			return fmt.Errorf("failed to discover accounts: %w", err)
 		}
 
+		minConfidence, _ := cmd.Flags().GetInt("min-confidence")
+		if minConfidence > 0 {
+			filtered := discovery.FilterByConfidence(discovered, minConfidence)
+			if skipped := len(discovered) - len(filtered); skipped > 0 {
+				fmt.Printf("🔽 Skipping %d account(s) with confidence below %d/10\n", skipped, minConfidence)
+			}
+			discovered = filtered
+		}
+
 		if len(discovered) == 0 {
 			fmt.Println("❌ No existing Git accounts found on your system.")
 			fmt.Println("💡 Use 'gitshift add-github username' for automatic setup!")
@@ -126,6 +140,9 @@ Examples:
 
 			fmt.Printf("   Source: %s\n", account.Source)
 			fmt.Printf("   Confidence: %d/10\n", account.Confidence)
+			for _, reason := range account.ConfidenceReasons {
+				fmt.Printf("     • %s\n", reason)
+			}
 
 			// More lenient import criteria - allow import if we have at least name OR email, and confidence is reasonable
 			canImport := !dryRun && (autoImport || account.Confidence >= 6) &&
@@ -275,6 +292,7 @@ Examples:
 		}
 
 		if imported > 0 {
+			refreshHostAliases(configManager, importedAccounts...)
 			fmt.Printf("🎉 Successfully imported %d account(s)!\n", imported)
 			fmt.Println("💡 Use 'gitshift list' to see all accounts")
 
@@ -315,18 +333,20 @@ func testSSHForAccount(account *models.Account) error {
 		return fmt.Errorf("no SSH key configured")
 	}
 
+	keyPath := pathutil.Expand(account.SSHKeyPath)
+
 	// Check if SSH key file exists
-	if _, err := os.Stat(account.SSHKeyPath); os.IsNotExist(err) {
-		return fmt.Errorf("SSH key file not found: %s", account.SSHKeyPath)
+	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+		return fmt.Errorf("SSH key file not found: %s", keyPath)
 	}
 
 	// Check if SSH key file is readable
-	if _, err := os.ReadFile(account.SSHKeyPath); err != nil {
+	if _, err := os.ReadFile(keyPath); err != nil {
 		return fmt.Errorf("SSH key file not readable: %s", err)
 	}
 
 	// Check file permissions (should be 600 for SSH keys)
-	if info, err := os.Stat(account.SSHKeyPath); err == nil {
+	if info, err := os.Stat(keyPath); err == nil {
 		mode := info.Mode().Perm()
 		if mode != 0600 {
 			fmt.Printf("   ⚠️  SSH key permissions should be 600, got %o\n", mode)
@@ -342,4 +362,5 @@ func init() {
 	discoverCmd.Flags().Bool("dry-run", false, "Show what would be discovered without importing")
 	discoverCmd.Flags().Bool("auto-import", false, "Automatically import suitable accounts")
 	discoverCmd.Flags().Bool("overwrite", false, "Allow discovery even when accounts already exist")
+	discoverCmd.Flags().Int("min-confidence", 0, "Only show and import accounts with at least this confidence (1-10)")
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"golang.org/x/term"
+)
+
+// bundlePassphraseEnv lets scripts supply the bundle passphrase without a terminal
+const bundlePassphraseEnv = "GITSHIFT_BUNDLE_PASSPHRASE"
+
+var exportCmd = &cobra.Command{
+	Use:   "export [account...]",
+	Short: "📦 Export accounts to a portable file",
+	Long: `Export accounts to a YAML bundle that 'gitshift import' can read on another
+machine. Without arguments all accounts are exported.
+
+Secrets are never exported in plaintext. With --include-tokens and
+--include-ssh-keys the stored tokens and SSH key files are added to the bundle
+encrypted with a passphrase, which is prompted for or read from
+GITSHIFT_BUNDLE_PASSPHRASE. The bundle is written with 0600 permissions.`,
+	Example: `  # Export all accounts without secrets
+  gitshift export --out accounts.yaml
+
+  # Export one account with its token and SSH key
+  gitshift export work --out work.yaml --include-tokens --include-ssh-keys`,
+	ValidArgsFunction: completeAccountAliases,
+	RunE:              runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringP("out", "o", "", "File to write the bundle to (default: standard output)")
+	exportCmd.Flags().Bool("include-tokens", false, "Include stored tokens, encrypted with a passphrase")
+	exportCmd.Flags().Bool("include-ssh-keys", false, "Include SSH key files, encrypted with a passphrase")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	out, _ := cmd.Flags().GetString("out")
+	includeTokens, _ := cmd.Flags().GetBool("include-tokens")
+	includeSSHKeys, _ := cmd.Flags().GetBool("include-ssh-keys")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	opts := config.ExportOptions{
+		Aliases:        args,
+		IncludeTokens:  includeTokens,
+		IncludeSSHKeys: includeSSHKeys,
+	}
+	if includeTokens || includeSSHKeys {
+		passphrase, err := readBundlePassphrase(true)
+		if err != nil {
+			return err
+		}
+		opts.Passphrase = passphrase
+	}
+
+	bundle, err := configManager.ExportAccounts(opts)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		return config.WriteBundle(os.Stdout, bundle)
+	}
+
+	file, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	// OpenFile keeps the mode of an existing file, so enforce it before writing secrets
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to set permissions on %s: %w", out, err)
+	}
+	if err := config.WriteBundle(file, bundle); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+
+	fmt.Printf("📦 Exported %d account(s) to %s\n", len(bundle.Accounts), out)
+	if bundle.Secrets != nil {
+		fmt.Println("🔐 Secrets are encrypted; keep the passphrase to import them")
+	}
+	return nil
+}
+
+// readBundlePassphrase reads the bundle passphrase from the environment or, on a terminal,
+// prompts for it without echo. New passphrases are asked for twice.
+func readBundlePassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(bundlePassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a passphrase is required: set %s when not running in a terminal", bundlePassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "🔐 Bundle passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("no passphrase provided")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "🔐 Repeat passphrase: ")
+		repeated, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if string(repeated) != string(passphrase) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return string(passphrase), nil
+}
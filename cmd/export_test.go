@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunExport_RestrictsAnExistingFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+
+	out := filepath.Join(home, "accounts.yaml")
+	if err := os.WriteFile(out, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("out", "", "")
+	cmd.Flags().Bool("include-tokens", false, "")
+	cmd.Flags().Bool("include-ssh-keys", false, "")
+	if err := cmd.ParseFlags([]string{"--out", out}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport(cmd, nil); err != nil {
+		t.Fatalf("runExport() error = %v", err)
+	}
+
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("bundle mode = %o, want 600", mode)
+	}
+}
@@ -42,8 +42,9 @@ The generated keys can be used with any Git platform:
 
   # Enable GPG signing automatically for this account
   gitshift gpg-keygen myaccount --enable`,
-	Args: cobra.ExactArgs(1),
-	RunE: runGPGKeygen,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
+	RunE:              runGPGKeygen,
 }
 
 var (
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// gitshiftHookMarker identifies hooks written by gitshift, so they can be updated or removed
+// without touching hooks the user wrote
+const gitshiftHookMarker = "# Installed by 'gitshift hooks install'"
+
+// hooksCmd groups commands that manage Git hooks in the current repository
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "🪝 Manage gitshift Git hooks",
+	Long:  `Manage the Git hooks gitshift installs in the current repository.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "🪝 Install a pre-push hook that enforces the right account",
+	Long: `Install a pre-push hook in the current repository that runs
+'gitshift whoami --check --offline' against the remote being pushed to, and
+aborts the push when the active account, commit email or GitHub username
+doesn't match the account the remote expects (see 'gitshift auto').
+
+The hook is written to the directory Git actually uses for hooks, so
+core.hooksPath is respected. It records the path of the gitshift binary that
+installed it and falls back to gitshift on PATH; if neither is available the
+push is allowed with a warning rather than blocked.
+
+An existing pre-push hook that gitshift didn't write is left alone unless
+--force is given.`,
+	Example: `  # Install the pre-push hook
+  gitshift hooks install
+
+  # Remove it again
+  gitshift hooks install --uninstall`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runHooksInstall,
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstallCmd)
+
+	hooksInstallCmd.Flags().Bool("uninstall", false, "Remove the gitshift pre-push hook")
+	hooksInstallCmd.Flags().Bool("force", false, "Replace an existing pre-push hook that gitshift didn't install")
+}
+
+func runHooksInstall(cmd *cobra.Command, args []string) error {
+	uninstall, _ := cmd.Flags().GetBool("uninstall")
+	force, _ := cmd.Flags().GetBool("force")
+
+	hookPath, err := prePushHookPath(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(hookPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", hookPath, err)
+	}
+	ownHook := strings.Contains(string(existing), gitshiftHookMarker)
+
+	if uninstall {
+		if existing == nil {
+			fmt.Println("ℹ️  No pre-push hook installed")
+			return nil
+		}
+		if !ownHook {
+			return fmt.Errorf("%s was not installed by gitshift; remove it manually", hookPath)
+		}
+		if err := os.Remove(hookPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", hookPath, err)
+		}
+		fmt.Printf("🗑️  Removed pre-push hook %s\n", hookPath)
+		return nil
+	}
+
+	if existing != nil && !ownHook && !force {
+		return fmt.Errorf("%s already exists and was not installed by gitshift; use --force to replace it", hookPath)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		executable = ""
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(hookPath, []byte(prePushHookScript(executable)), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", hookPath, err)
+	}
+	// WriteFile keeps the mode of an existing file, so make sure the hook is executable
+	if err := os.Chmod(hookPath, 0755); err != nil {
+		return fmt.Errorf("failed to make %s executable: %w", hookPath, err)
+	}
+
+	fmt.Printf("✅ Installed pre-push hook %s\n", hookPath)
+	fmt.Println("💡 Pushes now fail when the identity doesn't match the remote's account")
+	return nil
+}
+
+// prePushHookPath returns the pre-push hook path Git uses for the current repository,
+// honouring core.hooksPath
+func prePushHookPath(ctx context.Context) (string, error) {
+	if repoGitValue(ctx, ".", "rev-parse", "--is-inside-work-tree") != "true" {
+		return "", fmt.Errorf("not inside a Git repository")
+	}
+
+	hooksDir := repoGitValue(ctx, ".", "rev-parse", "--git-path", "hooks")
+	if hooksDir == "" {
+		return "", fmt.Errorf("failed to locate the repository's hooks directory")
+	}
+	if !filepath.IsAbs(hooksDir) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		hooksDir = filepath.Join(cwd, hooksDir)
+	}
+
+	return filepath.Join(hooksDir, "pre-push"), nil
+}
+
+// prePushHookScript renders the pre-push hook. Git passes the remote name and its URL as
+// $1 and $2, or the URL twice when pushing to a URL, which --remote accepts as well.
+// Pushes to a local path have no account to check.
+func prePushHookScript(executable string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s
+# Aborts the push when the Git identity doesn't match the account the remote expects.
+
+GITSHIFT=%s
+if [ ! -x "$GITSHIFT" ]; then
+	GITSHIFT=$(command -v gitshift 2>/dev/null)
+fi
+if [ -z "$GITSHIFT" ]; then
+	echo "gitshift: not found, skipping identity check" >&2
+	exit 0
+fi
+
+case "$2" in
+/*|./*|../*|file://*)
+	exit 0
+	;;
+esac
+
+exec "$GITSHIFT" whoami --check --offline --remote "$1"
+`, gitshiftHookMarker, shellQuote(executable))
+}
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrePushHookScript(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	gitshift := filepath.Join(dir, "gitshift")
+	if err := os.WriteFile(gitshift, []byte("#!/bin/sh\necho \"$@\" > "+shellQuote(argsFile)+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	hook := filepath.Join(dir, "pre-push")
+	if err := os.WriteFile(hook, []byte(prePushHookScript(gitshift)), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantArgs string // "" when the check is skipped
+	}{
+		{"named remote", []string{"origin", "git@github.com:acme/app.git"}, "whoami --check --offline --remote origin"},
+		{"URL", []string{"git@github.com:acme/app.git", "git@github.com:acme/app.git"}, "whoami --check --offline --remote git@github.com:acme/app.git"},
+		{"local path", []string{"../backup.git", "../backup.git"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(argsFile)
+			if output, err := exec.Command(hook, tt.args...).CombinedOutput(); err != nil {
+				t.Fatalf("hook failed: %v\n%s", err, output)
+			}
+			args, _ := os.ReadFile(argsFile)
+			if got := strings.TrimSpace(string(args)); got != tt.wantArgs {
+				t.Errorf("hook ran gitshift %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestResolveRemoteURL(t *testing.T) {
+	repo := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v\n%s", err, output)
+	}
+	if output, err := exec.Command("git", "-C", repo, "remote", "add", "origin", "git@github-work:acme/app.git").CombinedOutput(); err != nil {
+		t.Fatalf("git remote add failed: %v\n%s", err, output)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"origin", "git@github-work:acme/app.git"},
+		{"https://github.com/acme/app.git", "https://github.com/acme/app.git"},
+		{"git@github.com:acme/app.git", "git@github.com:acme/app.git"},
+		{"upstream", ""},
+		{"../backup.git", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveRemoteURL(context.Background(), tt.remote); got != tt.want {
+			t.Errorf("resolveRemoteURL(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "📥 Import accounts from an exported bundle",
+	Long: `Import accounts from a bundle written by 'gitshift export' and merge them
+into the current configuration.
+
+Accounts whose alias already exists are skipped unless --overwrite replaces
+them or --rename imports them under a free alias such as "work-2".
+
+If the bundle contains encrypted secrets, you are asked for the passphrase
+(or it is read from GITSHIFT_BUNDLE_PASSPHRASE). Tokens go to the configured
+token backend and SSH keys are written with 0600 permissions. Keys are only
+restored under ~/.ssh; a bundle with a key path elsewhere is rejected. Existing
+key files that differ are only replaced with --overwrite. Use --skip-secrets to
+import just the accounts.`,
+	Example: `  # Import new accounts, skipping ones that already exist
+  gitshift import accounts.yaml
+
+  # Import everything, keeping both copies of colliding accounts
+  gitshift import accounts.yaml --rename`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().Bool("overwrite", false, "Replace existing accounts with the same alias")
+	importCmd.Flags().Bool("rename", false, "Import colliding accounts under a new alias")
+	importCmd.Flags().Bool("skip-secrets", false, "Don't import the bundle's tokens and SSH keys")
+	importCmd.MarkFlagsMutuallyExclusive("overwrite", "rename")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+	rename, _ := cmd.Flags().GetBool("rename")
+	skipSecrets, _ := cmd.Flags().GetBool("skip-secrets")
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	bundle, err := config.ReadBundle(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	opts := config.ImportOptions{Overwrite: overwrite, Rename: rename}
+	if bundle.Secrets != nil && !skipSecrets {
+		if opts.Passphrase, err = readBundlePassphrase(false); err != nil {
+			return err
+		}
+	}
+
+	result, err := configManager.ImportAccounts(bundle, opts)
+	if err != nil {
+		return err
+	}
+
+	var imported []*models.Account
+	for _, alias := range result.Imported {
+		fmt.Printf("✅ Imported account '%s'\n", alias)
+		if account, err := configManager.GetAccount(alias); err == nil {
+			imported = append(imported, account)
+		}
+	}
+	refreshHostAliases(configManager, imported...)
+	for from, to := range result.Renamed {
+		fmt.Printf("🔀 '%s' already existed, imported as '%s'\n", from, to)
+	}
+	for _, alias := range result.Skipped {
+		fmt.Printf("⏭️  Skipped '%s': an account with that alias already exists\n", alias)
+	}
+	if result.Tokens > 0 {
+		fmt.Printf("🔑 Stored %d token(s)\n", result.Tokens)
+	}
+	for _, path := range result.SSHKeys {
+		fmt.Printf("🔐 Restored SSH key %s\n", path)
+	}
+	for _, path := range result.SkippedSSHKeys {
+		fmt.Printf("⚠️  Kept existing SSH key %s (differs from the bundle; use --overwrite to replace)\n", path)
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Println("\n💡 Use --rename to keep both copies or --overwrite to replace the existing accounts")
+	}
+	if len(result.Imported) > 0 {
+		fmt.Println("💡 Run 'gitshift ssh-config validate' and 'gitshift diagnose' to check the imported accounts")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// isolationCmd groups commands that manage account isolation levels
+var isolationCmd = &cobra.Command{
+	Use:   "isolation",
+	Short: "🔒 Manage account isolation levels",
+	Long: `Manage how strictly gitshift isolates an account's credentials from other
+accounts.
+
+Levels, from weakest to strongest:
+- none: legacy accounts, no isolation settings
+- basic: ssh only offers the account's own key (IdentitiesOnly)
+- standard: basic plus encrypted per-account token storage
+
+The strict and complete levels of GitPersona configs are not applicable:
+gitshift runs no isolated SSH agents or containers to enforce them.`,
+}
+
+var isolationUpgradeCmd = &cobra.Command{
+	Use:   "upgrade <account>",
+	Short: "⬆️  Upgrade an account to a stronger isolation level",
+	Long: `Upgrade an account to a stronger isolation level, provisioning what the level
+relies on: the encrypted token storage path, created with 0700 permissions.
+
+The upgraded account is validated before it is saved. If validation fails,
+nothing is saved and the directories created for it are removed. Running the
+command again with the same level changes nothing, and it never lowers a level.
+The settings that changed are printed.`,
+	Example: `  # Move a legacy account to standard isolation
+  gitshift isolation upgrade work --level standard`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
+	RunE:              runIsolationUpgrade,
+}
+
+func init() {
+	rootCmd.AddCommand(isolationCmd)
+	isolationCmd.AddCommand(isolationUpgradeCmd)
+
+	isolationUpgradeCmd.Flags().String("level", string(models.IsolationLevelStandard), "Isolation level to upgrade to: basic or standard")
+}
+
+func runIsolationUpgrade(cmd *cobra.Command, args []string) error {
+	alias := args[0]
+	level, _ := cmd.Flags().GetString("level")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	upgrade, err := configManager.UpgradeIsolation(alias, models.IsolationLevel(level))
+	if err != nil {
+		return err
+	}
+
+	if len(upgrade.Changes) == 0 {
+		fmt.Printf("✅ Account '%s' already has %s isolation, nothing to change\n", alias, upgrade.To)
+		return nil
+	}
+
+	fmt.Printf("🔒 Upgraded account '%s' from %s to %s isolation\n\n", alias, upgrade.From, upgrade.To)
+	for _, change := range upgrade.Changes {
+		fmt.Printf("  %s\n", change.Field)
+		if change.Old != "" {
+			fmt.Printf("    - %s\n", change.Old)
+		}
+		fmt.Printf("    + %s\n", change.New)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -9,17 +11,39 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/techishthoughts/gitshift/internal/config"
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/pkg/redact"
+	"gopkg.in/yaml.v3"
 )
 
+// listFormats are the output formats of the list command
+var listFormats = []string{"table", "wide", "json", "yaml"}
+
+// listSorts are the orders the list command can sort accounts in
+var listSorts = []string{"alias", "recent"}
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all configured Git platform accounts",
-	Long: `List all configured Git platform accounts with their details.
+	Long: `List all configured Git platform accounts, sorted by alias or, with
+--sort recent, by when they were last switched to, most recent first.
 
-The output shows the alias, name, email, platform, and additional information for each account.
 The current active account is marked with an asterisk (*).
 
+Output formats (--format):
+  table  alias, name, email, platform, username and description (the default)
+  wide   the table plus isolation level, last use and status
+  json   the accounts as a JSON array
+  yaml   the accounts as a YAML list
+
+The JSON and YAML output hold the accounts as stored in the configuration, with
+anything that looks like a token masked. Tokens themselves are never part of
+an account. Pending accounts found by discovery are only listed in the table
+formats.
+
+--json, --detailed and --verbose are deprecated: use --format json and
+--format wide.
+
 Displays accounts from all platforms:
 - GitHub (github.com and GitHub Enterprise)
 - GitLab (gitlab.com and self-hosted)
@@ -30,257 +54,181 @@ Examples:
   # List all accounts
   gitshift list
 
-  # List in table format
-  gitshift list --format table
+  # Include isolation level, last use and status
+  gitshift list --format wide
+
+  # The accounts used most recently first
+  gitshift list --sort recent
 
   # List in JSON format
   gitshift list --format json`,
 	Aliases: []string{"ls"},
-	RunE: func(cmd *cobra.Command, args []string) error {
-		configManager := config.NewManager()
-		if err := configManager.Load(); err != nil {
-			return fmt.Errorf("failed to load configuration: %w", err)
-		}
-
-		accounts := configManager.ListAccounts()
-		pendingAccounts := configManager.ListPendingAccounts()
-
-		if len(accounts) == 0 && len(pendingAccounts) == 0 {
-			fmt.Println("No accounts configured. Use 'gitshift add' to add an account.")
-			return nil
-		}
-
-		format, _ := cmd.Flags().GetString("format")
-		currentAccount := configManager.GetConfig().CurrentAccount
-
-		// Show active accounts
-		if len(accounts) > 0 {
-			switch format {
-			case "json":
-				return printAccountsJSON(accounts)
-			case "table":
-				return printAccountsTable(accounts, currentAccount)
-			default:
-				if err := printAccountsDefault(accounts, currentAccount); err != nil {
-					return err
-				}
-			}
-		}
-
-		// Show pending accounts if any
-		if len(pendingAccounts) > 0 {
-			fmt.Println()
-			fmt.Println("📋 Pending Accounts (need completion):")
-			fmt.Println()
-
-			for _, pending := range pendingAccounts {
-				fmt.Printf("  %s\n", pending.Alias)
-				if pending.GitHubUsername != "" {
-					fmt.Printf("    GitHub: @%s\n", pending.GitHubUsername)
-				}
-				fmt.Printf("    Missing: %v\n", pending.MissingFields)
-				fmt.Printf("    Source: %s\n", pending.Source)
-				fmt.Printf("    💡 Complete with: gitshift complete %s --name \"Your Name\" --email \"your@email.com\"\n", pending.Alias)
-				fmt.Println()
-			}
-		}
-
-		return nil
-	},
+	Args:    cobra.NoArgs,
+	RunE:    runList,
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 
-	listCmd.Flags().StringP("format", "f", "default", "Output format (default, table, json)")
+	listCmd.Flags().StringP("format", "f", "table", "Output format: "+strings.Join(listFormats, ", "))
+	listCmd.Flags().String("sort", "alias", "Order of the accounts: "+strings.Join(listSorts, ", "))
+	listCmd.Flags().Bool("json", false, "Output as JSON")
+	listCmd.Flags().Bool("detailed", false, "Show more details")
+	listCmd.Flags().Bool("verbose", false, "Show more details")
+	_ = listCmd.Flags().MarkDeprecated("json", "use --format json")
+	_ = listCmd.Flags().MarkDeprecated("detailed", "use --format wide")
+	_ = listCmd.Flags().MarkDeprecated("verbose", "use --format wide")
+	_ = listCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(listFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = listCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(listSorts, cobra.ShellCompDirectiveNoFileComp))
 }
 
-func printAccountsDefault(accounts []*models.Account, currentAccount string) error {
-	// Group accounts by platform
-	accountsByPlatform := groupAccountsByPlatform(accounts)
-
-	// Sort platforms for consistent output
-	platforms := make([]string, 0, len(accountsByPlatform))
-	for platform := range accountsByPlatform {
-		platforms = append(platforms, platform)
+func runList(cmd *cobra.Command, args []string) error {
+	format, err := listFormat(cmd)
+	if err != nil {
+		return err
+	}
+	order, _ := cmd.Flags().GetString("sort")
+	if !slices.Contains(listSorts, order) {
+		return fmt.Errorf("invalid --sort %q: use one of %s", order, strings.Join(listSorts, ", "))
 	}
-	sort.Strings(platforms)
-
-	// Print accounts grouped by platform
-	for _, platform := range platforms {
-		platformAccounts := accountsByPlatform[platform]
-		// Sort accounts within platform by alias
-		sort.Slice(platformAccounts, func(i, j int) bool {
-			return platformAccounts[i].Alias < platformAccounts[j].Alias
-		})
-
-		platformDisplay := getPlatformDisplay(platform)
-		fmt.Printf("%s\n", platformDisplay)
-		fmt.Println()
 
-		for _, account := range platformAccounts {
-			marker := "  "
-			if account.Alias == currentAccount {
-				marker = "* "
-			}
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
 
-			fmt.Printf("%s%s\n", marker, account.Alias)
-			fmt.Printf("    Name:  %s\n", account.Name)
-			fmt.Printf("    Email: %s\n", account.Email)
-
-			// Display platform-specific username
-			username := account.GetUsername()
-			if username != "" {
-				platformName := strings.ToUpper(platform[:1]) + platform[1:]
-				fmt.Printf("    %s: @%s\n", platformName, username)
-			} else {
-				platformName := strings.ToUpper(platform[:1]) + platform[1:]
-				fmt.Printf("    %s: (not set)\n", platformName)
-			}
+	accounts := configManager.ListAccounts()
+	sortAccounts(accounts, order)
 
-			// Display domain if different from default
-			domain := account.GetDomain()
-			defaultDomain := getDefaultDomain(platform)
-			if domain != "" && domain != defaultDomain {
-				fmt.Printf("    Domain: %s\n", domain)
-			}
+	switch format {
+	case "json", "yaml":
+		return printAccountsEncoded(accounts, format)
+	}
 
-			if account.SSHKeyPath != "" {
-				fmt.Printf("    SSH Key: %s\n", account.SSHKeyPath)
-			}
+	pendingAccounts := configManager.ListPendingAccounts()
+	if len(accounts) == 0 && len(pendingAccounts) == 0 {
+		fmt.Println("No accounts configured. Use 'gitshift add' to add an account.")
+		return nil
+	}
 
-			// Display GPG status
-			if account.HasGPGKey() {
-				gpgStatus := "🔐 GPG: Enabled"
-				if !account.IsGPGEnabled() {
-					gpgStatus = "🔓 GPG: Configured (signing disabled)"
-				}
-				fmt.Printf("    %s (%s)\n", gpgStatus, account.GPGKeyID)
-				if account.IsGPGKeyExpired() {
-					fmt.Printf("       ⚠️  Key expired: %s\n", account.GPGKeyExpiry.Format("2006-01-02"))
-				}
-			} else {
-				fmt.Printf("    ⚪ GPG: Not configured\n")
-			}
+	if len(accounts) > 0 {
+		printAccountsTable(accounts, configManager.GetConfig().CurrentAccount, format == "wide")
+	}
 
-			if account.Description != "" {
-				fmt.Printf("    Description: %s\n", account.Description)
-			}
+	// Show pending accounts if any
+	if len(pendingAccounts) > 0 {
+		fmt.Println()
+		fmt.Println("📋 Pending Accounts (need completion):")
+		fmt.Println()
 
-			if account.LastUsed != nil {
-				fmt.Printf("    Last Used: %s\n", formatTime(*account.LastUsed))
+		for _, pending := range pendingAccounts {
+			fmt.Printf("  %s\n", pending.Alias)
+			if pending.GitHubUsername != "" {
+				fmt.Printf("    GitHub: @%s\n", pending.GitHubUsername)
 			}
-
-			fmt.Printf("    Created: %s\n", formatTime(account.CreatedAt))
+			fmt.Printf("    Missing: %v\n", pending.MissingFields)
+			fmt.Printf("    Source: %s\n", pending.Source)
+			fmt.Printf("    💡 Complete with: gitshift complete %s --name \"Your Name\" --email \"your@email.com\"\n", pending.Alias)
 			fmt.Println()
 		}
 	}
 
-	if currentAccount != "" {
-		fmt.Printf("Current active account: %s\n", currentAccount)
-	} else {
-		fmt.Println("No active account set")
-	}
-
 	return nil
 }
 
-func printAccountsTable(accounts []*models.Account, currentAccount string) error {
-	// Group accounts by platform
-	accountsByPlatform := groupAccountsByPlatform(accounts)
+// sortAccounts sorts accounts by alias, or for the "recent" order by when they were last
+// used, most recent first, with accounts never used last
+func sortAccounts(accounts []*models.Account, order string) {
+	sort.Slice(accounts, func(i, j int) bool {
+		a, b := accounts[i], accounts[j]
+		if order == "recent" {
+			switch {
+			case a.LastUsed != nil && b.LastUsed == nil:
+				return true
+			case a.LastUsed == nil && b.LastUsed != nil:
+				return false
+			case a.LastUsed != nil && !a.LastUsed.Equal(*b.LastUsed):
+				return a.LastUsed.After(*b.LastUsed)
+			}
+		}
+		return a.Alias < b.Alias
+	})
+}
 
-	// Sort platforms for consistent output
-	platforms := make([]string, 0, len(accountsByPlatform))
-	for platform := range accountsByPlatform {
-		platforms = append(platforms, platform)
+// listFormat returns the output format selected by --format or by one of the deprecated
+// flags it replaces. "default", the format before table, is taken as table.
+func listFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	if !cmd.Flags().Changed("format") {
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			return "json", nil
+		}
+		detailed, _ := cmd.Flags().GetBool("detailed")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		if detailed || verbose {
+			return "wide", nil
+		}
 	}
-	sort.Strings(platforms)
 
-	// Calculate column widths
-	aliasWidth := len("ALIAS")
-	nameWidth := len("NAME")
-	emailWidth := len("EMAIL")
-	platformWidth := len("PLATFORM")
-	usernameWidth := len("USERNAME")
-	descWidth := len("DESCRIPTION")
+	if format == "default" {
+		return "table", nil
+	}
+	if !slices.Contains(listFormats, format) {
+		return "", fmt.Errorf("invalid --format %q: use one of %s", format, strings.Join(listFormats, ", "))
+	}
+	return format, nil
+}
 
+// printAccountsTable prints one row per account; wide adds the isolation level, last use
+// and status columns
+func printAccountsTable(accounts []*models.Account, currentAccount string, wide bool) {
+	header := []string{"ALIAS", "NAME", "EMAIL", "PLATFORM", "USERNAME", "DESCRIPTION"}
+	if wide {
+		header = append(header, "ISOLATION", "LAST USED", "STATUS")
+	}
+
+	rows := make([][]string, 0, len(accounts))
 	for _, account := range accounts {
-		if len(account.Alias) > aliasWidth {
-			aliasWidth = len(account.Alias)
-		}
-		if len(account.Name) > nameWidth {
-			nameWidth = len(account.Name)
+		row := []string{
+			account.Alias,
+			account.Name,
+			account.Email,
+			strings.ToUpper(account.GetPlatform()),
+			valueOrDash(account.GetUsername()),
+			account.Description,
 		}
-		if len(account.Email) > emailWidth {
-			emailWidth = len(account.Email)
-		}
-		platformDisplay := strings.ToUpper(account.GetPlatform())
-		if len(platformDisplay) > platformWidth {
-			platformWidth = len(platformDisplay)
-		}
-		usernameDisplay := account.GetUsername()
-		if usernameDisplay == "" {
-			usernameDisplay = "(not set)"
-		}
-		if len(usernameDisplay) > usernameWidth {
-			usernameWidth = len(usernameDisplay)
+		if wide {
+			lastUsed := "never"
+			if account.LastUsed != nil {
+				lastUsed = formatTime(*account.LastUsed)
+			}
+			row = append(row, valueOrDash(string(account.IsolationLevel)), lastUsed, valueOrDash(string(account.Status)))
 		}
-		if len(account.Description) > descWidth {
-			descWidth = len(account.Description)
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
 		}
 	}
 
-	// Add padding
-	aliasWidth += 2
-	nameWidth += 2
-	emailWidth += 2
-	platformWidth += 2
-	usernameWidth += 2
-	descWidth += 2
-
-	// Print accounts grouped by platform
-	for _, platform := range platforms {
-		platformAccounts := accountsByPlatform[platform]
-		// Sort accounts within platform by alias
-		sort.Slice(platformAccounts, func(i, j int) bool {
-			return platformAccounts[i].Alias < platformAccounts[j].Alias
-		})
-
-		platformDisplay := getPlatformDisplay(platform)
-		fmt.Printf("\n%s\n", platformDisplay)
-
-		// Print header
-		fmt.Printf("%-1s %-*s %-*s %-*s %-*s %-*s %-*s\n", "", aliasWidth, "ALIAS", nameWidth, "NAME", emailWidth, "EMAIL", platformWidth, "PLATFORM", usernameWidth, "USERNAME", descWidth, "DESCRIPTION")
-		fmt.Printf("%-1s %s %s %s %s %s %s\n", "",
-			strings.Repeat("-", aliasWidth),
-			strings.Repeat("-", nameWidth),
-			strings.Repeat("-", emailWidth),
-			strings.Repeat("-", platformWidth),
-			strings.Repeat("-", usernameWidth),
-			strings.Repeat("-", descWidth))
-
-		// Print accounts
-		for _, account := range platformAccounts {
-			marker := " "
-			if account.Alias == currentAccount {
-				marker = "*"
-			}
+	printRow := func(marker string, row []string) {
+		line := marker
+		for i, cell := range row {
+			line += " " + fmt.Sprintf("%-*s", widths[i]+1, cell)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
 
-			usernameDisplay := account.GetUsername()
-			if usernameDisplay == "" {
-				usernameDisplay = "(not set)"
-			}
-			platformDisplay := strings.ToUpper(account.GetPlatform())
-
-			fmt.Printf("%-1s %-*s %-*s %-*s %-*s %-*s %-*s\n",
-				marker,
-				aliasWidth, account.Alias,
-				nameWidth, account.Name,
-				emailWidth, account.Email,
-				platformWidth, platformDisplay,
-				usernameWidth, usernameDisplay,
-				descWidth, account.Description)
+	printRow(" ", header)
+	for i, account := range accounts {
+		marker := " "
+		if account.Alias == currentAccount {
+			marker = "*"
 		}
+		printRow(marker, rows[i])
 	}
 
 	fmt.Println()
@@ -289,88 +237,39 @@ func printAccountsTable(accounts []*models.Account, currentAccount string) error
 	} else {
 		fmt.Println("No active account set")
 	}
-
-	return nil
 }
 
-func printAccountsJSON(accounts []*models.Account) error {
-	// Group accounts by platform
-	accountsByPlatform := groupAccountsByPlatform(accounts)
-
-	// Sort platforms for consistent output
-	platforms := make([]string, 0, len(accountsByPlatform))
-	for platform := range accountsByPlatform {
-		platforms = append(platforms, platform)
+// printAccountsEncoded writes the accounts as a JSON array or YAML list. Anything that
+// looks like a token, e.g. in account metadata, is masked.
+func printAccountsEncoded(accounts []*models.Account, format string) error {
+	if accounts == nil {
+		accounts = []*models.Account{}
 	}
-	sort.Strings(platforms)
-
-	fmt.Println("{")
-	platformIndex := 0
-	for _, platform := range platforms {
-		platformAccounts := accountsByPlatform[platform]
-		// Sort accounts within platform by alias
-		sort.Slice(platformAccounts, func(i, j int) bool {
-			return platformAccounts[i].Alias < platformAccounts[j].Alias
-		})
-
-		fmt.Printf("  \"%s\": [\n", platform)
-		for i, account := range platformAccounts {
-			fmt.Printf("    {\n")
-			fmt.Printf("      \"alias\": \"%s\",\n", account.Alias)
-			fmt.Printf("      \"name\": \"%s\",\n", account.Name)
-			fmt.Printf("      \"email\": \"%s\",\n", account.Email)
-			fmt.Printf("      \"platform\": \"%s\",\n", account.GetPlatform())
-			if account.GetDomain() != "" {
-				fmt.Printf("      \"domain\": \"%s\",\n", account.GetDomain())
-			}
-			username := account.GetUsername()
-			if username != "" {
-				fmt.Printf("      \"username\": \"%s\",\n", username)
-			}
-			// Keep github_username for backward compatibility
-			if account.GitHubUsername != "" {
-				fmt.Printf("      \"github_username\": \"%s\",\n", account.GitHubUsername)
-			}
-			if account.SSHKeyPath != "" {
-				fmt.Printf("      \"ssh_key_path\": \"%s\",\n", account.SSHKeyPath)
-			}
-			if account.HasGPGKey() {
-				fmt.Printf("      \"gpg_key_id\": \"%s\",\n", account.GPGKeyID)
-				fmt.Printf("      \"gpg_enabled\": %t,\n", account.IsGPGEnabled())
-				if account.GPGKeyFingerprint != "" {
-					fmt.Printf("      \"gpg_fingerprint\": \"%s\",\n", account.GPGKeyFingerprint)
-				}
-			}
-			if account.Description != "" {
-				fmt.Printf("      \"description\": \"%s\",\n", account.Description)
-			}
-			fmt.Printf("      \"is_default\": %t,\n", account.IsDefault)
-			fmt.Printf("      \"created_at\": \"%s\"", account.CreatedAt.Format(time.RFC3339))
-			if account.LastUsed != nil {
-				fmt.Printf(",\n      \"last_used\": \"%s\"", account.LastUsed.Format(time.RFC3339))
-			}
-			fmt.Printf("\n    }")
-
-			if i < len(platformAccounts)-1 {
-				fmt.Println(",")
-			} else {
-				fmt.Println()
-			}
-		}
-		fmt.Printf("  ]")
 
-		if platformIndex < len(platforms)-1 {
-			fmt.Println(",")
-		} else {
-			fmt.Println()
-		}
-		platformIndex++
+	var data []byte
+	var err error
+	if format == "yaml" {
+		data, err = yaml.Marshal(accounts)
+	} else {
+		data, err = json.MarshalIndent(accounts, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode accounts: %w", err)
 	}
-	fmt.Println("}")
 
+	fmt.Print(redact.Secrets(string(data)))
 	return nil
 }
 
+// valueOrDash returns value, or "-" for an empty table cell
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
 func formatTime(t time.Time) string {
 	now := time.Now()
 
@@ -388,44 +287,3 @@ func formatTime(t time.Time) string {
 
 	return t.Format("Jan 02, 2006")
 }
-
-// groupAccountsByPlatform groups accounts by their platform
-func groupAccountsByPlatform(accounts []*models.Account) map[string][]*models.Account {
-	grouped := make(map[string][]*models.Account)
-	for _, account := range accounts {
-		platform := account.GetPlatform()
-		grouped[platform] = append(grouped[platform], account)
-	}
-	return grouped
-}
-
-// getPlatformDisplay returns a formatted platform header with emoji
-func getPlatformDisplay(platform string) string {
-	platformName := strings.ToUpper(platform[:1]) + platform[1:]
-	var emoji string
-	switch platform {
-	case "github":
-		emoji = "🐙"
-	case "gitlab":
-		emoji = "🦊"
-	case "bitbucket":
-		emoji = "🪣"
-	default:
-		emoji = "🔧"
-	}
-	return fmt.Sprintf("%s %s Accounts", emoji, platformName)
-}
-
-// getDefaultDomain returns the default domain for a platform
-func getDefaultDomain(platform string) string {
-	switch platform {
-	case "github":
-		return "github.com"
-	case "gitlab":
-		return "gitlab.com"
-	case "bitbucket":
-		return "bitbucket.org"
-	default:
-		return ""
-	}
-}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/metrics"
+)
+
+// metricsShutdownTimeout bounds how long 'metrics serve' waits for in-flight scrapes on exit
+const metricsShutdownTimeout = 5 * time.Second
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "📈 Expose gitshift metrics to Prometheus",
+	Long: `Every gitshift command adds what it did to a metrics file in the config
+directory when it exits:
+
+- gitshift_switches_total{account,result}
+- gitshift_validations_total{account,result}
+- gitshift_ssh_tests_total{domain,result}
+- gitshift_api_request_duration_seconds{platform,method,status} (histogram)
+
+Counters keep growing across runs, as Prometheus expects; delete the file to
+start over.`,
+}
+
+var metricsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "🌐 Serve the metrics on /metrics for Prometheus to scrape",
+	Long: `Serve the metrics recorded by all gitshift commands on /metrics in the
+Prometheus text format. The metrics file is read again on every scrape, so
+commands run while the server is up show up on the next scrape.`,
+	Example: `  # Serve on port 9090 of all interfaces
+  gitshift metrics serve
+
+  # Only listen on localhost
+  gitshift metrics serve --addr 127.0.0.1:9090`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMetricsServe,
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.AddCommand(metricsServeCmd)
+
+	metricsServeCmd.Flags().String("addr", ":9090", "Address to listen on")
+}
+
+// metricsStore returns the store shared by all gitshift processes
+func metricsStore() *metrics.Store {
+	homeDir, _ := os.UserHomeDir()
+	return metrics.NewStore(filepath.Join(config.ConfigDir(homeDir), metrics.StoreFileName))
+}
+
+func runMetricsServe(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	store := metricsStore()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(store))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Stop on Ctrl-C, letting in-flight scrapes finish
+	go func() {
+		<-cmd.Context().Done()
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	fmt.Printf("📈 Serving %s on %s at /metrics\n", store.Path(), addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+	return nil
+}
@@ -31,8 +31,9 @@ Examples:
   # Remove GitLab account
   gitshift remove work-gitlab
   gitshift remove personal-gitlab`,
-	Aliases: []string{"rm", "delete", "del"},
-	Args:    cobra.ExactArgs(1),
+	Aliases:           []string{"rm", "delete", "del"},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		alias := args[0]
 
@@ -64,6 +65,7 @@ Examples:
 		if err := configManager.RemoveAccount(alias); err != nil {
 			return fmt.Errorf("failed to remove account: %w", err)
 		}
+		refreshHostAliases(configManager, account)
 
 		fmt.Printf("✅ Successfully removed account '%s'\n", alias)
 
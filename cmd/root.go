@@ -1,16 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/metrics"
+	"github.com/techishthoughts/gitshift/internal/trace"
 )
 
 var cfgFile string
 
+// version is the gitshift release
+var version = "v0.1.0"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "gitshift",
@@ -31,6 +40,13 @@ Features:
 - 📧 Email extraction from SSH keys
 - 🛡️ No key conflicts or cross-contamination
 - 🌐 Multi-platform support (GitHub, GitLab, etc.)`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if enabled, _ := cmd.Flags().GetBool("trace"); enabled {
+			ctx := trace.Enable(cmd.Context(), os.Stderr)
+			cmd.SetContext(ctx)
+			fmt.Fprintf(os.Stderr, "🔎 Trace ID: %s\n", trace.ID(ctx))
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check if version flag is set
 		if version, _ := cmd.Flags().GetBool("version"); version {
@@ -47,15 +63,32 @@ Features:
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+//
+// An interrupt or SIGTERM cancels the command's context, which kills the external commands
+// started with it; a second interrupt terminates gitshift immediately. What the command
+// recorded is added to the metrics store before Execute returns.
+//
+// The context also carries a trace ID for the invocation, printed with its steps by --trace.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(trace.WithID(context.Background(), trace.NewID()), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := rootCmd.ExecuteContext(ctx)
+	if flushErr := metrics.Flush(metricsStore()); flushErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to save metrics: %v\n", flushErr)
+	}
+	return err
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Here you will define your flags and configuration settings.
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/gitshift/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/gitshift/config.yaml, or $HOME/.config/gitshift/config.yaml)")
+	rootCmd.PersistentFlags().Bool("trace", false, "Print each step, external command and API request, tagged with a trace ID")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -74,8 +107,8 @@ func initConfig() {
 		home, err := os.UserHomeDir()
 		cobra.CheckErr(err)
 
-		// Search config in home directory with name "config" (without extension).
-		viper.AddConfigPath(home + "/.config/gitshift")
+		// Search config in the XDG config directory with name "config" (without extension).
+		viper.AddConfigPath(config.ConfigDir(home))
 		viper.SetConfigType("yaml")
 		viper.SetConfigName("config")
 	}
@@ -91,7 +124,7 @@ func initConfig() {
 // showVersion displays version information
 func showVersion() {
 	fmt.Println("🎭 gitshift - Multi-Platform Git Identity Management")
-	fmt.Println("Version: v0.1.0")
+	fmt.Printf("Version: %s\n", version)
 	fmt.Println("Go Version: go1.23.0")
 	fmt.Println("Build Time: 2025-01-02")
 	fmt.Println()
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+var setDefaultCmd = &cobra.Command{
+	Use:   "set-default <account-alias>",
+	Short: "⭐ Make an account the default",
+	Long: `Make an account the default account, clearing the default flag on every
+other account in the same write.
+
+The default account is used whenever no account is given and none is active:
+'gitshift switch' outside a terminal, 'gitshift ssh-test', 'gitshift
+ssh-config print', 'gitshift ssh-keys upload' and 'gitshift ssh-keys rotate'
+fall back to it, and 'gitshift whoami' reports it when nothing else identifies
+the account. It is also preferred when several accounts match a remote.
+
+Setting the default doesn't switch to the account.`,
+	Example:           `  gitshift set-default work`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
+	SilenceUsage:      true,
+	RunE:              runSetDefault,
+}
+
+func init() {
+	rootCmd.AddCommand(setDefaultCmd)
+}
+
+func runSetDefault(cmd *cobra.Command, args []string) error {
+	alias := args[0]
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	previous := ""
+	if account, err := configManager.GetDefaultAccount(); err == nil {
+		previous = account.Alias
+	}
+	if previous == alias {
+		fmt.Printf("ℹ️  '%s' is already the default account\n", alias)
+		return nil
+	}
+
+	if err := configManager.SetDefaultAccount(alias); err != nil {
+		if errors.Is(err, models.ErrAccountNotFound) {
+			return fmt.Errorf("account '%s' not found", alias)
+		}
+		return fmt.Errorf("failed to set the default account: %w", err)
+	}
+
+	fmt.Printf("⭐ '%s' is now the default account\n", alias)
+	if previous != "" {
+		fmt.Printf("   It replaces '%s'\n", previous)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+)
+
+// sshAgentCmd groups commands that inspect the SSH agent
+var sshAgentCmd = &cobra.Command{
+	Use:   "ssh-agent",
+	Short: "🔐 Inspect the SSH agent",
+}
+
+var sshAgentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "📡 Show the SSH agent's state and which accounts' keys it holds",
+	Long: `Show whether the SSH agent in SSH_AUTH_SOCK is running, its socket, and the
+keys it holds, each with the accounts configured with it.
+
+The agent is one of:
+  running        running with keys loaded
+  no identities  running without any key loaded
+  not running    SSH_AUTH_SOCK is not set
+  stale          SSH_AUTH_SOCK points to an agent that is gone
+  unreachable    the agent's socket exists but the agent doesn't answer
+
+Every account with an SSH key is listed with whether its key is loaded, so a
+key missing from the agent, or the keys of several accounts loaded at once,
+show up immediately.`,
+	Example: `  gitshift ssh-agent status
+
+  # Machine-readable output
+  gitshift ssh-agent status --json`,
+	Args: cobra.NoArgs,
+	RunE: runSSHAgentStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(sshAgentCmd)
+	sshAgentCmd.AddCommand(sshAgentStatusCmd)
+
+	sshAgentStatusCmd.Flags().Bool("json", false, "Output as JSON")
+}
+
+// AgentStatusReport is the output of ssh-agent status
+type AgentStatusReport struct {
+	Status   string               `json:"status"`
+	Running  bool                 `json:"running"`
+	Socket   string               `json:"socket,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	KeyCount int                  `json:"key_count"`
+	Keys     []AgentKeyReport     `json:"keys"`
+	Accounts []AgentAccountReport `json:"accounts"`
+}
+
+// AgentKeyReport is a key loaded in the agent and the accounts configured with it
+type AgentKeyReport struct {
+	Fingerprint string   `json:"fingerprint"`
+	Type        string   `json:"type,omitempty"`
+	Bits        int      `json:"bits,omitempty"`
+	Comment     string   `json:"comment,omitempty"`
+	Accounts    []string `json:"accounts"`
+}
+
+// AgentAccountReport tells whether the SSH key of an account is loaded in the agent
+type AgentAccountReport struct {
+	Alias       string `json:"alias"`
+	SSHKeyPath  string `json:"ssh_key_path"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Loaded      bool   `json:"loaded"`
+	Error       string `json:"error,omitempty"`
+}
+
+func runSSHAgentStatus(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	sshManager := ssh.NewManager()
+	status, statusErr := sshManager.GetAgentStatus(cmd.Context())
+	report := AgentStatusReport{
+		Status:   status.String(),
+		Running:  status.Usable(),
+		Socket:   os.Getenv("SSH_AUTH_SOCK"),
+		Keys:     []AgentKeyReport{},
+		Accounts: []AgentAccountReport{},
+	}
+	if statusErr != nil {
+		report.Error = statusErr.Error()
+	}
+
+	var loaded []ssh.LoadedKey
+	if status == ssh.AgentRunning {
+		keys, err := sshManager.GetLoadedKeys(cmd.Context())
+		if err != nil {
+			return err
+		}
+		loaded = keys
+	}
+	report.KeyCount = len(loaded)
+
+	// Accounts are matched to loaded keys by fingerprint, since the comments of loaded
+	// keys are free text
+	owners := make(map[string][]string)
+	accounts := configManager.ListAccounts()
+	sortAccounts(accounts, "alias")
+	for _, account := range accounts {
+		if account.SSHKeyPath == "" {
+			continue
+		}
+		entry := AgentAccountReport{Alias: account.Alias, SSHKeyPath: account.SSHKeyPath}
+		if info, err := sshManager.ValidateKey(cmd.Context(), pathutil.Expand(account.SSHKeyPath)); err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.Fingerprint = info.Fingerprint
+			owners[info.Fingerprint] = append(owners[info.Fingerprint], account.Alias)
+		}
+		report.Accounts = append(report.Accounts, entry)
+	}
+	for _, key := range loaded {
+		report.Keys = append(report.Keys, AgentKeyReport{
+			Fingerprint: key.Fingerprint,
+			Type:        key.Type,
+			Bits:        key.Bits,
+			Comment:     key.Comment,
+			Accounts:    append([]string{}, owners[key.Fingerprint]...),
+		})
+		for i := range report.Accounts {
+			if report.Accounts[i].Fingerprint == key.Fingerprint {
+				report.Accounts[i].Loaded = true
+			}
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode agent status as JSON: %w", err)
+		}
+		return nil
+	}
+
+	printAgentStatus(report, status)
+	return nil
+}
+
+func printAgentStatus(report AgentStatusReport, status ssh.AgentStatus) {
+	icon := "✅"
+	if !report.Running {
+		icon = "❌"
+	}
+	fmt.Printf("%s SSH agent: %s\n", icon, report.Status)
+	if report.Socket != "" {
+		fmt.Printf("   Socket: %s\n", report.Socket)
+	}
+	if report.Error != "" {
+		fmt.Printf("   %s\n", report.Error)
+	}
+	if status == ssh.AgentNotRunning || status == ssh.AgentStale {
+		fmt.Printf("   💡 Start a new agent with: eval \"$(ssh-agent -s)\"\n")
+	}
+
+	if report.Running {
+		fmt.Printf("\n🔑 %d key(s) loaded\n", report.KeyCount)
+		for _, key := range report.Keys {
+			owner := "no account"
+			if len(key.Accounts) > 0 {
+				owner = strings.Join(key.Accounts, ", ")
+			}
+			fmt.Printf("   %s %s (%s) → %s\n", key.Fingerprint, valueOrNone(key.Comment), key.Type, owner)
+		}
+	}
+
+	if len(report.Accounts) == 0 {
+		return
+	}
+	fmt.Println("\n👥 Accounts")
+	for _, account := range report.Accounts {
+		switch {
+		case account.Error != "":
+			fmt.Printf("   ⚠️  key for '%s' can't be read: %s\n", account.Alias, account.Error)
+		case account.Loaded:
+			fmt.Printf("   ✅ key for '%s' is loaded\n", account.Alias)
+		default:
+			fmt.Printf("   ❌ key for '%s' is not loaded\n", account.Alias)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/switcher"
+)
+
+// sshConfigCmd groups commands that manage the gitshift-managed ~/.ssh/config
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "🗂️  Manage the gitshift-managed SSH config",
+	Long: `Manage the ~/.ssh/config entries gitshift maintains for your accounts.
+
+gitshift takes a timestamped backup of ~/.ssh/config every time it changes
+the file, so any change can be rolled back.`,
+}
+
+var sshConfigRestoreCmd = &cobra.Command{
+	Use:   "restore [backup-number|backup-path]",
+	Short: "⏪ Restore ~/.ssh/config from a backup",
+	Long: `Restore ~/.ssh/config from one of the backups gitshift created.
+
+Without arguments, the available backups are listed newest first and you are
+asked which one to restore. The current config is backed up before it is
+replaced, so a restore can itself be undone.`,
+	Example: `  # Pick a backup interactively
+  gitshift ssh-config restore
+
+  # Restore the most recent backup
+  gitshift ssh-config restore 1
+
+  # Restore a specific backup file
+  gitshift ssh-config restore ~/.ssh/config.gitshift-backup-20250102-150405.000000000`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSSHConfigRestore,
+}
+
+var sshConfigValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "🔍 Check ~/.ssh/config for duplicate or conflicting Host entries",
+	Long: `Check ~/.ssh/config for problems that silently break authentication:
+- Permissions that make ssh refuse the file
+- Host patterns defined more than once (ssh merges them: IdentityFile
+  entries add up, and the first value of other options wins). Several
+  Host * blocks are only a warning, since they are a common way to add
+  defaults
+- Host sections with conflicting IdentityFile directives
+
+With --fix, user entries that duplicate or shadow a gitshift-managed entry are
+removed in favour of the managed one and permissions are reset to 0600. The
+config is backed up first.`,
+	Example: `  # Report problems
+  gitshift ssh-config validate
+
+  # Report and repair what can be repaired automatically
+  gitshift ssh-config validate --fix`,
+	Args: cobra.NoArgs,
+	RunE: runSSHConfigValidate,
+}
+
+var sshConfigPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "📄 Print the SSH config gitshift would write for an account",
+	Long: `Print the managed ~/.ssh/config block gitshift writes when switching to an
+account, without changing anything. The block includes the Match blocks of
+other accounts on the same platform that set ssh_match.
+
+With --diff, a unified diff between the current ~/.ssh/config and the config
+after the switch is printed instead; the output is empty when the switch
+would not change the file.`,
+	Example: `  # Print the block for the current account
+  gitshift ssh-config print
+
+  # Review what switching to 'work' would change in ~/.ssh/config
+  gitshift ssh-config print --account work --diff`,
+	Args: cobra.NoArgs,
+	RunE: runSSHConfigPrint,
+}
+
+func init() {
+	rootCmd.AddCommand(sshConfigCmd)
+	sshConfigCmd.AddCommand(sshConfigRestoreCmd)
+	sshConfigCmd.AddCommand(sshConfigValidateCmd)
+	sshConfigCmd.AddCommand(sshConfigPrintCmd)
+
+	sshConfigValidateCmd.Flags().Bool("fix", false, "Repair fixable issues (the config is backed up first)")
+
+	sshConfigPrintCmd.Flags().String("account", "", "Account to render the config for (default: the current account)")
+	sshConfigPrintCmd.Flags().Bool("diff", false, "Print a unified diff against the current ~/.ssh/config")
+}
+
+// refreshHostAliases updates the SSH host aliases of the accounts' domains after the
+// accounts changed. The change is already saved, so a failure is only reported.
+func refreshHostAliases(configManager *config.Manager, accounts ...*models.Account) {
+	if err := switcher.RefreshHostAliases(configManager, accounts...); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+	}
+}
+
+func runSSHConfigPrint(cmd *cobra.Command, args []string) error {
+	alias, _ := cmd.Flags().GetString("account")
+	showDiff, _ := cmd.Flags().GetBool("diff")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var account *models.Account
+	var err error
+	if alias == "" {
+		if account, err = configManager.GetCurrentOrDefaultAccount(); err != nil {
+			return fmt.Errorf("no current or default account; pass --account <alias>")
+		}
+	} else if account, err = configManager.GetAccount(alias); err != nil {
+		return fmt.Errorf("account '%s' not found", alias)
+	}
+	if account.SSHKeyPath == "" {
+		return fmt.Errorf("account '%s' has no SSH key, so gitshift writes no SSH config for it", account.Alias)
+	}
+
+	preview, err := switcher.NewSSHManager(configManager).PreviewSSHConfig(account.Alias, account.SSHKeyPath, account.GetDomain(),
+		ssh.MatchIdentities(configManager.ListAccounts(), account)...)
+	if err != nil {
+		return err
+	}
+
+	if showDiff {
+		fmt.Print(preview.Diff())
+	} else {
+		fmt.Print(preview.Block)
+	}
+	return nil
+}
+
+func runSSHConfigValidate(cmd *cobra.Command, args []string) error {
+	fix, _ := cmd.Flags().GetBool("fix")
+	sshManager := ssh.NewManager()
+
+	validation, err := sshManager.ValidateConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔍 Validating %s\n", validation.ConfigPath)
+	printSSHConfigIssues(validation)
+
+	if !fix || !validation.HasFixableIssues() {
+		if validation.HasFixableIssues() {
+			fmt.Println("\n💡 Run 'gitshift ssh-config validate --fix' to repair the fixable issues")
+		}
+		if !validation.IsValid() {
+			return fmt.Errorf("SSH config has %d issue(s)", len(validation.Problems()))
+		}
+		return nil
+	}
+
+	fmt.Println("\n🔧 Repairing SSH config...")
+	validation, err = sshManager.FixConfig()
+	if err != nil {
+		return fmt.Errorf("failed to repair SSH config: %w", err)
+	}
+	printSSHConfigIssues(validation)
+
+	if !validation.IsValid() {
+		return fmt.Errorf("SSH config still has %d issue(s) that need manual attention", len(validation.Problems()))
+	}
+	return nil
+}
+
+// printSSHConfigIssues prints the result of an SSH config validation
+func printSSHConfigIssues(validation *ssh.SSHConfigValidation) {
+	if len(validation.Issues) == 0 {
+		fmt.Println("✅ No issues found")
+		return
+	}
+
+	for _, issue := range validation.Issues {
+		location := ""
+		if issue.Line > 0 {
+			location = fmt.Sprintf("line %d: ", issue.Line)
+		}
+		marker := "❌"
+		switch {
+		case issue.Fixable:
+			marker = "🔧"
+		case issue.Warning:
+			marker = "⚠️ "
+		}
+		fmt.Printf("  %s %s%s\n", marker, location, issue.Message)
+	}
+}
+
+func runSSHConfigRestore(cmd *cobra.Command, args []string) error {
+	sshManager := ssh.NewManager()
+
+	backups, err := sshManager.ListConfigBackups()
+	if err != nil {
+		return err
+	}
+
+	var selection string
+	if len(args) > 0 {
+		selection = args[0]
+	} else {
+		if len(backups) == 0 {
+			fmt.Println("📭 No SSH config backups found")
+			return nil
+		}
+
+		fmt.Println("🗂️  Available SSH config backups (newest first):")
+		for i, backup := range backups {
+			fmt.Printf("  %d. %s  (%s, %d bytes)\n", i+1, backup.Path,
+				backup.CreatedAt.Local().Format("2006-01-02 15:04:05"), backup.Size)
+		}
+		fmt.Println()
+
+		selection = promptForInput(fmt.Sprintf("Select a backup to restore [1-%d] (empty to cancel): ", len(backups)))
+		if selection == "" {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+	}
+
+	backupPath := selection
+	if index, err := strconv.Atoi(selection); err == nil {
+		if index < 1 || index > len(backups) {
+			return fmt.Errorf("invalid backup number %d: choose between 1 and %d", index, len(backups))
+		}
+		backupPath = backups[index-1].Path
+	}
+
+	if err := sshManager.RestoreConfig(cmd.Context(), backupPath); err != nil {
+		return fmt.Errorf("failed to restore SSH config: %w", err)
+	}
+
+	fmt.Printf("✅ Restored SSH config from %s\n", backupPath)
+	fmt.Println("💾 The previous config was backed up first; run 'gitshift ssh-config restore' to undo")
+
+	return nil
+}
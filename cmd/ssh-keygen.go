@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 var sshKeygenCmd = &cobra.Command{
@@ -43,10 +46,14 @@ The generated keys can be used with any Git platform:
   # Generate RSA key instead of Ed25519
   gitshift ssh-keygen myaccount --type rsa --bits 4096
 
+  # Generate a key backed by a FIDO2 security key
+  gitshift ssh-keygen myaccount --type ed25519-sk
+
   # Generate key and add to GitHub automatically
   gitshift ssh-keygen myaccount --add-to-github`,
-	Args: cobra.ExactArgs(1),
-	RunE: runSSHKeygen,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
+	RunE:              runSSHKeygen,
 }
 
 var (
@@ -61,8 +68,8 @@ var (
 func init() {
 	rootCmd.AddCommand(sshKeygenCmd)
 
-	sshKeygenCmd.Flags().StringVar(&keyType, "type", "ed25519", "SSH key type (ed25519, rsa, ecdsa)")
-	sshKeygenCmd.Flags().IntVar(&keyBits, "bits", 0, "Key size in bits (RSA: 2048/4096, ECDSA: 256/384/521)")
+	sshKeygenCmd.Flags().StringVar(&keyType, "type", "ed25519", "SSH key type (ed25519, rsa, ecdsa, ed25519-sk, ecdsa-sk)")
+	sshKeygenCmd.Flags().IntVar(&keyBits, "bits", 0, "Key size in bits (RSA: >= 2048, default 4096; ECDSA: 256/384/521)")
 	sshKeygenCmd.Flags().StringVar(&keyEmail, "email", "", "Email for SSH key comment")
 	sshKeygenCmd.Flags().StringVar(&keyPassphrase, "passphrase", "", "Passphrase for private key (empty for no passphrase)")
 	sshKeygenCmd.Flags().BoolVar(&addToGitHub, "add-to-github", false, "Automatically add the public key to GitHub")
@@ -104,7 +111,7 @@ func runSSHKeygen(cmd *cobra.Command, args []string) error {
 
 	// Generate the SSH key
 	keyManager := &SSHKeyManager{}
-	keyPath, err := keyManager.GenerateKey(GenerateKeyParams{
+	key, err := keyManager.GenerateKey(GenerateKeyParams{
 		Alias:      accountAlias,
 		Email:      keyEmail,
 		Type:       keyType,
@@ -115,9 +122,17 @@ func runSSHKeygen(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to generate SSH key: %w", err)
 	}
+	keyPath := key.Path
 
-	fmt.Printf("✅ SSH key generated: %s\n", keyPath)
-	fmt.Printf("📋 Public key: %s.pub\n", keyPath)
+	if key.Bits > 0 {
+		fmt.Printf("✅ SSH key generated: %s (%s, %d bits)\n", keyPath, strings.ToUpper(key.Type), key.Bits)
+	} else {
+		fmt.Printf("✅ SSH key generated: %s (%s)\n", keyPath, strings.ToUpper(key.Type))
+	}
+	fmt.Printf("📋 Public key: %s\n", key.PublicKeyPath)
+	if key.Encrypted {
+		fmt.Printf("🔐 Private key is passphrase-protected\n")
+	}
 
 	// Update account if it exists
 	if account != nil {
@@ -127,6 +142,7 @@ func runSSHKeygen(cmd *cobra.Command, args []string) error {
 			if err := configManager.AddAccount(account); err != nil {
 				fmt.Printf("⚠️  Warning: Failed to update account with new SSH key path: %v\n", err)
 			} else {
+				refreshHostAliases(configManager, account)
 				fmt.Printf("🔗 Updated account '%s' with new SSH key\n", accountAlias)
 			}
 		}
@@ -181,32 +197,58 @@ type GenerateKeyParams struct {
 	Bits       int
 	Passphrase string
 	Force      bool
+	Path       string // where to write the private key, ~/.ssh/id_<type>_<alias> when empty
+}
+
+// SSHKey describes a key pair produced by GenerateKey
+type SSHKey struct {
+	Path          string
+	PublicKeyPath string
+	Type          string
+	Bits          int
+	Encrypted     bool // true when the private key is protected by a passphrase
 }
 
 type SSHKeyManager struct{}
 
-func (m *SSHKeyManager) GenerateKey(params GenerateKeyParams) (string, error) {
+func (m *SSHKeyManager) GenerateKey(params GenerateKeyParams) (*SSHKey, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
 	sshDir := filepath.Join(homeDir, ".ssh")
 	if err := os.MkdirAll(sshDir, 0700); err != nil {
-		return "", fmt.Errorf("failed to create SSH directory: %w", err)
+		return nil, fmt.Errorf("failed to create SSH directory: %w", err)
 	}
 
-	// Generate key file path
-	keyPath := filepath.Join(sshDir, fmt.Sprintf("id_%s_%s", params.Type, params.Alias))
+	// Generate key file path (id_ed25519_sk_<alias> for security key types, matching ssh-keygen's naming)
+	keyPath := params.Path
+	if keyPath == "" {
+		keyPath = defaultKeyPath(sshDir, params.Type, params.Alias)
+	}
 
 	// Check if key already exists
 	if !params.Force {
 		if _, err := os.Stat(keyPath); err == nil {
-			return "", fmt.Errorf("SSH key already exists at %s (use --force to overwrite)", keyPath)
+			return nil, fmt.Errorf("SSH key already exists at %s (use --force to overwrite)", keyPath)
+		}
+	}
+
+	// RSA keys default to 4096 bits; anything below 2048 is rejected even when called outside the CLI
+	if params.Type == "rsa" {
+		if params.Bits == 0 {
+			params.Bits = 4096
+		} else if params.Bits < 2048 {
+			return nil, fmt.Errorf("RSA key size must be at least 2048 bits, got %d", params.Bits)
 		}
 	}
 
-	fmt.Printf("🔧 Generating %s key with %d bits...\n", strings.ToUpper(params.Type), params.Bits)
+	if params.Bits > 0 {
+		fmt.Printf("🔧 Generating %s key with %d bits...\n", strings.ToUpper(params.Type), params.Bits)
+	} else {
+		fmt.Printf("🔧 Generating %s key...\n", strings.ToUpper(params.Type))
+	}
 
 	// Build ssh-keygen command
 	args := []string{
@@ -221,39 +263,60 @@ func (m *SSHKeyManager) GenerateKey(params GenerateKeyParams) (string, error) {
 	}
 
 	// Add passphrase (empty means no passphrase)
-	if params.Passphrase == "" {
-		args = append(args, "-N", "")
-	} else {
-		args = append(args, "-N", params.Passphrase)
-	}
+	args = append(args, "-N", params.Passphrase)
 
 	// Execute ssh-keygen
 	cmd := exec.Command("ssh-keygen", args...)
 	cmd.Stderr = os.Stderr
 
+	// Security key types need the user to touch the authenticator (and possibly enter a PIN),
+	// so connect ssh-keygen to the terminal and let its prompts through
+	if isSecurityKeyType(params.Type) {
+		fmt.Printf("👆 Touch your security key when it blinks to confirm key generation\n")
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+	}
+
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("ssh-keygen failed: %w", err)
+		return nil, fmt.Errorf("ssh-keygen failed: %w", err)
 	}
 
 	// Set proper permissions
 	if err := os.Chmod(keyPath, 0600); err != nil {
-		return "", fmt.Errorf("failed to set private key permissions: %w", err)
+		return nil, fmt.Errorf("failed to set private key permissions: %w", err)
 	}
 
 	if err := os.Chmod(keyPath+".pub", 0644); err != nil {
-		return "", fmt.Errorf("failed to set public key permissions: %w", err)
+		return nil, fmt.Errorf("failed to set public key permissions: %w", err)
 	}
 
 	fmt.Printf("🔒 Set proper key permissions (600 for private, 644 for public)\n")
 
 	// Automatically add the key to ssh-agent
-	if err := m.addKeyToAgent(keyPath); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to add key to ssh-agent: %v\n", err)
+	var agentErr error
+	if params.Passphrase == "" {
+		agentErr = m.addKeyToAgent(keyPath)
+	} else {
+		agentErr = m.addEncryptedKeyToAgent(keyPath, params.Passphrase)
+	}
+	if agentErr != nil {
+		fmt.Printf("⚠️  Warning: Failed to add key to ssh-agent: %v\n", agentErr)
 	} else {
 		fmt.Printf("🔑 Key automatically added to ssh-agent\n")
 	}
 
-	return keyPath, nil
+	return &SSHKey{
+		Path:          keyPath,
+		PublicKeyPath: keyPath + ".pub",
+		Type:          params.Type,
+		Bits:          params.Bits,
+		Encrypted:     params.Passphrase != "",
+	}, nil
+}
+
+// defaultKeyPath returns the path GenerateKey writes a key of keyType for alias to
+func defaultKeyPath(sshDir, keyType, alias string) string {
+	return filepath.Join(sshDir, fmt.Sprintf("id_%s_%s", strings.ReplaceAll(keyType, "-", "_"), alias))
 }
 
 // addKeyToAgent adds a key to the SSH agent
@@ -261,7 +324,57 @@ func (m *SSHKeyManager) addKeyToAgent(keyPath string) error {
 	cmd := exec.Command("ssh-add", keyPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("ssh-add failed: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("ssh-add failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
+	}
+	return nil
+}
+
+// addEncryptedKeyToAgent adds a passphrase-protected key to the SSH agent without prompting.
+// The passphrase is handed to ssh-add through a throwaway SSH_ASKPASS helper that reads it
+// from the environment, so it never touches the disk.
+func (m *SSHKeyManager) addEncryptedKeyToAgent(keyPath, passphrase string) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("non-interactive loading of encrypted keys is not supported on Windows - run: ssh-add %s", keyPath)
+	}
+
+	askpass, err := os.CreateTemp("", "gitshift-askpass-*")
+	if err != nil {
+		return fmt.Errorf("failed to create askpass helper: %w", err)
+	}
+	defer func() {
+		if err := os.Remove(askpass.Name()); err != nil {
+			log.Printf("Warning: failed to remove askpass helper: %v", err)
+		}
+	}()
+
+	script := "#!/bin/sh\nprintf '%s\\n' \"$GITSHIFT_ASKPASS_PASSPHRASE\"\n"
+	if _, err := askpass.WriteString(script); err != nil {
+		_ = askpass.Close()
+		return fmt.Errorf("failed to write askpass helper: %w", err)
+	}
+	if err := askpass.Close(); err != nil {
+		return fmt.Errorf("failed to write askpass helper: %w", err)
+	}
+	if err := os.Chmod(askpass.Name(), 0700); err != nil {
+		return fmt.Errorf("failed to make askpass helper executable: %w", err)
+	}
+
+	cmd := exec.Command("ssh-add", keyPath)
+	cmd.Env = append(os.Environ(),
+		"SSH_ASKPASS="+askpass.Name(),
+		"SSH_ASKPASS_REQUIRE=force",
+		"GITSHIFT_ASKPASS_PASSPHRASE="+passphrase,
+	)
+	// Older OpenSSH releases ignore SSH_ASKPASS_REQUIRE and only use the helper
+	// when DISPLAY is set and stdin is not a terminal
+	if os.Getenv("DISPLAY") == "" {
+		cmd.Env = append(cmd.Env, "DISPLAY=:0")
+	}
+	cmd.Stdin = nil
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ssh-add failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
 	}
 	return nil
 }
@@ -348,13 +461,23 @@ func validateKeyParameters() error {
 		} else if keyBits != 256 && keyBits != 384 && keyBits != 521 {
 			return fmt.Errorf("ECDSA key size must be 256, 384, or 521 bits")
 		}
+	case "ed25519-sk", "ecdsa-sk":
+		if keyBits != 0 {
+			fmt.Printf("ℹ️  %s keys have a fixed size, ignoring --bits parameter\n", strings.ToUpper(keyType))
+			keyBits = 0
+		}
 	default:
-		return fmt.Errorf("unsupported key type: %s (supported: ed25519, rsa, ecdsa)", keyType)
+		return fmt.Errorf("unsupported key type: %s (supported: ed25519, rsa, ecdsa, ed25519-sk, ecdsa-sk)", keyType)
 	}
 
 	return nil
 }
 
+// isSecurityKeyType reports whether the key type is backed by a FIDO2 hardware authenticator
+func isSecurityKeyType(keyType string) bool {
+	return strings.HasSuffix(keyType, "-sk")
+}
+
 func showPublicKey(pubKeyPath string) error {
 	content, err := os.ReadFile(pubKeyPath)
 	if err != nil {
@@ -367,9 +490,8 @@ func showPublicKey(pubKeyPath string) error {
 	fmt.Printf("└─────────────────────────────────────────────────────────────────┘\n")
 
 	// Show key fingerprint
-	cmd := exec.Command("ssh-keygen", "-lf", pubKeyPath)
-	if output, err := cmd.Output(); err == nil {
-		fmt.Printf("🔍 Key fingerprint: %s", output)
+	if info, err := ssh.NewManager().ValidateKey(context.Background(), pubKeyPath); err == nil {
+		fmt.Printf("🔍 Key fingerprint: %s (%s)\n", info.Fingerprint, info.Type)
 	}
 
 	return nil
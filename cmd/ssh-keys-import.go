@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+)
+
+var sshKeysImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "📥 Attach an existing SSH key to an account",
+	Long: `Attach an SSH key pair created outside gitshift to an account.
+
+The key is checked with ssh-keygen, its permissions are set to 0600 for the
+private key and 0644 for the .pub file next to it, and the account's SSH key
+path is pointed at it. When the key's comment is an email address it is shown,
+and used as the account's email if the account has none.
+
+A key belongs to one account: keys another account already uses, at the same
+path or as a copy, are refused.
+
+For GitHub and GitLab accounts, the public key can then be uploaded; you are
+asked on a terminal, and --upload does it without asking.`,
+	Example: `  # Attach an existing key to the 'work' account
+  gitshift ssh-keys import --account work --path ~/.ssh/mykey
+
+  # Attach it and register it on GitHub in one go
+  gitshift ssh-keys import --account work --path ~/.ssh/mykey --upload`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runSSHKeysImport,
+}
+
+func init() {
+	sshKeysCmd.AddCommand(sshKeysImportCmd)
+
+	sshKeysImportCmd.Flags().String("account", "", "Account to attach the key to")
+	sshKeysImportCmd.Flags().String("path", "", "Path of the private key (or its .pub file)")
+	sshKeysImportCmd.Flags().Bool("upload", false, "Upload the public key to GitHub or GitLab without asking")
+	_ = sshKeysImportCmd.MarkFlagRequired("account")
+	_ = sshKeysImportCmd.MarkFlagRequired("path")
+	_ = sshKeysImportCmd.RegisterFlagCompletionFunc("account", completeAccountFlag)
+}
+
+func runSSHKeysImport(cmd *cobra.Command, args []string) error {
+	alias, _ := cmd.Flags().GetString("account")
+	keyPath, _ := cmd.Flags().GetString("path")
+	upload, _ := cmd.Flags().GetBool("upload")
+
+	configManager := config.NewManager()
+	if err := configManager.LoadContext(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	account, err := configManager.GetAccount(alias)
+	if err != nil {
+		return fmt.Errorf("account '%s' not found", alias)
+	}
+
+	key, err := ssh.NewManager().ImportKey(cmd.Context(), keyPath, ssh.ImportKeyOptions{
+		OtherAccounts: otherAccounts(configManager, alias),
+	})
+	var inUse *ssh.KeyInUseError
+	if errors.As(err, &inUse) {
+		return models.NewUserError(models.CategoryConfig,
+			fmt.Sprintf("%s is already the key of account(s) %s", inUse.KeyPath, strings.Join(inUse.Accounts, ", ")),
+			fmt.Sprintf("Keep one key per account; generate a new one with 'gitshift ssh-keygen %s'", alias), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔑 %s (%s, %d bits)\n", key.Path, key.Info.Type, key.Info.Bits)
+	fmt.Printf("   %s\n", key.Info.Fingerprint)
+	if weakness := key.Info.Weakness(); weakness != "" {
+		fmt.Printf("⚠️  Weak key: %s\n", weakness)
+	}
+	for _, path := range key.FixedModes {
+		fmt.Printf("🔒 Fixed the permissions of %s\n", path)
+	}
+
+	switch {
+	case key.Email == "":
+	case account.Email == "":
+		account.Email = key.Email
+		fmt.Printf("📧 Using the key's email %s for the account\n", key.Email)
+	case !strings.EqualFold(account.Email, key.Email):
+		fmt.Printf("ℹ️  The key is labelled %s, but the account uses %s\n", key.Email, account.Email)
+	}
+
+	previous := account.SSHKeyPath
+	account.SSHKeyPath = key.Path
+	if err := configManager.UpdateAccount(account); err != nil {
+		return fmt.Errorf("failed to update account: %w", err)
+	}
+	refreshHostAliases(configManager, account)
+	fmt.Printf("✅ Account '%s' now uses %s\n", alias, key.Path)
+	if previous != "" && previous != key.Path {
+		fmt.Printf("   (it used %s before, which is left on disk)\n", previous)
+	}
+
+	if !supportsKeyAPI(account) {
+		return nil
+	}
+	if !upload && isInteractive() {
+		answer := promptForInput(fmt.Sprintf("Upload the public key to %s now? [y/N]: ", platformName(account)))
+		upload = answer == "y" || answer == "Y" || answer == "yes" || answer == "Yes"
+	}
+	if !upload {
+		fmt.Printf("💡 Upload it later with: gitshift ssh-keys upload --account %s\n", alias)
+		return nil
+	}
+	return uploadSSHKey(cmd.Context(), configManager, account)
+}
+
+// otherAccounts returns every configured account but alias
+func otherAccounts(configManager *config.Manager, alias string) []*models.Account {
+	var accounts []*models.Account
+	for _, account := range configManager.ListAccounts() {
+		if account.Alias != alias {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
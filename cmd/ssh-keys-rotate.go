@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/token"
+	"github.com/techishthoughts/gitshift/pkg/gh"
+	"golang.org/x/term"
+)
+
+// AccountMetadata keys recording an unfinished key rotation, so that running the command
+// again picks up where it stopped
+const (
+	rotationNewKeyMetadata   = "ssh_key_rotation_new_key"
+	rotationOldKeyMetadata   = "ssh_key_rotation_old_key"
+	rotationNewKeyIDMetadata = "ssh_key_rotation_new_key_id"
+)
+
+var sshKeysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "🔄 Replace an account's SSH key with a new one",
+	Long: `Generate a new SSH key for an account and replace the old one everywhere.
+
+The rotation runs these steps:
+1. Generate a new key of the same type as the current one (DSA keys are
+   replaced with ed25519, use --type to choose another type)
+2. Upload the new public key to GitHub or GitLab
+3. Verify that the platform accepts the new key over SSH
+4. Point the account's SSH key path at the new key
+5. Remove the old key from the platform and the SSH agent, and move it to
+   ~/.ssh/gitshift/trash. When another account still uses the old key, it is
+   left in all three places.
+
+On a terminal you are asked for a passphrase for the new key; --passphrase
+sets it and --no-passphrase skips it. Without a terminal and either flag the
+new key has no passphrase.
+
+The new key is verified with only that key offered, through the HostName,
+Port and ProxyJump or ProxyCommand your SSH config sets for the platform.
+
+Progress is recorded in the account, so if a step fails, running the command
+again resumes where it stopped. The old key stays in place until the new one
+has been verified.
+
+The account's token must be allowed to add and delete SSH keys: the
+admin:public_key scope on GitHub, the api scope on GitLab.`,
+	Example: `  # Rotate the key of the 'work' account
+  gitshift ssh-keys rotate --account work
+
+  # Move a legacy RSA key to ed25519
+  gitshift ssh-keys rotate --account work --type ed25519
+
+  # Rotate from a script, without a passphrase
+  gitshift ssh-keys rotate --account work --no-passphrase`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runSSHKeysRotate,
+}
+
+func init() {
+	sshKeysCmd.AddCommand(sshKeysRotateCmd)
+
+	sshKeysRotateCmd.Flags().String("account", "", "Account whose key to rotate (default: the current account)")
+	sshKeysRotateCmd.Flags().String("type", "", "Type of the new key (default: the type of the current key)")
+	sshKeysRotateCmd.Flags().String("passphrase", "", "Passphrase for the new key (default: ask on a terminal)")
+	sshKeysRotateCmd.Flags().Bool("no-passphrase", false, "Create the new key without a passphrase, without asking")
+	sshKeysRotateCmd.MarkFlagsMutuallyExclusive("passphrase", "no-passphrase")
+	_ = sshKeysRotateCmd.RegisterFlagCompletionFunc("account", completeAccountFlag)
+}
+
+func runSSHKeysRotate(cmd *cobra.Command, args []string) error {
+	alias, _ := cmd.Flags().GetString("account")
+	newKeyType, _ := cmd.Flags().GetString("type")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var account *models.Account
+	var err error
+	if alias == "" {
+		account, err = configManager.GetCurrentOrDefaultAccount()
+		if err != nil {
+			return fmt.Errorf("no current or default account; pass --account <alias>")
+		}
+	} else if account, err = configManager.GetAccount(alias); err != nil {
+		return fmt.Errorf("account '%s' not found", alias)
+	}
+	alias = account.Alias
+
+	if !supportsKeyAPI(account) {
+		return fmt.Errorf("account '%s' is on %s; rotating keys is only supported for GitHub and GitLab", alias, account.GetPlatform())
+	}
+
+	store, err := configManager.TokenStore()
+	if err != nil {
+		return err
+	}
+	accessToken, err := store.Get(alias)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return models.NewUserError(platformCategory(account),
+			fmt.Sprintf("no token stored for account '%s'", alias),
+			fmt.Sprintf("Store one that may add and delete SSH keys: gitshift token set %s", alias),
+			nil)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := sshKeyClient(account, accessToken, 0)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	sshManager := ssh.NewManager()
+	save := func() error {
+		if err := configManager.UpdateAccount(account); err != nil {
+			return fmt.Errorf("failed to save the rotation progress: %w", err)
+		}
+		return nil
+	}
+	if account.AccountMetadata == nil {
+		account.AccountMetadata = make(map[string]string)
+	}
+	meta := account.AccountMetadata
+
+	// Step 1: generate the new key
+	newKeyPath := meta[rotationNewKeyMetadata]
+	oldKeyPath := meta[rotationOldKeyMetadata]
+	if newKeyPath == "" {
+		if account.SSHKeyPath == "" {
+			return fmt.Errorf("account '%s' has no SSH key to rotate; run 'gitshift ssh-keygen %s' instead", alias, alias)
+		}
+		oldKeyPath = account.SSHKeyPath
+		if newKeyType == "" {
+			newKeyType, err = rotationKeyType(ctx, sshManager, oldKeyPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		passphrase, err := newKeyPassphrase(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, err := rotatedKeyPath(oldKeyPath, newKeyType, alias)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("🔄 Rotating the SSH key of account '%s' (%s)\n", alias, oldKeyPath)
+		key, err := (&SSHKeyManager{}).GenerateKey(GenerateKeyParams{
+			Alias:      alias,
+			Email:      account.Email,
+			Type:       newKeyType,
+			Passphrase: passphrase,
+			Path:       path,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate the new SSH key: %w", err)
+		}
+		newKeyPath = key.Path
+		fmt.Printf("✅ New key generated: %s\n", newKeyPath)
+
+		meta[rotationNewKeyMetadata] = newKeyPath
+		meta[rotationOldKeyMetadata] = oldKeyPath
+		if err := save(); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("🔄 Resuming the rotation of account '%s' to %s\n", alias, newKeyPath)
+	}
+
+	newPublicKey, err := os.ReadFile(newKeyPath + ".pub")
+	if err != nil {
+		return fmt.Errorf("failed to read the new public key: %w", err)
+	}
+
+	// Step 2: upload the new public key
+	if meta[rotationNewKeyIDMetadata] == "" {
+		keys, err := client.ListSSHKeys(ctx)
+		if err != nil {
+			return keyAPIError(account, err)
+		}
+		registered, err := gh.FindSSHKeyByFingerprint(keys, string(newPublicKey))
+		if err != nil {
+			return fmt.Errorf("%s.pub: %w", newKeyPath, err)
+		}
+		if registered == nil {
+			fmt.Printf("⬆️  Uploading %s.pub to %s...\n", newKeyPath, platformName(account))
+			if registered, err = client.AddSSHKey(ctx, sshKeyTitle(alias), string(newPublicKey)); err != nil {
+				return keyAPIError(account, err)
+			}
+		}
+		fmt.Printf("✅ New key registered on %s (ID %d)\n", platformName(account), registered.ID)
+
+		meta[rotationNewKeyIDMetadata] = strconv.FormatInt(registered.ID, 10)
+		if err := save(); err != nil {
+			return err
+		}
+	}
+
+	// Step 3: verify the new key before the account depends on it
+	fmt.Printf("🔗 Verifying the new key against %s...\n", account.GetDomain())
+	user, err := sshManager.TestKeyConnection(ctx, account.GetDomain(), newKeyPath)
+	if err != nil {
+		return models.NewUserError(platformCategory(account),
+			"the new SSH key could not authenticate, the account still uses the old key",
+			fmt.Sprintf("Check the key on %s and run 'gitshift ssh-keys rotate --account %s' again", platformName(account), alias),
+			err)
+	}
+	if expected := account.GetUsername(); user != "" && expected != "" && !strings.EqualFold(user, expected) {
+		return models.NewUserError(platformCategory(account),
+			fmt.Sprintf("the new SSH key authenticates as @%s, expected @%s", user, expected),
+			"Check which account the token belongs to; the account still uses the old key",
+			nil)
+	}
+	fmt.Printf("✅ New key verified\n")
+
+	// Step 4: switch the account to the new key
+	if account.SSHKeyPath != newKeyPath {
+		account.SSHKeyPath = newKeyPath
+		if err := save(); err != nil {
+			return err
+		}
+		refreshHostAliases(configManager, account)
+		fmt.Printf("🔗 Account '%s' now uses %s\n", alias, newKeyPath)
+	}
+
+	// Step 5: retire the old key
+	if oldKeyPath != "" && oldKeyPath != newKeyPath {
+		if err := retireOldKey(ctx, configManager, client, sshManager, account, oldKeyPath); err != nil {
+			return err
+		}
+	}
+
+	newKeyID, _ := strconv.ParseInt(meta[rotationNewKeyIDMetadata], 10, 64)
+	meta[account.GetPlatform()+sshKeyIDMetadataSuffix] = strconv.FormatInt(newKeyID, 10)
+	delete(meta, rotationNewKeyMetadata)
+	delete(meta, rotationOldKeyMetadata)
+	delete(meta, rotationNewKeyIDMetadata)
+	if err := save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("🎉 Key of account '%s' rotated\n", alias)
+	if configManager.GetConfig().CurrentAccount == alias {
+		fmt.Printf("💡 Run 'gitshift switch %s' to load the new key into your SSH setup\n", alias)
+	}
+	return nil
+}
+
+// retireOldKey removes the old key from the platform, the SSH agent and disk. When another
+// account uses the same key, by path or by fingerprint, it is left everywhere, since that
+// account would otherwise lose access.
+func retireOldKey(ctx context.Context, configManager *config.Manager, client sshKeyAPI, sshManager *ssh.Manager, account *models.Account, oldKeyPath string) error {
+	oldPublicKey, err := os.ReadFile(pathutil.Expand(oldKeyPath) + ".pub")
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the old public key: %w", err)
+	}
+	var fingerprint string
+	if oldPublicKey != nil {
+		if info, err := sshManager.ValidateKey(ctx, oldKeyPath+".pub"); err == nil {
+			fingerprint = info.Fingerprint
+		}
+	}
+
+	if usedBy := sshManager.AccountsUsingKey(ctx, oldKeyPath, otherAccounts(configManager, account.Alias)); len(usedBy) > 0 {
+		fmt.Printf("⚠️  Keeping the old key %s on %s, in the SSH agent and on disk: account(s) %s still use it\n",
+			oldKeyPath, platformName(account), strings.Join(usedBy, ", "))
+		return nil
+	}
+
+	if oldPublicKey != nil {
+		keys, err := client.ListSSHKeys(ctx)
+		if err != nil {
+			return keyAPIError(account, err)
+		}
+		if registered, err := gh.FindSSHKeyByFingerprint(keys, string(oldPublicKey)); err == nil && registered != nil {
+			if err := client.DeleteSSHKey(ctx, registered.ID); err != nil {
+				return keyAPIError(account, err)
+			}
+			fmt.Printf("🗑️  Removed the old key from %s (ID %d)\n", platformName(account), registered.ID)
+		}
+
+		if fingerprint != "" {
+			// The key may not be loaded at all, which is fine
+			_ = sshManager.UnloadKeyByFingerprint(ctx, fingerprint)
+		}
+	}
+
+	trashDir, err := sshManager.DeleteKey(oldKeyPath, ssh.DeleteKeyOptions{})
+	switch {
+	case err != nil && sshKeyExists(oldKeyPath):
+		return fmt.Errorf("failed to delete the old key: %w", err)
+	case err == nil:
+		fmt.Printf("🗑️  Moved the old key to %s\n", trashDir)
+	}
+	return nil
+}
+
+// newKeyPassphrase returns the passphrase for the new key: the one given with
+// --passphrase, none with --no-passphrase, otherwise one asked for on the terminal, or none
+// without a terminal
+func newKeyPassphrase(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("passphrase") {
+		passphrase, _ := cmd.Flags().GetString("passphrase")
+		return passphrase, nil
+	}
+	if noPassphrase, _ := cmd.Flags().GetBool("no-passphrase"); noPassphrase || !isInteractive() {
+		return "", nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	fmt.Fprint(os.Stderr, "🔐 Passphrase for the new key (empty for none): ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return "", nil
+	}
+
+	fmt.Fprint(os.Stderr, "🔐 Repeat passphrase: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if string(repeated) != string(passphrase) {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return string(passphrase), nil
+}
+
+// rotationKeyType returns the type of the key replacing the one at keyPath: the same type,
+// except for DSA keys, which are replaced with ed25519
+func rotationKeyType(ctx context.Context, sshManager *ssh.Manager, keyPath string) (string, error) {
+	info, err := sshManager.ValidateKey(ctx, keyPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the type of the current key, pass --type: %w", err)
+	}
+	if info.Type == "dsa" {
+		return "ed25519", nil
+	}
+	return info.Type, nil
+}
+
+// maxRotatedKeyPathAttempts bounds how many numbered paths rotatedKeyPath tries
+const maxRotatedKeyPathAttempts = 100
+
+// rotatedKeyPath picks a path for the new key next to the old one, named after the key
+// type, the alias and today's date, e.g. ~/.ssh/id_ed25519_work_20240102
+func rotatedKeyPath(oldKeyPath, keyType, alias string) (string, error) {
+	oldKeyPath = pathutil.Expand(oldKeyPath)
+	base := defaultKeyPath(filepath.Dir(oldKeyPath), keyType, alias) + "_" + time.Now().Format("20060102")
+	path := base
+	for n := 2; n <= maxRotatedKeyPathAttempts+1; n++ {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) && path != oldKeyPath {
+			return path, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to check %s: %w", path, err)
+		}
+		path = fmt.Sprintf("%s_%d", base, n)
+	}
+	return "", fmt.Errorf("no free path for the new key next to %s after %d attempts", oldKeyPath, maxRotatedKeyPathAttempts)
+}
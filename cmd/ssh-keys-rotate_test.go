@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/pkg/gh"
+)
+
+// fakeKeyAPI is an in-memory platform SSH key API
+type fakeKeyAPI struct {
+	keys    []gh.SSHKey
+	deleted []int64
+}
+
+func (f *fakeKeyAPI) ListSSHKeys(ctx context.Context) ([]gh.SSHKey, error) {
+	return f.keys, nil
+}
+
+func (f *fakeKeyAPI) AddSSHKey(ctx context.Context, title, publicKey string) (*gh.SSHKey, error) {
+	key := gh.SSHKey{ID: int64(len(f.keys) + 1), Key: publicKey, Title: title}
+	f.keys = append(f.keys, key)
+	return &key, nil
+}
+
+func (f *fakeKeyAPI) DeleteSSHKey(ctx context.Context, id int64) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+// keygenRunner answers ssh-keygen -lf with the fingerprints of the public keys it knows
+// and records every other command
+type keygenRunner struct {
+	fingerprints map[string]string // public key path to fingerprint
+	commands     []string
+}
+
+func (r *keygenRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if name == "ssh-keygen" && len(args) == 2 && args[0] == "-lf" {
+		if fingerprint, ok := r.fingerprints[args[1]]; ok {
+			return []byte("256 " + fingerprint + " dev@example.com (ED25519)\n"), nil
+		}
+		return []byte(args[1] + ": No such file or directory\n"), fmt.Errorf("exit status 255")
+	}
+	r.commands = append(r.commands, name+" "+strings.Join(args, " "))
+	return []byte("The agent has no identities.\n"), fmt.Errorf("exit status 1")
+}
+
+func (r *keygenRunner) InteractiveCombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return r.CombinedOutput(ctx, name, args...)
+}
+
+func TestRetireOldKey(t *testing.T) {
+	const fingerprint = "SHA256:oldkeyfingerprint"
+
+	tests := []struct {
+		name       string
+		other      *models.Account // another account, nil for none
+		copyOldKey bool            // write a copy of the old key for the other account
+		tilde      bool            // pass the old key path relative to ~
+		wantKept   bool
+	}{
+		{name: "unused elsewhere"},
+		{name: "home-relative path", tilde: true},
+		{name: "same path", other: &models.Account{Alias: "personal", SSHKeyPath: "~/.ssh/id_ed25519_work"}, wantKept: true},
+		{name: "copy of the key", other: &models.Account{Alias: "personal", SSHKeyPath: "~/.ssh/id_ed25519_copy"}, copyOldKey: true, wantKept: true},
+		{name: "agent-only key", other: &models.Account{Alias: "personal", SSHKeyFingerprint: strings.TrimPrefix(fingerprint, "SHA256:")}, wantKept: true},
+		{name: "different key", other: &models.Account{Alias: "personal", SSHKeyPath: "~/.ssh/id_ed25519_copy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			sshDir := filepath.Join(home, ".ssh")
+			if err := os.MkdirAll(sshDir, 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			oldKeyPath := filepath.Join(sshDir, "id_ed25519_work")
+			publicKey := writeTestPublicKey(t, oldKeyPath)
+			if err := os.WriteFile(oldKeyPath, []byte("PRIVATE KEY"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			runner := &keygenRunner{fingerprints: map[string]string{oldKeyPath + ".pub": fingerprint}}
+
+			copyPath := filepath.Join(sshDir, "id_ed25519_copy")
+			if tt.other != nil && tt.other.SSHKeyPath == "~/.ssh/id_ed25519_copy" {
+				writeTestPublicKey(t, copyPath)
+				runner.fingerprints[copyPath+".pub"] = "SHA256:otherkeyfingerprint"
+				if tt.copyOldKey {
+					runner.fingerprints[copyPath+".pub"] = fingerprint
+				}
+			}
+
+			configManager := config.NewManager()
+			if err := configManager.Load(); err != nil {
+				t.Fatal(err)
+			}
+			account := &models.Account{Alias: "work", Name: "Dev", Email: "dev@work.com", SSHKeyPath: filepath.Join(sshDir, "id_ed25519_work_new")}
+			if err := configManager.AddAccount(account); err != nil {
+				t.Fatal(err)
+			}
+			if tt.other != nil {
+				other := *tt.other
+				other.Name, other.Email = "Dev", "dev@example.com"
+				if err := configManager.AddAccount(&other); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			api := &fakeKeyAPI{keys: []gh.SSHKey{{ID: 7, Key: publicKey, Title: "old"}}}
+			sshManager := ssh.NewManagerWithRunner(runner)
+			retiredPath := oldKeyPath
+			if tt.tilde {
+				retiredPath = "~/.ssh/id_ed25519_work"
+			}
+			if err := retireOldKey(context.Background(), configManager, api, sshManager, account, retiredPath); err != nil {
+				t.Fatalf("retireOldKey() error = %v", err)
+			}
+
+			_, statErr := os.Stat(oldKeyPath)
+			if tt.wantKept {
+				if len(api.deleted) != 0 {
+					t.Errorf("deleted platform keys %v of a key another account uses", api.deleted)
+				}
+				if len(runner.commands) != 0 {
+					t.Errorf("ran %v for a key another account uses", runner.commands)
+				}
+				if statErr != nil {
+					t.Errorf("old key removed from disk: %v", statErr)
+				}
+				return
+			}
+			if !slices.Equal(api.deleted, []int64{7}) {
+				t.Errorf("deleted platform keys %v, want [7]", api.deleted)
+			}
+			if len(runner.commands) == 0 || !strings.HasPrefix(runner.commands[0], "ssh-add") {
+				t.Errorf("ran %v, want the old key unloaded from the agent", runner.commands)
+			}
+			if statErr == nil {
+				t.Error("old key still on disk")
+			}
+		})
+	}
+}
+
+func TestRotatedKeyPath_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := rotatedKeyPath("~/.ssh/id_ed25519_work", "ed25519", "work")
+	want := filepath.Join(home, ".ssh", "id_ed25519_work_"+time.Now().Format("20060102"))
+	if err != nil || path != want {
+		t.Errorf("rotatedKeyPath() = %q, %v, want %q", path, err, want)
+	}
+}
+
+func TestRotatedKeyPath_StatError(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// ~/.ssh is a file, so every candidate fails with ENOTDIR rather than not existing
+	if err := os.WriteFile(filepath.Join(home, ".ssh"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := rotatedKeyPath("~/.ssh/id_ed25519_work", "ed25519", "work"); err == nil {
+		t.Errorf("rotatedKeyPath() = %q, want an error", path)
+	}
+}
+
+func TestRotatedKeyPath_GivesUp(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(sshDir, "id_ed25519_work_"+time.Now().Format("20060102"))
+	taken := []string{base}
+	for n := 2; n <= maxRotatedKeyPathAttempts+1; n++ {
+		taken = append(taken, fmt.Sprintf("%s_%d", base, n))
+	}
+	for _, path := range taken {
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if path, err := rotatedKeyPath("~/.ssh/id_ed25519_work", "ed25519", "work"); err == nil {
+		t.Errorf("rotatedKeyPath() = %q, want an error", path)
+	}
+}
+
+func TestNewKeyPassphrase(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--passphrase", "correct horse"}, "correct horse"},
+		{[]string{"--passphrase", ""}, ""},
+		{[]string{"--no-passphrase"}, ""},
+		{nil, ""}, // not a terminal
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("passphrase", "", "")
+		cmd.Flags().Bool("no-passphrase", false, "")
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		got, err := newKeyPassphrase(cmd)
+		if err != nil || got != tt.want {
+			t.Errorf("newKeyPassphrase(%v) = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
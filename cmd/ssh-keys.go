@@ -0,0 +1,434 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	ghapi "github.com/cli/go-gh/v2/pkg/api"
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/metrics"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/token"
+	"github.com/techishthoughts/gitshift/internal/trace"
+	"github.com/techishthoughts/gitshift/pkg/gh"
+	"github.com/techishthoughts/gitshift/pkg/gitlab"
+)
+
+// sshKeyIDMetadataSuffix is appended to the platform name to form the AccountMetadata key
+// recording the ID the platform assigned to the account's uploaded SSH key, e.g.
+// "github_ssh_key_id"
+const sshKeyIDMetadataSuffix = "_ssh_key_id"
+
+// sshKeysCmd groups commands that manage the SSH keys registered on the platform
+var sshKeysCmd = &cobra.Command{
+	Use:   "ssh-keys",
+	Short: "🗝️  Manage the SSH keys registered on your Git platform accounts",
+}
+
+var sshKeysUploadCmd = &cobra.Command{
+	Use:   "upload",
+	Short: "⬆️  Upload an account's SSH public key to GitHub or GitLab",
+	Long: `Register an account's SSH public key on GitHub or GitLab through the API.
+
+The account's token is read from the token store (see 'gitshift token set').
+It needs the write:public_key scope on GitHub and the api scope on GitLab.
+Self-hosted instances are reached through the account's API endpoint. The key
+is titled after this machine's hostname and the account alias, and the ID the
+platform assigns to it is recorded in the account. Keys that are already
+registered are detected by fingerprint and skipped.`,
+	Example: `  # Upload the key of the 'work' account
+  gitshift ssh-keys upload --account work`,
+	Args: cobra.NoArgs,
+	RunE: runSSHKeysUpload,
+}
+
+var sshKeysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "📋 List the SSH keys in ~/.ssh",
+	Long: `List the SSH key pairs in ~/.ssh with their type, size and fingerprint, and
+the accounts configured with each.
+
+Keys are recognised by their content rather than their name, so keys such as
+~/.ssh/work_github or ~/.ssh/personal.key are listed alongside id_* keys. A
+private key is only listed when its .pub file sits next to it.`,
+	Args: cobra.NoArgs,
+	RunE: runSSHKeysList,
+}
+
+var sshKeysDeleteCmd = &cobra.Command{
+	Use:   "delete <key-path>",
+	Short: "🗑️  Delete an SSH key pair",
+	Long: `Delete an SSH private key and its .pub file.
+
+By default the key pair is moved to a new directory under
+~/.ssh/gitshift/trash/ named after the time of deletion, from where it can be
+restored by moving it back. Use --permanent to unlink it
+instead.
+
+Keys still used by a configured account are not deleted unless --force is
+given; the accounts using the key are listed.`,
+	Example: `  # Move a key to the trash
+  gitshift ssh-keys delete ~/.ssh/id_ed25519_old
+
+  # Delete a key for good, even though an account still uses it
+  gitshift ssh-keys delete ~/.ssh/id_ed25519_old --permanent --force`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runSSHKeysDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(sshKeysCmd)
+	sshKeysCmd.AddCommand(sshKeysUploadCmd)
+	sshKeysCmd.AddCommand(sshKeysDeleteCmd)
+	sshKeysCmd.AddCommand(sshKeysListCmd)
+
+	sshKeysUploadCmd.Flags().String("account", "", "Account whose key to upload (default: the current account)")
+	_ = sshKeysUploadCmd.RegisterFlagCompletionFunc("account", completeAccountFlag)
+
+	sshKeysDeleteCmd.Flags().Bool("force", false, "Delete the key even when accounts still use it")
+	sshKeysDeleteCmd.Flags().Bool("permanent", false, "Unlink the key pair instead of moving it to the trash")
+}
+
+func runSSHKeysList(cmd *cobra.Command, args []string) error {
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	sshManager := ssh.NewManager()
+	keys, err := sshManager.ListKeys(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Println("No SSH keys found in ~/.ssh")
+		return nil
+	}
+
+	for _, key := range keys {
+		if key.Err != nil {
+			fmt.Printf("⚠️  %s (ssh-keygen could not read it)\n", key.Path)
+		} else {
+			fmt.Printf("🔑 %s (%s, %d bits)\n", key.Path, key.Info.Type, key.Info.Bits)
+			fmt.Printf("   %s\n", key.Info.Fingerprint)
+		}
+		if users := sshManager.AccountsUsingKey(cmd.Context(), key.Path, configManager.ListAccounts()); len(users) > 0 {
+			fmt.Printf("   Used by: %s\n", strings.Join(users, ", "))
+		}
+	}
+	return nil
+}
+
+func runSSHKeysDelete(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	permanent, _ := cmd.Flags().GetBool("permanent")
+	keyPath := strings.TrimSuffix(pathutil.Expand(args[0]), ".pub")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	sshManager := ssh.NewManager()
+	usedBy := sshManager.AccountsUsingKey(cmd.Context(), keyPath, configManager.ListAccounts())
+	trashDir, err := sshManager.DeleteKey(keyPath, ssh.DeleteKeyOptions{
+		UsedBy:    usedBy,
+		Force:     force,
+		Permanent: permanent,
+	})
+	var inUse *ssh.KeyInUseError
+	if errors.As(err, &inUse) {
+		return models.NewUserError(models.CategoryConfig, err.Error(),
+			"Point those accounts at another key first, or pass --force to delete it anyway", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(usedBy) > 0 {
+		fmt.Printf("⚠️  Account(s) %s still point at the deleted key\n", strings.Join(usedBy, ", "))
+	}
+	if permanent {
+		fmt.Printf("🗑️  Deleted %s\n", keyPath)
+	} else {
+		fmt.Printf("🗑️  Moved %s to %s\n", keyPath, trashDir)
+		fmt.Printf("💡 Restore it with: mv %s/* %s/\n", trashDir, filepath.Dir(keyPath))
+	}
+	return nil
+}
+
+func runSSHKeysUpload(cmd *cobra.Command, args []string) error {
+	alias, _ := cmd.Flags().GetString("account")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var account *models.Account
+	var err error
+	if alias == "" {
+		account, err = configManager.GetCurrentOrDefaultAccount()
+		if err != nil {
+			return fmt.Errorf("no current or default account; pass --account <alias>")
+		}
+	} else if account, err = configManager.GetAccount(alias); err != nil {
+		return fmt.Errorf("account '%s' not found", alias)
+	}
+	return uploadSSHKey(cmd.Context(), configManager, account)
+}
+
+// uploadSSHKey registers the account's public key on its platform, unless a key with the
+// same fingerprint is already there, and records the ID the platform assigned to it
+func uploadSSHKey(ctx context.Context, configManager *config.Manager, account *models.Account) error {
+	alias := account.Alias
+	if !supportsKeyAPI(account) {
+		return fmt.Errorf("account '%s' is on %s; uploading keys is only supported for GitHub and GitLab", alias, account.GetPlatform())
+	}
+	if account.SSHKeyPath == "" {
+		return fmt.Errorf("account '%s' has no SSH key; run 'gitshift ssh-keygen %s' first", alias, alias)
+	}
+
+	pubKeyPath := pathutil.Expand(account.SSHKeyPath) + ".pub"
+	publicKey, err := os.ReadFile(pubKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key %s: %w", pubKeyPath, err)
+	}
+
+	store, err := configManager.TokenStore()
+	if err != nil {
+		return err
+	}
+	accessToken, err := store.Get(alias)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		return models.NewUserError(platformCategory(account),
+			fmt.Sprintf("no token stored for account '%s'", alias),
+			fmt.Sprintf("Store one with the %s scope: gitshift token set %s", keyScope(account), alias),
+			nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := sshKeyClient(account, accessToken, 0)
+	if err != nil {
+		return err
+	}
+
+	keys, err := client.ListSSHKeys(ctx)
+	if err != nil {
+		return keyAPIError(account, err)
+	}
+
+	existing, err := gh.FindSSHKeyByFingerprint(keys, string(publicKey))
+	if err != nil {
+		return fmt.Errorf("%s: %w", pubKeyPath, err)
+	}
+	if existing != nil {
+		fmt.Printf("✅ Key %s is already registered on %s as '%s' (ID %d), skipping upload\n", pubKeyPath, platformName(account), existing.Title, existing.ID)
+		return recordSSHKeyID(configManager, account, existing.ID)
+	}
+
+	title := sshKeyTitle(alias)
+	fmt.Printf("⬆️  Uploading %s to %s as '%s'...\n", pubKeyPath, platformName(account), title)
+	key, err := client.AddSSHKey(ctx, title, string(publicKey))
+	if err != nil {
+		return keyAPIError(account, err)
+	}
+
+	fmt.Printf("✅ Key uploaded (ID %d)\n", key.ID)
+	return recordSSHKeyID(configManager, account, key.ID)
+}
+
+// sshKeyTitle names an uploaded key after this machine and the account
+func sshKeyTitle(alias string) string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown-host"
+	}
+	return fmt.Sprintf("gitshift %s (%s)", alias, strings.TrimSuffix(hostname, ".local"))
+}
+
+// recordSSHKeyID stores the platform's key ID in the account's metadata
+func recordSSHKeyID(configManager *config.Manager, account *models.Account, id int64) error {
+	if account.AccountMetadata == nil {
+		account.AccountMetadata = make(map[string]string)
+	}
+	account.AccountMetadata[account.GetPlatform()+sshKeyIDMetadataSuffix] = strconv.FormatInt(id, 10)
+
+	if err := configManager.UpdateAccount(account); err != nil {
+		return fmt.Errorf("failed to record the SSH key ID: %w", err)
+	}
+	return nil
+}
+
+// keyAPIError turns GitHub and GitLab API failures into errors that say what to do about
+// them
+func keyAPIError(account *models.Account, err error) error {
+	category, name, alias := platformCategory(account), platformName(account), account.Alias
+
+	var rateLimitErr *gh.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return models.NewUserError(category, "GitHub is rate limiting this token",
+			"Try again once the limit has reset", err)
+	}
+
+	var statusCode int
+	var ghErr *ghapi.HTTPError
+	var gitlabErr *gitlab.HTTPError
+	switch {
+	case errors.As(err, &ghErr):
+		statusCode = ghErr.StatusCode
+	case errors.As(err, &gitlabErr):
+		statusCode = gitlabErr.StatusCode
+	default:
+		return err
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return models.NewUserError(category,
+			fmt.Sprintf("%s rejected the token of account '%s'", name, alias),
+			fmt.Sprintf("The token may be expired or revoked; store a new one with 'gitshift token set %s'", alias),
+			err)
+	case http.StatusForbidden, http.StatusNotFound:
+		return models.NewUserError(category,
+			fmt.Sprintf("the token of account '%s' may not manage SSH keys", alias),
+			fmt.Sprintf("Create a token with the %s scope and store it with 'gitshift token set %s'", keyScope(account), alias),
+			err)
+	case http.StatusTooManyRequests:
+		return models.NewUserError(category, fmt.Sprintf("%s is rate limiting this token", name),
+			"Try again once the limit has reset", err)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return models.NewUserError(category,
+			fmt.Sprintf("%s refused the SSH key", name),
+			fmt.Sprintf("A key can only be registered on one %s account; check that it isn't used by another account, or generate a new one with 'gitshift ssh-keygen %s --force'", name, alias),
+			err)
+	}
+	return err
+}
+
+// sshKeyAPI is the part of a platform API client that manages the SSH keys of the
+// authenticated user
+type sshKeyAPI interface {
+	ListSSHKeys(ctx context.Context) ([]gh.SSHKey, error)
+	AddSSHKey(ctx context.Context, title, publicKey string) (*gh.SSHKey, error)
+	DeleteSSHKey(ctx context.Context, id int64) error
+}
+
+// supportsKeyAPI reports whether gitshift can manage the account's SSH keys through its
+// platform's API
+func supportsKeyAPI(account *models.Account) bool {
+	switch account.GetPlatform() {
+	case "github", "gitlab":
+		return true
+	}
+	return false
+}
+
+// sshKeyClient returns the SSH key API of the account's platform. Requests are attempted
+// attempts times, or as often as the client does by default when attempts is zero.
+func sshKeyClient(account *models.Account, accessToken string, attempts int) (sshKeyAPI, error) {
+	switch account.GetPlatform() {
+	case "github":
+		return githubClient(account, accessToken, attempts)
+	case "gitlab":
+		var opts []gitlab.ClientOption
+		if attempts > 0 {
+			opts = append(opts, gitlab.WithMaxAttempts(attempts))
+		}
+		client, err := gitlab.NewClient(account.GetAPIEndpoint(), accessToken, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return gitlabKeyAPI{client}, nil
+	}
+	return nil, fmt.Errorf("managing SSH keys is not supported on %s", account.GetPlatform())
+}
+
+// gitlabKeyAPI adapts a GitLab client to sshKeyAPI
+type gitlabKeyAPI struct {
+	*gitlab.Client
+}
+
+func (g gitlabKeyAPI) ListSSHKeys(ctx context.Context) ([]gh.SSHKey, error) {
+	keys, err := g.Client.ListSSHKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]gh.SSHKey, len(keys))
+	for i, key := range keys {
+		converted[i] = gh.SSHKey(key)
+	}
+	return converted, nil
+}
+
+func (g gitlabKeyAPI) AddSSHKey(ctx context.Context, title, publicKey string) (*gh.SSHKey, error) {
+	key, err := g.Client.AddSSHKey(ctx, title, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	converted := gh.SSHKey(*key)
+	return &converted, nil
+}
+
+// platformName returns the display name of the account's platform
+func platformName(account *models.Account) string {
+	switch account.GetPlatform() {
+	case "github":
+		return "GitHub"
+	case "gitlab":
+		return "GitLab"
+	case "bitbucket":
+		return "Bitbucket"
+	}
+	return account.GetDomain()
+}
+
+// platformCategory returns the error category for API failures on the account's platform
+func platformCategory(account *models.Account) models.ErrorCategory {
+	if account.GetPlatform() == "gitlab" {
+		return models.CategoryGitLab
+	}
+	return models.CategoryGitHub
+}
+
+// keyScope returns the token scope needed to manage SSH keys on the account's platform
+func keyScope(account *models.Account) string {
+	if account.GetPlatform() == "gitlab" {
+		return "api"
+	}
+	return "write:public_key"
+}
+
+// githubClient returns an API client for a GitHub or GitHub Enterprise account, sending
+// requests to the account's API endpoint when one is configured. Requests are attempted
+// attempts times, or as often as the client does by default when attempts is zero.
+func githubClient(account *models.Account, accessToken string, attempts int) (*gh.Client, error) {
+	opts := []gh.ClientOption{gh.WithRequestObserver(func(ctx context.Context, method, path string, status int, duration time.Duration) {
+		trace.Printf(ctx, "api", "%s %s: %d in %.3fs", method, path, status, duration.Seconds())
+		metrics.Observe(metrics.APIRequestDuration, metrics.Labels{
+			"platform": "github",
+			"method":   method,
+			"status":   strconv.Itoa(status),
+		}, duration.Seconds())
+	})}
+	if account.APIEndpoint != "" {
+		opts = append(opts, gh.WithAPIEndpoint(account.APIEndpoint))
+	}
+	if attempts > 0 {
+		opts = append(opts, gh.WithMaxAttempts(attempts))
+	}
+	return gh.WithTokenForHost(account.GetDomain(), accessToken, opts...)
+}
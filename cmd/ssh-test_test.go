@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/switcher"
+)
+
+// fakeSSHPlatform puts an ssh on PATH that resolves configs with the real ssh -G and
+// greets a connection to git@<domain>-<alias> as user <alias>, the way GitHub does
+func fakeSSHPlatform(t *testing.T) {
+	t.Helper()
+	realSSH, err := exec.LookPath("ssh")
+	if err != nil {
+		t.Skip("ssh not installed")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+for arg; do host=$arg; done
+case " $* " in
+*" -G "*) exec ` + shellQuote(realSSH) + ` "$@" ;;
+esac
+echo "Hi ${host##*-}! You've successfully authenticated, but GitHub does not provide shell access." >&2
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSSHTester_ViaAliasForEveryAccount(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	fakeSSHPlatform(t)
+
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sshDir, "known_hosts"), []byte("github.com ssh-ed25519 AAAAplaceholder\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		t.Fatal(err)
+	}
+	aliases := []string{"personal", "work"}
+	for _, alias := range aliases {
+		keyPath := filepath.Join(sshDir, "id_ed25519_"+alias)
+		writeTestPublicKey(t, keyPath)
+		if err := os.WriteFile(keyPath, []byte("PRIVATE KEY"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		account := models.NewAccount(alias, "Dev", alias+"@example.com", keyPath)
+		account.GitHubUsername = alias
+		if err := configManager.AddAccount(account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testVia := func(alias string) (string, error) {
+		account, err := configManager.GetAccount(alias)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		tester := &SSHTester{viaAlias: true, out: &out}
+		err = tester.TestAccount(alias, account)
+		return out.String(), err
+	}
+
+	// Before any switch there are no aliases, which is reported as such rather than as a
+	// failed connection
+	output, err := testVia("personal")
+	if err == nil || !strings.Contains(output, "Alias not configured") || strings.Contains(output, "Testing SSH connection") {
+		t.Errorf("TestAccount() without the alias = %v\n%s", err, output)
+	}
+
+	work, _ := configManager.GetAccount("work")
+	if err := switcher.Switch(context.Background(), configManager, work, switcher.Options{Offline: true}); err != nil {
+		t.Fatalf("Switch() error = %v", err)
+	}
+
+	// The inactive account is reachable through its alias as well as the active one
+	for _, alias := range aliases {
+		output, err := testVia(alias)
+		if err != nil || !strings.Contains(output, "authenticated as @"+alias) {
+			t.Errorf("TestAccount(%s) = %v\n%s", alias, err, output)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"golang.org/x/term"
+)
+
+// tokenCmd groups commands that manage the platform tokens stored for accounts
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "🔑 Manage the platform tokens stored for accounts",
+	Long: `Manage the platform API tokens gitshift stores for your accounts.
+
+Tokens are kept in the backend selected by token_storage in the config:
+- file (default): AES-GCM encrypted files in the gitshift config directory
+- keychain: the macOS Keychain, the Secret Service on Linux or the Windows
+  Credential Manager
+
+'gitshift diagnose' reports the backend in use and whether each stored token
+can be read back.`,
+}
+
+var tokenSetCmd = &cobra.Command{
+	Use:   "set <account>",
+	Short: "Store the token for an account",
+	Long: `Store the platform API token for an account, replacing any previous one.
+
+The token is read from standard input so it never appears in the shell history
+or the process list. On a terminal it is prompted for without echo.`,
+	Example: `  # Prompt for the token
+  gitshift token set work
+
+  # Read the token from another tool
+  gh auth token | gitshift token set work`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
+	RunE:              runTokenSet,
+}
+
+var tokenDeleteCmd = &cobra.Command{
+	Use:               "delete <account>",
+	Short:             "Delete the token stored for an account",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
+	RunE:              runTokenDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenSetCmd)
+	tokenCmd.AddCommand(tokenDeleteCmd)
+}
+
+func runTokenSet(cmd *cobra.Command, args []string) error {
+	alias := args[0]
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if _, err := configManager.GetAccount(alias); err != nil {
+		return fmt.Errorf("account '%s' not found", alias)
+	}
+
+	store, err := configManager.TokenStore()
+	if err != nil {
+		return err
+	}
+
+	value, err := readToken(alias)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("no token provided")
+	}
+
+	if err := store.Set(alias, value); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Token for '%s' stored in the %s backend\n", alias, store.Backend())
+	return nil
+}
+
+func runTokenDelete(cmd *cobra.Command, args []string) error {
+	alias := args[0]
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	store, err := configManager.TokenStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(alias); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Token for '%s' deleted from the %s backend\n", alias, store.Backend())
+	return nil
+}
+
+// readToken reads a token from standard input, prompting without echo on a terminal
+func readToken(alias string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Printf("🔑 Token for '%s': ", alias)
+		value, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		return strings.TrimSpace(string(value)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -41,7 +41,8 @@ Examples:
 
   # Update SSH key
   gitshift update work --ssh-key "~/.ssh/new_key" --description "Updated description"`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountAlias,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		alias := args[0]
 
@@ -176,14 +177,22 @@ Examples:
 		if err := configManager.AddAccount(updatedAccount); err != nil {
 			return fmt.Errorf("failed to add updated account: %w", err)
 		}
+		refreshHostAliases(configManager, existingAccount, updatedAccount)
 
-		// Set as default if requested
+		// Set as default if requested, or keep it the default: removing the old entry
+		// handed the flag to another account
 		if setDefault {
 			if err := configManager.SetCurrentAccount(alias); err != nil {
 				fmt.Printf("⚠️  Failed to set as default: %v\n", err)
+			} else if err := configManager.SetDefaultAccount(alias); err != nil {
+				fmt.Printf("⚠️  Failed to set as default: %v\n", err)
 			} else {
 				changes = append(changes, "set as default account")
 			}
+		} else if existingAccount.IsDefault {
+			if err := configManager.SetDefaultAccount(alias); err != nil {
+				fmt.Printf("⚠️  Failed to keep as default: %v\n", err)
+			}
 		}
 
 		// Show summary of changes
@@ -235,4 +244,6 @@ func init() {
 	updateCmd.Flags().StringP("platform", "p", "", "Set platform (github, gitlab, bitbucket)")
 	updateCmd.Flags().Bool("auto-gpg", false, "Automatically find and associate GPG key by email")
 	updateCmd.Flags().Bool("default", false, "Set this account as the default")
+	_ = updateCmd.RegisterFlagCompletionFunc("ssh-key", completeSSHKeyPath)
+	_ = updateCmd.RegisterFlagCompletionFunc("platform", completePlatform)
 }
@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/metrics"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/trace"
+)
+
+// Validation check categories
+const (
+	CheckIdentity     = "identity"
+	CheckPlatform     = "platform"
+	CheckSSHKey       = "ssh_key"
+	CheckConnectivity = "connectivity"
+)
+
+// Validation check statuses
+const (
+	CheckPassed  = "pass"
+	CheckFailed  = "fail"
+	CheckWarning = "warn"
+	CheckSkipped = "skip"
+)
+
+// maxConcurrentValidations bounds how many accounts are validated at once, each of which
+// may open an SSH connection
+const maxConcurrentValidations = 4
+
+// ValidationOptions controls how thorough account validation is
+type ValidationOptions struct {
+	// SkipConnectivity skips the live SSH connection test, e.g. offline or in CI
+	SkipConnectivity bool
+}
+
+// ValidationCheck is the outcome of a single check of an account
+type ValidationCheck struct {
+	Category string `json:"category"`
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+}
+
+// AccountValidation is the outcome of validating one account. Only failed checks make an
+// account invalid; warnings such as a failed connection test don't.
+type AccountValidation struct {
+	Alias  string            `json:"alias"`
+	Valid  bool              `json:"valid"`
+	Checks []ValidationCheck `json:"checks"`
+}
+
+// ValidationSummary aggregates the validation of several accounts
+type ValidationSummary struct {
+	Total              int                  `json:"total"`
+	Valid              int                  `json:"valid"`
+	Invalid            int                  `json:"invalid"`
+	FailuresByCategory map[string]int       `json:"failures_by_category"`
+	Accounts           []*AccountValidation `json:"accounts"`
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [account...]",
+	Short: "✔️  Validate account configurations",
+	Long: `Validate the configuration of one or more accounts:
+- Display name and email
+- Username against the platform's rules (GitHub, GitLab, Bitbucket; custom
+  platforms only reject whitespace and separators)
+- Platform domain, required for custom platforms
+- SSH key presence, type and strength (DSA and RSA keys under 3072 bits fail),
+  and a live SSH connection test (skipped with --offline)
+
+Accounts are validated concurrently. The summary counts valid and invalid
+accounts and the failed checks per category (identity, platform, ssh_key,
+connectivity). A failed connection test is reported as a warning and does not
+make an account invalid.
+
+With --exit-on-fail the command exits with a non-zero status when any account
+is invalid, so CI can gate on it.`,
+	Example: `  # Validate all accounts
+  gitshift validate --all
+
+  # Validate specific accounts
+  gitshift validate work personal
+
+  # Gate a CI job on a machine-readable summary
+  gitshift validate --all --offline --json --exit-on-fail`,
+	SilenceUsage:      true,
+	ValidArgsFunction: completeAccountAliases,
+	RunE:              runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().Bool("all", false, "Validate all configured accounts")
+	validateCmd.Flags().Bool("json", false, "Output the summary as JSON")
+	validateCmd.Flags().Bool("exit-on-fail", false, "Exit with a non-zero status when any account is invalid")
+	validateCmd.Flags().Bool("offline", false, "Skip the live SSH connection tests")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	exitOnFail, _ := cmd.Flags().GetBool("exit-on-fail")
+	offline, _ := cmd.Flags().GetBool("offline")
+
+	if all == (len(args) > 0) {
+		return fmt.Errorf("specify account aliases or --all")
+	}
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var accounts []*models.Account
+	if all {
+		accounts = configManager.ListAccounts()
+	} else {
+		for _, alias := range args {
+			account, err := configManager.GetAccount(alias)
+			if err != nil {
+				return fmt.Errorf("account '%s' not found", alias)
+			}
+			accounts = append(accounts, account)
+		}
+	}
+
+	summary := summarizeValidations(validateAccounts(cmd.Context(), configManager, accounts, ValidationOptions{SkipConnectivity: offline}))
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(summary); err != nil {
+			return fmt.Errorf("failed to encode validation summary as JSON: %w", err)
+		}
+	} else {
+		for _, result := range summary.Accounts {
+			printAccountValidation(result)
+			fmt.Println()
+		}
+		printValidationSummary(summary)
+	}
+
+	if exitOnFail && summary.Invalid > 0 {
+		return fmt.Errorf("%d of %d account(s) failed validation", summary.Invalid, summary.Total)
+	}
+	return nil
+}
+
+// validateAccounts validates the accounts concurrently and returns the results sorted by alias
+func validateAccounts(ctx context.Context, configManager *config.Manager, accounts []*models.Account, opts ValidationOptions) []*AccountValidation {
+	results := make([]*AccountValidation, len(accounts))
+	semaphore := make(chan struct{}, maxConcurrentValidations)
+
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = checkAccount(ctx, configManager, account, opts)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Alias < results[j].Alias
+	})
+	return results
+}
+
+// summarizeValidations counts valid and invalid accounts and failed checks per category
+func summarizeValidations(results []*AccountValidation) *ValidationSummary {
+	summary := &ValidationSummary{
+		Total:              len(results),
+		FailuresByCategory: make(map[string]int),
+		Accounts:           results,
+	}
+	for _, result := range results {
+		if result.Valid {
+			summary.Valid++
+		} else {
+			summary.Invalid++
+		}
+		for _, check := range result.Checks {
+			if check.Status == CheckFailed {
+				summary.FailuresByCategory[check.Category]++
+			}
+		}
+	}
+	return summary
+}
+
+// checkAccount runs every check for one account
+func checkAccount(ctx context.Context, configManager *config.Manager, account *models.Account, opts ValidationOptions) *AccountValidation {
+	trace.Printf(ctx, "validate", "checking %s", account.Alias)
+	result := &AccountValidation{Alias: account.Alias}
+	add := func(category, status, message string) {
+		result.Checks = append(result.Checks, ValidationCheck{Category: category, Status: status, Message: message})
+	}
+
+	if account.Name == "" {
+		add(CheckIdentity, CheckFailed, "Missing display name")
+	} else {
+		add(CheckIdentity, CheckPassed, fmt.Sprintf("Display name: %s", account.Name))
+	}
+
+	if account.Email == "" {
+		add(CheckIdentity, CheckFailed, "Missing email address")
+	} else {
+		add(CheckIdentity, CheckPassed, fmt.Sprintf("Email: %s", account.Email))
+	}
+
+	if username := account.GetUsername(); username != "" {
+		if err := models.ValidateUsername(account.GetPlatform(), username); err != nil {
+			add(CheckIdentity, CheckFailed, err.Error())
+		} else {
+			add(CheckIdentity, CheckPassed, fmt.Sprintf("Username: %s", username))
+		}
+	}
+
+	if account.GetDomain() == "" {
+		add(CheckPlatform, CheckFailed, "Custom platform accounts need a domain")
+	} else {
+		add(CheckPlatform, CheckPassed, fmt.Sprintf("Platform: %s (%s)", account.GetPlatform(), account.GetDomain()))
+	}
+
+	var keyInfo *ssh.KeyInfo
+	keyFound := false
+	switch {
+	case account.SSHKeyPath == "" && account.SSHKeyFingerprint != "":
+		// The key only lives in the agent, e.g. on a hardware token or a forwarded agent
+		agentKey, err := ssh.NewManager().FindAgentKey(ctx, account.SSHKeyFingerprint)
+		if err != nil {
+			add(CheckSSHKey, CheckFailed, fmt.Sprintf("SSH key: %v", err))
+			break
+		}
+		keyInfo, keyFound = agentKey, true
+		add(CheckSSHKey, CheckPassed, fmt.Sprintf("SSH key found in the SSH agent: %s", agentKey.Fingerprint))
+	case account.SSHKeyPath == "":
+		add(CheckSSHKey, CheckWarning, "No SSH key configured")
+	default:
+		if _, err := pathutil.Stat(account.SSHKeyPath); err != nil {
+			add(CheckSSHKey, CheckFailed, fmt.Sprintf("SSH key %v", err))
+			break
+		}
+		keyFound = true
+		add(CheckSSHKey, CheckPassed, fmt.Sprintf("SSH key found: %s", account.SSHKeyPath))
+
+		var err error
+		if keyInfo, err = ssh.NewManager().ValidateKey(ctx, account.SSHKeyPath); err != nil {
+			add(CheckSSHKey, CheckFailed, fmt.Sprintf("SSH key is not valid: %v", err))
+		}
+	}
+
+	if keyInfo != nil {
+		if weakness := keyInfo.Weakness(); weakness != "" {
+			add(CheckSSHKey, CheckFailed, fmt.Sprintf("Weak SSH key (%s, %d bits): %s; regenerate it as ed25519 with 'gitshift ssh-keygen %s --type ed25519 --force'",
+				keyInfo.Type, keyInfo.Bits, weakness, account.Alias))
+		} else {
+			add(CheckSSHKey, CheckPassed, fmt.Sprintf("SSH key type: %s (%d bits)", keyInfo.Type, keyInfo.Bits))
+		}
+	}
+
+	if keyFound {
+		if opts.SkipConnectivity {
+			add(CheckConnectivity, CheckSkipped, "SSH connection test skipped (offline)")
+		} else if cached, err := testAccountConnectivity(ctx, configManager, account); err != nil {
+			add(CheckConnectivity, CheckWarning, fmt.Sprintf("SSH connection test failed: %v", err))
+		} else if cached {
+			add(CheckConnectivity, CheckPassed, fmt.Sprintf("SSH connection test passed (cached from %s)", account.LastConnectivityTest.Format("15:04:05")))
+		} else {
+			add(CheckConnectivity, CheckPassed, "SSH connection test passed")
+		}
+	}
+
+	result.Valid = true
+	for _, check := range result.Checks {
+		if check.Status == CheckFailed {
+			result.Valid = false
+		}
+	}
+
+	outcome := metrics.ResultSuccess
+	if !result.Valid {
+		outcome = metrics.ResultFailure
+	}
+	trace.Printf(ctx, "validate", "%s: %s", account.Alias, outcome)
+	metrics.Inc(metrics.ValidationsTotal, metrics.Labels{"account": account.Alias, "result": outcome})
+
+	return result
+}
+
+// sshKeyExists reports whether the key file can be stat'ed
+func sshKeyExists(path string) bool {
+	_, err := pathutil.Stat(path)
+	return err == nil
+}
+
+// failures returns how many checks of the account failed
+func (v *AccountValidation) failures() int {
+	count := 0
+	for _, check := range v.Checks {
+		if check.Status == CheckFailed {
+			count++
+		}
+	}
+	return count
+}
+
+// printAccountValidation prints the checks of one account
+func printAccountValidation(result *AccountValidation) {
+	fmt.Printf("🔍 Validating account '%s'...\n", result.Alias)
+	for _, check := range result.Checks {
+		fmt.Printf("%s %s\n", checkEmoji(check.Status), check.Message)
+	}
+
+	if result.Valid {
+		fmt.Printf("\n✅ Account '%s' is valid and ready to use!\n", result.Alias)
+	} else {
+		fmt.Printf("\n❌ Account '%s' has %d issue(s) that need to be resolved\n", result.Alias, result.failures())
+	}
+}
+
+// printValidationSummary prints the totals of a multi-account validation
+func printValidationSummary(summary *ValidationSummary) {
+	fmt.Println("══════════════════════════════════════════════════════")
+	fmt.Printf("📊 %d account(s): %d valid, %d invalid\n", summary.Total, summary.Valid, summary.Invalid)
+
+	categories := make([]string, 0, len(summary.FailuresByCategory))
+	for category := range summary.FailuresByCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		fmt.Printf("   ❌ %s: %d failed check(s)\n", category, summary.FailuresByCategory[category])
+	}
+}
+
+// checkEmoji returns the emoji shown next to a check status
+func checkEmoji(status string) string {
+	switch status {
+	case CheckPassed:
+		return "✅"
+	case CheckFailed:
+		return "❌"
+	case CheckSkipped:
+		return "⏭️ "
+	default:
+		return "⚠️ "
+	}
+}
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "🪪 Show the identity Git will actually use here",
+	Long: `Show the identity Git will use in the current directory, reconciled from
+what is actually configured rather than what gitshift last switched to:
+
+- The effective user.name and user.email (repository config wins over global)
+- The configured account they belong to, resolved from the remote's host alias,
+  the account pinned in the nearest .gitshift.yaml, the email address, the
+  active account or the default account (marked "default")
+- The SSH key ssh will offer, from GIT_SSH_COMMAND, core.sshCommand or ~/.ssh/config
+- The account the platform authenticates that key as (via ssh -T)
+
+Works both inside and outside a Git repository.
+
+With --check, the identity is compared with the account the remote expects
+(see 'gitshift auto') and the command fails when the active account, the
+commit email or the authenticated user doesn't match. This is what the
+pre-push hook installed by 'gitshift hooks install' runs.`,
+	Example: `  # Show the effective identity
+  gitshift whoami
+
+  # Skip the ssh -T check
+  gitshift whoami --offline
+
+  # Fail if the identity doesn't match what the upstream remote expects
+  gitshift whoami --check --remote upstream`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runWhoami,
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+
+	whoamiCmd.Flags().Bool("offline", false, "Don't connect to the Git host to confirm the authenticated user")
+	whoamiCmd.Flags().Bool("check", false, "Fail if the identity doesn't match the account the remote expects")
+	whoamiCmd.Flags().String("remote", "origin", "Remote name or URL to resolve the host and expected account from")
+}
+
+func runWhoami(cmd *cobra.Command, args []string) error {
+	offline, _ := cmd.Flags().GetBool("offline")
+	check, _ := cmd.Flags().GetBool("check")
+	remote, _ := cmd.Flags().GetString("remote")
+
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	name := effectiveGitValue(cmd.Context(), "user.name")
+	email := effectiveGitValue(cmd.Context(), "user.email")
+
+	repoRoot := repoGitValue(cmd.Context(), ".", "rev-parse", "--show-toplevel")
+	inRepo := repoRoot != ""
+	remoteURL, host := "", ""
+	if inRepo {
+		remoteURL = resolveRemoteURL(cmd.Context(), remote)
+		host = remoteHost(remoteURL)
+	}
+	if check && remoteURL == "" {
+		return fmt.Errorf("--check needs a Git repository with a '%s' remote", remote)
+	}
+
+	account, resolvedBy, err := resolveWhoamiAccount(configManager, host, email)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		host = "github.com"
+		if account != nil {
+			host = account.GetDomain()
+		}
+	}
+
+	var warnings []string
+
+	keyPath, keySource := effectiveSSHKey(cmd.Context(), host)
+	fingerprint := ""
+	if keyPath != "" {
+		if info, err := ssh.NewManager().ValidateKey(cmd.Context(), keyPath); err == nil {
+			fingerprint = info.Fingerprint
+		} else {
+			warnings = append(warnings, fmt.Sprintf("Cannot read SSH key %s", keyPath))
+		}
+	}
+
+	authenticatedUser := ""
+	if !offline {
+		authenticatedUser = authenticatedSSHUser(cmd.Context(), host, keyPath, keySource)
+		if authenticatedUser == "" {
+			warnings = append(warnings, fmt.Sprintf("Could not confirm the authenticated user with ssh -T git@%s", host))
+		}
+	}
+
+	alias := "(no matching account)"
+	if account != nil {
+		alias = account.Alias
+		if account.IsDefault {
+			alias += " (default)"
+		}
+		if email != "" && account.Email != "" && !strings.EqualFold(email, account.Email) {
+			warnings = append(warnings, fmt.Sprintf("Commits are authored as %s but account '%s' uses %s", email, account.Alias, account.Email))
+		}
+		if keyPath != "" && account.SSHKeyPath != "" && pathutil.Expand(keyPath) != pathutil.Expand(account.SSHKeyPath) {
+			warnings = append(warnings, fmt.Sprintf("ssh offers %s but account '%s' uses %s", keyPath, account.Alias, account.SSHKeyPath))
+		}
+		if authenticatedUser != "" && account.GitHubUsername != "" && !strings.EqualFold(authenticatedUser, account.GitHubUsername) {
+			warnings = append(warnings, fmt.Sprintf("%s authenticates as @%s but account '%s' is @%s", host, authenticatedUser, account.Alias, account.GitHubUsername))
+		}
+	}
+
+	user := "(unverified)"
+	if authenticatedUser != "" {
+		user = "@" + authenticatedUser
+	}
+	key := valueOrNone(fingerprint)
+	if keyPath != "" {
+		key = fmt.Sprintf("%s (%s)", key, filepath.Base(keyPath))
+	}
+
+	fmt.Printf("👤 %s · %s <%s> · %s · 🔑 %s\n", alias, valueOrNone(name), valueOrNone(email), user, key)
+
+	location := "outside a repository"
+	if inRepo {
+		location = "in this repository"
+	}
+	fmt.Printf("   %s, account resolved from %s, key from %s\n", location, resolvedBy, valueOrNone(keySource))
+
+	for _, warning := range warnings {
+		fmt.Printf("   ⚠️  %s\n", warning)
+	}
+
+	if !check {
+		return nil
+	}
+
+	selection, err := selectAutoAccount(configManager, ".", remoteURL)
+	if err != nil {
+		return err
+	}
+	if selection == nil {
+		fmt.Printf("   ℹ️  No account is expected for %s; nothing to check\n", remoteURL)
+		return nil
+	}
+	expected, err := configManager.GetAccount(selection.Alias)
+	if err != nil {
+		return err
+	}
+
+	problems := identityProblems(configManager, expected, email, authenticatedUser)
+	if len(problems) == 0 {
+		fmt.Printf("   ✅ Matches account '%s' expected for %s\n", expected.Alias, remote)
+		return nil
+	}
+	for _, problem := range problems {
+		fmt.Printf("   ❌ %s\n", problem)
+	}
+	return fmt.Errorf("identity does not match account '%s' expected for remote '%s' (%s); run 'gitshift auto' to switch", expected.Alias, remote, selection.Reason)
+}
+
+// resolveRemoteURL returns the URL of the named remote of the current repository. A URL
+// with a host is returned as is, since Git names no remote when pushing to a URL.
+func resolveRemoteURL(ctx context.Context, remote string) string {
+	if remoteURL := repoGitValue(ctx, ".", "remote", "get-url", remote); remoteURL != "" {
+		return remoteURL
+	}
+	if remoteHost(remote) != "" {
+		return remote
+	}
+	return ""
+}
+
+// identityProblems compares the active account, commit email and authenticated user with
+// the account the remote expects. Accounts sharing a GitHub username count as the same
+// identity.
+func identityProblems(configManager *config.Manager, expected *models.Account, email, authenticatedUser string) []string {
+	var problems []string
+
+	currentAlias := configManager.GetConfig().CurrentAccount
+	if currentAlias != expected.Alias {
+		current, err := configManager.GetAccount(currentAlias)
+		sameUser := err == nil && expected.GitHubUsername != "" && strings.EqualFold(current.GitHubUsername, expected.GitHubUsername)
+		if !sameUser {
+			problems = append(problems, fmt.Sprintf("Active account is '%s' but the remote expects '%s'", valueOrNone(currentAlias), expected.Alias))
+		}
+	}
+
+	if expected.Email != "" && !strings.EqualFold(email, expected.Email) {
+		problems = append(problems, fmt.Sprintf("Commits would be authored as %s instead of %s", valueOrNone(email), expected.Email))
+	}
+
+	if authenticatedUser != "" && expected.GitHubUsername != "" && !strings.EqualFold(authenticatedUser, expected.GitHubUsername) {
+		problems = append(problems, fmt.Sprintf("SSH authenticates as @%s instead of @%s", authenticatedUser, expected.GitHubUsername))
+	}
+
+	return problems
+}
+
+// effectiveGitValue reads a Git config value as Git resolves it in the current directory,
+// so repository config wins over global config
+func effectiveGitValue(ctx context.Context, key string) string {
+	output, err := execrunner.CommandContext(ctx, "git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// resolveWhoamiAccount finds the configured account in use: the one whose host alias the
+// remote uses, then the one pinned by the nearest .gitshift.yaml, then the one matching the
+// commit email, then the active account and finally the default account. When several
+// accounts share the commit email, the user chooses on a terminal; otherwise the first is
+// taken.
+func resolveWhoamiAccount(configManager *config.Manager, host, email string) (*models.Account, string, error) {
+	accounts := configManager.ListAccounts()
+
+	if host != "" {
+		for _, account := range accounts {
+			if host == ssh.HostAlias(account.GetDomain(), account.Alias) {
+				return account, "remote host alias " + host, nil
+			}
+		}
+	}
+
+	pinned, pinPath, err := configManager.ProjectAccount(".")
+	if err != nil {
+		return nil, "", err
+	}
+	if pinned != nil {
+		return pinned, pinPath, nil
+	}
+
+	if email != "" {
+		var matches []*models.Account
+		for _, account := range accounts {
+			if strings.EqualFold(account.Email, email) {
+				matches = append(matches, account)
+			}
+		}
+		if len(matches) > 1 && isInteractive() {
+			account, err := pickAccount(fmt.Sprintf("🪪 Several accounts use %s; which one is this?", email), matches)
+			if err != nil {
+				return nil, "", err
+			}
+			return account, "user.email, chosen from several accounts", nil
+		}
+		if len(matches) > 0 {
+			return matches[0], "user.email", nil
+		}
+	}
+
+	if current := configManager.GetConfig().CurrentAccount; current != "" {
+		if account, err := configManager.GetAccount(current); err == nil {
+			return account, "active account", nil
+		}
+	}
+	if account, err := configManager.GetDefaultAccount(); err == nil {
+		return account, "default account", nil
+	}
+
+	return nil, "nothing", nil
+}
+
+// effectiveSSHKey returns the SSH key Git's ssh will offer for host and where it comes from.
+// An explicit -i in GIT_SSH_COMMAND or core.sshCommand wins; otherwise the first existing
+// IdentityFile ssh resolves for the host is used.
+func effectiveSSHKey(ctx context.Context, host string) (keyPath, source string) {
+	for _, candidate := range []struct{ command, source string }{
+		{os.Getenv("GIT_SSH_COMMAND"), "GIT_SSH_COMMAND"},
+		{effectiveGitValue(ctx, "core.sshCommand"), "core.sshCommand"},
+	} {
+		if candidate.command == "" {
+			continue
+		}
+		if keyPath := identityFromCommand(candidate.command); keyPath != "" {
+			return keyPath, candidate.source
+		}
+	}
+
+	output, err := execrunner.CommandContext(ctx, "ssh", "-G", host).Output()
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		keyword, value, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found || keyword != "identityfile" {
+			continue
+		}
+		value = pathutil.Expand(value)
+		if _, err := os.Stat(value); err == nil {
+			return value, "ssh config"
+		}
+	}
+	return "", ""
+}
+
+// identityFromCommand returns the identity file an ssh command line forces with -i,
+// expanded with pathutil.Expand, or "" when it doesn't force one
+func identityFromCommand(command string) string {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		if field == "-i" && i+1 < len(fields) {
+			return pathutil.Expand(strings.Trim(fields[i+1], `"'`))
+		}
+		if strings.HasPrefix(field, "-i") && len(field) > 2 {
+			return pathutil.Expand(strings.Trim(field[2:], `"'`))
+		}
+	}
+	return ""
+}
+
+// authenticatedSSHUser runs ssh -T against host and returns the account the platform greets,
+// or "" when it can't be determined
+func authenticatedSSHUser(ctx context.Context, host, keyPath, keySource string) string {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=5"}
+	if keyPath != "" && keySource != "ssh config" {
+		args = append(args, "-i", keyPath, "-o", "IdentitiesOnly=yes")
+	}
+	args = append(args, "-T", "git@"+host)
+
+	// ssh -T exits non-zero even when authentication succeeds, so only the output matters
+	output, _ := execrunner.CommandContext(ctx, "ssh", args...).CombinedOutput()
+	return ssh.AuthenticatedUser(string(output))
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentityFromCommand(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"ssh -i ~/.ssh/id_work -o IdentitiesOnly=yes", filepath.Join(home, ".ssh", "id_work")},
+		{"ssh -i~/.ssh/id_work", filepath.Join(home, ".ssh", "id_work")},
+		{`ssh -i "/keys/id_work"`, "/keys/id_work"},
+		{"ssh -i '/keys/id_work' -F /dev/null", "/keys/id_work"},
+		{"ssh -o IdentitiesOnly=yes", ""},
+		{"ssh -i", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := identityFromCommand(tt.command); got != tt.want {
+			t.Errorf("identityFromCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package audit keeps an append-only log of account switches and SSH key loads. Entries are
+// JSON lines chained by hash: each entry records the hash of the one before it, so editing,
+// removing or reordering entries breaks the chain and Verify reports where. Entries cut
+// from the end of the log leave a valid chain; that needs an external copy to detect.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/filelock"
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+const (
+	// LogFileName is the file in the config directory that holds the audit log
+	LogFileName = "audit.log"
+
+	// lockTimeout is how long Record waits for another process to finish appending
+	lockTimeout = 5 * time.Second
+
+	// tailSize is how much of the end of the log is read to find the last entry's hash;
+	// entries are far shorter
+	tailSize = 64 * 1024
+)
+
+// Events
+const (
+	EventSwitch  = "switch"
+	EventKeyLoad = "key_load"
+	EventFix     = "fix" // a problem repaired by 'gitshift diagnose --fix'
+)
+
+// Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry is one audited operation. It deliberately holds no error messages, command
+// arguments or command output, which could carry a token or passphrase into the log.
+type Entry struct {
+	Time           time.Time `json:"time"`
+	Command        string    `json:"command,omitempty"` // e.g. "gitshift switch", never its arguments
+	Event          string    `json:"event"`
+	Account        string    `json:"account"`
+	KeyFingerprint string    `json:"key_fingerprint,omitempty"`
+	Detail         string    `json:"detail,omitempty"` // what gitshift did, in its own words
+	Outcome        string    `json:"outcome"`
+	PrevHash       string    `json:"prev_hash"`
+	Hash           string    `json:"hash"`
+}
+
+// computeHash returns the hash of the entry's fields, including PrevHash but not Hash
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Outcome returns the outcome of an operation that ended with err
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// Log is an audit log file. Entries are written by the command named at construction.
+type Log struct {
+	path    string
+	command string
+	clock   models.Clock
+}
+
+// NewLog returns the audit log at path for entries recorded by command
+func NewLog(path, command string) *Log {
+	return &Log{path: path, command: command, clock: models.SystemClock{}}
+}
+
+// Path returns the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends entry to the log, chained to the last entry. Its time, command and hashes
+// are filled in.
+func (l *Log) Record(entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	unlock, err := filelock.Acquire(l.path+".lock", lockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer unlock()
+
+	prevHash, err := l.lastHash()
+	if err != nil {
+		return err
+	}
+
+	entry.Time = l.clock.Now().UTC()
+	entry.Command = l.command
+	entry.PrevHash = prevHash
+	entry.Hash = entry.computeHash()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// lastHash returns the hash of the last entry, or "" when the log is empty
+func (l *Log) lastHash() (string, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect audit log: %w", err)
+	}
+	offset := info.Size() - tailSize
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	tail = bytes.TrimRight(tail, "\n")
+	if len(tail) == 0 {
+		return "", nil
+	}
+	last := tail[bytes.LastIndexByte(tail, '\n')+1:]
+
+	var entry Entry
+	if err := json.Unmarshal(last, &entry); err != nil {
+		return "", fmt.Errorf("audit log %s ends with a damaged entry: %w", l.path, err)
+	}
+	return entry.Hash, nil
+}
+
+// Read returns the entries of the log in order; a log that was never written is empty
+func (l *Log) Read() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("audit log %s is damaged at line %d: %w", l.path, lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// ChainError reports where the hash chain of a log is broken
+type ChainError struct {
+	Entry  int // 1-based position of the first entry that doesn't check out
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit log was tampered with at entry %d: %s", e.Entry, e.Reason)
+}
+
+// Verify checks the hash chain of entries read from a log, returning a *ChainError for the
+// first entry that was modified or doesn't follow its predecessor
+func Verify(entries []Entry) error {
+	prevHash := ""
+	for i, entry := range entries {
+		switch {
+		case entry.PrevHash != prevHash:
+			return &ChainError{Entry: i + 1, Reason: "entry doesn't follow the previous one; entries were removed or reordered"}
+		case entry.computeHash() != entry.Hash:
+			return &ChainError{Entry: i + 1, Reason: "entry was modified"}
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
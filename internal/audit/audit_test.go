@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when the test advances it
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestLog(t *testing.T) (*Log, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	log := NewLog(filepath.Join(t.TempDir(), LogFileName), "gitshift switch")
+	log.clock = clock
+	return log, clock
+}
+
+func TestRecord_ChainsEntries(t *testing.T) {
+	log, clock := newTestLog(t)
+
+	if err := log.Record(Entry{Event: EventKeyLoad, Account: "work", KeyFingerprint: "SHA256:abc", Outcome: OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if err := log.Record(Entry{Event: EventSwitch, Account: "work", KeyFingerprint: "SHA256:abc", Outcome: Outcome(errors.New("boom"))}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := log.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	first, second := entries[0], entries[1]
+	if first.PrevHash != "" || second.PrevHash != first.Hash {
+		t.Errorf("entries are not chained: %+v", entries)
+	}
+	if second.Event != EventSwitch || second.Outcome != OutcomeFailure || second.Command != "gitshift switch" {
+		t.Errorf("unexpected second entry: %+v", second)
+	}
+	if !second.Time.Equal(time.Date(2024, 3, 1, 9, 1, 0, 0, time.UTC)) {
+		t.Errorf("second entry time = %v", second.Time)
+	}
+	if err := Verify(entries); err != nil {
+		t.Errorf("Verify() = %v, want nil", err)
+	}
+
+	info, err := os.Stat(log.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("log mode = %o, want 600", perm)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	log, _ := newTestLog(t)
+	for _, account := range []string{"work", "personal", "work"} {
+		if err := log.Record(Entry{Event: EventSwitch, Account: account, Outcome: OutcomeSuccess}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		tamper    func(lines []string) []string
+		wantEntry int
+	}{
+		{
+			name: "modified entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"personal"`, `"other"`, 1)
+				return lines
+			},
+			wantEntry: 2,
+		},
+		{
+			name: "removed entry",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantEntry: 2,
+		},
+		{
+			name: "reordered entries",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			wantEntry: 1,
+		},
+	}
+
+	original, err := os.ReadFile(log.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(strings.TrimSuffix(string(original), "\n"), "\n")
+			tampered := NewLog(filepath.Join(t.TempDir(), LogFileName), "")
+			content := strings.Join(tt.tamper(lines), "\n") + "\n"
+			if err := os.WriteFile(tampered.Path(), []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := tampered.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var chainErr *ChainError
+			if err := Verify(entries); !errors.As(err, &chainErr) {
+				t.Fatalf("Verify() = %v, want a *ChainError", err)
+			}
+			if chainErr.Entry != tt.wantEntry {
+				t.Errorf("chain broken at entry %d, want %d", chainErr.Entry, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestRecord_ConcurrentAppendsStayChained(t *testing.T) {
+	log, _ := newTestLog(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A separate Log per goroutine, like separate gitshift processes
+			other := NewLog(log.Path(), "gitshift auto")
+			if err := other.Record(Entry{Event: EventSwitch, Account: "work", Outcome: OutcomeSuccess}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := log.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 20 {
+		t.Fatalf("got %d entries, want 20", len(entries))
+	}
+	if err := Verify(entries); err != nil {
+		t.Errorf("Verify() = %v, want nil", err)
+	}
+}
+
+func TestRead_MissingLogIsEmpty(t *testing.T) {
+	log := NewLog(filepath.Join(t.TempDir(), LogFileName), "")
+	entries, err := log.Read()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Read() = %v, %v; want no entries", entries, err)
+	}
+}
@@ -0,0 +1,470 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/token"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleVersion is the format version of the account bundles written by ExportAccounts
+const BundleVersion = 1
+
+// ErrWrongPassphrase is returned when a bundle's secrets can't be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted bundle secrets")
+
+// AccountBundle is a portable export of accounts. Secrets are only ever included encrypted
+// with a passphrase.
+type AccountBundle struct {
+	Version    int               `yaml:"version"`
+	ExportedAt time.Time         `yaml:"exported_at"`
+	Accounts   []*models.Account `yaml:"accounts"`
+	Secrets    *EncryptedSecrets `yaml:"secrets,omitempty"`
+}
+
+// EncryptedSecrets holds the bundle's tokens and SSH keys, AES-GCM encrypted with a key
+// derived from the passphrase with scrypt
+type EncryptedSecrets struct {
+	Salt       []byte `yaml:"salt"`
+	Nonce      []byte `yaml:"nonce"`
+	Ciphertext []byte `yaml:"ciphertext"`
+}
+
+// bundleSecrets is the plaintext of EncryptedSecrets, keyed by the exported alias
+type bundleSecrets struct {
+	Tokens  map[string]string        `yaml:"tokens,omitempty"`
+	SSHKeys map[string]bundledSSHKey `yaml:"ssh_keys,omitempty"`
+}
+
+type bundledSSHKey struct {
+	PrivateKey string `yaml:"private_key"`
+	PublicKey  string `yaml:"public_key,omitempty"`
+}
+
+// ExportOptions controls what ExportAccounts includes
+type ExportOptions struct {
+	// Aliases limits the export to these accounts; empty exports all of them
+	Aliases []string
+
+	IncludeTokens  bool
+	IncludeSSHKeys bool
+
+	// Passphrase encrypts the secrets; required with IncludeTokens or IncludeSSHKeys
+	Passphrase string
+}
+
+// ImportOptions controls how ImportAccounts resolves alias collisions
+type ImportOptions struct {
+	// Overwrite replaces existing accounts (and differing SSH key files) with the imported ones
+	Overwrite bool
+
+	// Rename imports colliding accounts under a free alias such as "work-2"
+	Rename bool
+
+	// Passphrase decrypts the bundle's secrets; without it only the accounts are imported
+	Passphrase string
+}
+
+// ImportResult describes what ImportAccounts changed
+type ImportResult struct {
+	Imported       []string          // aliases the accounts were stored under
+	Renamed        map[string]string // bundle alias to the alias it was imported as
+	Skipped        []string          // bundle aliases left out because they already exist
+	Tokens         int               // tokens stored
+	SSHKeys        []string          // private key files written
+	SkippedSSHKeys []string          // key files left alone because a different one exists
+	SecretsSkipped bool              // the bundle has secrets but no passphrase was given
+}
+
+// ExportAccounts builds a portable bundle of the configured accounts. SSH key paths under
+// the home directory are written relative to "~" so they resolve on the importing machine.
+func (m *Manager) ExportAccounts(opts ExportOptions) (*AccountBundle, error) {
+	if (opts.IncludeTokens || opts.IncludeSSHKeys) && opts.Passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required to export tokens or SSH keys")
+	}
+
+	accounts, err := m.selectAccounts(opts.Aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &AccountBundle{Version: BundleVersion, ExportedAt: time.Now().UTC()}
+	secrets := &bundleSecrets{}
+
+	var store token.TokenStore
+	if opts.IncludeTokens {
+		if store, err = m.TokenStore(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, account := range accounts {
+		exported := *account
+		// Usage and validation state belongs to this machine
+		exported.IsDefault = false
+		exported.LastUsed = nil
+		exported.LastValidation = nil
+		exported.LastConnectivityTest = nil
+		exported.LastConnectivityTestKey = ""
+		exported.ValidationErrors = nil
+		exported.SSHSocketPath = ""
+		exported.SSHKeyPath = m.collapseHome(account.SSHKeyPath)
+		bundle.Accounts = append(bundle.Accounts, &exported)
+
+		if opts.IncludeTokens {
+			value, err := store.Get(account.Alias)
+			if err != nil && !errors.Is(err, token.ErrTokenNotFound) {
+				return nil, fmt.Errorf("failed to read token for '%s': %w", account.Alias, err)
+			}
+			if err == nil {
+				if secrets.Tokens == nil {
+					secrets.Tokens = make(map[string]string)
+				}
+				secrets.Tokens[account.Alias] = value
+			}
+		}
+
+		if opts.IncludeSSHKeys && account.SSHKeyPath != "" {
+			keyPath := pathutil.Expand(account.SSHKeyPath)
+			privateKey, err := os.ReadFile(keyPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read SSH key of '%s': %w", account.Alias, err)
+			}
+			publicKey, _ := os.ReadFile(keyPath + ".pub")
+			if secrets.SSHKeys == nil {
+				secrets.SSHKeys = make(map[string]bundledSSHKey)
+			}
+			secrets.SSHKeys[account.Alias] = bundledSSHKey{PrivateKey: string(privateKey), PublicKey: string(publicKey)}
+		}
+	}
+
+	if opts.IncludeTokens || opts.IncludeSSHKeys {
+		if bundle.Secrets, err = encryptSecrets(secrets, opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	return bundle, nil
+}
+
+// ImportAccounts merges the bundle's accounts into the configuration and, with the right
+// passphrase, restores their tokens and SSH keys. Colliding aliases are skipped unless
+// Overwrite or Rename is set.
+func (m *Manager) ImportAccounts(bundle *AccountBundle, opts ImportOptions) (*ImportResult, error) {
+	if opts.Overwrite && opts.Rename {
+		return nil, fmt.Errorf("overwrite and rename are mutually exclusive")
+	}
+	if bundle.Version > BundleVersion {
+		return nil, fmt.Errorf("bundle format version %d is newer than this gitshift supports (%d)", bundle.Version, BundleVersion)
+	}
+
+	var secrets *bundleSecrets
+	if bundle.Secrets != nil && opts.Passphrase != "" {
+		var err error
+		if secrets, err = decryptSecrets(bundle.Secrets, opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, account := range bundle.Accounts {
+		if err := account.Validate(); err != nil {
+			return nil, fmt.Errorf("bundle account '%s' is invalid: %w", account.Alias, err)
+		}
+		if _, ok := secrets.sshKey(account.Alias); ok && account.SSHKeyPath != "" {
+			if err := m.checkSSHKeyRestorePath(account.SSHKeyPath); err != nil {
+				return nil, fmt.Errorf("bundle account '%s': %w", account.Alias, err)
+			}
+		}
+	}
+
+	result := &ImportResult{
+		Renamed:        make(map[string]string),
+		SecretsSkipped: bundle.Secrets != nil && secrets == nil,
+	}
+
+	// Bundle alias to the imported account, for restoring secrets once the config is saved
+	imported := make(map[string]*models.Account)
+
+	m.mu.Lock()
+	err := m.update(func() error {
+		hadAccounts := len(m.config.Accounts) > 0
+
+		for _, bundled := range bundle.Accounts {
+			account := *bundled
+			account.SSHKeyPath = pathutil.Expand(bundled.SSHKeyPath)
+			account.IsDefault = false
+
+			if existing, exists := m.config.Accounts[account.Alias]; exists {
+				switch {
+				case opts.Overwrite:
+					account.IsDefault = existing.IsDefault
+				case opts.Rename:
+					account.Alias = freeAlias(m.config.Accounts, account.Alias)
+					result.Renamed[bundled.Alias] = account.Alias
+				default:
+					result.Skipped = append(result.Skipped, bundled.Alias)
+					continue
+				}
+			}
+
+			m.config.Accounts[account.Alias] = &account
+			imported[bundled.Alias] = &account
+			result.Imported = append(result.Imported, account.Alias)
+		}
+
+		// Mirror AddAccount: the first account of an empty config becomes the default
+		if !hadAccounts && m.config.CurrentAccount == "" && len(result.Imported) > 0 {
+			first := m.config.Accounts[result.Imported[0]]
+			first.IsDefault = true
+			m.config.CurrentAccount = first.Alias
+		}
+		return nil
+	})
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if secrets != nil {
+		if err := m.restoreSecrets(secrets, imported, opts.Overwrite, result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// restoreSecrets stores the imported tokens and writes the imported SSH keys
+func (m *Manager) restoreSecrets(secrets *bundleSecrets, imported map[string]*models.Account, overwrite bool, result *ImportResult) error {
+	var store token.TokenStore
+	if len(secrets.Tokens) > 0 {
+		var err error
+		if store, err = m.TokenStore(); err != nil {
+			return err
+		}
+	}
+
+	bundleAliases := make([]string, 0, len(imported))
+	for alias := range imported {
+		bundleAliases = append(bundleAliases, alias)
+	}
+	sort.Strings(bundleAliases)
+
+	for _, bundleAlias := range bundleAliases {
+		account := imported[bundleAlias]
+
+		if value, ok := secrets.Tokens[bundleAlias]; ok {
+			if err := store.Set(account.Alias, value); err != nil {
+				return fmt.Errorf("failed to store token for '%s': %w", account.Alias, err)
+			}
+			result.Tokens++
+		}
+
+		if key, ok := secrets.sshKey(bundleAlias); ok && account.SSHKeyPath != "" {
+			written, err := writeSSHKey(account.SSHKeyPath, key, overwrite)
+			if err != nil {
+				return fmt.Errorf("failed to restore SSH key of '%s': %w", account.Alias, err)
+			}
+			if written {
+				result.SSHKeys = append(result.SSHKeys, account.SSHKeyPath)
+			} else {
+				result.SkippedSSHKeys = append(result.SkippedSSHKeys, account.SSHKeyPath)
+			}
+		}
+	}
+	return nil
+}
+
+// sshKey returns the bundled SSH key of the account with the given bundle alias. It is
+// safe to call on nil secrets.
+func (s *bundleSecrets) sshKey(alias string) (bundledSSHKey, bool) {
+	if s == nil {
+		return bundledSSHKey{}, false
+	}
+	key, ok := s.SSHKeys[alias]
+	return key, ok
+}
+
+// checkSSHKeyRestorePath makes sure a bundled SSH key would be written under ~/.ssh. The
+// bundle chooses the path, so without this check a hostile bundle could overwrite any file
+// the user can write.
+func (m *Manager) checkSSHKeyRestorePath(path string) error {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return fmt.Errorf("SSH key path %s must not contain '..'", path)
+		}
+	}
+
+	sshDir := filepath.Join(m.homeDir, ".ssh")
+	rel, err := filepath.Rel(sshDir, pathutil.Expand(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("SSH key path %s is outside %s; keys are only restored there", path, sshDir)
+	}
+	return nil
+}
+
+// writeSSHKey writes a key pair with the permissions ssh requires. An existing identical key
+// counts as written; a different one is only replaced with overwrite.
+func writeSSHKey(path string, key bundledSSHKey, overwrite bool) (bool, error) {
+	if existing, err := os.ReadFile(path); err == nil {
+		if bytes.Equal(existing, []byte(key.PrivateKey)) {
+			return true, nil
+		}
+		if !overwrite {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, []byte(key.PrivateKey), 0600); err != nil {
+		return false, err
+	}
+	// WriteFile keeps the mode of an existing file, so enforce it explicitly
+	if err := os.Chmod(path, 0600); err != nil {
+		return false, err
+	}
+
+	if key.PublicKey != "" {
+		if err := os.WriteFile(path+".pub", []byte(key.PublicKey), 0644); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// selectAccounts returns the accounts with the given aliases, or all of them sorted by alias
+func (m *Manager) selectAccounts(aliases []string) ([]*models.Account, error) {
+	if len(aliases) == 0 {
+		accounts := m.ListAccounts()
+		sort.Slice(accounts, func(i, j int) bool {
+			return accounts[i].Alias < accounts[j].Alias
+		})
+		return accounts, nil
+	}
+
+	accounts := make([]*models.Account, 0, len(aliases))
+	for _, alias := range aliases {
+		account, err := m.GetAccount(alias)
+		if err != nil {
+			return nil, fmt.Errorf("account '%s' not found", alias)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// freeAlias returns alias with the lowest numeric suffix that isn't taken
+func freeAlias(accounts map[string]*models.Account, alias string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", alias, i)
+		if _, exists := accounts[candidate]; !exists {
+			return candidate
+		}
+	}
+}
+
+// collapseHome rewrites a path under the home directory as "~/..."
+func (m *Manager) collapseHome(path string) string {
+	if rel, err := filepath.Rel(m.homeDir, path); err == nil && path != "" && !strings.HasPrefix(rel, "..") && filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Join("~", rel))
+	}
+	return path
+}
+
+// WriteBundle writes the bundle as YAML, readable only by the user
+func WriteBundle(w io.Writer, bundle *AccountBundle) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(bundle); err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	return encoder.Close()
+}
+
+// ReadBundle parses a bundle written by WriteBundle
+func ReadBundle(r io.Reader) (*AccountBundle, error) {
+	var bundle AccountBundle
+	if err := yaml.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if bundle.Version == 0 {
+		return nil, fmt.Errorf("not a gitshift account bundle")
+	}
+	return &bundle, nil
+}
+
+// bundleKey derives the AES-256 key for a bundle from the passphrase
+func bundleKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptSecrets(secrets *bundleSecrets, passphrase string) (*EncryptedSecrets, error) {
+	plaintext, err := yaml.Marshal(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secrets: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := bundleKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedSecrets{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, nil
+}
+
+func decryptSecrets(encrypted *EncryptedSecrets, passphrase string) (*bundleSecrets, error) {
+	gcm, err := bundleKey(passphrase, encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	plaintext, err := gcm.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	var secrets bundleSecrets
+	if err := yaml.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+	return &secrets, nil
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func newTestManager(t *testing.T, home string) *Manager {
+	t.Helper()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return m
+}
+
+func TestExportImport_RoundTripsSecrets(t *testing.T) {
+	source := t.TempDir()
+	m := newTestManager(t, source)
+
+	keyPath := filepath.Join(source, ".ssh", "id_ed25519_work")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("PRIVATE KEY DATA"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddAccount(models.NewAccount("work", "Dev", "dev@work.com", keyPath)); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	store, err := m.TokenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("work", "ghp_secret_token"); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := m.ExportAccounts(ExportOptions{IncludeTokens: true, IncludeSSHKeys: true, Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("ExportAccounts() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, bundle); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"ghp_secret_token", "PRIVATE KEY DATA"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("bundle contains plaintext secret %q", secret)
+		}
+	}
+	if !strings.Contains(buf.String(), "~/.ssh/id_ed25519_work") {
+		t.Errorf("bundle SSH key path is not home-relative:\n%s", buf.String())
+	}
+
+	target := t.TempDir()
+	imported := newTestManager(t, target)
+	read, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("ReadBundle() error = %v", err)
+	}
+
+	if _, err := imported.ImportAccounts(read, ImportOptions{Passphrase: "wrong"}); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("ImportAccounts() with wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+
+	result, err := imported.ImportAccounts(read, ImportOptions{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("ImportAccounts() error = %v", err)
+	}
+	if result.Tokens != 1 || len(result.SSHKeys) != 1 {
+		t.Errorf("ImportAccounts() restored %d token(s) and %d key(s), want 1 and 1", result.Tokens, len(result.SSHKeys))
+	}
+
+	account, err := imported.GetAccount("work")
+	if err != nil {
+		t.Fatalf("imported account missing: %v", err)
+	}
+	wantKey := filepath.Join(target, ".ssh", "id_ed25519_work")
+	if account.SSHKeyPath != wantKey {
+		t.Errorf("SSHKeyPath = %q, want %q", account.SSHKeyPath, wantKey)
+	}
+	info, err := os.Stat(wantKey)
+	if err != nil {
+		t.Fatalf("SSH key not restored: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("SSH key mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	importedStore, err := imported.TokenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := importedStore.Get("work"); err != nil || value != "ghp_secret_token" {
+		t.Errorf("imported token = %q, %v", value, err)
+	}
+}
+
+func TestImportAccounts_AliasCollisions(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	if err := m.AddAccount(&models.Account{Alias: "work", Name: "Existing", Email: "old@work.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := &AccountBundle{
+		Version:  BundleVersion,
+		Accounts: []*models.Account{{Alias: "work", Name: "Imported", Email: "new@work.com"}},
+	}
+
+	result, err := m.ImportAccounts(bundle, ImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportAccounts() error = %v", err)
+	}
+	if len(result.Skipped) != 1 || len(result.Imported) != 0 {
+		t.Errorf("default import = %+v, want the colliding account skipped", result)
+	}
+
+	result, err = m.ImportAccounts(bundle, ImportOptions{Rename: true})
+	if err != nil {
+		t.Fatalf("ImportAccounts(rename) error = %v", err)
+	}
+	if result.Renamed["work"] != "work-2" {
+		t.Errorf("Renamed = %v, want work -> work-2", result.Renamed)
+	}
+	if account, err := m.GetAccount("work"); err != nil || account.Email != "old@work.com" {
+		t.Errorf("rename changed the existing account: %+v, %v", account, err)
+	}
+
+	if _, err := m.ImportAccounts(bundle, ImportOptions{Overwrite: true}); err != nil {
+		t.Fatalf("ImportAccounts(overwrite) error = %v", err)
+	}
+	account, err := m.GetAccount("work")
+	if err != nil || account.Email != "new@work.com" {
+		t.Errorf("overwrite kept the existing account: %+v, %v", account, err)
+	}
+	if !account.IsDefault || m.GetConfig().CurrentAccount != "work" {
+		t.Errorf("overwrite lost the current account")
+	}
+}
+
+func TestImportAccounts_RejectsKeyPathsOutsideSSHDir(t *testing.T) {
+	source := t.TempDir()
+	m := newTestManager(t, source)
+
+	keyPath := filepath.Join(source, ".ssh", "id_ed25519_work")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("PRIVATE KEY DATA"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddAccount(models.NewAccount("work", "Dev", "dev@work.com", keyPath)); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	bundle, err := m.ExportAccounts(ExportOptions{IncludeSSHKeys: true, Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("ExportAccounts() error = %v", err)
+	}
+
+	target := t.TempDir()
+	imported := newTestManager(t, target)
+	outside := filepath.Join(t.TempDir(), "authorized_keys")
+
+	for _, hostile := range []string{
+		"~/.ssh/../.bashrc",
+		"~/.ssh/keys/../../.profile",
+		"~/.bashrc",
+		outside,
+		"/etc/passwd",
+	} {
+		bundle.Accounts[0].SSHKeyPath = hostile
+		if _, err := imported.ImportAccounts(bundle, ImportOptions{Passphrase: "correct horse"}); err == nil {
+			t.Errorf("ImportAccounts() with SSH key path %s succeeded", hostile)
+		}
+	}
+
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("key written outside ~/.ssh: %v", err)
+	}
+	for _, name := range []string{".bashrc", ".profile"} {
+		if _, err := os.Stat(filepath.Join(target, name)); !os.IsNotExist(err) {
+			t.Errorf("key written to ~/%s: %v", name, err)
+		}
+	}
+	if len(imported.ListAccounts()) != 0 {
+		t.Errorf("rejected bundle still imported %d account(s)", len(imported.ListAccounts()))
+	}
+
+	bundle.Accounts[0].SSHKeyPath = "~/.ssh/keys/id_ed25519_work"
+	if _, err := imported.ImportAccounts(bundle, ImportOptions{Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("ImportAccounts() under ~/.ssh error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, ".ssh", "keys", "id_ed25519_work")); err != nil {
+		t.Errorf("SSH key under ~/.ssh not restored: %v", err)
+	}
+}
+
+func TestExportAccounts_ExpandsHomeRelativeKeyPath(t *testing.T) {
+	m := writeTestConfig(t, `accounts:
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    ssh_key_path: ~/.ssh/id_ed25519_work
+`)
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+
+	keyPath := filepath.Join(home, ".ssh", "id_ed25519_work")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("PRIVATE KEY DATA"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.ExportAccounts(ExportOptions{IncludeSSHKeys: true, Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("ExportAccounts() error = %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"gopkg.in/yaml.v3"
+)
+
+// CloneOptions are the credentials of a cloned account, which must all differ from the
+// source account's
+type CloneOptions struct {
+	Email      string
+	Username   string // required when the source account has a username, or is on GitHub
+	SSHKeyPath string // required when the source account has an SSH key
+}
+
+// CloneAccount adds an account named alias that takes the name, platform, domain, API
+// endpoint, description and isolation settings of the account sourceAlias. Its email,
+// username and SSH key come from opts and must not be the source's; callers check that no
+// other account uses the SSH key either, since that takes reading the keys (see
+// ssh.Manager.AccountsUsingKey). Tokens, GPG keys, match rules and usage history are not
+// copied.
+func (m *Manager) CloneAccount(sourceAlias, alias string, opts CloneOptions) (*models.Account, error) {
+	source, err := m.GetAccount(sourceAlias)
+	if err != nil {
+		return nil, fmt.Errorf("account '%s': %w", sourceAlias, err)
+	}
+
+	switch {
+	case opts.Email == "":
+		return nil, fmt.Errorf("an email is required for the cloned account")
+	case strings.EqualFold(opts.Email, source.Email):
+		return nil, fmt.Errorf("the cloned account needs its own email; %s is used by '%s'", opts.Email, sourceAlias)
+	}
+
+	opts.Username = strings.TrimPrefix(opts.Username, "@")
+	sourceUsername := source.GetUsername()
+	switch {
+	case opts.Username == "" && (sourceUsername != "" || source.GetPlatform() == "github"):
+		return nil, fmt.Errorf("a username is required for the cloned account")
+	case opts.Username != "" && strings.EqualFold(opts.Username, sourceUsername):
+		return nil, fmt.Errorf("the cloned account needs its own username; @%s is used by '%s'", opts.Username, sourceAlias)
+	}
+
+	if opts.SSHKeyPath == "" && source.SSHKeyPath != "" {
+		return nil, fmt.Errorf("an SSH key is required for the cloned account; it must not be the key of '%s'", sourceAlias)
+	}
+	if opts.SSHKeyPath != "" && source.SSHKeyPath != "" && pathutil.Expand(opts.SSHKeyPath) == pathutil.Expand(source.SSHKeyPath) {
+		return nil, fmt.Errorf("the cloned account needs its own SSH key; %s is used by '%s'", opts.SSHKeyPath, sourceAlias)
+	}
+
+	// A YAML round trip copies the nested isolation settings instead of sharing them
+	data, err := yaml.Marshal(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy account '%s': %w", sourceAlias, err)
+	}
+	clone := &models.Account{}
+	if err := yaml.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("failed to copy account '%s': %w", sourceAlias, err)
+	}
+
+	clone.Alias = alias
+	clone.Email = opts.Email
+	clone.Username, clone.GitHubUsername = "", ""
+	if opts.Username != "" {
+		clone.SetUsername(opts.Username)
+	}
+	clone.SSHKeyPath = opts.SSHKeyPath
+
+	// Credentials, machine state and history stay with the source account
+	clone.IsDefault = false
+	clone.Status = models.AccountStatusActive
+	clone.CreatedAt = m.clock.Now()
+	clone.LastUsed = nil
+	clone.LastValidation = nil
+	clone.LastConnectivityTest = nil
+	clone.LastConnectivityTestKey = ""
+	clone.ValidationErrors = nil
+	clone.MissingFields = nil
+	clone.TokenPath = ""
+	clone.SSHSocketPath = ""
+	clone.MatchRules = nil
+	clone.SSHMatch = nil
+	clone.GPGKeyID, clone.GPGKeyType, clone.GPGKeyFingerprint = "", "", ""
+	clone.GPGKeySize = 0
+	clone.GPGKeyExpiry = nil
+	clone.GPGEnabled = false
+	if meta := clone.IsolationMetadata; meta != nil {
+		if meta.SSHIsolation != nil {
+			meta.SSHIsolation.SocketPath = ""
+		}
+		if meta.TokenIsolation != nil {
+			meta.TokenIsolation.StoragePath = ""
+		}
+	}
+
+	if err := m.AddAccount(clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
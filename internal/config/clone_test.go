@@ -0,0 +1,80 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestCloneAccount(t *testing.T) {
+	home := t.TempDir()
+	m := newTestManager(t, home)
+	m.clock = &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+
+	source := models.NewAccount("work", "Dev", "dev@work.com", "~/.ssh/id_work")
+	source.Platform = "gitlab"
+	source.Domain = "git.acme.com"
+	source.SetUsername("dev")
+	source.IsolationLevel = models.IsolationLevelStrict
+	source.IsolationMetadata.SSHIsolation.SocketPath = "/tmp/agent-work.sock"
+	source.GPGKeyID = "4BB6D45482678BE3"
+	source.GPGEnabled = true
+	source.MatchRules = []string{"git.acme.com/team-*"}
+	source.MarkAsUsed(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC))
+	if err := m.AddAccount(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddAccount(models.NewAccount("other", "Other", "other@home.com", "~/.ssh/id_other")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    CloneOptions
+		wantErr string
+	}{
+		{"same email", CloneOptions{Email: "DEV@work.com", Username: "dev2", SSHKeyPath: "~/.ssh/id_work2"}, "its own email"},
+		{"same username", CloneOptions{Email: "dev2@work.com", Username: "@dev", SSHKeyPath: "~/.ssh/id_work2"}, "its own username"},
+		{"no username", CloneOptions{Email: "dev2@work.com", SSHKeyPath: "~/.ssh/id_work2"}, "username is required"},
+		{"no SSH key", CloneOptions{Email: "dev2@work.com", Username: "dev2"}, "SSH key is required"},
+		{"source SSH key", CloneOptions{Email: "dev2@work.com", Username: "dev2", SSHKeyPath: home + "/.ssh//id_work"}, "its own SSH key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.CloneAccount("work", "work2", tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CloneAccount() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	clone, err := m.CloneAccount("work", "work2", CloneOptions{Email: "dev2@work.com", Username: "dev2", SSHKeyPath: "~/.ssh/id_work2"})
+	if err != nil {
+		t.Fatalf("CloneAccount() error = %v", err)
+	}
+	if clone.Name != "Dev" || clone.GetPlatform() != "gitlab" || clone.Domain != "git.acme.com" || clone.IsolationLevel != models.IsolationLevelStrict {
+		t.Errorf("persona settings were not copied: %+v", clone)
+	}
+	if clone.Email != "dev2@work.com" || clone.GetUsername() != "dev2" || clone.SSHKeyPath != filepath.Join(home, ".ssh", "id_work2") {
+		t.Errorf("credentials were not set from the options: %+v", clone)
+	}
+	if clone.GPGKeyID != "" || clone.GPGEnabled || clone.MatchRules != nil || clone.LastUsed != nil || clone.IsDefault {
+		t.Errorf("GPG key, match rules or usage were copied: %+v", clone)
+	}
+	if clone.IsolationMetadata.SSHIsolation.SocketPath != "" {
+		t.Errorf("agent socket was copied: %s", clone.IsolationMetadata.SSHIsolation.SocketPath)
+	}
+
+	// The copy doesn't share the source's nested settings
+	clone.IsolationMetadata.SSHIsolation.AgentTimeout = 1
+	if saved, _ := m.GetAccount("work"); saved.IsolationMetadata.SSHIsolation.AgentTimeout == 1 {
+		t.Error("clone shares the isolation settings of the source")
+	}
+
+	if _, err := m.CloneAccount("work", "work2", CloneOptions{Email: "dev3@work.com", Username: "dev3", SSHKeyPath: "~/.ssh/id_work3"}); err == nil {
+		t.Error("CloneAccount() to an existing alias succeeded")
+	}
+}
@@ -1,14 +1,20 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/spf13/viper"
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/token"
+	"github.com/techishthoughts/gitshift/internal/trace"
 	"gopkg.in/yaml.v3"
 )
 
@@ -16,12 +22,29 @@ const (
 	ConfigDirName     = ".config/gitshift"
 	ConfigFileName    = "config"
 	ProjectConfigName = ".gitshift.yaml"
+
+	// xdgAppDirName is the gitshift directory inside $XDG_CONFIG_HOME
+	xdgAppDirName = "gitshift"
+
+	// tokenDirName is the directory inside the config directory holding file-stored tokens
+	tokenDirName = "tokens"
+
+	// backupSuffix names the copy of the previous config kept by every save
+	backupSuffix = ".bak"
+
+	// LegacyConfigName is the single-file config used by GitPersona, read only for migration
+	LegacyConfigName = ".git-persona.yaml"
 )
 
 type Manager struct {
-	configPath string
-	config     *models.Config
-	mu         sync.RWMutex
+	homeDir      string
+	configPath   string
+	legacyFiles  []string // configs migrated from, in order, when configPath has none
+	migratedFrom string
+	lockTimeout  time.Duration
+	clock        models.Clock
+	config       *models.Config
+	mu           sync.RWMutex
 }
 
 // NewManager creates a new configuration manager
@@ -31,52 +54,97 @@ func NewManager() *Manager {
 		panic(fmt.Sprintf("failed to get user home directory: %v", err))
 	}
 
-	configPath := filepath.Join(homeDir, ConfigDirName)
 	return &Manager{
-		configPath: configPath,
-		config:     models.NewConfig(),
+		homeDir:    homeDir,
+		configPath: ConfigDir(homeDir),
+		legacyFiles: []string{
+			filepath.Join(homeDir, ConfigDirName, ConfigFileName+".yaml"),
+			filepath.Join(homeDir, LegacyConfigName),
+		},
+		lockTimeout: DefaultLockTimeout,
+		clock:       models.SystemClock{},
+		config:      models.NewConfig(),
 	}
 }
 
+// ConfigDir returns the gitshift config directory. The search order is:
+//  1. $XDG_CONFIG_HOME/gitshift, when XDG_CONFIG_HOME is set to an absolute path, after
+//     expanding a literal ~ or $HOME in it (as unit and launcher files may leave them)
+//  2. ~/.config/gitshift
+//
+// When the directory has no config yet, Load migrates the first one found in
+// ~/.config/gitshift/config.yaml or the legacy ~/.git-persona.yaml.
+func ConfigDir(homeDir string) string {
+	if xdgConfigHome := pathutil.ExpandUser(os.Getenv("XDG_CONFIG_HOME")); filepath.IsAbs(xdgConfigHome) {
+		return filepath.Join(xdgConfigHome, xdgAppDirName)
+	}
+	return filepath.Join(homeDir, ConfigDirName)
+}
+
+// ConfigFile returns the path of the config file the manager loads and saves
+func (m *Manager) ConfigFile() string {
+	return filepath.Join(m.configPath, ConfigFileName+".yaml")
+}
+
+// BackupFile returns the path of the copy of the previous config kept by every save
+func (m *Manager) BackupFile() string {
+	return m.ConfigFile() + backupSuffix
+}
+
+// TokenStore returns the token store selected by the token_storage setting
+func (m *Manager) TokenStore() (token.TokenStore, error) {
+	return token.NewStore(m.config.TokenStorage, filepath.Join(m.configPath, tokenDirName))
+}
+
+// MigratedFrom returns the legacy config file Load migrated from, or "" when no migration
+// happened
+func (m *Manager) MigratedFrom() string {
+	return m.migratedFrom
+}
+
 // Load loads the configuration from file
 func (m *Manager) Load() error {
+	return m.LoadContext(context.Background())
+}
+
+// LoadContext is Load, giving up on waiting for the config lock when ctx is done. The
+// steps are traced under the context's trace ID.
+func (m *Manager) LoadContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	trace.Printf(ctx, "config", "loading %s", m.ConfigFile())
+
 	// Ensure config directory exists
 	if err := os.MkdirAll(m.configPath, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	configFile := filepath.Join(m.configPath, ConfigFileName+".yaml")
-
 	// Check if config file exists
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		// Create a default config file
-		return m.Save()
+	if _, err := os.Stat(m.ConfigFile()); os.IsNotExist(err) {
+		if err := m.initConfigFile(ctx); err != nil {
+			return err
+		}
 	}
 
-	// Read the file directly and use yaml.v3 to unmarshal
-	// This avoids Viper's issue with dots in map keys
-	data, err := os.ReadFile(configFile)
+	data, cfg, err := m.readConfigData()
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	// Unmarshal using yaml.v3 which properly handles map keys with dots
-	if err := yaml.Unmarshal(data, m.config); err != nil {
-		return fmt.Errorf("failed to unmarshal config: %w", err)
+		return err
 	}
 
-	// Initialize accounts map if nil
-	if m.config.Accounts == nil {
-		m.config.Accounts = make(map[string]*models.Account)
+	// Bring configs written by older gitshift versions up to the current schema
+	needsSave, err := migrateConfig(cfg)
+	if err != nil {
+		return err
 	}
-
-	// Initialize pending accounts map if nil
-	if m.config.PendingAccounts == nil {
-		m.config.PendingAccounts = make(map[string]*models.PendingAccount)
+	// Hand-edited mistakes are reported here, all at once, rather than by whichever
+	// command trips over them
+	if err := schemaError(m.ConfigFile(), blockingProblems(validateSchema(data, cfg))); err != nil {
+		return err
 	}
+	m.config = cfg
 
 	// Fix accounts with zero CreatedAt values (migration fix)
-	needsSave := false
 	for _, account := range m.config.Accounts {
 		if account.CreatedAt.IsZero() {
 			account.CreatedAt = time.Now()
@@ -94,16 +162,176 @@ func (m *Manager) Load() error {
 
 	// Save the config if we made any fixes
 	if needsSave {
-		return m.Save()
+		trace.Printf(ctx, "config", "saving the migrated config")
+		return m.SaveContext(ctx)
 	}
 
 	return nil
 }
 
-// Save saves the current configuration to file
+// normalizePaths stores the account's SSH key path expanded, so the paths gitshift writes
+// are absolute. Paths written by hand, such as $HOME/.ssh/id_work, are kept as written
+// until the account is updated; everything that uses them expands them first and names
+// both forms when the key is missing.
+func normalizePaths(account *models.Account) {
+	if account != nil {
+		account.SSHKeyPath = pathutil.Expand(account.SSHKeyPath)
+	}
+}
+
+// initConfigFile creates the config file, migrated from a legacy config when there is one.
+// It holds the config lock so concurrent first runs don't race each other.
+func (m *Manager) initConfigFile(ctx context.Context) error {
+	unlock, err := m.acquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another process may have created it while we waited for the lock
+	if _, err := os.Stat(m.ConfigFile()); err == nil {
+		return nil
+	}
+
+	migrated, err := m.migrateLegacyConfig(ctx)
+	if err != nil || migrated {
+		return err
+	}
+
+	// Create a default config file
+	return m.writeConfigFile()
+}
+
+// readConfigFile parses the config file from disk. A file without a version field is
+// returned as version 0 so Load migrates it.
+func (m *Manager) readConfigFile() (*models.Config, error) {
+	_, cfg, err := m.readConfigData()
+	return cfg, err
+}
+
+// readConfigData is readConfigFile, also returning the raw content of the file
+func (m *Manager) readConfigData() ([]byte, *models.Config, error) {
+	// Read the file directly and use yaml.v3 to unmarshal
+	// This avoids Viper's issue with dots in map keys
+	data, err := os.ReadFile(m.ConfigFile())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, nil, models.NewUserError(
+			models.CategoryConfig,
+			fmt.Sprintf("failed to load %s", m.ConfigFile()),
+			"Run 'gitshift config doctor --fix' to restore it from "+m.BackupFile(),
+			err,
+		)
+	}
+	return data, cfg, nil
+}
+
+// parseConfig unmarshals config file content, rejecting empty content so a truncated file
+// is never mistaken for a config without accounts
+func parseConfig(data []byte) (*models.Config, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", models.ErrConfigCorrupted)
+	}
+
+	// Unmarshal using yaml.v3 which properly handles map keys with dots
+	cfg := models.NewConfig()
+	cfg.Version = 0
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrConfigCorrupted, err)
+	}
+
+	// Initialize accounts map if nil
+	if cfg.Accounts == nil {
+		cfg.Accounts = make(map[string]*models.Account)
+	}
+
+	// Initialize pending accounts map if nil
+	if cfg.PendingAccounts == nil {
+		cfg.PendingAccounts = make(map[string]*models.PendingAccount)
+	}
+
+	return cfg, nil
+}
+
+// migrateLegacyConfig copies the first existing legacy config into the config directory.
+// The legacy file is left in place.
+func (m *Manager) migrateLegacyConfig(ctx context.Context) (bool, error) {
+	for _, legacyFile := range m.legacyFiles {
+		if filepath.Clean(legacyFile) == filepath.Clean(m.ConfigFile()) {
+			continue
+		}
+
+		data, err := os.ReadFile(legacyFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return false, fmt.Errorf("failed to read legacy config file: %w", err)
+		}
+
+		if err := writeFileAtomic(m.ConfigFile(), data, 0644); err != nil {
+			return false, fmt.Errorf("failed to migrate legacy config file: %w", err)
+		}
+
+		m.migratedFrom = legacyFile
+		trace.Printf(ctx, "config", "migrated legacy config %s", legacyFile)
+		return true, nil
+	}
+	return false, nil
+}
+
+// Save writes the in-memory configuration to file as is, replacing whatever another
+// process saved in the meantime. Methods that add, remove or select accounts go through
+// update instead, which reloads the file first so concurrent changes are kept.
 func (m *Manager) Save() error {
-	configFile := filepath.Join(m.configPath, ConfigFileName+".yaml")
+	return m.SaveContext(context.Background())
+}
+
+// SaveContext is Save, giving up on waiting for the config lock when ctx is done
+func (m *Manager) SaveContext(ctx context.Context) error {
+	unlock, err := m.acquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
+	return m.writeConfigFile()
+}
+
+// update runs modify against the configuration as currently saved on disk and writes the
+// result, all while holding the config lock, so concurrent gitshift processes serialize
+// their changes instead of overwriting each other
+func (m *Manager) update(modify func() error) error {
+	unlock, err := m.acquireLock(context.Background())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(m.ConfigFile()); err == nil {
+		cfg, err := m.readConfigFile()
+		if err != nil {
+			return err
+		}
+		if _, err := migrateConfig(cfg); err != nil {
+			return err
+		}
+		m.config = cfg
+	}
+
+	if err := modify(); err != nil {
+		return err
+	}
+	return m.writeConfigFile()
+}
+
+// writeConfigFile atomically writes the in-memory configuration. Callers must hold the
+// config lock.
+func (m *Manager) writeConfigFile() error {
 	// Use direct YAML marshaling instead of Viper to properly handle map keys with dots
 	// Viper has issues with dots in map keys, treating them as path separators
 	data, err := marshalConfigToYAML(m.config)
@@ -111,14 +339,69 @@ func (m *Manager) Save() error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Write to file with proper permissions
-	if err := os.WriteFile(configFile, data, 0644); err != nil {
+	if err := m.backupConfigFile(); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(m.ConfigFile(), data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
+// backupConfigFile replaces the single rolling backup with the config about to be
+// overwritten. A config that doesn't parse is not backed up, so it can never replace the
+// last good backup. Callers must hold the config lock.
+func (m *Manager) backupConfigFile() error {
+	data, err := os.ReadFile(m.ConfigFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file for backup: %w", err)
+	}
+	if _, err := parseConfig(data); err != nil {
+		return nil
+	}
+
+	if err := writeFileAtomic(m.BackupFile(), data, 0644); err != nil {
+		return fmt.Errorf("failed to backup config file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so readers see either the old or the new content and never a partial write
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 // marshalConfigToYAML marshals the config to YAML format
 // This uses gopkg.in/yaml.v3 directly to properly handle map keys with dots
 func marshalConfigToYAML(config *models.Config) ([]byte, error) {
@@ -153,18 +436,27 @@ func (m *Manager) AddAccount(account *models.Account) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.config.Accounts[account.Alias]; exists {
-		return models.ErrAccountExists
-	}
+	normalizePaths(account)
+	return m.update(func() error {
+		if _, exists := m.config.Accounts[account.Alias]; exists {
+			return models.ErrAccountExists
+		}
 
-	// If this is the first account, make it default
-	if len(m.config.Accounts) == 0 {
-		account.IsDefault = true
-		m.config.CurrentAccount = account.Alias
-	}
+		// If this is the first account, make it default
+		if len(m.config.Accounts) == 0 {
+			account.IsDefault = true
+			m.config.CurrentAccount = account.Alias
+		}
+		// There is only ever one default
+		if account.IsDefault {
+			for _, other := range m.config.Accounts {
+				other.IsDefault = false
+			}
+		}
 
-	m.config.Accounts[account.Alias] = account
-	return m.Save()
+		m.config.Accounts[account.Alias] = account
+		return nil
+	})
 }
 
 // RemoveAccount removes an account from the configuration
@@ -172,29 +464,52 @@ func (m *Manager) RemoveAccount(alias string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	account, exists := m.config.Accounts[alias]
-	if !exists {
-		return models.ErrAccountNotFound
-	}
+	return m.update(func() error {
+		account, exists := m.config.Accounts[alias]
+		if !exists {
+			return models.ErrAccountNotFound
+		}
 
-	delete(m.config.Accounts, alias)
+		delete(m.config.Accounts, alias)
 
-	// If we removed the current account, clear it
-	if m.config.CurrentAccount == alias {
-		m.config.CurrentAccount = ""
-	}
+		// If we removed the current account, clear it
+		if m.config.CurrentAccount == alias {
+			m.config.CurrentAccount = ""
+		}
 
-	// If we removed the default account, set a new one
-	if account.IsDefault && len(m.config.Accounts) > 0 {
-		// Set the first remaining account as default
-		for _, acc := range m.config.Accounts {
-			acc.IsDefault = true
-			m.config.CurrentAccount = acc.Alias
-			break
+		// If we removed the default account, set a new one
+		if account.IsDefault && len(m.config.Accounts) > 0 {
+			// Set the first remaining account as default
+			for _, acc := range m.config.Accounts {
+				acc.IsDefault = true
+				m.config.CurrentAccount = acc.Alias
+				break
+			}
 		}
+
+		return nil
+	})
+}
+
+// UpdateAccount replaces the saved account with the same alias, e.g. after recording a
+// connectivity test on it
+func (m *Manager) UpdateAccount(account *models.Account) error {
+	if account == nil {
+		return fmt.Errorf("cannot update nil account")
 	}
 
-	return m.Save()
+	normalizePaths(account)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		if _, exists := m.config.Accounts[account.Alias]; !exists {
+			return models.ErrAccountNotFound
+		}
+
+		m.config.Accounts[account.Alias] = account
+		return nil
+	})
 }
 
 // GetAccount returns an account by alias
@@ -223,22 +538,114 @@ func (m *Manager) ListAccounts() []*models.Account {
 
 // ClearAllAccounts removes all accounts from the configuration
 func (m *Manager) ClearAllAccounts() error {
-	m.config.Accounts = make(map[string]*models.Account)
-	m.config.PendingAccounts = make(map[string]*models.PendingAccount)
-	m.config.CurrentAccount = ""
-	return m.Save()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		m.config.Accounts = make(map[string]*models.Account)
+		m.config.PendingAccounts = make(map[string]*models.PendingAccount)
+		m.config.CurrentAccount = ""
+		return nil
+	})
 }
 
 // SetCurrentAccount sets the current active account
 func (m *Manager) SetCurrentAccount(alias string) error {
-	account, exists := m.config.Accounts[alias]
-	if !exists {
-		return models.ErrAccountNotFound
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		if _, exists := m.config.Accounts[alias]; !exists {
+			return models.ErrAccountNotFound
+		}
+
+		m.config.CurrentAccount = alias
+		return nil
+	})
+}
+
+// SetDefaultAccount makes alias the default account, clearing the flag on every other one
+func (m *Manager) SetDefaultAccount(alias string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		if _, exists := m.config.Accounts[alias]; !exists {
+			return models.ErrAccountNotFound
+		}
+
+		for _, account := range m.config.Accounts {
+			account.IsDefault = account.Alias == alias
+		}
+		return nil
+	})
+}
+
+// ClearExtraDefaults keeps a single default when several accounts are marked as default,
+// which makes default-based choices depend on map order. The most recently used of them
+// stays the default. It returns the alias kept and the aliases cleared, both empty when
+// there was nothing to clear.
+func (m *Manager) ClearExtraDefaults() (kept string, cleared []string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err = m.update(func() error {
+		kept, cleared = keepOneDefault(m.config.Accounts)
+		return nil
+	})
+	return kept, cleared, err
+}
+
+// keepOneDefault clears IsDefault on all but the most recently used default account;
+// accounts never used rank last, ties go to the first alias. It returns the alias kept and
+// the aliases cleared when there was more than one default.
+func keepOneDefault(accounts map[string]*models.Account) (string, []string) {
+	var defaults []*models.Account
+	for _, account := range accounts {
+		if account != nil && account.IsDefault {
+			defaults = append(defaults, account)
+		}
+	}
+	if len(defaults) < 2 {
+		return "", nil
 	}
 
-	m.config.CurrentAccount = alias
-	account.MarkAsUsed()
-	return m.Save()
+	sort.Slice(defaults, func(i, j int) bool {
+		a, b := defaults[i], defaults[j]
+		switch {
+		case a.LastUsed != nil && b.LastUsed == nil:
+			return true
+		case a.LastUsed == nil && b.LastUsed != nil:
+			return false
+		case a.LastUsed != nil && !a.LastUsed.Equal(*b.LastUsed):
+			return a.LastUsed.After(*b.LastUsed)
+		}
+		return a.Alias < b.Alias
+	})
+
+	cleared := make([]string, 0, len(defaults)-1)
+	for _, account := range defaults[1:] {
+		account.IsDefault = false
+		cleared = append(cleared, account.Alias)
+	}
+	sort.Strings(cleared)
+	return defaults[0].Alias, cleared
+}
+
+// RecordAccountUsed sets the account's LastUsed to now, after a successful switch to it
+func (m *Manager) RecordAccountUsed(alias string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		account, exists := m.config.Accounts[alias]
+		if !exists {
+			return models.ErrAccountNotFound
+		}
+
+		account.MarkAsUsed(m.clock.Now())
+		return nil
+	})
 }
 
 // GetCurrentAccount returns the current active account
@@ -250,6 +657,39 @@ func (m *Manager) GetCurrentAccount() (*models.Account, error) {
 	return m.GetAccount(m.config.CurrentAccount)
 }
 
+// GetDefaultAccount returns the account marked as the default
+func (m *Manager) GetDefaultAccount() (*models.Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found *models.Account
+	for _, account := range m.config.Accounts {
+		if account == nil || !account.IsDefault {
+			continue
+		}
+		// Extra defaults are repaired by diagnose --fix; until then the first alias wins,
+		// so the choice doesn't depend on map order
+		if found == nil || account.Alias < found.Alias {
+			found = account
+		}
+	}
+	if found == nil {
+		return nil, models.ErrNoDefaultAccount
+	}
+	return found, nil
+}
+
+// GetCurrentOrDefaultAccount returns the current account, or the default account when
+// none is active. Commands that act on an account when none is named fall back to it.
+func (m *Manager) GetCurrentOrDefaultAccount() (*models.Account, error) {
+	if m.config.CurrentAccount != "" {
+		if account, err := m.GetAccount(m.config.CurrentAccount); err == nil {
+			return account, nil
+		}
+	}
+	return m.GetDefaultAccount()
+}
+
 // LoadProjectConfig loads project-specific configuration
 func (m *Manager) LoadProjectConfig(projectPath string) (*models.ProjectConfig, error) {
 	configFile := filepath.Join(projectPath, ProjectConfigName)
@@ -301,8 +741,13 @@ func (m *Manager) AddPendingAccount(pending *models.PendingAccount) error {
 		return fmt.Errorf("pending account must have an alias")
 	}
 
-	m.config.PendingAccounts[pending.Alias] = pending
-	return m.Save()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		m.config.PendingAccounts[pending.Alias] = pending
+		return nil
+	})
 }
 
 // GetPendingAccount returns a pending account by alias
@@ -325,38 +770,43 @@ func (m *Manager) ListPendingAccounts() []*models.PendingAccount {
 
 // CompletePendingAccount converts a pending account to an active account
 func (m *Manager) CompletePendingAccount(alias string, name, email string) (*models.Account, error) {
-	pending, exists := m.config.PendingAccounts[alias]
-	if !exists {
-		return nil, models.ErrAccountNotFound
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	// Create the completed account
-	account := &models.Account{
-		Alias:          alias,
-		Name:           name,
-		Email:          email,
-		GitHubUsername: pending.GitHubUsername,
-		SSHKeyPath:     pending.PartialData["ssh_key_path"],
-		Description:    "Completed from pending account (source: " + pending.Source + ")",
-		Status:         models.AccountStatusActive,
-		IsDefault:      false,
-		CreatedAt:      time.Now(),
-	}
+	var account *models.Account
+	err := m.update(func() error {
+		pending, exists := m.config.PendingAccounts[alias]
+		if !exists {
+			return models.ErrAccountNotFound
+		}
 
-	// Validate the completed account
-	if err := account.Validate(); err != nil {
-		return nil, fmt.Errorf("completed account validation failed: %w", err)
-	}
+		// Create the completed account
+		account = &models.Account{
+			Alias:          alias,
+			Name:           name,
+			Email:          email,
+			GitHubUsername: pending.GitHubUsername,
+			SSHKeyPath:     pending.PartialData["ssh_key_path"],
+			Description:    "Completed from pending account (source: " + pending.Source + ")",
+			Status:         models.AccountStatusActive,
+			IsDefault:      false,
+			CreatedAt:      time.Now(),
+		}
 
-	// Add to active accounts
-	m.config.Accounts[alias] = account
+		// Validate the completed account
+		if err := account.Validate(); err != nil {
+			return fmt.Errorf("completed account validation failed: %w", err)
+		}
 
-	// Remove from pending accounts
-	delete(m.config.PendingAccounts, alias)
+		// Add to active accounts
+		m.config.Accounts[alias] = account
 
-	// Save configuration
-	if err := m.Save(); err != nil {
-		return nil, fmt.Errorf("failed to save completed account: %w", err)
+		// Remove from pending accounts
+		delete(m.config.PendingAccounts, alias)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return account, nil
@@ -364,16 +814,26 @@ func (m *Manager) CompletePendingAccount(alias string, name, email string) (*mod
 
 // RemovePendingAccount removes a pending account
 func (m *Manager) RemovePendingAccount(alias string) error {
-	if _, exists := m.config.PendingAccounts[alias]; !exists {
-		return models.ErrAccountNotFound
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		if _, exists := m.config.PendingAccounts[alias]; !exists {
+			return models.ErrAccountNotFound
+		}
 
-	delete(m.config.PendingAccounts, alias)
-	return m.Save()
+		delete(m.config.PendingAccounts, alias)
+		return nil
+	})
 }
 
 // ClearAllPendingAccounts removes all pending accounts
 func (m *Manager) ClearAllPendingAccounts() error {
-	m.config.PendingAccounts = make(map[string]*models.PendingAccount)
-	return m.Save()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(func() error {
+		m.config.PendingAccounts = make(map[string]*models.PendingAccount)
+		return nil
+	})
 }
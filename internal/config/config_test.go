@@ -0,0 +1,532 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestConfigDir(t *testing.T) {
+	home := t.TempDir()
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if got, want := ConfigDir(home), filepath.Join(home, ".config", "gitshift"); got != want {
+		t.Errorf("ConfigDir() without XDG_CONFIG_HOME = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "relative/dir")
+	if got, want := ConfigDir(home), filepath.Join(home, ".config", "gitshift"); got != want {
+		t.Errorf("ConfigDir() with relative XDG_CONFIG_HOME = %q, want %q", got, want)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	if got, want := ConfigDir(home), filepath.Join(xdg, "gitshift"); got != want {
+		t.Errorf("ConfigDir() with XDG_CONFIG_HOME = %q, want %q", got, want)
+	}
+
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "$HOME/.xdg")
+	if got, want := ConfigDir(home), filepath.Join(home, ".xdg", "gitshift"); got != want {
+		t.Errorf("ConfigDir() with XDG_CONFIG_HOME=$HOME/.xdg = %q, want %q", got, want)
+	}
+}
+
+func TestLoad_MigratesLegacyConfig(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	legacyDir := filepath.Join(home, ".config", "gitshift")
+	if err := os.MkdirAll(legacyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := "accounts:\n  work:\n    alias: work\n    name: Dev\n    email: dev@example.com\ncurrent_account: work\n"
+	if err := os.WriteFile(filepath.Join(legacyDir, "config.yaml"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager()
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got, want := m.ConfigFile(), filepath.Join(xdg, "gitshift", "config.yaml"); got != want {
+		t.Errorf("ConfigFile() = %q, want %q", got, want)
+	}
+	if m.MigratedFrom() != filepath.Join(legacyDir, "config.yaml") {
+		t.Errorf("MigratedFrom() = %q, want the legacy config", m.MigratedFrom())
+	}
+	if _, err := m.GetAccount("work"); err != nil {
+		t.Errorf("migrated config lost account 'work': %v", err)
+	}
+	if _, err := os.Stat(m.ConfigFile()); err != nil {
+		t.Errorf("migrated config was not written: %v", err)
+	}
+}
+
+func TestLoad_MigratesGitPersonaConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	legacy := "accounts:\n  personal:\n    alias: personal\n    name: Dev\n    email: dev@example.com\n"
+	legacyFile := filepath.Join(home, ".git-persona.yaml")
+	if err := os.WriteFile(legacyFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager()
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if m.MigratedFrom() != legacyFile {
+		t.Errorf("MigratedFrom() = %q, want %q", m.MigratedFrom(), legacyFile)
+	}
+	if _, err := m.GetAccount("personal"); err != nil {
+		t.Errorf("migrated config lost account 'personal': %v", err)
+	}
+}
+
+func TestAddAccount_ConcurrentManagersKeepBothAccounts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	first := NewManager()
+	second := NewManager()
+	for _, m := range []*Manager{first, second} {
+		if err := m.Load(); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+	}
+
+	// Both managers loaded the same empty config; neither write may clobber the other
+	if err := first.AddAccount(&models.Account{Alias: "work", Name: "Dev", Email: "dev@work.com"}); err != nil {
+		t.Fatalf("AddAccount(work) error = %v", err)
+	}
+	if err := second.AddAccount(&models.Account{Alias: "personal", Name: "Dev", Email: "dev@home.com"}); err != nil {
+		t.Fatalf("AddAccount(personal) error = %v", err)
+	}
+
+	reloaded := NewManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, alias := range []string{"work", "personal"} {
+		if _, err := reloaded.GetAccount(alias); err != nil {
+			t.Errorf("account %q was lost: %v", alias, err)
+		}
+	}
+	if _, err := os.Stat(reloaded.ConfigFile() + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary config file was left behind: %v", err)
+	}
+}
+
+func TestSave_LockTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	holder := NewManager()
+	unlock, err := holder.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+	defer unlock()
+
+	m := NewManager()
+	m.lockTimeout = 100 * time.Millisecond
+	err = m.Save()
+
+	var userErr *models.UserError
+	if !errors.As(err, &userErr) {
+		t.Fatalf("Save() error = %v, want a UserError", err)
+	}
+	if userErr.Category != models.CategoryConfig {
+		t.Errorf("Category = %q, want %q", userErr.Category, models.CategoryConfig)
+	}
+}
+
+func TestSaveContext_StopsWaitingForLockWhenDone(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	holder := NewManager()
+	unlock, err := holder.acquireLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireLock() error = %v", err)
+	}
+	defer unlock()
+
+	// The context ends long before the lock timeout
+	m := NewManager()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = m.SaveContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SaveContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > DefaultLockTimeout/2 {
+		t.Errorf("SaveContext() waited %s for the lock after its context was done", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.LoadContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadContext() with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestLoad_MigratesUnversionedConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := os.MkdirAll(filepath.Dir(m.ConfigFile()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := "accounts: {}\nconfig_version: \"1.0.0\"\n"
+	if err := os.WriteFile(m.ConfigFile(), []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := m.GetConfig().Version; got != models.CurrentConfigVersion {
+		t.Errorf("Version = %d, want %d", got, models.CurrentConfigVersion)
+	}
+
+	reloaded := NewManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := reloaded.GetConfig().Version; got != models.CurrentConfigVersion {
+		t.Errorf("saved Version = %d, want %d", got, models.CurrentConfigVersion)
+	}
+}
+
+func TestLoad_RejectsNewerConfigVersion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := os.MkdirAll(filepath.Dir(m.ConfigFile()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(m.ConfigFile(), []byte("version: 99\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var userErr *models.UserError
+	if err := m.Load(); !errors.As(err, &userErr) {
+		t.Fatalf("Load() error = %v, want a UserError", err)
+	}
+}
+
+func TestConfigMigrationsCoverCurrentVersion(t *testing.T) {
+	if len(configMigrations) != models.CurrentConfigVersion {
+		t.Errorf("len(configMigrations) = %d, want %d", len(configMigrations), models.CurrentConfigVersion)
+	}
+}
+
+func TestRepairConfig_RestoresCorruptedFileFromBackup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := m.AddAccount(&models.Account{Alias: "work", Name: "Dev", Email: "dev@work.com"}); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	// The second save moves the config holding 'work' into the backup
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := os.WriteFile(m.ConfigFile(), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewManager().Load(); err == nil {
+		t.Fatal("Load() of an empty config file succeeded, want an error")
+	}
+
+	check, err := m.CheckConfig()
+	if err != nil {
+		t.Fatalf("CheckConfig() error = %v", err)
+	}
+	if !check.HasFixableIssues() {
+		t.Fatalf("CheckConfig() issues = %+v, want a fixable issue", check.Issues)
+	}
+
+	check, err = m.RepairConfig()
+	if err != nil {
+		t.Fatalf("RepairConfig() error = %v", err)
+	}
+	if !check.IsValid() {
+		t.Errorf("RepairConfig() left issues: %+v", check.Issues)
+	}
+
+	reloaded := NewManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() after repair error = %v", err)
+	}
+	if _, err := reloaded.GetAccount("work"); err != nil {
+		t.Errorf("restored config lost account 'work': %v", err)
+	}
+}
+
+func TestRepairConfig_KeepsCorruptedFileWithoutBackup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := os.MkdirAll(filepath.Dir(m.ConfigFile()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A hand edit lost the space after a colon
+	content := "version: 1\naccounts:\n  work:\n    alias: work\n    name:Dev\n    email: [dev@work.com\n"
+	if err := os.WriteFile(m.ConfigFile(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := m.RepairConfig()
+	if err != nil {
+		t.Fatalf("RepairConfig() error = %v", err)
+	}
+	if !check.IsValid() {
+		t.Errorf("RepairConfig() left issues: %+v", check.Issues)
+	}
+	if check.CorruptCopy == "" || filepath.Dir(check.CorruptCopy) != filepath.Dir(m.ConfigFile()) {
+		t.Fatalf("CorruptCopy = %q, want a copy next to the config", check.CorruptCopy)
+	}
+	if kept, err := os.ReadFile(check.CorruptCopy); err != nil || string(kept) != content {
+		t.Errorf("kept copy = %q, %v; want the corrupted content", kept, err)
+	}
+	if len(m.ListAccounts()) != 0 {
+		t.Errorf("recreated config has accounts %v, want an empty one", m.ListAccounts())
+	}
+}
+
+func TestRepairConfig_FixesInconsistentAccounts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := os.MkdirAll(filepath.Dir(m.ConfigFile()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "version: 1\naccounts:\n  work:\n    alias: old\n    name: Dev\n    email: dev@work.com\ncurrent_account: gone\n"
+	if err := os.WriteFile(m.ConfigFile(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := m.RepairConfig()
+	if err != nil {
+		t.Fatalf("RepairConfig() error = %v", err)
+	}
+	if !check.IsValid() {
+		t.Errorf("RepairConfig() left issues: %+v", check.Issues)
+	}
+	if got := m.GetConfig().Accounts["work"].Alias; got != "work" {
+		t.Errorf("Alias = %q, want %q", got, "work")
+	}
+	if got := m.GetConfig().CurrentAccount; got != "" {
+		t.Errorf("CurrentAccount = %q, want it cleared", got)
+	}
+}
+
+func TestRepairConfig_ConcurrentWithAccountChanges(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+	m.lockTimeout = 2 * time.Second
+	content := "version: 1\naccounts:\n  work:\n    alias: old\n    name: Dev\n    email: dev@work.com\n"
+
+	// Repairs and account changes of one manager must take the mutex and the config lock in
+	// the same order, or each ends up holding what the other waits for
+	for i := 0; i < 50; i++ {
+		if err := os.WriteFile(m.ConfigFile(), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			_, err := m.RepairConfig()
+			done <- err
+		}()
+		if err := m.SetCurrentAccount("work"); err != nil {
+			t.Fatalf("SetCurrentAccount() error = %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("RepairConfig() error = %v", err)
+		}
+	}
+}
+
+func TestClearExtraDefaults_KeepsMostRecentlyUsed(t *testing.T) {
+	used := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := used.Add(time.Hour)
+	m := writeTestConfig(t, `version: 1
+accounts:
+  oss:
+    alias: oss
+    name: Dev
+    email: dev@oss.org
+    is_default: true
+  personal:
+    alias: personal
+    name: Dev
+    email: dev@home.org
+    is_default: true
+    last_used: `+used.Format(time.RFC3339)+`
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    is_default: true
+    last_used: `+later.Format(time.RFC3339)+`
+`)
+
+	// Several defaults don't keep the config from loading, so they can be repaired
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	kept, cleared, err := m.ClearExtraDefaults()
+	if err != nil {
+		t.Fatalf("ClearExtraDefaults() error = %v", err)
+	}
+	if kept != "work" || len(cleared) != 2 || cleared[0] != "oss" || cleared[1] != "personal" {
+		t.Errorf("ClearExtraDefaults() = %s, %v, want work, [oss personal]", kept, cleared)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() after ClearExtraDefaults() = %v", err)
+	}
+
+	if kept, cleared, _ := m.ClearExtraDefaults(); kept != "" || cleared != nil {
+		t.Errorf("ClearExtraDefaults() with one default = %s, %v", kept, cleared)
+	}
+}
+
+func TestSetDefaultAccount_ClearsOtherDefaults(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	for _, account := range []*models.Account{
+		{Alias: "work", Name: "Dev", Email: "dev@work.com"},
+		{Alias: "personal", Name: "Dev", Email: "dev@home.org"},
+	} {
+		if err := m.AddAccount(account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.SetDefaultAccount("personal"); err != nil {
+		t.Fatalf("SetDefaultAccount() error = %v", err)
+	}
+	if m.GetConfig().Accounts["work"].IsDefault || !m.GetConfig().Accounts["personal"].IsDefault {
+		t.Errorf("defaults after SetDefaultAccount(personal): work=%v personal=%v",
+			m.GetConfig().Accounts["work"].IsDefault, m.GetConfig().Accounts["personal"].IsDefault)
+	}
+
+	if err := m.AddAccount(&models.Account{Alias: "oss", Name: "Dev", Email: "dev@oss.org", IsDefault: true}); err != nil {
+		t.Fatal(err)
+	}
+	if m.GetConfig().Accounts["personal"].IsDefault {
+		t.Error("AddAccount() of a default account left the previous default set")
+	}
+	if err := m.SetDefaultAccount("gone"); !errors.Is(err, models.ErrAccountNotFound) {
+		t.Errorf("SetDefaultAccount() of a missing account = %v", err)
+	}
+}
+
+func TestGetCurrentOrDefaultAccount(t *testing.T) {
+	m := writeTestConfig(t, `version: 1
+accounts:
+  personal:
+    alias: personal
+    name: Dev
+    email: dev@home.org
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    is_default: true
+`)
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Without an active account, the default one is used
+	if account, err := m.GetCurrentOrDefaultAccount(); err != nil || account.Alias != "work" {
+		t.Errorf("GetCurrentOrDefaultAccount() = %v, %v, want work", account, err)
+	}
+
+	if err := m.SetCurrentAccount("personal"); err != nil {
+		t.Fatal(err)
+	}
+	if account, err := m.GetCurrentOrDefaultAccount(); err != nil || account.Alias != "personal" {
+		t.Errorf("GetCurrentOrDefaultAccount() with an active account = %v, %v, want personal", account, err)
+	}
+
+	m.GetConfig().CurrentAccount = ""
+	m.GetConfig().Accounts["work"].IsDefault = false
+	if _, err := m.GetCurrentOrDefaultAccount(); !errors.Is(err, models.ErrNoDefaultAccount) {
+		t.Errorf("GetCurrentOrDefaultAccount() without current or default account = %v, want ErrNoDefaultAccount", err)
+	}
+}
+
+func TestSSHKeyPaths_WrittenByHandAreExpandedWhenUsed(t *testing.T) {
+	m := writeTestConfig(t, `version: 1
+accounts:
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    ssh_key_path: $HOME/.ssh/id_work
+`)
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	home := os.Getenv("HOME")
+
+	// Kept as written, so errors can name the path the user wrote
+	if got := m.GetConfig().Accounts["work"].SSHKeyPath; got != "$HOME/.ssh/id_work" {
+		t.Errorf("loaded SSHKeyPath = %q, want it as written", got)
+	}
+
+	err := NewConfigValidator().validateSSHKeyPolicy("$HOME/.ssh/id_work", "work")
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "$HOME/.ssh/id_work (expanded to "+filepath.Join(home, ".ssh", "id_work")+")") {
+		t.Errorf("validateSSHKeyPolicy() of a missing key = %v, want both forms of the path", err)
+	}
+
+	account := &models.Account{Alias: "oss", Name: "Dev", Email: "dev@oss.org", SSHKeyPath: "${HOME}/.ssh/id_oss"}
+	if err := m.AddAccount(account); err != nil {
+		t.Fatalf("AddAccount() error = %v", err)
+	}
+	if want := filepath.Join(home, ".ssh", "id_oss"); m.GetConfig().Accounts["oss"].SSHKeyPath != want {
+		t.Errorf("added SSHKeyPath = %q, want %q", m.GetConfig().Accounts["oss"].SSHKeyPath, want)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+)
+
+// ConnectivityTestCached reports whether the account's last successful SSH connection test
+// is recent enough, per the configured connectivity_test_ttl, to reuse instead of dialing
+// the platform again. A test made with another key than the account's current one is
+// never reused.
+func (m *Manager) ConnectivityTestCached(account *models.Account) bool {
+	return account.LastConnectivityTestKey == connectivityTestKey(account) &&
+		account.HasRecentConnectivityTest(m.clock.Now(), m.GetConfig().GetConnectivityTestTTL())
+}
+
+// RecordConnectivityTest records a successful SSH connection test for the account now and
+// saves it, so later validations within the TTL reuse the result
+func (m *Manager) RecordConnectivityTest(account *models.Account) error {
+	account.MarkConnectivityTested(m.clock.Now())
+	account.LastConnectivityTestKey = connectivityTestKey(account)
+	return m.UpdateAccount(account)
+}
+
+// connectivityTestKey identifies the SSH key the account connects with: the fingerprint of
+// an agent-only key, or the key's path with a hash of its public key. Without a .pub file
+// the private key's size and modification time stand in for the hash.
+func connectivityTestKey(account *models.Account) string {
+	if account.SSHKeyPath == "" {
+		if account.SSHKeyFingerprint == "" {
+			return ""
+		}
+		return "agent:SHA256:" + strings.TrimPrefix(account.SSHKeyFingerprint, "SHA256:")
+	}
+
+	keyPath := pathutil.Expand(account.SSHKeyPath)
+	if publicKey, err := os.ReadFile(keyPath + ".pub"); err == nil {
+		// The comment can change without the key changing
+		fields := strings.Fields(string(publicKey))
+		if len(fields) > 2 {
+			fields = fields[:2]
+		}
+		sum := sha256.Sum256([]byte(strings.Join(fields, " ")))
+		return keyPath + ":" + hex.EncodeToString(sum[:])
+	}
+	if info, err := os.Stat(keyPath); err == nil {
+		return fmt.Sprintf("%s:%d:%d", keyPath, info.Size(), info.ModTime().UnixNano())
+	}
+	return keyPath
+}
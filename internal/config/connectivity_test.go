@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// fakeClock is a Clock that only moves when the test advances it
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestConnectivityTestCache_ExpiresAfterTTL(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	m.clock = clock
+
+	account := models.NewAccount("work", "Dev", "dev@work.com", "")
+	if err := m.AddAccount(account); err != nil {
+		t.Fatal(err)
+	}
+	if m.ConnectivityTestCached(account) {
+		t.Fatal("an account that was never tested has a cached connectivity test")
+	}
+
+	if err := m.RecordConnectivityTest(account); err != nil {
+		t.Fatalf("RecordConnectivityTest() error = %v", err)
+	}
+	saved, err := m.GetAccount("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.LastConnectivityTest == nil || !saved.LastConnectivityTest.Equal(clock.now) {
+		t.Errorf("LastConnectivityTest = %v, want %v", saved.LastConnectivityTest, clock.now)
+	}
+
+	clock.Advance(models.DefaultConnectivityTestTTL - time.Second)
+	if !m.ConnectivityTestCached(account) {
+		t.Error("connectivity test expired before the default TTL")
+	}
+	clock.Advance(time.Second)
+	if m.ConnectivityTestCached(account) {
+		t.Error("connectivity test still cached at the default TTL")
+	}
+}
+
+func TestConnectivityTestCache_UsesConfiguredTTL(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	m.clock = clock
+	m.GetConfig().ConnectivityTestTTL = 2 * time.Hour
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	account := models.NewAccount("work", "Dev", "dev@work.com", "")
+	if err := m.AddAccount(account); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RecordConnectivityTest(account); err != nil {
+		t.Fatal(err)
+	}
+
+	if next, want := account.NextConnectivityTest(m.GetConfig().GetConnectivityTestTTL()), clock.now.Add(2*time.Hour); !next.Equal(want) {
+		t.Errorf("NextConnectivityTest() = %v, want %v", next, want)
+	}
+	clock.Advance(time.Hour)
+	if !m.ConnectivityTestCached(account) {
+		t.Error("connectivity test expired before the configured TTL")
+	}
+	clock.Advance(time.Hour)
+	if m.ConnectivityTestCached(account) {
+		t.Error("connectivity test still cached at the configured TTL")
+	}
+}
+
+func TestConnectivityTestCache_InvalidatedByKeyChange(t *testing.T) {
+	home := t.TempDir()
+	m := newTestManager(t, home)
+	m.clock = &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeKey := func(name, publicKey string) string {
+		keyPath := filepath.Join(sshDir, name)
+		if err := os.WriteFile(keyPath, []byte("placeholder\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(keyPath+".pub", []byte(publicKey), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return keyPath
+	}
+	workKey := writeKey("id_work", "ssh-ed25519 AAAAfirst dev@work\n")
+	otherKey := writeKey("id_other", "ssh-ed25519 AAAAother dev@other\n")
+
+	account := models.NewAccount("work", "Dev", "dev@work.com", workKey)
+	if err := m.AddAccount(account); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RecordConnectivityTest(account); err != nil {
+		t.Fatal(err)
+	}
+	if !m.ConnectivityTestCached(account) {
+		t.Fatal("connectivity test not cached right after it was recorded")
+	}
+
+	// Only the comment changes
+	writeKey("id_work", "ssh-ed25519 AAAAfirst renamed\n")
+	if !m.ConnectivityTestCached(account) {
+		t.Error("a changed key comment invalidated the connectivity test")
+	}
+
+	// The key is regenerated in place
+	writeKey("id_work", "ssh-ed25519 AAAAsecond dev@work\n")
+	if m.ConnectivityTestCached(account) {
+		t.Error("connectivity test made with the replaced key is still cached")
+	}
+
+	if err := m.RecordConnectivityTest(account); err != nil {
+		t.Fatal(err)
+	}
+	account.SSHKeyPath = otherKey
+	if m.ConnectivityTestCached(account) {
+		t.Error("connectivity test made with the previous key path is still cached")
+	}
+}
+
+func TestRecordAccountUsed(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	m.clock = clock
+
+	for _, alias := range []string{"work", "personal"} {
+		if err := m.AddAccount(models.NewAccount(alias, "Dev", "dev@"+alias+".com", "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.SetCurrentAccount("work"); err != nil {
+		t.Fatal(err)
+	}
+	if work, _ := m.GetAccount("work"); work.LastUsed != nil {
+		t.Errorf("SetCurrentAccount() set LastUsed = %v; only a completed switch should", work.LastUsed)
+	}
+
+	// Another process records a use of another account in between
+	other := NewManager()
+	if err := other.Load(); err != nil {
+		t.Fatal(err)
+	}
+	other.clock = &fakeClock{now: clock.now.Add(-time.Hour)}
+	if err := other.RecordAccountUsed("personal"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RecordAccountUsed("work"); err != nil {
+		t.Fatalf("RecordAccountUsed() error = %v", err)
+	}
+
+	reloaded := NewManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for alias, want := range map[string]time.Time{"work": clock.now, "personal": clock.now.Add(-time.Hour)} {
+		account, err := reloaded.GetAccount(alias)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.LastUsed == nil || !account.LastUsed.Equal(want) {
+			t.Errorf("%s LastUsed = %v, want %v", alias, account.LastUsed, want)
+		}
+	}
+
+	if err := m.RecordAccountUsed("missing"); !errors.Is(err, models.ErrAccountNotFound) {
+		t.Errorf("RecordAccountUsed(missing) error = %v, want ErrAccountNotFound", err)
+	}
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/token"
+)
+
+// corruptCopyTimeFormat sorts lexically and keeps copies taken within a second distinct
+const corruptCopyTimeFormat = "20060102-150405.000000000"
+
+// ConfigCheck is the result of checking the gitshift config file
+type ConfigCheck struct {
+	ConfigPath string
+	Issues     []ConfigIssue
+
+	// CorruptCopy is where RepairConfig kept the unparseable file it replaced, if any
+	CorruptCopy string
+}
+
+// ConfigIssue describes a single problem found in the config file
+type ConfigIssue struct {
+	Account string // alias of the account the issue is about, empty for file-level issues
+	Message string
+	Fixable bool // whether RepairConfig can repair the issue
+}
+
+// IsValid returns true when no issues were found
+func (c *ConfigCheck) IsValid() bool {
+	return len(c.Issues) == 0
+}
+
+// HasFixableIssues returns true when RepairConfig would change something
+func (c *ConfigCheck) HasFixableIssues() bool {
+	for _, issue := range c.Issues {
+		if issue.Fixable {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConfig validates the config file without changing it: that it exists and parses,
+// that its schema version is current, and that every account is consistent and valid
+func (m *Manager) CheckConfig() (*ConfigCheck, error) {
+	unlock, err := m.acquireLock(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	check, _, err := m.checkConfigFile()
+	return check, err
+}
+
+// RepairConfig fixes the fixable issues CheckConfig reports: a missing or corrupted file is
+// restored from the backup (or recreated empty when there is none), older schema versions
+// are migrated and inconsistent account entries are corrected. A corrupted file is first
+// copied aside, since it may hold accounts the backup doesn't. It returns the check of
+// the repaired file.
+func (m *Manager) RepairConfig() (*ConfigCheck, error) {
+	// The mutex is taken before the config lock, in the same order as every other change
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unlock, err := m.acquireLock(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	check, cfg, err := m.checkConfigFile()
+	if err != nil || !check.HasFixableIssues() {
+		return check, err
+	}
+
+	var corruptCopy string
+	if cfg == nil {
+		if corruptCopy, err = m.keepCorruptConfig(); err != nil {
+			return nil, err
+		}
+		if cfg, err = m.readBackupFile(); err != nil {
+			return nil, err
+		}
+		if cfg == nil {
+			cfg = models.NewConfig()
+		}
+	}
+
+	if _, err := migrateConfig(cfg); err != nil {
+		return nil, err
+	}
+	repairAccounts(cfg)
+
+	m.config = cfg
+	if err := m.writeConfigFile(); err != nil {
+		return nil, err
+	}
+
+	check, _, err = m.checkConfigFile()
+	if check != nil {
+		check.CorruptCopy = corruptCopy
+	}
+	return check, err
+}
+
+// keepCorruptConfig copies the config file that doesn't parse to config.yaml.corrupt-<time>
+// and returns the copy's path, or "" when there is no file to keep
+func (m *Manager) keepCorruptConfig() (string, error) {
+	data, err := os.ReadFile(m.ConfigFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	path := m.ConfigFile() + ".corrupt-" + m.clock.Now().UTC().Format(corruptCopyTimeFormat)
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to keep a copy of the corrupted config: %w", err)
+	}
+	return path, nil
+}
+
+// checkConfigFile checks the config file and returns the parsed config, or nil when the
+// file is missing or doesn't parse. Callers must hold the config lock.
+func (m *Manager) checkConfigFile() (*ConfigCheck, *models.Config, error) {
+	check := &ConfigCheck{ConfigPath: m.ConfigFile()}
+
+	data, err := os.ReadFile(m.ConfigFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		check.Issues = append(check.Issues, ConfigIssue{
+			Message: "config file does not exist" + m.restoreHint(),
+			Fixable: true,
+		})
+		return check, nil, nil
+	}
+
+	cfg, err := parseConfig(data)
+	if err != nil {
+		check.Issues = append(check.Issues, ConfigIssue{
+			Message: err.Error() + m.restoreHint(),
+			Fixable: true,
+		})
+		return check, nil, nil
+	}
+
+	switch {
+	case cfg.Version > models.CurrentConfigVersion:
+		check.Issues = append(check.Issues, ConfigIssue{
+			Message: fmt.Sprintf("schema version %d is newer than this gitshift supports (%d); upgrade gitshift", cfg.Version, models.CurrentConfigVersion),
+		})
+	case cfg.Version < models.CurrentConfigVersion:
+		check.Issues = append(check.Issues, ConfigIssue{
+			Message: fmt.Sprintf("schema version %d is outdated, current is %d", cfg.Version, models.CurrentConfigVersion),
+			Fixable: true,
+		})
+	}
+
+	if _, err := token.NewStore(cfg.TokenStorage, ""); err != nil {
+		check.Issues = append(check.Issues, ConfigIssue{Message: err.Error()})
+	}
+
+	problems := validateSchema(data, cfg)
+	hasProblems := make(map[string]bool, len(problems))
+	for _, problem := range problems {
+		message := problem.Message
+		if problem.Line > 0 {
+			message = fmt.Sprintf("line %d: %s", problem.Line, message)
+		}
+		check.Issues = append(check.Issues, ConfigIssue{Account: problem.Account, Message: message, Fixable: problem.Fixable})
+		hasProblems[problem.Account] = true
+	}
+	check.Issues = append(check.Issues, findAccountIssues(cfg, hasProblems)...)
+	return check, cfg, nil
+}
+
+// restoreHint describes what RepairConfig replaces a missing or corrupted file with
+func (m *Manager) restoreHint() string {
+	if _, err := os.Stat(m.BackupFile()); err == nil {
+		return fmt.Sprintf(" (can be restored from %s)", m.BackupFile())
+	}
+	return " (no backup available, an empty config can be created)"
+}
+
+// readBackupFile parses the backup config, returning nil when there is none or it doesn't
+// parse either
+func (m *Manager) readBackupFile() (*models.Config, error) {
+	data, err := os.ReadFile(m.BackupFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config backup: %w", err)
+	}
+
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, nil
+	}
+	return cfg, nil
+}
+
+// findAccountIssues reports account entries that are inconsistent with the rest of the
+// config or fail validation. Accounts in skip already have schema problems reported, which
+// validation would only repeat.
+func findAccountIssues(cfg *models.Config, skip map[string]bool) []ConfigIssue {
+	var issues []ConfigIssue
+
+	aliases := make([]string, 0, len(cfg.Accounts))
+	for alias := range cfg.Accounts {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		account := cfg.Accounts[alias]
+		if account == nil {
+			issues = append(issues, ConfigIssue{Account: alias, Message: "account entry is empty", Fixable: true})
+			continue
+		}
+		if account.Alias != alias {
+			issues = append(issues, ConfigIssue{
+				Account: alias,
+				Message: fmt.Sprintf("account is stored under '%s' but its alias is '%s'", alias, account.Alias),
+				Fixable: true,
+			})
+		}
+		if err := account.Validate(); err != nil && account.Alias != "" && !skip[alias] {
+			issues = append(issues, ConfigIssue{
+				Account: alias,
+				Message: fmt.Sprintf("account is invalid: %v; run 'gitshift update %s' to correct it", err, alias),
+			})
+		}
+	}
+
+	if cfg.CurrentAccount != "" && cfg.Accounts[cfg.CurrentAccount] == nil {
+		issues = append(issues, ConfigIssue{
+			Message: fmt.Sprintf("current account '%s' does not exist", cfg.CurrentAccount),
+			Fixable: true,
+		})
+	}
+
+	return issues
+}
+
+// repairAccounts fixes the fixable issues findAccountIssues reports, and keeps a single
+// default account when several are marked as default
+func repairAccounts(cfg *models.Config) {
+	for alias, account := range cfg.Accounts {
+		if account == nil {
+			delete(cfg.Accounts, alias)
+			continue
+		}
+		// The map key is what every command looks accounts up by, so it wins
+		account.Alias = alias
+	}
+
+	if cfg.CurrentAccount != "" && cfg.Accounts[cfg.CurrentAccount] == nil {
+		cfg.CurrentAccount = ""
+	}
+	keepOneDefault(cfg.Accounts)
+}
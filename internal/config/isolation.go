@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+)
+
+// Kinds of credentials that accounts must not share
+const (
+	SharedSSHKey         = "ssh_key"
+	SharedToken          = "token"
+	SharedGitHubUsername = "github_username"
+)
+
+// IsolationIssue is a credential shared by two or more accounts, which makes them act as
+// the same identity no matter which one is active
+type IsolationIssue struct {
+	Kind     string   `json:"kind"`
+	Accounts []string `json:"accounts"`
+	Message  string   `json:"message"`
+}
+
+// FindSharedCredentials reports accounts that share an SSH key, a token or a GitHub username
+// on the same platform. tokens maps account aliases to their stored tokens; the values are
+// only compared, never included in the issues.
+func FindSharedCredentials(accounts []*models.Account, tokens map[string]string) []IsolationIssue {
+	keys := make(map[string][]string)
+	secrets := make(map[string][]string)
+	usernames := make(map[string][]string)
+
+	for _, account := range accounts {
+		if account.SSHKeyPath != "" {
+			path := filepath.Clean(pathutil.Expand(account.SSHKeyPath))
+			keys[path] = append(keys[path], account.Alias)
+		}
+		if value := tokens[account.Alias]; value != "" {
+			secrets[value] = append(secrets[value], account.Alias)
+		}
+		if account.GitHubUsername != "" {
+			key := account.GetDomain() + "/" + strings.ToLower(account.GitHubUsername)
+			usernames[key] = append(usernames[key], account.Alias)
+		}
+	}
+
+	var issues []IsolationIssue
+	for path, aliases := range keys {
+		if len(aliases) > 1 {
+			issues = append(issues, sharedIssue(SharedSSHKey, aliases, fmt.Sprintf("share the SSH key %s", path)))
+		}
+	}
+	for _, aliases := range secrets {
+		if len(aliases) > 1 {
+			issues = append(issues, sharedIssue(SharedToken, aliases, "share the same token"))
+		}
+	}
+	for key, aliases := range usernames {
+		if len(aliases) > 1 {
+			domain, username, _ := strings.Cut(key, "/")
+			issues = append(issues, sharedIssue(SharedGitHubUsername, aliases, fmt.Sprintf("use the same username %s on %s", username, domain)))
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind < issues[j].Kind
+		}
+		return issues[i].Message < issues[j].Message
+	})
+	return issues
+}
+
+func sharedIssue(kind string, aliases []string, what string) IsolationIssue {
+	sort.Strings(aliases)
+	return IsolationIssue{
+		Kind:     kind,
+		Accounts: aliases,
+		Message:  fmt.Sprintf("Accounts %s %s", quoteAliases(aliases), what),
+	}
+}
+
+// quoteAliases formats aliases as 'a', 'b' and 'c'
+func quoteAliases(aliases []string) string {
+	quoted := make([]string, len(aliases))
+	for i, alias := range aliases {
+		quoted[i] = "'" + alias + "'"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " and " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestFindSharedCredentials(t *testing.T) {
+	accounts := []*models.Account{
+		{Alias: "work", SSHKeyPath: "/home/dev/.ssh/id_work", GitHubUsername: "Dev"},
+		{Alias: "oss", SSHKeyPath: "/home/dev/.ssh/../.ssh/id_work", GitHubUsername: "dev"},
+		{Alias: "personal", SSHKeyPath: "/home/dev/.ssh/id_personal", GitHubUsername: "dev", Platform: "gitlab"},
+	}
+	tokens := map[string]string{"work": "ghp_a", "personal": "ghp_a", "oss": "ghp_b"}
+
+	issues := FindSharedCredentials(accounts, tokens)
+
+	want := map[string]string{
+		SharedGitHubUsername: "'oss' and 'work'",
+		SharedSSHKey:         "'oss' and 'work'",
+		SharedToken:          "'personal' and 'work'",
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("FindSharedCredentials() = %+v, want %d issues", issues, len(want))
+	}
+	for _, issue := range issues {
+		if !strings.Contains(issue.Message, want[issue.Kind]) {
+			t.Errorf("%s issue = %q, want it to name %s", issue.Kind, issue.Message, want[issue.Kind])
+		}
+		if strings.Contains(issue.Message, "ghp_") {
+			t.Errorf("issue message leaks a token: %q", issue.Message)
+		}
+	}
+}
+
+func TestFindSharedCredentials_NoneShared(t *testing.T) {
+	accounts := []*models.Account{
+		{Alias: "work", SSHKeyPath: "/home/dev/.ssh/id_work", GitHubUsername: "dev-work"},
+		{Alias: "personal", SSHKeyPath: "/home/dev/.ssh/id_personal", GitHubUsername: "dev"},
+	}
+	if issues := FindSharedCredentials(accounts, map[string]string{"work": "a", "personal": "b"}); len(issues) != 0 {
+		t.Errorf("FindSharedCredentials() = %+v, want none", issues)
+	}
+}
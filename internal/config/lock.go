@@ -0,0 +1,48 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/filelock"
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// DefaultLockTimeout is how long a Manager waits for another gitshift process to release
+// the config lock
+const DefaultLockTimeout = 5 * time.Second
+
+// lockFile returns the path of the advisory lock guarding the config file
+func (m *Manager) lockFile() string {
+	return m.ConfigFile() + ".lock"
+}
+
+// acquireLock takes the advisory lock on the config file, waiting up to the manager's lock
+// timeout or until ctx is done. The returned function releases it.
+func (m *Manager) acquireLock(ctx context.Context) (func(), error) {
+	if err := os.MkdirAll(m.configPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	timeout := m.lockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+
+	unlock, err := filelock.AcquireContext(ctx, m.lockFile(), timeout)
+	switch {
+	case errors.Is(err, filelock.ErrBusy):
+		return nil, models.NewUserError(
+			models.CategoryConfig,
+			fmt.Sprintf("timed out after %s waiting for the config lock %s", timeout, m.lockFile()),
+			"Another gitshift command is updating the configuration; wait for it to finish and try again",
+			err,
+		)
+	case err != nil:
+		return nil, fmt.Errorf("failed to lock config file: %w", err)
+	}
+	return unlock, nil
+}
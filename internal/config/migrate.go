@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// configMigrations upgrade a config one schema version at a time: configMigrations[i]
+// turns a version i config into a version i+1 config
+var configMigrations = []func(cfg *models.Config) error{
+	// 0 → 1: the version field replaces the config_version string, which was never read.
+	// Unmarshalling already dropped it, so there is nothing else to convert.
+	func(cfg *models.Config) error { return nil },
+}
+
+// migrateConfig upgrades cfg to models.CurrentConfigVersion and reports whether anything
+// changed. Configs written by a newer gitshift are rejected rather than downgraded.
+func migrateConfig(cfg *models.Config) (bool, error) {
+	if cfg.Version > models.CurrentConfigVersion {
+		return false, models.NewUserError(
+			models.CategoryConfig,
+			fmt.Sprintf("config file has schema version %d, this gitshift only supports up to %d", cfg.Version, models.CurrentConfigVersion),
+			"Upgrade gitshift to the version that wrote the configuration",
+			nil,
+		)
+	}
+	if cfg.Version < 0 {
+		return false, fmt.Errorf("config file has invalid schema version %d", cfg.Version)
+	}
+
+	migrated := false
+	for cfg.Version < models.CurrentConfigVersion {
+		if err := configMigrations[cfg.Version](cfg); err != nil {
+			return false, fmt.Errorf("failed to migrate config from version %d: %w", cfg.Version, err)
+		}
+		cfg.Version++
+		migrated = true
+	}
+	return migrated, nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// ProjectAccount returns the account pinned by the nearest project config, looking for
+// ProjectConfigName in dir and then in each parent directory, together with the path of
+// the file that pins it. Subdirectories of a monorepo can pin their own account this way.
+// It returns a nil account when no project config is found, and a project config error
+// when the nearest one can't be read or doesn't name a configured account.
+func (m *Manager) ProjectAccount(dir string) (*models.Account, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		projectConfig, err := m.LoadProjectConfig(dir)
+		switch {
+		case err == nil:
+			if projectConfig.Account == "" {
+				return nil, path, models.NewProjectConfigError(path, errors.New("no account set"))
+			}
+			account, err := m.GetAccount(projectConfig.Account)
+			if err != nil {
+				return nil, path, models.NewProjectConfigError(path, fmt.Errorf("account '%s' is not configured", projectConfig.Account))
+			}
+			return account, path, nil
+		case !errors.Is(err, models.ErrConfigNotFound):
+			return nil, path, models.NewProjectConfigError(path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestProjectAccount_NearestProjectConfigWins(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	for _, alias := range []string{"personal", "work"} {
+		if err := m.AddAccount(models.NewAccount(alias, "Dev", "dev@"+alias+".com", "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A monorepo pinned to one account with a subproject pinned to another
+	repo := t.TempDir()
+	subproject := filepath.Join(repo, "services", "billing")
+	nested := filepath.Join(subproject, "internal", "api")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeProjectConfig(t, repo, "account: personal\n")
+	writeProjectConfig(t, subproject, "account: work\n")
+
+	tests := []struct {
+		dir       string
+		wantAlias string
+		wantPath  string
+	}{
+		{dir: repo, wantAlias: "personal", wantPath: filepath.Join(repo, ProjectConfigName)},
+		{dir: filepath.Join(repo, "services"), wantAlias: "personal", wantPath: filepath.Join(repo, ProjectConfigName)},
+		{dir: subproject, wantAlias: "work", wantPath: filepath.Join(subproject, ProjectConfigName)},
+		{dir: nested, wantAlias: "work", wantPath: filepath.Join(subproject, ProjectConfigName)},
+	}
+	for _, tt := range tests {
+		account, path, err := m.ProjectAccount(tt.dir)
+		if err != nil {
+			t.Fatalf("ProjectAccount(%s) error = %v", tt.dir, err)
+		}
+		if account == nil || account.Alias != tt.wantAlias || path != tt.wantPath {
+			t.Errorf("ProjectAccount(%s) = %v, %s; want %s, %s", tt.dir, account, path, tt.wantAlias, tt.wantPath)
+		}
+	}
+
+	account, path, err := m.ProjectAccount(t.TempDir())
+	if err != nil || account != nil || path != "" {
+		t.Errorf("ProjectAccount() without a project config = %v, %q, %v; want nothing", account, path, err)
+	}
+}
+
+func TestProjectAccount_RejectsUnknownAccount(t *testing.T) {
+	m := newTestManager(t, t.TempDir())
+	if err := m.AddAccount(models.NewAccount("work", "Dev", "dev@work.com", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"account: wrok\n", "created_at: 2024-03-01T09:00:00Z\n"} {
+		dir := t.TempDir()
+		writeProjectConfig(t, dir, content)
+
+		_, path, err := m.ProjectAccount(dir)
+		var userErr *models.UserError
+		if !errors.As(err, &userErr) || !errors.Is(err, models.ErrProjectConfigInvalid) {
+			t.Errorf("ProjectAccount() with %q error = %v, want a project config error", content, err)
+		}
+		if path != filepath.Join(dir, ProjectConfigName) {
+			t.Errorf("ProjectAccount() with %q path = %q", content, path)
+		}
+	}
+}
+
+func writeProjectConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, ProjectConfigName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// accountStatuses and isolationLevels are the values the status and isolation_level of an
+// account may take; an empty value means the default
+var (
+	accountStatuses = []models.AccountStatus{
+		models.AccountStatusActive, models.AccountStatusPending, models.AccountStatusDisabled, models.AccountStatusIsolated,
+	}
+	isolationLevels = []models.IsolationLevel{
+		models.IsolationLevelNone, models.IsolationLevelBasic, models.IsolationLevelStandard,
+		models.IsolationLevelStrict, models.IsolationLevelComplete,
+	}
+)
+
+// SchemaProblem is a value in the config file that doesn't fit the schema
+type SchemaProblem struct {
+	Line    int    // 1-based line of the offending entry, 0 when unknown
+	Account string // map key of the account the problem is about, empty for file-level problems
+	Message string
+	Fixable bool // whether ClearExtraDefaults and config doctor --fix repair it
+}
+
+func (p SchemaProblem) String() string {
+	var b strings.Builder
+	if p.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", p.Line)
+	}
+	if p.Account != "" {
+		fmt.Fprintf(&b, "account '%s': ", p.Account)
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Validate checks the config file against the schema: the required fields of every
+// account, the values of status, isolation_level and platform, that no two entries share
+// an alias and that at most one account is the default. All problems are reported at
+// once, with their line in the file, in a CategoryConfig UserError. Load refuses a config
+// that fails these checks, except for extra defaults, which 'gitshift diagnose --fix' and
+// 'gitshift config doctor --fix' repair. Format rules that 'gitshift update' can correct, such as an
+// invalid email, are left to Account.Validate.
+func (m *Manager) Validate() error {
+	data, cfg, err := m.readConfigData()
+	if err != nil {
+		return err
+	}
+	return schemaError(m.ConfigFile(), validateSchema(data, cfg))
+}
+
+// schemaError consolidates problems into one UserError, or returns nil when there are none
+func schemaError(path string, problems []SchemaProblem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	lines := make([]string, len(problems))
+	for i, problem := range problems {
+		lines[i] = "  " + problem.String()
+	}
+	return models.NewUserError(
+		models.CategoryConfig,
+		path,
+		fmt.Sprintf("Correct the entries in %s; 'gitshift config doctor' lists them again", path),
+		fmt.Errorf("%w, %d problem(s):\n%s", models.ErrInvalidConfig, len(problems), strings.Join(lines, "\n")),
+	)
+}
+
+// validateSchema checks cfg, parsed from data, against the schema. data is parsed again
+// as a YAML node tree to find the line of each problem.
+func validateSchema(data []byte, cfg *models.Config) []SchemaProblem {
+	lines := newLineIndex(data)
+
+	var problems []SchemaProblem
+	add := func(account, field, message string) {
+		problems = append(problems, SchemaProblem{Line: lines.find(account, field), Account: account, Message: message})
+	}
+
+	keys := make([]string, 0, len(cfg.Accounts))
+	for key := range cfg.Accounts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	aliasOwners := make(map[string][]string)
+	var defaults []string
+	for _, key := range keys {
+		account := cfg.Accounts[key]
+		if account == nil {
+			continue
+		}
+		alias := account.Alias
+		if alias == "" {
+			alias = key
+		}
+		aliasOwners[alias] = append(aliasOwners[alias], key)
+		if account.IsDefault {
+			defaults = append(defaults, key)
+		}
+
+		// Accounts discovered from GitHub may only have a username so far
+		if account.GitHubUsername == "" {
+			if account.Name == "" {
+				add(key, "name", "name is required")
+			}
+			if account.Email == "" {
+				add(key, "email", "email is required")
+			}
+		}
+		if account.Status != "" && !isOneOf(account.Status, accountStatuses) {
+			add(key, "status", fmt.Sprintf("unknown status %q, expected %s", account.Status, listOf(accountStatuses)))
+		}
+		if account.IsolationLevel != "" && !isOneOf(account.IsolationLevel, isolationLevels) {
+			add(key, "isolation_level", fmt.Sprintf("unknown isolation_level %q, expected %s", account.IsolationLevel, listOf(isolationLevels)))
+		}
+		if account.Platform != "" && !models.IsKnownPlatform(account.Platform) {
+			add(key, "platform", fmt.Sprintf("unknown platform %q, expected %s", account.Platform, listOf(models.Platforms)))
+		}
+		if account.SSHKeyFingerprint != "" && !isSHA256Fingerprint(account.SSHKeyFingerprint) {
+			add(key, "ssh_key_fingerprint", fmt.Sprintf("ssh_key_fingerprint %q is not a SHA256 fingerprint as printed by ssh-add -l", account.SSHKeyFingerprint))
+		}
+	}
+
+	aliases := make([]string, 0, len(aliasOwners))
+	for alias, owners := range aliasOwners {
+		if len(owners) > 1 {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		owners := aliasOwners[alias]
+		for _, key := range owners[1:] {
+			add(key, "alias", fmt.Sprintf("alias '%s' is also used by the entry '%s'", alias, owners[0]))
+		}
+	}
+
+	if len(defaults) > 1 {
+		for _, key := range defaults[1:] {
+			add(key, "is_default", fmt.Sprintf("is_default is also set on '%s'; at most one account may be the default", defaults[0]))
+			problems[len(problems)-1].Fixable = true
+		}
+	}
+
+	return problems
+}
+
+// blockingProblems returns the problems that have to be corrected by hand before the config
+// can be loaded
+func blockingProblems(problems []SchemaProblem) []SchemaProblem {
+	var blocking []SchemaProblem
+	for _, problem := range problems {
+		if !problem.Fixable {
+			blocking = append(blocking, problem)
+		}
+	}
+	return blocking
+}
+
+// isSHA256Fingerprint reports whether fingerprint is a SHA256 key fingerprint, with or
+// without its "SHA256:" prefix
+func isSHA256Fingerprint(fingerprint string) bool {
+	sum, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(fingerprint, "SHA256:"))
+	return err == nil && len(sum) == sha256.Size
+}
+
+// isOneOf reports whether value is one of allowed
+func isOneOf[T ~string](value T, allowed []T) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// listOf renders values as "a, b or c"
+func listOf[T ~string](values []T) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = string(value)
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// lineIndex maps the accounts of a config file, and their fields, to their lines
+type lineIndex struct {
+	accounts map[string]*yaml.Node // account key node to the account's mapping node
+	keyLines map[string]int
+}
+
+func newLineIndex(data []byte) *lineIndex {
+	index := &lineIndex{accounts: make(map[string]*yaml.Node), keyLines: make(map[string]int)}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return index
+	}
+	accounts := mappingValue(doc.Content[0], "accounts")
+	if accounts == nil || accounts.Kind != yaml.MappingNode {
+		return index
+	}
+	for i := 0; i+1 < len(accounts.Content); i += 2 {
+		key := accounts.Content[i]
+		index.accounts[key.Value] = accounts.Content[i+1]
+		index.keyLines[key.Value] = key.Line
+	}
+	return index
+}
+
+// find returns the line of field in the account stored under key, or of the account
+// itself when it doesn't set field
+func (l *lineIndex) find(key, field string) int {
+	if field != "" {
+		if value := mappingValue(l.accounts[key], field); value != nil {
+			return value.Line
+		}
+	}
+	return l.keyLines[key]
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// writeTestConfig writes content as the config file of a manager under a fresh home
+func writeTestConfig(t *testing.T, content string) *Manager {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	m := NewManager()
+	if err := os.MkdirAll(m.configPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(m.ConfigFile(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestValidate_DuplicateAliases(t *testing.T) {
+	m := writeTestConfig(t, `version: 1
+accounts:
+  personal:
+    alias: personal
+    name: Dev
+    email: dev@home.org
+  work:
+    alias: personal
+    name: Dev
+    email: dev@work.com
+`)
+
+	err := m.Validate()
+	if !errors.Is(err, models.ErrInvalidConfig) {
+		t.Fatalf("Validate() error = %v, want ErrInvalidConfig", err)
+	}
+	if want := "line 8: account 'work': alias 'personal' is also used by the entry 'personal'"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Validate() error does not contain %q:\n%v", want, err)
+	}
+
+	// A key repeated in the file is rejected by the parser, which names the line
+	m = writeTestConfig(t, `version: 1
+accounts:
+  work:
+    name: Dev
+    email: dev@work.com
+  work:
+    name: Dev
+    email: dev@oss.org
+`)
+	if err := m.Validate(); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Validate() of a repeated key = %v, want an error naming line 3", err)
+	}
+}
+
+func TestValidate_InvalidIsolationLevel(t *testing.T) {
+	m := writeTestConfig(t, `version: 1
+accounts:
+  oss:
+    alias: oss
+    name: Dev
+    email: dev@oss.org
+    is_default: true
+  work:
+    alias: work
+    email: dev@work.com
+    status: activ
+    isolation_level: maximum
+    is_default: true
+`)
+
+	err := m.Validate()
+	var userErr *models.UserError
+	if !errors.As(err, &userErr) || userErr.Category != models.CategoryConfig {
+		t.Fatalf("Validate() error = %v, want a config UserError", err)
+	}
+	for _, want := range []string{
+		"invalid configuration format, 4 problem(s)",
+		"line 8: account 'work': name is required",
+		`line 11: account 'work': unknown status "activ", expected active, pending, disabled or isolated`,
+		`line 12: account 'work': unknown isolation_level "maximum", expected none, basic, standard, strict or complete`,
+		"line 13: account 'work': is_default is also set on 'oss'",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error does not contain %q:\n%v", want, err)
+		}
+	}
+
+	if err := m.Load(); !errors.Is(err, models.ErrInvalidConfig) {
+		t.Errorf("Load() error = %v, want the schema problems", err)
+	}
+	check, err := m.CheckConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(check.Issues) != 4 {
+		t.Errorf("CheckConfig() = %+v, want the 4 schema problems", check.Issues)
+	}
+}
+
+func TestValidate_SSHKeyFingerprint(t *testing.T) {
+	m := writeTestConfig(t, `version: 1
+accounts:
+  token:
+    alias: token
+    name: Dev
+    email: dev@work.com
+    ssh_key_fingerprint: SHA256:sBNGdLe3fTvJBZw/8HDxY/3B0iRQKzfJ7UMcD0DqQ4U
+  forwarded:
+    alias: forwarded
+    name: Dev
+    email: dev@oss.org
+    ssh_key_fingerprint: sBNGdLe3fTvJBZw/8HDxY/3B0iRQKzfJ7UMcD0DqQ4U
+  typo:
+    alias: typo
+    name: Dev
+    email: dev@home.org
+    ssh_key_fingerprint: MD5:16:27:ac:a5:76:28:2d:36
+`)
+
+	err := m.Validate()
+	if err == nil || !strings.Contains(err.Error(), "1 problem(s)") {
+		t.Fatalf("Validate() error = %v, want one problem", err)
+	}
+	if want := `line 17: account 'typo': ssh_key_fingerprint "MD5:16:27:ac:a5:76:28:2d:36" is not a SHA256 fingerprint`; !strings.Contains(err.Error(), want) {
+		t.Errorf("Validate() error does not contain %q:\n%v", want, err)
+	}
+}
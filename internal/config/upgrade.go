@@ -0,0 +1,256 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/token"
+	"gopkg.in/yaml.v3"
+)
+
+// agentDirName is the directory inside the config directory holding isolated agent sockets
+const agentDirName = "agents"
+
+// legacyAgentDir is where GitPersona kept the sockets of isolated agents, relative to the
+// home directory
+const legacyAgentDir = ".ssh/gitpersona"
+
+// AgentDir returns the directory holding the sockets of isolated SSH agents
+func (m *Manager) AgentDir() string {
+	return filepath.Join(m.configPath, agentDirName)
+}
+
+// AgentDirs returns AgentDir and the directory GitPersona used for agent sockets, where
+// sockets of crashed agents may still be left
+func (m *Manager) AgentDirs() []string {
+	return []string{m.AgentDir(), filepath.Join(m.homeDir, filepath.FromSlash(legacyAgentDir))}
+}
+
+// isolationRank orders the isolation levels from weakest to strongest
+var isolationRank = map[models.IsolationLevel]int{
+	models.IsolationLevelNone:     0,
+	models.IsolationLevelBasic:    1,
+	models.IsolationLevelStandard: 2,
+	models.IsolationLevelStrict:   3,
+	models.IsolationLevelComplete: 4,
+}
+
+// IsolationChange is one account setting changed by UpgradeIsolation
+type IsolationChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// IsolationUpgrade is the outcome of UpgradeIsolation. No changes means the account
+// already met the requested level.
+type IsolationUpgrade struct {
+	Alias   string
+	From    models.IsolationLevel
+	To      models.IsolationLevel
+	Changes []IsolationChange
+}
+
+// UpgradeIsolation raises an account to the given isolation level and provisions what the
+// level relies on:
+//   - basic: IdentitiesOnly for the account's SSH key
+//   - standard: encrypted token storage at a per-account path
+//
+// The strict and complete levels are refused: gitshift runs no isolated SSH agents or
+// containers, so their settings would be recorded without being enforced.
+//
+// The upgraded account is validated before it is saved; on failure nothing is saved and
+// directories created for it are removed again. Running it again is a no-op.
+func (m *Manager) UpgradeIsolation(alias string, level models.IsolationLevel) (*IsolationUpgrade, error) {
+	rank, known := isolationRank[level]
+	if !known {
+		return nil, fmt.Errorf("unknown isolation level '%s', expected basic or standard", level)
+	}
+	switch level {
+	case models.IsolationLevelStrict:
+		return nil, fmt.Errorf("isolation level '%s' is not applicable: gitshift doesn't run isolated SSH agents, so its settings would not be enforced; use 'standard'", level)
+	case models.IsolationLevelComplete:
+		return nil, fmt.Errorf("isolation level '%s' requires container support and can't be provisioned by upgrade", level)
+	}
+
+	account, err := m.GetAccount(alias)
+	if err != nil {
+		return nil, fmt.Errorf("account '%s' not found", alias)
+	}
+	current := account.GetIsolationLevel()
+	if isolationRank[current] > rank {
+		return nil, fmt.Errorf("account '%s' is already at isolation level '%s'; upgrade never lowers it", alias, current)
+	}
+
+	upgraded, err := cloneAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	result := &IsolationUpgrade{Alias: alias, From: current, To: level}
+	provisioner := &isolationProvisioner{account: upgraded, result: result}
+
+	if err := m.provisionIsolation(provisioner, level); err != nil {
+		provisioner.rollback()
+		return nil, err
+	}
+	if len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	if err := validateIsolation(upgraded, rank); err != nil {
+		provisioner.rollback()
+		return nil, fmt.Errorf("upgraded account failed validation, nothing was changed: %w", err)
+	}
+	if err := m.UpdateAccount(upgraded); err != nil {
+		provisioner.rollback()
+		return nil, err
+	}
+	return result, nil
+}
+
+// provisionIsolation applies the settings of every level up to the given one
+func (m *Manager) provisionIsolation(p *isolationProvisioner, level models.IsolationLevel) error {
+	account := p.account
+	rank := isolationRank[level]
+	if account.IsolationMetadata == nil {
+		account.IsolationMetadata = &models.IsolationMetadata{}
+	}
+	metadata := account.IsolationMetadata
+
+	if rank >= isolationRank[models.IsolationLevelBasic] {
+		if metadata.SSHIsolation == nil {
+			metadata.SSHIsolation = &models.SSHIsolationSettings{}
+		}
+		p.setBool("isolation_metadata.ssh_isolation.force_identities_only", &metadata.SSHIsolation.ForceIdentitiesOnly, true)
+	}
+
+	if rank >= isolationRank[models.IsolationLevelStandard] {
+		if metadata.TokenIsolation == nil {
+			metadata.TokenIsolation = &models.TokenIsolationSettings{}
+		}
+		store, err := m.TokenStore()
+		if err != nil {
+			return err
+		}
+		storagePath := token.BackendKeychain
+		if fileStore, ok := store.(*token.FileStore); ok {
+			storagePath = fileStore.Path(account.Alias)
+			if err := p.ensureDir(filepath.Dir(storagePath)); err != nil {
+				return err
+			}
+		}
+		p.setBool("isolation_metadata.token_isolation.use_encrypted_storage", &metadata.TokenIsolation.UseEncryptedStorage, true)
+		p.setString("isolation_metadata.token_isolation.storage_path", &metadata.TokenIsolation.StoragePath, storagePath)
+		p.setString("token_path", &account.TokenPath, storagePath)
+	}
+
+	isolationLevel := string(account.IsolationLevel)
+	p.setString("isolation_level", &isolationLevel, string(level))
+	account.IsolationLevel = models.IsolationLevel(isolationLevel)
+	return nil
+}
+
+// validateIsolation checks that an account has everything its isolation level relies on
+func validateIsolation(account *models.Account, rank int) error {
+	if err := account.Validate(); err != nil {
+		return err
+	}
+	if account.SSHKeyPath == "" {
+		return fmt.Errorf("isolation requires an SSH key; run 'gitshift ssh-keygen %s' first", account.Alias)
+	}
+	if _, err := pathutil.Stat(account.SSHKeyPath); err != nil {
+		return fmt.Errorf("SSH key %w", err)
+	}
+
+	if rank >= isolationRank[models.IsolationLevelStandard] {
+		storagePath := account.IsolationMetadata.TokenIsolation.StoragePath
+		if storagePath != token.BackendKeychain {
+			if err := checkPrivateDir(filepath.Dir(storagePath)); err != nil {
+				return err
+			}
+			if info, err := os.Stat(storagePath); err == nil && info.Mode().Perm()&0077 != 0 {
+				return fmt.Errorf("token file %s has permissions %04o; expected 0600", storagePath, info.Mode().Perm())
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPrivateDir checks that a directory exists and is only accessible by the user
+func checkPrivateDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("directory %s is missing: %w", dir, err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("directory %s has permissions %04o; expected 0700", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// isolationProvisioner records the changes made to an account and the directories created
+// for it, so a failed upgrade can be rolled back
+type isolationProvisioner struct {
+	account *models.Account
+	result  *IsolationUpgrade
+	created []string
+}
+
+func (p *isolationProvisioner) setString(field string, target *string, value string) {
+	if *target == value {
+		return
+	}
+	p.result.Changes = append(p.result.Changes, IsolationChange{Field: field, Old: *target, New: value})
+	*target = value
+}
+
+func (p *isolationProvisioner) setBool(field string, target *bool, value bool) {
+	if *target == value {
+		return
+	}
+	p.result.Changes = append(p.result.Changes, IsolationChange{Field: field, Old: strconv.FormatBool(*target), New: strconv.FormatBool(value)})
+	*target = value
+}
+
+// ensureDir creates a private directory, remembering it for rollback when it didn't exist
+func (p *isolationProvisioner) ensureDir(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	p.created = append(p.created, dir)
+	return nil
+}
+
+// rollback removes the directories created during provisioning, newest first. Only empty
+// directories are removed, so nothing placed there in the meantime is lost.
+func (p *isolationProvisioner) rollback() {
+	for i := len(p.created) - 1; i >= 0; i-- {
+		os.Remove(p.created[i])
+	}
+	p.created = nil
+}
+
+// cloneAccount returns a deep copy of the account, so an upgrade can be prepared without
+// touching the loaded configuration
+func cloneAccount(account *models.Account) (*models.Account, error) {
+	data, err := yaml.Marshal(account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy account: %w", err)
+	}
+	var clone models.Account
+	if err := yaml.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy account: %w", err)
+	}
+	return &clone, nil
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestUpgradeIsolation_StandardIsIdempotent(t *testing.T) {
+	home := t.TempDir()
+	m := newTestManager(t, home)
+
+	keyPath := filepath.Join(home, "id_work")
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	legacy := &models.Account{Alias: "work", Name: "Dev", Email: "dev@work.com", SSHKeyPath: keyPath, IsolationLevel: models.IsolationLevelNone}
+	if err := m.AddAccount(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	upgrade, err := m.UpgradeIsolation("work", models.IsolationLevelStandard)
+	if err != nil {
+		t.Fatalf("UpgradeIsolation() error = %v", err)
+	}
+	if len(upgrade.Changes) == 0 {
+		t.Fatal("UpgradeIsolation() reported no changes for a legacy account")
+	}
+
+	reloaded := NewManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	account, err := reloaded.GetAccount("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.IsolationLevel != models.IsolationLevelStandard || !account.RequiresTokenIsolation() {
+		t.Errorf("upgraded account = %+v, want standard isolation settings", account)
+	}
+	if info, err := os.Stat(filepath.Dir(account.TokenPath)); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("token directory not provisioned with 0700: %v", err)
+	}
+
+	again, err := reloaded.UpgradeIsolation("work", models.IsolationLevelStandard)
+	if err != nil {
+		t.Fatalf("second UpgradeIsolation() error = %v", err)
+	}
+	if len(again.Changes) != 0 {
+		t.Errorf("second UpgradeIsolation() changed %+v, want nothing", again.Changes)
+	}
+
+	if _, err := reloaded.UpgradeIsolation("work", models.IsolationLevelBasic); err == nil {
+		t.Error("UpgradeIsolation() lowered the isolation level")
+	}
+}
+
+func TestUpgradeIsolation_RefusesUnenforcedLevels(t *testing.T) {
+	home := t.TempDir()
+	m := newTestManager(t, home)
+
+	keyPath := filepath.Join(home, "id_work")
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddAccount(&models.Account{Alias: "work", Name: "Dev", Email: "dev@work.com", SSHKeyPath: keyPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, level := range []models.IsolationLevel{models.IsolationLevelStrict, models.IsolationLevelComplete} {
+		if _, err := m.UpgradeIsolation("work", level); err == nil {
+			t.Errorf("UpgradeIsolation(%s) succeeded, but nothing enforces its settings", level)
+		}
+	}
+	account, err := m.GetAccount("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.SSHSocketPath != "" || account.RequiresSSHIsolation() {
+		t.Errorf("refused upgrade recorded an isolated agent: %+v", account)
+	}
+	if _, err := os.Stat(m.AgentDir()); !os.IsNotExist(err) {
+		t.Errorf("refused upgrade created the agent directory: %v", err)
+	}
+}
+
+func TestUpgradeIsolation_RollsBackOnValidationFailure(t *testing.T) {
+	home := t.TempDir()
+	m := newTestManager(t, home)
+
+	legacy := &models.Account{Alias: "work", Name: "Dev", Email: "dev@work.com", SSHKeyPath: filepath.Join(home, "missing"), IsolationLevel: models.IsolationLevelNone}
+	if err := m.AddAccount(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.UpgradeIsolation("work", models.IsolationLevelStandard); err == nil {
+		t.Fatal("UpgradeIsolation() succeeded for an account without an SSH key")
+	}
+
+	account, err := m.GetAccount("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.IsolationLevel != models.IsolationLevelNone || account.TokenPath != "" {
+		t.Errorf("failed upgrade changed the account: %+v", account)
+	}
+	if _, err := os.Stat(filepath.Join(m.configPath, tokenDirName)); !os.IsNotExist(err) {
+		t.Errorf("token directory was not rolled back: %v", err)
+	}
+}
+
+func TestUpgradeIsolation_ExpandsHomeRelativeKeyPath(t *testing.T) {
+	// Paths written by hand are kept as written, so the key path must be expanded on use
+	m := writeTestConfig(t, `accounts:
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    ssh_key_path: ~/.ssh/id_work
+`)
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+
+	keyPath := filepath.Join(home, ".ssh", "id_work")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.UpgradeIsolation("work", models.IsolationLevelStandard); err != nil {
+		t.Fatalf("UpgradeIsolation() error = %v", err)
+	}
+}
@@ -1,15 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -22,6 +24,11 @@ type ConfigValidator struct {
 func NewConfigValidator() *ConfigValidator {
 	v := validator.New()
 
+	// The struct tags' email rule must agree with models.Account.Validate
+	if err := v.RegisterValidation("email", validateEmail); err != nil {
+		panic(fmt.Sprintf("failed to register email validator: %v", err))
+	}
+
 	// Register custom validators for 2025 security standards
 	if err := v.RegisterValidation("secure_email", validateSecureEmail); err != nil {
 		panic(fmt.Sprintf("failed to register secure_email validator: %v", err))
@@ -163,23 +170,16 @@ func (cv *ConfigValidator) validateEmailPolicy(email, alias string) error {
 
 // validateSSHKeyPolicy enforces SSH key security standards
 func (cv *ConfigValidator) validateSSHKeyPolicy(keyPath, alias string) error {
-	// Expand home directory
-	if strings.HasPrefix(keyPath, "~/") {
-		homeDir, _ := os.UserHomeDir()
-		keyPath = filepath.Join(homeDir, keyPath[2:])
-	}
-
 	// Check key exists
-	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-		return fmt.Errorf("SSH key '%s' for account '%s' does not exist", keyPath, alias)
-	}
-
-	// Check key permissions (must be 600)
-	info, err := os.Stat(keyPath)
-	if err != nil {
+	info, err := pathutil.Stat(keyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("SSH key for account '%s': %w", alias, err)
+	} else if err != nil {
 		return err
 	}
+	keyPath = pathutil.Expand(keyPath)
 
+	// Check key permissions (must be 600)
 	mode := info.Mode()
 	if mode.Perm() != 0600 {
 		return fmt.Errorf("SSH key '%s' has insecure permissions %o, should be 0600", keyPath, mode.Perm())
@@ -211,7 +211,7 @@ func (cv *ConfigValidator) validateKeyStrength(publicKeyPath string) error {
 	keyType := publicKey.Type()
 
 	switch keyType {
-	case "ssh-ed25519":
+	case "ssh-ed25519", "sk-ssh-ed25519@openssh.com":
 		return nil // Perfect for 2025
 	case "ssh-rsa":
 		// RSA must be at least 3072 bits in 2025 (4096 recommended)
@@ -224,6 +224,8 @@ func (cv *ConfigValidator) validateKeyStrength(publicKeyPath string) error {
 		return fmt.Errorf("NIST P-256 curves deprecated in 2025 due to quantum concerns")
 	case "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp521":
 		return nil // Acceptable for 2025
+	case "sk-ecdsa-sha2-nistp256@openssh.com":
+		return nil // Hardware-backed, the private key never leaves the authenticator
 	default:
 		return fmt.Errorf("unsupported key type '%s' for 2025 security standards", keyType)
 	}
@@ -243,13 +245,16 @@ func (cv *ConfigValidator) validateAccountAge(account *models.Account) error {
 
 // Custom validators for 2025 standards
 
+// validateEmail applies models.ValidateEmail to the struct tags' email rule
+func validateEmail(fl validator.FieldLevel) bool {
+	return models.ValidateEmail(fl.Field().String()) == nil
+}
+
 // validateSecureEmail validates email format and security requirements
 func validateSecureEmail(fl validator.FieldLevel) bool {
 	email := fl.Field().String()
 
-	// Basic email validation
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(email) {
+	if models.ValidateEmail(email) != nil {
 		return false
 	}
 
@@ -270,23 +275,7 @@ func validateSecureEmail(fl validator.FieldLevel) bool {
 
 // validateGitHubUsername validates GitHub username format
 func validateGitHubUsername(fl validator.FieldLevel) bool {
-	username := fl.Field().String()
-
-	// GitHub username rules (2025)
-	if len(username) > 39 || len(username) < 1 {
-		return false
-	}
-
-	if strings.HasPrefix(username, "-") || strings.HasSuffix(username, "-") {
-		return false
-	}
-
-	if strings.Contains(username, "--") {
-		return false
-	}
-
-	usernameRegex := regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
-	return usernameRegex.MatchString(username)
+	return models.ValidateUsername("github", fl.Field().String()) == nil
 }
 
 // validateSSHKeyPath validates SSH key path format
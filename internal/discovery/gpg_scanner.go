@@ -212,15 +212,18 @@ func (s *GPGScanner) createAccountFromGPGKey(key GPGKeyInfo) *DiscoveredAccount
 
 	// Determine confidence based on key information
 	confidence := 7 // Base confidence for GPG keys
+	reasons := []string{"GPG key has email"}
 
 	// Higher confidence if key is recent (within last 2 years)
 	if time.Since(key.CreatedAt) < 2*365*24*time.Hour {
 		confidence = 8
+		reasons = append(reasons, "GPG key created within the last 2 years")
 	}
 
 	// Lower confidence if key is expired
 	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
 		confidence = 5
+		reasons = append(reasons, "GPG key is expired")
 	}
 
 	status := "active"
@@ -254,8 +257,9 @@ func (s *GPGScanner) createAccountFromGPGKey(key GPGKeyInfo) *DiscoveredAccount
 			Platform:           platform,
 			Description:        "Discovered from GPG keyring",
 		},
-		Source:     "gpg",
-		Confidence: confidence,
+		Source:            "gpg",
+		Confidence:        confidence,
+		ConfidenceReasons: reasons,
 	}
 }
 
@@ -299,7 +303,7 @@ func (s *GPGScanner) detectPlatformFromEmail(email string) string {
 func (s *GPGScanner) parseUID(uid string) (name, email string) {
 	// Extract email using regex
 	emailRegex := regexp.MustCompile(`<([^>]+)>`)
-	if matches := emailRegex.FindStringSubmatch(uid); len(matches) >= 2 {
+	if matches := emailRegex.FindStringSubmatch(uid); len(matches) >= 2 && models.ValidateEmail(matches[1]) == nil {
 		email = matches[1]
 	}
 
@@ -2,9 +2,11 @@ package discovery
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/pkg/platform"
 )
 
 // AccountDiscovery handles automatic detection of existing Git accounts
@@ -20,9 +22,21 @@ func NewAccountDiscovery() *AccountDiscovery {
 // DiscoveredAccount represents an account found during discovery
 type DiscoveredAccount struct {
 	*models.Account
-	Source      string // where it was found ("ssh", "gpg", "ssh+gpg")
-	Confidence  int    // confidence level (1-10)
-	Conflicting bool   // if there are conflicting accounts
+	Source            string   // where it was found ("ssh", "gpg", "ssh+gpg")
+	Confidence        int      // confidence level (1-10)
+	ConfidenceReasons []string // signals that contributed to the confidence level
+	Conflicting       bool     // if there are conflicting accounts
+}
+
+// FilterByConfidence returns the accounts whose confidence is at least minConfidence
+func FilterByConfidence(accounts []*DiscoveredAccount, minConfidence int) []*DiscoveredAccount {
+	var filtered []*DiscoveredAccount
+	for _, account := range accounts {
+		if account.Confidence >= minConfidence {
+			filtered = append(filtered, account)
+		}
+	}
+	return filtered
 }
 
 // ScanExistingAccounts scans for existing SSH keys and GPG keys
@@ -38,6 +52,15 @@ func (d *AccountDiscovery) ScanExistingAccounts() ([]*DiscoveredAccount, error)
 	}
 	fmt.Println()
 
+	// Scan SSH config hosts, unifying them with the keys found above
+	configScanner := NewSSHConfigScanner()
+	hostAccounts, err := configScanner.ScanSSHConfig()
+	if err != nil {
+		return nil, fmt.Errorf("SSH config scan failed: %w", err)
+	}
+	sshAccounts = mergeByIdentity(append(sshAccounts, hostAccounts...))
+	fmt.Println()
+
 	// Scan GPG keys
 	gpgScanner := NewGPGScanner()
 	gpgAccounts, err := gpgScanner.ScanGPGKeys()
@@ -46,13 +69,119 @@ func (d *AccountDiscovery) ScanExistingAccounts() ([]*DiscoveredAccount, error)
 	}
 	fmt.Println()
 
-	// Merge accounts by email
-	merged := d.mergeAccounts(sshAccounts, gpgAccounts)
+	// Merge accounts by email, then by SSH key and GitHub username
+	merged := mergeByIdentity(d.mergeAccounts(sshAccounts, gpgAccounts))
 
 	fmt.Printf("🎯 Discovery complete: %d account(s) found\n", len(merged))
 	return merged, nil
 }
 
+// mergeByIdentity unifies accounts that share the same SSH key or GitHub username, which
+// are different discoveries of the same identity. Matches are transitive: an account that
+// shares a key with one discovery and a username with another joins all three.
+func mergeByIdentity(accounts []*DiscoveredAccount) []*DiscoveredAccount {
+	var result []*DiscoveredAccount
+
+	for _, account := range accounts {
+		result = append(result, account)
+
+		// A merge can give the account the key or username of another earlier discovery,
+		// so keep folding matches into it until none is left
+		for i := len(result) - 1; ; {
+			j := matchingIdentity(result, i)
+			if j < 0 {
+				break
+			}
+			earlier, later := j, i
+			if i < j {
+				earlier, later = i, j
+			}
+			fmt.Printf("🔗 Merged %s discovery into %s\n", result[later].Source, result[earlier].Alias)
+			result[earlier] = mergeDuplicateAccounts(result[earlier], result[later])
+			result = append(result[:later], result[later+1:]...)
+			i = earlier
+		}
+	}
+
+	return result
+}
+
+// matchingIdentity returns the index of another account with the same identity as
+// accounts[i], or -1 when there is none
+func matchingIdentity(accounts []*DiscoveredAccount, i int) int {
+	for j, other := range accounts {
+		if j != i && sameIdentity(other, accounts[i]) {
+			return j
+		}
+	}
+	return -1
+}
+
+// sameIdentity reports whether two discovered accounts use the same SSH key or GitHub username
+func sameIdentity(a, b *DiscoveredAccount) bool {
+	if a.SSHKeyPath != "" && b.SSHKeyPath != "" && filepath.Clean(a.SSHKeyPath) == filepath.Clean(b.SSHKeyPath) {
+		return true
+	}
+	return a.GitHubUsername != "" && strings.EqualFold(a.GitHubUsername, b.GitHubUsername)
+}
+
+// mergeDuplicateAccounts combines two discoveries of the same identity. Fields set by the
+// higher-confidence discovery win; empty fields are filled in from the other one.
+func mergeDuplicateAccounts(a, b *DiscoveredAccount) *DiscoveredAccount {
+	primary, secondary := a, b
+	if b.Confidence > a.Confidence {
+		primary, secondary = b, a
+	}
+
+	account := *primary.Account
+	fillEmpty(&account.Name, secondary.Name)
+	fillEmpty(&account.Email, secondary.Email)
+	fillEmpty(&account.GitHubUsername, secondary.GitHubUsername)
+	fillEmpty(&account.SSHKeyPath, secondary.SSHKeyPath)
+
+	// Platform and Domain are taken together. A discovery that knows the host beats one
+	// whose platform was only guessed from the email.
+	if account.Domain == "" && secondary.Domain != "" {
+		account.Platform = secondary.Platform
+		account.Domain = secondary.Domain
+	}
+	fillEmpty(&account.Platform, secondary.Platform)
+
+	if account.GPGKeyID == "" && secondary.GPGKeyID != "" {
+		account.GPGKeyID = secondary.GPGKeyID
+		account.GPGKeyFingerprint = secondary.GPGKeyFingerprint
+		account.GPGKeyType = secondary.GPGKeyType
+		account.GPGKeySize = secondary.GPGKeySize
+		account.GPGKeyExpiry = secondary.GPGKeyExpiry
+		account.GPGEnabled = secondary.GPGEnabled
+	}
+
+	return &DiscoveredAccount{
+		Account:           &account,
+		Source:            joinSources(primary.Source, secondary.Source),
+		Confidence:        max(primary.Confidence, secondary.Confidence),
+		ConfidenceReasons: mergeReasons(primary.ConfidenceReasons, secondary.ConfidenceReasons),
+		Conflicting:       primary.Conflicting || secondary.Conflicting,
+	}
+}
+
+// fillEmpty sets *field to value when the field is empty
+func fillEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// joinSources concatenates discovery sources such as "ssh" and "ssh-config+gpg" into
+// "ssh+ssh-config+gpg", dropping duplicates
+func joinSources(sources ...string) string {
+	var parts []string
+	for _, source := range sources {
+		parts = append(parts, strings.Split(source, "+")...)
+	}
+	return strings.Join(mergeReasons(parts), "+")
+}
+
 // mergeAccounts merges SSH and GPG discovered accounts by matching email addresses
 func (d *AccountDiscovery) mergeAccounts(sshAccounts, gpgAccounts []*DiscoveredAccount) []*DiscoveredAccount {
 	var result []*DiscoveredAccount
@@ -111,11 +240,21 @@ func (d *AccountDiscovery) mergeSingleAccount(sshAcc, gpgAcc *DiscoveredAccount)
 			GPGKeySize:         gpgAcc.GPGKeySize,
 			GPGKeyExpiry:       gpgAcc.GPGKeyExpiry,
 			GPGEnabled:         true,
-			Platform:           detectPlatform(sshAcc.Email),
+			Platform:           sshAcc.Platform,
+			Domain:             sshAcc.Domain,
 			Description:        "Discovered from SSH key and GPG keyring",
 		},
-		Source:     "ssh+gpg",
-		Confidence: max(sshAcc.Confidence, gpgAcc.Confidence) + 1, // Bonus for having both
+		Source:            "ssh+gpg",
+		Confidence:        max(sshAcc.Confidence, gpgAcc.Confidence) + 1, // Bonus for having both
+		ConfidenceReasons: mergeReasons(sshAcc.ConfidenceReasons, gpgAcc.ConfidenceReasons, []string{"matched SSH and GPG keys by email"}),
+	}
+
+	if merged.Platform == "" {
+		if merged.Domain != "" {
+			merged.Platform = platform.DetectPlatformFromDomain(merged.Domain).String()
+		} else {
+			merged.Platform = detectPlatform(sshAcc.Email)
+		}
 	}
 
 	// Use GPG name if it's more complete than SSH name
@@ -126,6 +265,21 @@ func (d *AccountDiscovery) mergeSingleAccount(sshAcc, gpgAcc *DiscoveredAccount)
 	return merged
 }
 
+// mergeReasons combines confidence reasons, dropping duplicates while keeping their order
+func mergeReasons(reasonSets ...[]string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, reasons := range reasonSets {
+		for _, reason := range reasons {
+			if !seen[reason] {
+				seen[reason] = true
+				merged = append(merged, reason)
+			}
+		}
+	}
+	return merged
+}
+
 // detectPlatform attempts to detect the Git platform from email domain
 func detectPlatform(email string) string {
 	if email == "" {
@@ -0,0 +1,129 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestMergeSingleAccount_CombinesReasons(t *testing.T) {
+	sshAcc := &DiscoveredAccount{
+		Account:           &models.Account{Alias: "work", Email: "dev@gmail.com", SSHKeyPath: "/home/dev/.ssh/id_ed25519_work"},
+		Source:            "ssh",
+		Confidence:        8,
+		ConfidenceReasons: []string{"SSH key comment has email"},
+	}
+	gpgAcc := &DiscoveredAccount{
+		Account:           &models.Account{Alias: "dev", Email: "dev@gmail.com", GPGKeyID: "ABCDEF"},
+		Source:            "gpg",
+		Confidence:        7,
+		ConfidenceReasons: []string{"GPG key has email", "SSH key comment has email"},
+	}
+
+	merged := NewAccountDiscovery().mergeSingleAccount(sshAcc, gpgAcc)
+
+	want := []string{"SSH key comment has email", "GPG key has email", "matched SSH and GPG keys by email"}
+	if !reflect.DeepEqual(merged.ConfidenceReasons, want) {
+		t.Errorf("ConfidenceReasons = %v, want %v", merged.ConfidenceReasons, want)
+	}
+	if merged.Confidence != 9 {
+		t.Errorf("Confidence = %d, want 9", merged.Confidence)
+	}
+}
+
+func TestFilterByConfidence(t *testing.T) {
+	accounts := []*DiscoveredAccount{
+		{Account: &models.Account{Alias: "low"}, Confidence: 5},
+		{Account: &models.Account{Alias: "high"}, Confidence: 9},
+	}
+
+	filtered := FilterByConfidence(accounts, 8)
+	if len(filtered) != 1 || filtered[0].Alias != "high" {
+		t.Errorf("FilterByConfidence(8) = %v, want only the high confidence account", filtered)
+	}
+}
+
+func TestMergeByIdentity_KeyPathAndEmailOnly(t *testing.T) {
+	hostAcc := &DiscoveredAccount{
+		Account: &models.Account{
+			Alias:      "work",
+			SSHKeyPath: "/home/dev/.ssh/id_ed25519_work",
+			Platform:   "gitlab",
+			Domain:     "gitlab.com",
+		},
+		Source:            "ssh-config",
+		Confidence:        7,
+		ConfidenceReasons: []string{"matched SSH key to host alias gitlab-work"},
+	}
+	keyAcc := &DiscoveredAccount{
+		Account: &models.Account{
+			Alias:      "dev",
+			Name:       "Dev",
+			Email:      "dev@example.com",
+			SSHKeyPath: "/home/dev/.ssh/../.ssh/id_ed25519_work",
+			Platform:   "github", // guessed from the email by the SSH key scanner
+		},
+		Source:            "ssh",
+		Confidence:        8,
+		ConfidenceReasons: []string{"SSH key comment has email"},
+	}
+	other := &DiscoveredAccount{
+		Account:    &models.Account{Alias: "personal", SSHKeyPath: "/home/dev/.ssh/id_ed25519"},
+		Source:     "ssh",
+		Confidence: 8,
+	}
+
+	merged := mergeByIdentity([]*DiscoveredAccount{hostAcc, keyAcc, other})
+	if len(merged) != 2 {
+		t.Fatalf("mergeByIdentity() returned %d accounts, want 2", len(merged))
+	}
+
+	account := merged[0]
+	if account.Alias != "dev" || account.Email != "dev@example.com" {
+		t.Errorf("merged account = %s <%s>, want the higher-confidence fields", account.Alias, account.Email)
+	}
+	if account.Platform != "gitlab" || account.Domain != "gitlab.com" {
+		t.Errorf("merged platform = %s (%s), want gitlab (gitlab.com)", account.Platform, account.Domain)
+	}
+	if account.Source != "ssh+ssh-config" {
+		t.Errorf("Source = %q, want %q", account.Source, "ssh+ssh-config")
+	}
+	if account.Confidence != 8 || len(account.ConfidenceReasons) != 2 {
+		t.Errorf("Confidence = %d with reasons %v, want 8 with both reasons", account.Confidence, account.ConfidenceReasons)
+	}
+}
+
+func TestMergeByIdentity_GitHubUsername(t *testing.T) {
+	accounts := []*DiscoveredAccount{
+		{Account: &models.Account{Alias: "a", GitHubUsername: "Octocat", SSHKeyPath: "/k1"}, Source: "ssh", Confidence: 9},
+		{Account: &models.Account{Alias: "b", GitHubUsername: "octocat", Email: "octo@example.com"}, Source: "gpg", Confidence: 7},
+	}
+
+	merged := mergeByIdentity(accounts)
+	if len(merged) != 1 {
+		t.Fatalf("mergeByIdentity() returned %d accounts, want 1", len(merged))
+	}
+	if merged[0].Email != "octo@example.com" || merged[0].SSHKeyPath != "/k1" || merged[0].Source != "ssh+gpg" {
+		t.Errorf("merged account = %+v", merged[0].Account)
+	}
+}
+
+func TestMergeByIdentity_Transitive(t *testing.T) {
+	// The last discovery shares a key with the first and a username with the second, which
+	// don't match each other
+	accounts := []*DiscoveredAccount{
+		{Account: &models.Account{Alias: "a", SSHKeyPath: "/k1"}, Source: "ssh", Confidence: 6},
+		{Account: &models.Account{Alias: "b", GitHubUsername: "octocat", Email: "octo@example.com"}, Source: "gpg", Confidence: 5},
+		{Account: &models.Account{Alias: "c", GitHubUsername: "octocat", SSHKeyPath: "/k1"}, Source: "ssh-config", Confidence: 7},
+	}
+
+	merged := mergeByIdentity(accounts)
+	if len(merged) != 1 {
+		t.Fatalf("mergeByIdentity() returned %d accounts, want 1", len(merged))
+	}
+	got := merged[0]
+	if got.Alias != "c" || got.Email != "octo@example.com" || got.SSHKeyPath != "/k1" || got.Source != "ssh-config+ssh+gpg" {
+		t.Errorf("merged account = %+v from %s", got.Account, got.Source)
+	}
+}
@@ -0,0 +1,211 @@
+package discovery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/sshconfig"
+	"github.com/techishthoughts/gitshift/pkg/platform"
+)
+
+// SSHHost represents a Host block found in the SSH config
+type SSHHost struct {
+	Alias        string // first non-wildcard pattern of the Host line
+	HostName     string
+	User         string
+	IdentityFile string
+	Platform     string // github, gitlab, bitbucket or custom; empty when not a Git host
+	Domain       string // platform domain, i.e. the lowercased HostName
+	IsGitHub     bool
+	SourceFile   string // config file the block was read from
+}
+
+// selfHostedPrefixes are hostname prefixes commonly used for self-hosted Git servers
+var selfHostedPrefixes = []string{"git.", "git-", "scm.", "code.", "source.", "forge."}
+
+// detectPlatform fills in Platform, Domain and IsGitHub from the host's HostName. Known
+// platforms are recognized by domain (including Enterprise and self-hosted domains such as
+// gitlab.company.com); other hosts count as custom Git hosts when they log in as the git
+// user or use a typical self-hosted Git hostname.
+func (h *SSHHost) detectPlatform() {
+	h.Domain = strings.ToLower(h.HostName)
+
+	platformType := platform.DetectPlatformFromDomain(h.Domain)
+	switch {
+	case platformType != platform.TypeCustom:
+		h.Platform = platformType.String()
+	case h.User == "git":
+		h.Platform = platformType.String()
+	default:
+		for _, prefix := range selfHostedPrefixes {
+			if strings.HasPrefix(h.Domain, prefix) {
+				h.Platform = platformType.String()
+				break
+			}
+		}
+	}
+
+	h.IsGitHub = h.Platform == platform.TypeGitHub.String()
+}
+
+// SSHConfigScanner discovers accounts from Host blocks in ~/.ssh/config and the files it
+// includes
+type SSHConfigScanner struct {
+	homeDir string
+}
+
+// NewSSHConfigScanner creates a new SSH config scanner
+func NewSSHConfigScanner() *SSHConfigScanner {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		panic(fmt.Sprintf("failed to get user home directory: %v", err))
+	}
+
+	return &SSHConfigScanner{
+		homeDir: homeDir,
+	}
+}
+
+// ScanSSHConfig scans the SSH config for Git hosts (GitHub, GitLab, Bitbucket and
+// self-hosted servers) with a dedicated identity file
+func (s *SSHConfigScanner) ScanSSHConfig() ([]*DiscoveredAccount, error) {
+	var discovered []*DiscoveredAccount
+
+	fmt.Println("🔍 Scanning SSH config for Git hosts...")
+
+	configPath := filepath.Join(s.homeDir, ".ssh", "config")
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return discovered, nil
+	}
+
+	hosts, err := s.parseSSHHosts(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH config: %w", err)
+	}
+
+	for _, host := range hosts {
+		if host.Platform == "" || host.IdentityFile == "" {
+			continue
+		}
+
+		account := s.createAccountFromHost(host)
+		if account != nil {
+			discovered = append(discovered, account)
+		}
+	}
+
+	fmt.Printf("✅ Found %d Git host(s) in SSH config\n", len(discovered))
+	return discovered, nil
+}
+
+// createAccountFromHost creates a discovered account from an SSH config host block
+func (s *SSHConfigScanner) createAccountFromHost(host SSHHost) *DiscoveredAccount {
+	if _, err := os.Stat(host.IdentityFile); err != nil {
+		fmt.Printf("⚠️  Skipping SSH host %s (identity file %s not found)\n", host.Alias, host.IdentityFile)
+		return nil
+	}
+
+	keyScanner := &SSHOnlyScanner{homeDir: s.homeDir}
+	email := keyScanner.extractEmailFromPublicKey(host.IdentityFile + ".pub")
+
+	alias := aliasFromHost(host.Alias, host.Domain, host.Platform)
+	if strings.EqualFold(alias, host.HostName) {
+		// A block for the bare domain carries no account name; fall back to the key file name
+		alias = keyScanner.extractUsernameFromKeyFilename(filepath.Base(host.IdentityFile))
+		if alias == "" {
+			alias = host.Platform
+		}
+	}
+	confidence := 7 // A host block with its own key is a deliberate account setup
+	reasons := []string{"matched SSH key to host alias " + host.Alias}
+	if email != "" {
+		confidence = 8
+		reasons = append(reasons, "SSH key comment has email")
+	}
+
+	fmt.Printf("🔑 Found SSH host: %s -> %s (%s)\n", host.Alias, host.IdentityFile, host.Platform)
+
+	return &DiscoveredAccount{
+		Account: &models.Account{
+			Alias:       alias,
+			Name:        keyScanner.generateNameFromEmail(email),
+			Email:       email,
+			SSHKeyPath:  host.IdentityFile,
+			Platform:    host.Platform,
+			Domain:      host.Domain,
+			Description: fmt.Sprintf("Discovered from SSH config host %s", host.Alias),
+		},
+		Source:            "ssh-config",
+		Confidence:        confidence,
+		ConfidenceReasons: reasons,
+	}
+}
+
+// parseSSHHosts parses the Host blocks of an SSH config file, following Include directives
+func (s *SSHConfigScanner) parseSSHHosts(configPath string) ([]SSHHost, error) {
+	blocks, err := sshconfig.Load(configPath, s.homeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []SSHHost
+	for _, block := range blocks {
+		if block.IsMatch() {
+			continue
+		}
+		host := SSHHost{
+			Alias:      firstConcretePattern(block.Patterns),
+			HostName:   block.Value("hostname"),
+			User:       block.Value("user"),
+			SourceFile: block.SourceFile,
+		}
+		if host.Alias == "" {
+			continue
+		}
+		if host.HostName == "" {
+			host.HostName = host.Alias
+		}
+		// ssh uses the first IdentityFile it finds for a host
+		if identityFile := block.Value("identityfile"); identityFile != "" {
+			host.IdentityFile = sshconfig.ExpandPath(identityFile, s.homeDir)
+		}
+		host.detectPlatform()
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// firstConcretePattern returns the first Host pattern without wildcards or negation
+func firstConcretePattern(patterns []string) string {
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?!") {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// aliasFromHost derives an account alias from a host alias such as github-work,
+// gitlab.com-work, gl-work or work-gitlab
+func aliasFromHost(host, domain, platformName string) string {
+	shortNames := map[string]string{"github": "gh-", "gitlab": "gl-", "bitbucket": "bb-"}
+
+	prefixes := []string{domain + "-", platformName + "-"}
+	if short, ok := shortNames[platformName]; ok {
+		prefixes = append(prefixes, short)
+	}
+	for _, prefix := range prefixes {
+		if prefix != "-" && strings.HasPrefix(host, prefix) && len(host) > len(prefix) {
+			return strings.TrimPrefix(host, prefix)
+		}
+	}
+
+	suffix := "-" + platformName
+	if platformName != "" && strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+		return strings.TrimSuffix(host, suffix)
+	}
+	return host
+}
@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestSSHConfigScanner_ParseSSHHostsFollowsIncludes(t *testing.T) {
+	homeDir := t.TempDir()
+	sshDir := filepath.Join(homeDir, ".ssh")
+	scanner := &SSHConfigScanner{homeDir: homeDir}
+
+	writeTestFile(t, filepath.Join(sshDir, "config"), `Include config.d/*
+Include ~/.ssh/config
+
+Host personal.example.com
+    HostName example.com
+    User me
+`)
+	// The included file points back at the top-level config to exercise the cycle guard
+	writeTestFile(t, filepath.Join(sshDir, "config.d", "work"), `Include ../config
+
+Host github-work
+    HostName github.com
+    User git
+    IdentityFile ~/.ssh/id_ed25519_work
+    IdentityFile ~/.ssh/id_ignored
+`)
+
+	hosts, err := scanner.parseSSHHosts(filepath.Join(sshDir, "config"))
+	if err != nil {
+		t.Fatalf("parseSSHHosts() error = %v", err)
+	}
+
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d: %+v", len(hosts), hosts)
+	}
+
+	byAlias := make(map[string]SSHHost)
+	for _, host := range hosts {
+		byAlias[host.Alias] = host
+	}
+
+	work, ok := byAlias["github-work"]
+	if !ok {
+		t.Fatalf("github-work from the included file was not discovered: %+v", hosts)
+	}
+	if !work.IsGitHub {
+		t.Errorf("github-work should be detected as a GitHub host")
+	}
+	if want := filepath.Join(sshDir, "id_ed25519_work"); work.IdentityFile != want {
+		t.Errorf("IdentityFile = %s, want %s", work.IdentityFile, want)
+	}
+	if want := filepath.Join(sshDir, "config.d", "work"); work.SourceFile != want {
+		t.Errorf("SourceFile = %s, want %s", work.SourceFile, want)
+	}
+
+	if personal := byAlias["personal.example.com"]; personal.IsGitHub {
+		t.Errorf("personal.example.com should not be detected as a GitHub host")
+	}
+}
+
+func TestSSHHost_DetectPlatform(t *testing.T) {
+	tests := []struct {
+		host     SSHHost
+		platform string
+	}{
+		{SSHHost{HostName: "github.com"}, "github"},
+		{SSHHost{HostName: "github.company.com"}, "github"},
+		{SSHHost{HostName: "GitLab.com"}, "gitlab"},
+		{SSHHost{HostName: "gitlab.internal.example"}, "gitlab"},
+		{SSHHost{HostName: "bitbucket.org"}, "bitbucket"},
+		{SSHHost{HostName: "git.example.com"}, "custom"},
+		{SSHHost{HostName: "forge.example.com", User: "git"}, "custom"},
+		{SSHHost{HostName: "build01.example.com", User: "deploy"}, ""},
+	}
+
+	for _, tt := range tests {
+		host := tt.host
+		host.detectPlatform()
+		if host.Platform != tt.platform {
+			t.Errorf("detectPlatform(%s) = %q, want %q", tt.host.HostName, host.Platform, tt.platform)
+		}
+		if host.IsGitHub != (tt.platform == "github") {
+			t.Errorf("detectPlatform(%s) IsGitHub = %v", tt.host.HostName, host.IsGitHub)
+		}
+	}
+}
+
+func TestAliasFromHost(t *testing.T) {
+	tests := []struct {
+		host, domain, platform, want string
+	}{
+		{"github-work", "github.com", "github", "work"},
+		{"github.com-work", "github.com", "github", "work"},
+		{"oss-github", "github.com", "github", "oss"},
+		{"gl-client", "gitlab.com", "gitlab", "client"},
+		{"gitlab.company.com-work", "gitlab.company.com", "gitlab", "work"},
+		{"github.com", "github.com", "github", "github.com"},
+	}
+
+	for _, tt := range tests {
+		if got := aliasFromHost(tt.host, tt.domain, tt.platform); got != tt.want {
+			t.Errorf("aliasFromHost(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
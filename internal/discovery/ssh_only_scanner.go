@@ -10,6 +10,7 @@ import (
 	"golang.org/x/text/language"
 
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/ssh"
 )
 
 // SSHOnlyScanner handles SSH-only discovery without any GitHub API/CLI integration
@@ -35,31 +36,15 @@ func (s *SSHOnlyScanner) ScanSSHKeys() ([]*DiscoveredAccount, error) {
 
 	fmt.Println("🔍 Scanning SSH keys in ~/.ssh directory (SSH-only mode)...")
 
-	sshDir := filepath.Join(s.homeDir, ".ssh")
-	if _, err := os.Stat(sshDir); os.IsNotExist(err) {
-		return discovered, nil
-	}
-
-	entries, err := os.ReadDir(sshDir)
+	// Keys are recognised by content, so keys not named id_* are found too
+	keyPaths, err := ssh.FindKeyPairs(filepath.Join(s.homeDir, ".ssh"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read SSH directory: %w", err)
+		return nil, err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "id_") || strings.HasSuffix(entry.Name(), ".pub") {
-			continue
-		}
-
-		keyPath := filepath.Join(sshDir, entry.Name())
-		pubKeyPath := keyPath + ".pub"
-
-		// Only process if both private and public key exist
-		if _, err := os.Stat(pubKeyPath); os.IsNotExist(err) {
-			continue
-		}
-
+	for _, keyPath := range keyPaths {
 		// Extract information from SSH key
-		account := s.createAccountFromSSHKey(keyPath, pubKeyPath)
+		account := s.createAccountFromSSHKey(keyPath, keyPath+".pub")
 		if account != nil {
 			discovered = append(discovered, account)
 		}
@@ -92,8 +77,10 @@ func (s *SSHOnlyScanner) createAccountFromSSHKey(privateKeyPath, publicKeyPath s
 	}
 
 	confidence := 8 // High confidence for SSH keys with email
+	reasons := []string{"SSH key comment has email"}
 	if username != "" {
 		confidence = 9 // Even higher if we have clear username
+		reasons = append(reasons, "username in SSH key filename")
 	}
 
 	fmt.Printf("🔑 Found SSH key: %s -> %s (%s)\n", alias, name, email)
@@ -118,8 +105,9 @@ func (s *SSHOnlyScanner) createAccountFromSSHKey(privateKeyPath, publicKeyPath s
 			Platform:       platform,
 			Description:    "Discovered from SSH key",
 		},
-		Source:     "ssh",
-		Confidence: confidence,
+		Source:            "ssh",
+		Confidence:        confidence,
+		ConfidenceReasons: reasons,
 	}
 }
 
@@ -169,8 +157,8 @@ func (s *SSHOnlyScanner) extractEmailFromPublicKey(pubKeyPath string) string {
 	parts := strings.Fields(strings.TrimSpace(string(content)))
 	if len(parts) >= 3 {
 		comment := parts[2]
-		// Check if comment looks like an email
-		if strings.Contains(comment, "@") && strings.Contains(comment, ".") {
+		// Key comments are often user@hostname; only keep real email addresses
+		if models.ValidateEmail(comment) == nil {
 			return comment
 		}
 	}
@@ -180,12 +168,13 @@ func (s *SSHOnlyScanner) extractEmailFromPublicKey(pubKeyPath string) string {
 
 // extractUsernameFromKeyFilename extracts username from SSH key filename
 func (s *SSHOnlyScanner) extractUsernameFromKeyFilename(filename string) string {
-	// Pattern: id_ed25519_username or id_rsa_username
-	if strings.HasPrefix(filename, "id_ed25519_") {
-		return strings.TrimPrefix(filename, "id_ed25519_")
-	}
-	if strings.HasPrefix(filename, "id_rsa_") {
-		return strings.TrimPrefix(filename, "id_rsa_")
+	// Pattern: id_ed25519_username, id_rsa_username, id_ed25519_sk_username, ...
+	// Security key prefixes are checked first so "sk" is not mistaken for the username
+	prefixes := []string{"id_ed25519_sk_", "id_ecdsa_sk_", "id_ed25519_", "id_ecdsa_", "id_rsa_"}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(filename, prefix) {
+			return strings.TrimPrefix(filename, prefix)
+		}
 	}
 	return ""
 }
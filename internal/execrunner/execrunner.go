@@ -0,0 +1,81 @@
+// Package execrunner starts external commands that are stopped with their context.
+package execrunner
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/trace"
+	"github.com/techishthoughts/gitshift/pkg/redact"
+)
+
+// waitDelay bounds how long Wait blocks on a killed command's output pipes, which a
+// descendant that escaped the process group may still hold open
+const waitDelay = 2 * time.Second
+
+// CommandContext is exec.CommandContext for commands that never need the terminal. The
+// command runs in a new session and cancelling ctx kills its whole process group with
+// SIGKILL, so a hung ssh takes its ProxyCommand and any other children down with it,
+// instead of leaving them behind. Without a controlling terminal a command that tries to
+// prompt fails straight away rather than being stopped in the background; pass ssh
+// -o BatchMode=yes so it doesn't try. On platforms without sessions only the command
+// itself is killed.
+//
+// The command line is written to the trace of ctx, with credentials masked.
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := command(ctx, name, args...)
+	setProcessGroup(cmd)
+	return cmd
+}
+
+// InteractiveCommandContext is exec.CommandContext for commands that may prompt on the
+// terminal, such as ssh-add asking for a passphrase or git asking for credentials. The
+// command stays in the foreground process group so it can read the terminal, and
+// cancelling ctx kills only the command itself.
+func InteractiveCommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return command(ctx, name, args...)
+}
+
+// command traces the command line and builds the command with the shared WaitDelay
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	trace.Printf(ctx, "exec", "%s", redact.Secrets(strings.Join(append([]string{name}, args...), " ")))
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// CmdRunner runs external commands. Code that shells out takes one so tests can
+// substitute canned output for the real tools.
+type CmdRunner interface {
+	// CombinedOutput runs name with args, which must not need the terminal, and returns
+	// its combined stdout and stderr
+	CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	// InteractiveCombinedOutput is CombinedOutput for a command that may prompt on the
+	// terminal
+	InteractiveCombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// RealCmdRunner runs commands with CommandContext and InteractiveCommandContext
+type RealCmdRunner struct{}
+
+// CombinedOutput runs the command and returns its combined stdout and stderr
+func (RealCmdRunner) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return combinedOutput(ctx, name, CommandContext(ctx, name, args...))
+}
+
+// InteractiveCombinedOutput runs the command in the foreground and returns its combined
+// stdout and stderr
+func (RealCmdRunner) InteractiveCombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return combinedOutput(ctx, name, InteractiveCommandContext(ctx, name, args...))
+}
+
+func combinedOutput(ctx context.Context, name string, cmd *exec.Cmd) ([]byte, error) {
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		trace.Printf(ctx, "exec", "%s failed: %v", name, err)
+	}
+	return output, err
+}
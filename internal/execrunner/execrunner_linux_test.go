@@ -0,0 +1,97 @@
+package execrunner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// promptScript reads an answer from the terminal, like ssh asking to confirm a host key
+const promptScript = "read answer </dev/tty && echo got $answer"
+
+// TestTerminalPrompts runs terminalPromptHelper in a session whose controlling terminal is
+// a pty with an answer already typed, the way gitshift runs when a user starts it
+func TestTerminalPrompts(t *testing.T) {
+	master, slave := openPTY(t)
+	defer master.Close()
+	defer slave.Close()
+	if _, err := master.WriteString("yes\n"); err != nil {
+		t.Fatalf("failed to type on the pty: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTerminalPromptHelper$")
+	cmd.Env = append(os.Environ(), "GITSHIFT_TERMINAL_HELPER=1")
+	cmd.Stdin = slave
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	cmd.WaitDelay = waitDelay
+
+	done := make(chan error, 1)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start the helper: %v", err)
+	}
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("helper failed: %v\n%s%s", err, stdout.String(), stderr.String())
+		}
+	case <-time.After(20 * time.Second):
+		cmd.Process.Kill()
+		t.Fatalf("helper hung on the terminal prompt\n%s", stdout.String())
+	}
+}
+
+// TestTerminalPromptHelper is the process TestTerminalPrompts starts; it does nothing when
+// run on its own
+func TestTerminalPromptHelper(t *testing.T) {
+	if os.Getenv("GITSHIFT_TERMINAL_HELPER") != "1" {
+		t.Skip("only runs under TestTerminalPrompts")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Without a terminal the prompt fails at once instead of stopping in the background
+	if output, err := CommandContext(ctx, "sh", "-c", promptScript).CombinedOutput(); err == nil || ctx.Err() != nil {
+		t.Errorf("CommandContext prompt = %q, %v; want it to fail without a terminal", output, err)
+	}
+
+	output, err := InteractiveCommandContext(ctx, "sh", "-c", promptScript).Output()
+	if err != nil || strings.TrimSpace(string(output)) != "got yes" {
+		t.Errorf("InteractiveCommandContext prompt = %q, %v; want the answer typed on the terminal", output, err)
+	}
+}
+
+// openPTY opens a pseudo-terminal pair, skipping the test where there are none
+func openPTY(t *testing.T) (master, slave *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		t.Skipf("failed to unlock the pty: %v", err)
+	}
+	number, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		t.Skipf("failed to get the pty number: %v", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		t.Skipf("failed to open the pty: %v", err)
+	}
+	return master, slave
+}
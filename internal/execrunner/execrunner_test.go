@@ -0,0 +1,65 @@
+//go:build unix
+
+package execrunner
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCommandContext_KillsProcessGroupOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The shell starts a grandchild and reports its PID, then waits for it
+	cmd := CommandContext(ctx, "sh", "-c", "sleep 30 & echo $!; wait")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the grandchild PID: %v", err)
+	}
+	grandchild, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatalf("unexpected output %q", line)
+	}
+
+	cancel()
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command still running after its context was cancelled")
+	}
+
+	// The grandchild is reaped by init once killed, so poll until it is gone
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(grandchild, 0) == nil {
+		if time.Now().After(deadline) {
+			syscall.Kill(grandchild, syscall.SIGKILL)
+			t.Fatalf("grandchild %d survived the cancellation", grandchild)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCommandContext_RunsToCompletion(t *testing.T) {
+	output, err := CommandContext(context.Background(), "sh", "-c", "echo ok").Output()
+	if err != nil {
+		t.Fatalf("Output() error = %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "ok" {
+		t.Errorf("Output() = %q, want ok", output)
+	}
+}
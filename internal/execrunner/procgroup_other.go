@@ -0,0 +1,8 @@
+//go:build !unix
+
+package execrunner
+
+import "os/exec"
+
+// setProcessGroup leaves cmd alone; exec.CommandContext already kills the process itself
+func setProcessGroup(cmd *exec.Cmd) {}
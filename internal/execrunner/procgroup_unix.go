@@ -0,0 +1,19 @@
+//go:build unix
+
+package execrunner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd as the leader of a new session, and so of a new process group
+// without a controlling terminal, and makes its Cancel kill the group rather than the
+// leader alone
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	cmd.Cancel = func() error {
+		// The group ID is the leader's PID; a negative PID signals the whole group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
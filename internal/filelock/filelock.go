@@ -0,0 +1,62 @@
+// Package filelock takes the advisory file locks that keep concurrent gitshift processes
+// from interleaving updates to the files they share.
+package filelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// retryInterval is how often Acquire retries a lock held by another process
+const retryInterval = 50 * time.Millisecond
+
+// ErrBusy is returned when another process holds the lock
+var ErrBusy = errors.New("lock is held by another process")
+
+// Acquire takes an exclusive lock on path, creating the file if needed, and waits up to
+// timeout for another process to release it. The error wraps ErrBusy when the wait timed
+// out. The returned function releases the lock.
+func Acquire(path string, timeout time.Duration) (func(), error) {
+	return AcquireContext(context.Background(), path, timeout)
+}
+
+// AcquireContext is Acquire, also giving up when ctx is done; the error then wraps the
+// context's error rather than ErrBusy
+func AcquireContext(ctx context.Context, path string, timeout time.Duration) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for {
+		err := tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrBusy) || time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, fmt.Errorf("gave up waiting for %s: %w", path, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
@@ -1,14 +1,20 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	neturl "net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/techishthoughts/gitshift/internal/execrunner"
 	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 // Manager handles Git configuration operations
@@ -31,14 +37,14 @@ func NewSSHManager() *Manager {
 }
 
 // IsGitRepo checks if the current directory is a Git repository
-func (m *Manager) IsGitRepo(path string) bool {
+func (m *Manager) IsGitRepo(ctx context.Context, path string) bool {
 	gitDir := filepath.Join(path, ".git")
 	if _, err := os.Stat(gitDir); err == nil {
 		return true
 	}
 
 	// Check if we're inside a git worktree
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
+	cmd := execrunner.CommandContext(ctx, "git", "rev-parse", "--git-dir")
 	cmd.Dir = path
 	if err := cmd.Run(); err != nil {
 		return false
@@ -47,16 +53,16 @@ func (m *Manager) IsGitRepo(path string) bool {
 }
 
 // SetLocalConfig sets the Git configuration for the current repository
-func (m *Manager) SetLocalConfig(account *models.Account) error {
+func (m *Manager) SetLocalConfig(ctx context.Context, account *models.Account) error {
 	if account == nil {
 		return fmt.Errorf("account cannot be nil")
 	}
 
-	if err := m.setUserName(account.Name, false); err != nil {
+	if err := m.setUserName(ctx, account.Name, false); err != nil {
 		return fmt.Errorf("failed to set user.name: %w", err)
 	}
 
-	if err := m.setUserEmail(account.Email, false); err != nil {
+	if err := m.setUserEmail(ctx, account.Email, false); err != nil {
 		return fmt.Errorf("failed to set user.email: %w", err)
 	}
 
@@ -64,16 +70,16 @@ func (m *Manager) SetLocalConfig(account *models.Account) error {
 }
 
 // SetGlobalConfig sets the global Git configuration
-func (m *Manager) SetGlobalConfig(account *models.Account) error {
+func (m *Manager) SetGlobalConfig(ctx context.Context, account *models.Account) error {
 	if account == nil {
 		return fmt.Errorf("account cannot be nil")
 	}
 
-	if err := m.setUserName(account.Name, true); err != nil {
+	if err := m.setUserName(ctx, account.Name, true); err != nil {
 		return fmt.Errorf("failed to set global user.name: %w", err)
 	}
 
-	if err := m.setUserEmail(account.Email, true); err != nil {
+	if err := m.setUserEmail(ctx, account.Email, true); err != nil {
 		return fmt.Errorf("failed to set global user.email: %w", err)
 	}
 
@@ -81,13 +87,13 @@ func (m *Manager) SetGlobalConfig(account *models.Account) error {
 }
 
 // GetCurrentConfig returns the current Git configuration
-func (m *Manager) GetCurrentConfig() (name, email string, err error) {
-	name, err = m.getConfigValue("user.name")
+func (m *Manager) GetCurrentConfig(ctx context.Context) (name, email string, err error) {
+	name, err = m.getConfigValue(ctx, "user.name")
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get user.name: %w", err)
 	}
 
-	email, err = m.getConfigValue("user.email")
+	email, err = m.getConfigValue(ctx, "user.email")
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get user.email: %w", err)
 	}
@@ -101,13 +107,7 @@ func (m *Manager) GenerateSSHCommand(sshKeyPath string) string {
 		return ""
 	}
 
-	// Expand home directory if needed
-	if strings.HasPrefix(sshKeyPath, "~/") {
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			sshKeyPath = filepath.Join(homeDir, sshKeyPath[2:])
-		}
-	}
+	sshKeyPath = pathutil.Expand(sshKeyPath)
 
 	// Check if the SSH key exists
 	if _, err := os.Stat(sshKeyPath); os.IsNotExist(err) {
@@ -117,25 +117,33 @@ func (m *Manager) GenerateSSHCommand(sshKeyPath string) string {
 	return fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", sshKeyPath)
 }
 
+// SetGlobalSSHCommand sets the global core.sshCommand to use only the specified key, or
+// does nothing when the key doesn't exist
+func (m *Manager) SetGlobalSSHCommand(ctx context.Context, sshKeyPath string) error {
+	sshCommand := m.GenerateSSHCommand(sshKeyPath)
+	if sshCommand == "" {
+		return nil
+	}
+
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "core.sshCommand", sshCommand)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to set global core.sshCommand: %w", err)
+	}
+
+	return nil
+}
+
 // ValidateSSHKey checks if the SSH key file exists and is readable
 func (m *Manager) ValidateSSHKey(sshKeyPath string) error {
 	if sshKeyPath == "" {
 		return nil // SSH key is optional
 	}
 
-	// Expand home directory if needed
-	if strings.HasPrefix(sshKeyPath, "~/") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		sshKeyPath = filepath.Join(homeDir, sshKeyPath[2:])
-	}
-
 	// Check if file exists
-	if _, err := os.Stat(sshKeyPath); os.IsNotExist(err) {
-		return models.ErrSSHKeyNotFound
+	if _, err := pathutil.Stat(sshKeyPath); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", models.ErrSSHKeyNotFound, err)
 	}
+	sshKeyPath = pathutil.Expand(sshKeyPath)
 
 	// Check if file is readable
 	file, err := os.Open(sshKeyPath)
@@ -148,8 +156,8 @@ func (m *Manager) ValidateSSHKey(sshKeyPath string) error {
 }
 
 // GetGitVersion returns the Git version
-func (m *Manager) GetGitVersion() (string, error) {
-	cmd := exec.Command("git", "--version")
+func (m *Manager) GetGitVersion(ctx context.Context) (string, error) {
+	cmd := execrunner.CommandContext(ctx, "git", "--version")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", models.ErrGitNotFound
@@ -159,14 +167,14 @@ func (m *Manager) GetGitVersion() (string, error) {
 }
 
 // setUserName sets the Git user.name configuration
-func (m *Manager) setUserName(name string, global bool) error {
+func (m *Manager) setUserName(ctx context.Context, name string, global bool) error {
 	args := []string{"config"}
 	if global {
 		args = append(args, "--global")
 	}
 	args = append(args, "user.name", name)
 
-	cmd := exec.Command("git", args...)
+	cmd := execrunner.CommandContext(ctx, "git", args...)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("git config failed: %w", err)
 	}
@@ -175,14 +183,14 @@ func (m *Manager) setUserName(name string, global bool) error {
 }
 
 // setUserEmail sets the Git user.email configuration
-func (m *Manager) setUserEmail(email string, global bool) error {
+func (m *Manager) setUserEmail(ctx context.Context, email string, global bool) error {
 	args := []string{"config"}
 	if global {
 		args = append(args, "--global")
 	}
 	args = append(args, "user.email", email)
 
-	cmd := exec.Command("git", args...)
+	cmd := execrunner.CommandContext(ctx, "git", args...)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("git config failed: %w", err)
 	}
@@ -191,9 +199,9 @@ func (m *Manager) setUserEmail(email string, global bool) error {
 }
 
 // getConfigValue retrieves a Git configuration value
-func (m *Manager) getConfigValue(key string) (string, error) {
+func (m *Manager) getConfigValue(ctx context.Context, key string) (string, error) {
 	// Always read global configuration to ensure consistency
-	cmd := exec.Command("git", "config", "--global", "--get", key)
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "--get", key)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -203,12 +211,12 @@ func (m *Manager) getConfigValue(key string) (string, error) {
 }
 
 // GetRemoteURL returns the remote URL for the current repository
-func (m *Manager) GetRemoteURL(remote string) (string, error) {
+func (m *Manager) GetRemoteURL(ctx context.Context, remote string) (string, error) {
 	if remote == "" {
 		remote = "origin"
 	}
 
-	cmd := exec.Command("git", "config", "--get", fmt.Sprintf("remote.%s.url", remote))
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--get", fmt.Sprintf("remote.%s.url", remote))
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get remote URL: %w", err)
@@ -218,8 +226,8 @@ func (m *Manager) GetRemoteURL(remote string) (string, error) {
 }
 
 // GetCurrentBranch returns the current Git branch
-func (m *Manager) GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
+func (m *Manager) GetCurrentBranch(ctx context.Context) (string, error) {
+	cmd := execrunner.CommandContext(ctx, "git", "branch", "--show-current")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
@@ -229,14 +237,14 @@ func (m *Manager) GetCurrentBranch() (string, error) {
 }
 
 // SetRemoteURL sets the remote URL for the current repository
-func (m *Manager) SetRemoteURL(remoteName, repoURL string) error {
+func (m *Manager) SetRemoteURL(ctx context.Context, remoteName, repoURL string) error {
 	// Ensure we have the right protocol
 	finalURL := m.normalizeURL(repoURL)
 
-	cmd := exec.Command("git", "remote", "set-url", remoteName, finalURL)
+	cmd := execrunner.CommandContext(ctx, "git", "remote", "set-url", remoteName, finalURL)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to set remote URL: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("failed to set remote URL: %w\nOutput: %s", err, redact.Secrets(string(output)))
 	}
 
 	return nil
@@ -316,9 +324,37 @@ func (m *Manager) extractRepoPath(url, domain string) string {
 	return ""
 }
 
+// ParseRemoteURL splits a Git remote URL into its host (or SSH host alias) and repository
+// path. It handles both URL forms (ssh://git@host:22/owner/repo.git, https://host/owner/repo)
+// and scp-like git@host:owner/repo.git. The path has no leading slash or .git suffix.
+func ParseRemoteURL(remoteURL string) (host, repoPath string, err error) {
+	if strings.Contains(remoteURL, "://") {
+		parsed, err := neturl.Parse(remoteURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid remote URL %q: %w", remoteURL, err)
+		}
+		host, repoPath = parsed.Hostname(), parsed.Path
+	} else {
+		hostPart, pathPart, found := strings.Cut(remoteURL, ":")
+		if !found {
+			return "", "", fmt.Errorf("unrecognized remote URL %q", remoteURL)
+		}
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		host, repoPath = hostPart, pathPart
+	}
+
+	if host == "" {
+		return "", "", fmt.Errorf("remote URL %q has no host", remoteURL)
+	}
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	return host, repoPath, nil
+}
+
 // GetCurrentRemoteURL gets the current remote URL
-func (m *Manager) GetCurrentRemoteURL(remoteName string) (string, error) {
-	cmd := exec.Command("git", "remote", "get-url", remoteName)
+func (m *Manager) GetCurrentRemoteURL(ctx context.Context, remoteName string) (string, error) {
+	cmd := execrunner.CommandContext(ctx, "git", "remote", "get-url", remoteName)
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get remote URL: %w", err)
@@ -328,36 +364,36 @@ func (m *Manager) GetCurrentRemoteURL(remoteName string) (string, error) {
 }
 
 // IsGitRepository checks if the current directory is a git repository
-func (m *Manager) IsGitRepository() bool {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
+func (m *Manager) IsGitRepository(ctx context.Context) bool {
+	cmd := execrunner.CommandContext(ctx, "git", "rev-parse", "--git-dir")
 	err := cmd.Run()
 	return err == nil
 }
 
 // TestGitOperation tests if git operations work correctly
-func (m *Manager) TestGitOperation() error {
-	if !m.IsGitRepository() {
+func (m *Manager) TestGitOperation(ctx context.Context) error {
+	if !m.IsGitRepository(ctx) {
 		return fmt.Errorf("not in a git repository")
 	}
 
 	// Test basic git operation
-	cmd := exec.Command("git", "status", "--porcelain")
+	cmd := execrunner.CommandContext(ctx, "git", "status", "--porcelain")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("git status failed: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("git status failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
 	}
 
 	return nil
 }
 
 // SafeFetch performs a safe git fetch operation
-func (m *Manager) SafeFetch(remoteName string) error {
-	if !m.IsGitRepository() {
+func (m *Manager) SafeFetch(ctx context.Context, remoteName string) error {
+	if !m.IsGitRepository(ctx) {
 		return fmt.Errorf("not in a git repository")
 	}
 
 	// Use HTTPS for fetch to avoid SSH issues
-	originalURL, err := m.GetCurrentRemoteURL(remoteName)
+	originalURL, err := m.GetCurrentRemoteURL(ctx, remoteName)
 	if err != nil {
 		return err
 	}
@@ -366,20 +402,21 @@ func (m *Manager) SafeFetch(remoteName string) error {
 	httpsURL := m.convertToHTTPS(originalURL)
 	if httpsURL != originalURL {
 		// Switch to HTTPS
-		if err := m.SetRemoteURL(remoteName, httpsURL); err != nil {
+		if err := m.SetRemoteURL(ctx, remoteName, httpsURL); err != nil {
 			return fmt.Errorf("failed to switch to HTTPS: %w", err)
 		}
 
 		// Restore original URL after fetch
 		defer func() {
-			_ = m.SetRemoteURL(remoteName, originalURL)
+			_ = m.SetRemoteURL(ctx, remoteName, originalURL)
 		}()
 	}
 
-	cmd := exec.Command("git", "fetch", remoteName)
+	// git may ask for HTTPS credentials on the terminal
+	cmd := execrunner.InteractiveCommandContext(ctx, "git", "fetch", remoteName)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("git fetch failed: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("git fetch failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
 	}
 
 	return nil
@@ -403,16 +440,16 @@ func (m *Manager) convertToHTTPS(url string) string {
 }
 
 // SetUserConfig sets the git user configuration
-func (m *Manager) SetUserConfig(name, email string) error {
+func (m *Manager) SetUserConfig(ctx context.Context, name, email string) error {
 	if name != "" {
-		cmd := exec.Command("git", "config", "--global", "user.name", name)
+		cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "user.name", name)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to set git user.name: %w", err)
 		}
 	}
 
 	if email != "" {
-		cmd := exec.Command("git", "config", "--global", "user.email", email)
+		cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "user.email", email)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to set git user.email: %w", err)
 		}
@@ -422,14 +459,14 @@ func (m *Manager) SetUserConfig(name, email string) error {
 }
 
 // GetUserConfig gets the current git user configuration
-func (m *Manager) GetUserConfig() (name, email string, err error) {
-	nameCmd := exec.Command("git", "config", "--global", "user.name")
+func (m *Manager) GetUserConfig(ctx context.Context) (name, email string, err error) {
+	nameCmd := execrunner.CommandContext(ctx, "git", "config", "--global", "user.name")
 	nameOutput, nameErr := nameCmd.Output()
 	if nameErr == nil {
 		name = strings.TrimSpace(string(nameOutput))
 	}
 
-	emailCmd := exec.Command("git", "config", "--global", "user.email")
+	emailCmd := execrunner.CommandContext(ctx, "git", "config", "--global", "user.email")
 	emailOutput, emailErr := emailCmd.Output()
 	if emailErr == nil {
 		email = strings.TrimSpace(string(emailOutput))
@@ -443,14 +480,14 @@ func (m *Manager) GetUserConfig() (name, email string, err error) {
 }
 
 // ClearSSHConfig removes problematic SSH configurations
-func (m *Manager) ClearSSHConfig() error {
+func (m *Manager) ClearSSHConfig(ctx context.Context) error {
 	// Remove global SSH command
-	if err := exec.Command("git", "config", "--global", "--unset", "core.sshcommand").Run(); err != nil {
+	if err := execrunner.CommandContext(ctx, "git", "config", "--global", "--unset", "core.sshcommand").Run(); err != nil {
 		log.Printf("Warning: failed to unset global git config: %v", err)
 	}
 
 	// Remove local SSH command
-	if err := exec.Command("git", "config", "--local", "--unset", "core.sshcommand").Run(); err != nil {
+	if err := execrunner.CommandContext(ctx, "git", "config", "--local", "--unset", "core.sshcommand").Run(); err != nil {
 		log.Printf("Warning: failed to unset local git config: %v", err)
 	}
 
@@ -463,7 +500,7 @@ func (m *Manager) ClearSSHConfig() error {
 }
 
 // SetGPGConfig sets the GPG signing configuration for Git
-func (m *Manager) SetGPGConfig(account *models.Account) error {
+func (m *Manager) SetGPGConfig(ctx context.Context, account *models.Account) error {
 	if account == nil {
 		return fmt.Errorf("account cannot be nil")
 	}
@@ -471,21 +508,21 @@ func (m *Manager) SetGPGConfig(account *models.Account) error {
 	// Only configure GPG if the account has a GPG key
 	if !account.HasGPGKey() {
 		// No GPG key, disable signing
-		return m.DisableGPGSigning()
+		return m.DisableGPGSigning(ctx)
 	}
 
 	// Set the signing key
-	if err := m.setGPGSigningKey(account.GPGKeyID, true); err != nil {
+	if err := m.setGPGSigningKey(ctx, account.GPGKeyID, true); err != nil {
 		return fmt.Errorf("failed to set GPG signing key: %w", err)
 	}
 
 	// Enable or disable automatic signing based on account preferences
 	if account.IsGPGEnabled() {
-		if err := m.EnableGPGSigning(); err != nil {
+		if err := m.EnableGPGSigning(ctx); err != nil {
 			return fmt.Errorf("failed to enable GPG signing: %w", err)
 		}
 	} else {
-		if err := m.DisableGPGSigning(); err != nil {
+		if err := m.DisableGPGSigning(ctx); err != nil {
 			return fmt.Errorf("failed to disable GPG signing: %w", err)
 		}
 	}
@@ -494,14 +531,14 @@ func (m *Manager) SetGPGConfig(account *models.Account) error {
 }
 
 // setGPGSigningKey sets the user.signingkey configuration
-func (m *Manager) setGPGSigningKey(keyID string, global bool) error {
+func (m *Manager) setGPGSigningKey(ctx context.Context, keyID string, global bool) error {
 	args := []string{"config"}
 	if global {
 		args = append(args, "--global")
 	}
 	args = append(args, "user.signingkey", keyID)
 
-	cmd := exec.Command("git", args...)
+	cmd := execrunner.CommandContext(ctx, "git", args...)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("git config failed: %w", err)
 	}
@@ -510,15 +547,15 @@ func (m *Manager) setGPGSigningKey(keyID string, global bool) error {
 }
 
 // EnableGPGSigning enables automatic GPG commit and tag signing
-func (m *Manager) EnableGPGSigning() error {
+func (m *Manager) EnableGPGSigning(ctx context.Context) error {
 	// Enable commit signing
-	cmd := exec.Command("git", "config", "--global", "commit.gpgsign", "true")
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "commit.gpgsign", "true")
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to enable commit.gpgsign: %w", err)
 	}
 
 	// Enable tag signing
-	cmd = exec.Command("git", "config", "--global", "tag.gpgsign", "true")
+	cmd = execrunner.CommandContext(ctx, "git", "config", "--global", "tag.gpgsign", "true")
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to enable tag.gpgsign: %w", err)
 	}
@@ -527,15 +564,15 @@ func (m *Manager) EnableGPGSigning() error {
 }
 
 // DisableGPGSigning disables automatic GPG commit and tag signing
-func (m *Manager) DisableGPGSigning() error {
+func (m *Manager) DisableGPGSigning(ctx context.Context) error {
 	// Disable commit signing
-	cmd := exec.Command("git", "config", "--global", "commit.gpgsign", "false")
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "commit.gpgsign", "false")
 	if err := cmd.Run(); err != nil {
 		log.Printf("Warning: failed to disable commit.gpgsign: %v", err)
 	}
 
 	// Disable tag signing
-	cmd = exec.Command("git", "config", "--global", "tag.gpgsign", "false")
+	cmd = execrunner.CommandContext(ctx, "git", "config", "--global", "tag.gpgsign", "false")
 	if err := cmd.Run(); err != nil {
 		log.Printf("Warning: failed to disable tag.gpgsign: %v", err)
 	}
@@ -544,32 +581,32 @@ func (m *Manager) DisableGPGSigning() error {
 }
 
 // UnsetGPGConfig removes GPG signing configuration from Git
-func (m *Manager) UnsetGPGConfig() error {
+func (m *Manager) UnsetGPGConfig(ctx context.Context) error {
 	// Unset signing key
-	if err := exec.Command("git", "config", "--global", "--unset", "user.signingkey").Run(); err != nil {
+	if err := execrunner.CommandContext(ctx, "git", "config", "--global", "--unset", "user.signingkey").Run(); err != nil {
 		log.Printf("Warning: failed to unset user.signingkey: %v", err)
 	}
 
 	// Disable signing
-	return m.DisableGPGSigning()
+	return m.DisableGPGSigning(ctx)
 }
 
 // GetGPGConfig returns the current GPG configuration
-func (m *Manager) GetGPGConfig() (signingKey string, commitSign, tagSign bool, err error) {
+func (m *Manager) GetGPGConfig(ctx context.Context) (signingKey string, commitSign, tagSign bool, err error) {
 	// Get signing key
-	cmd := exec.Command("git", "config", "--global", "--get", "user.signingkey")
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "--get", "user.signingkey")
 	if output, err := cmd.Output(); err == nil {
 		signingKey = strings.TrimSpace(string(output))
 	}
 
 	// Get commit.gpgsign
-	cmd = exec.Command("git", "config", "--global", "--get", "commit.gpgsign")
+	cmd = execrunner.CommandContext(ctx, "git", "config", "--global", "--get", "commit.gpgsign")
 	if output, err := cmd.Output(); err == nil {
 		commitSign = strings.TrimSpace(string(output)) == "true"
 	}
 
 	// Get tag.gpgsign
-	cmd = exec.Command("git", "config", "--global", "--get", "tag.gpgsign")
+	cmd = execrunner.CommandContext(ctx, "git", "config", "--global", "--get", "tag.gpgsign")
 	if output, err := cmd.Output(); err == nil {
 		tagSign = strings.TrimSpace(string(output)) == "true"
 	}
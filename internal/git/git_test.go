@@ -0,0 +1,30 @@
+package git
+
+import "testing"
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url, host, repoPath string
+	}{
+		{"git@github.com:acme/api.git", "github.com", "acme/api"},
+		{"git@github.com-work:acme/api.git", "github.com-work", "acme/api"},
+		{"ssh://git@gitlab.acme.com:2222/platform/infra.git", "gitlab.acme.com", "platform/infra"},
+		{"https://github.com/acme/api", "github.com", "acme/api"},
+		{"https://user@bitbucket.org/acme/api.git/", "bitbucket.org", "acme/api"},
+	}
+
+	for _, tt := range tests {
+		host, repoPath, err := ParseRemoteURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseRemoteURL(%q) error = %v", tt.url, err)
+			continue
+		}
+		if host != tt.host || repoPath != tt.repoPath {
+			t.Errorf("ParseRemoteURL(%q) = %q, %q, want %q, %q", tt.url, host, repoPath, tt.host, tt.repoPath)
+		}
+	}
+
+	if _, _, err := ParseRemoteURL("/srv/git/api.git"); err == nil {
+		t.Error("ParseRemoteURL() accepted a local path")
+	}
+}
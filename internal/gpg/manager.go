@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 // Manager handles GPG key generation and management
@@ -127,7 +129,7 @@ func (m *Manager) GenerateKey(params GenerateKeyParams) (*KeyInfo, error) {
 	cmd := exec.Command("gpg", "--batch", "--gen-key", tmpFile.Name())
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("GPG key generation failed: %w\nOutput: %s", err, string(output))
+		return nil, fmt.Errorf("GPG key generation failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
 	}
 
 	fmt.Printf("✅ GPG key generated successfully\n")
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteText writes the snapshot in the Prometheus text exposition format. Every known
+// metric is written, with no series until it was first recorded.
+func (s *Snapshot) WriteText(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	names := make([]string, 0, len(descriptors))
+	for name := range descriptors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		desc := descriptors[name]
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, desc.help, name, desc.kind)
+
+		switch desc.kind {
+		case kindCounter:
+			series := s.Counters[name]
+			for _, key := range sortedKeys(series) {
+				fmt.Fprintf(out, "%s%s %s\n", name, key, formatValue(series[key]))
+			}
+		case kindHistogram:
+			series := s.Histograms[name]
+			for _, key := range sortedKeys(series) {
+				writeHistogram(out, name, key, series[key])
+			}
+		}
+	}
+
+	return out.Flush()
+}
+
+// writeHistogram writes the cumulative buckets, sum and count of a histogram series
+func writeHistogram(out io.Writer, name, key string, h *Histogram) {
+	var cumulative uint64
+	for i, count := range h.Buckets {
+		cumulative += count
+		bound := math.Inf(1)
+		if i < len(LatencyBuckets) {
+			bound = LatencyBuckets[i]
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", name, withLabel(key, "le", formatValue(bound)), cumulative)
+	}
+	fmt.Fprintf(out, "%s_sum%s %s\n", name, key, formatValue(h.Sum))
+	fmt.Fprintf(out, "%s_count%s %d\n", name, key, h.Count)
+}
+
+// withLabel adds a label to a rendered label set
+func withLabel(key, name, value string) string {
+	label := formatLabel(name, value)
+	if key == "" {
+		return "{" + label + "}"
+	}
+	return strings.TrimSuffix(key, "}") + "," + label + "}"
+}
+
+// formatValue formats a sample value the way Prometheus writes it
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Handler serves the metrics in store, reading them again on every scrape
+func Handler(store *Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := store.Load()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		_ = snapshot.WriteText(w)
+	})
+}
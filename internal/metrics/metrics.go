@@ -0,0 +1,235 @@
+// Package metrics counts what gitshift does so automation can scrape it with Prometheus.
+// Each gitshift process records into an in-memory Recorder and adds it to a Store shared by
+// all processes when it exits; 'gitshift metrics serve' exposes the Store on /metrics.
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metric names
+const (
+	SwitchesTotal      = "gitshift_switches_total"
+	ValidationsTotal   = "gitshift_validations_total"
+	SSHTestsTotal      = "gitshift_ssh_tests_total"
+	APIRequestDuration = "gitshift_api_request_duration_seconds"
+)
+
+// Values of the "result" label
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultCached  = "cached"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the histogram buckets
+var LatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metricKind string
+
+const (
+	kindCounter   metricKind = "counter"
+	kindHistogram metricKind = "histogram"
+)
+
+// descriptor documents a metric in the exposition format
+type descriptor struct {
+	kind metricKind
+	help string
+}
+
+var descriptors = map[string]descriptor{
+	SwitchesTotal:      {kindCounter, "Account switches by account and result."},
+	ValidationsTotal:   {kindCounter, "Account validations by account and result."},
+	SSHTestsTotal:      {kindCounter, "SSH connection tests by platform domain and result; cached results were reused without dialing."},
+	APIRequestDuration: {kindHistogram, "Latency of platform API requests by platform, method and status code."},
+}
+
+// Labels are the label names and values of a series
+type Labels map[string]string
+
+// key renders labels the way the exposition format writes them, e.g. {account="work"},
+// sorted by name so equal label sets share a key
+func (l Labels) key() string {
+	if len(l) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = formatLabel(name, l[name])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelValueEscaper escapes label values as the exposition format requires
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabel renders a label pair, e.g. account="work"
+func formatLabel(name, value string) string {
+	return name + `="` + labelValueEscaper.Replace(value) + `"`
+}
+
+// Result returns the result label for an operation that ended with err
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// Histogram is the state of one histogram series
+type Histogram struct {
+	// Buckets counts the observations per bucket of LatencyBuckets, not cumulatively,
+	// with the observations above the last bound at the end
+	Buckets []uint64 `json:"buckets"`
+	Sum     float64  `json:"sum"`
+	Count   uint64   `json:"count"`
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{Buckets: make([]uint64, len(LatencyBuckets)+1)}
+}
+
+func (h *Histogram) observe(value float64) {
+	bucket := sort.SearchFloat64s(LatencyBuckets, value)
+	h.Buckets[bucket]++
+	h.Sum += value
+	h.Count++
+}
+
+// add merges other into h. A series recorded with different buckets can't be merged and
+// is started over.
+func (h *Histogram) add(other *Histogram) {
+	if len(h.Buckets) != len(other.Buckets) {
+		*h = Histogram{Buckets: make([]uint64, len(other.Buckets))}
+	}
+	for i, count := range other.Buckets {
+		h.Buckets[i] += count
+	}
+	h.Sum += other.Sum
+	h.Count += other.Count
+}
+
+// Snapshot holds the values of all series, keyed by metric name and then by label set
+type Snapshot struct {
+	Counters   map[string]map[string]float64    `json:"counters,omitempty"`
+	Histograms map[string]map[string]*Histogram `json:"histograms,omitempty"`
+}
+
+// NewSnapshot returns an empty snapshot
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		Counters:   make(map[string]map[string]float64),
+		Histograms: make(map[string]map[string]*Histogram),
+	}
+}
+
+// IsEmpty reports whether the snapshot holds no series
+func (s *Snapshot) IsEmpty() bool {
+	return len(s.Counters) == 0 && len(s.Histograms) == 0
+}
+
+func (s *Snapshot) inc(name, key string, delta float64) {
+	if s.Counters[name] == nil {
+		s.Counters[name] = make(map[string]float64)
+	}
+	s.Counters[name][key] += delta
+}
+
+func (s *Snapshot) histogram(name, key string) *Histogram {
+	if s.Histograms[name] == nil {
+		s.Histograms[name] = make(map[string]*Histogram)
+	}
+	h := s.Histograms[name][key]
+	if h == nil {
+		h = newHistogram()
+		s.Histograms[name][key] = h
+	}
+	return h
+}
+
+// Add merges the series of other into s
+func (s *Snapshot) Add(other *Snapshot) {
+	for name, series := range other.Counters {
+		for key, value := range series {
+			s.inc(name, key, value)
+		}
+	}
+	for name, series := range other.Histograms {
+		for key, h := range series {
+			s.histogram(name, key).add(h)
+		}
+	}
+}
+
+// Recorder collects the observations of the running process. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	pending *Snapshot
+}
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{pending: NewSnapshot()}
+}
+
+// Inc adds one to the counter series
+func (r *Recorder) Inc(name string, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending.inc(name, labels.key(), 1)
+}
+
+// Observe records value, in seconds, in the histogram series
+func (r *Recorder) Observe(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending.histogram(name, labels.key()).observe(value)
+}
+
+// Flush adds what was recorded since the last flush to store. Nothing is written when
+// nothing was recorded.
+func (r *Recorder) Flush(store *Store) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = NewSnapshot()
+	r.mu.Unlock()
+
+	if pending.IsEmpty() {
+		return nil
+	}
+	if err := store.Add(pending); err != nil {
+		// Keep the observations for the next flush
+		r.mu.Lock()
+		r.pending.Add(pending)
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// defaultRecorder records the observations of the gitshift process
+var defaultRecorder = NewRecorder()
+
+// Inc adds one to a counter series of the process
+func Inc(name string, labels Labels) {
+	defaultRecorder.Inc(name, labels)
+}
+
+// Observe records value, in seconds, in a histogram series of the process
+func Observe(name string, labels Labels, value float64) {
+	defaultRecorder.Observe(name, labels, value)
+}
+
+// Flush adds the observations of the process to store
+func Flush(store *Store) error {
+	return defaultRecorder.Flush(store)
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot_WriteText(t *testing.T) {
+	r := NewRecorder()
+	r.Inc(SwitchesTotal, Labels{"result": ResultSuccess, "account": "work"})
+	r.Inc(SwitchesTotal, Labels{"account": "work", "result": ResultSuccess})
+	r.Inc(SwitchesTotal, Labels{"account": `say "hi"`, "result": ResultFailure})
+	r.Observe(APIRequestDuration, Labels{"platform": "github", "method": "GET", "status": "200"}, 0.2)
+	r.Observe(APIRequestDuration, Labels{"platform": "github", "method": "GET", "status": "200"}, 0.05)
+	r.Observe(APIRequestDuration, Labels{"platform": "github", "method": "GET", "status": "200"}, 30)
+
+	var out strings.Builder
+	if err := r.pending.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# TYPE gitshift_switches_total counter\n",
+		`gitshift_switches_total{account="say \"hi\"",result="failure"} 1` + "\n",
+		`gitshift_switches_total{account="work",result="success"} 2` + "\n",
+		"# TYPE gitshift_api_request_duration_seconds histogram\n",
+		`gitshift_api_request_duration_seconds_bucket{method="GET",platform="github",status="200",le="0.05"} 1` + "\n",
+		`gitshift_api_request_duration_seconds_bucket{method="GET",platform="github",status="200",le="0.25"} 2` + "\n",
+		`gitshift_api_request_duration_seconds_bucket{method="GET",platform="github",status="200",le="10"} 2` + "\n",
+		`gitshift_api_request_duration_seconds_bucket{method="GET",platform="github",status="200",le="+Inf"} 3` + "\n",
+		`gitshift_api_request_duration_seconds_sum{method="GET",platform="github",status="200"} 30.25` + "\n",
+		`gitshift_api_request_duration_seconds_count{method="GET",platform="github",status="200"} 3` + "\n",
+		// Metrics without series are still described
+		"# TYPE gitshift_ssh_tests_total counter\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("exposition is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStore_AccumulatesFlushesFromConcurrentProcesses(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "gitshift", StoreFileName))
+
+	// Each recorder stands in for a separate gitshift process
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := NewRecorder()
+			r.Inc(ValidationsTotal, Labels{"account": "work", "result": ResultSuccess})
+			r.Observe(APIRequestDuration, Labels{"platform": "github"}, 0.3)
+			if err := r.Flush(store); err != nil {
+				t.Errorf("Flush() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := snapshot.Counters[ValidationsTotal][`{account="work",result="success"}`]; got != 8 {
+		t.Errorf("validations = %v, want 8", got)
+	}
+	if h := snapshot.Histograms[APIRequestDuration][`{platform="github"}`]; h == nil || h.Count != 8 || h.Buckets[3] != 8 {
+		t.Errorf("API latency histogram = %+v, want 8 observations in the 0.5s bucket", h)
+	}
+
+	// An empty flush leaves the store alone
+	if err := NewRecorder().Flush(store); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(Handler(store))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != ContentType {
+		t.Errorf("scrape returned %d with Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/filelock"
+)
+
+const (
+	// StoreFileName is the file in the config directory that holds the metrics
+	StoreFileName = "metrics.json"
+
+	// storeLockTimeout is how long Add waits for another process to finish its update
+	storeLockTimeout = 5 * time.Second
+)
+
+// Store keeps the metrics of all gitshift processes in a JSON file. Updates are serialized
+// with an advisory lock and written atomically, so concurrent processes don't lose counts.
+type Store struct {
+	path string
+}
+
+// NewStore returns a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the file the store reads and writes
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the stored metrics; a store that was never written is empty
+func (s *Store) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return NewSnapshot(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	snapshot := NewSnapshot()
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics %s: %w", s.path, err)
+	}
+	if snapshot.Counters == nil {
+		snapshot.Counters = make(map[string]map[string]float64)
+	}
+	if snapshot.Histograms == nil {
+		snapshot.Histograms = make(map[string]map[string]*Histogram)
+	}
+	return snapshot, nil
+}
+
+// Add merges delta into the stored metrics
+func (s *Store) Add(delta *Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	unlock, err := filelock.Acquire(s.path+".lock", storeLockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock metrics: %w", err)
+	}
+	defer unlock()
+
+	snapshot, err := s.Load()
+	if err != nil {
+		return err
+	}
+	snapshot.Add(delta)
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	// Write to a temporary file and rename it so a scrape never reads a partial file
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
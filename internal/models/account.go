@@ -1,7 +1,7 @@
 package models
 
 import (
-	"regexp"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -22,6 +22,11 @@ type Account struct {
 	// SSHKeyPath is the path to the SSH private key file
 	SSHKeyPath string `json:"ssh_key_path" yaml:"ssh_key_path" mapstructure:"ssh_key_path"`
 
+	// SSHKeyFingerprint names, by its SHA256 fingerprint, a key that only the SSH agent
+	// holds, such as a hardware token or a forwarded agent's key. It is used when
+	// SSHKeyPath is empty.
+	SSHKeyFingerprint string `json:"ssh_key_fingerprint,omitempty" yaml:"ssh_key_fingerprint,omitempty" mapstructure:"ssh_key_fingerprint"`
+
 	// GitHubUsername is the GitHub username (required for GitHub platform)
 	// Deprecated: Use Username instead with Platform field
 	GitHubUsername string `json:"github_username" yaml:"github_username" mapstructure:"github_username"`
@@ -42,6 +47,15 @@ type Account struct {
 	// Description is an optional description of the account
 	Description string `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description"`
 
+	// MatchRules select this account automatically for repositories whose remote matches,
+	// as host[/owner[/repo]] glob patterns (e.g. "github.com/acme-*")
+	MatchRules []string `json:"match_rules,omitempty" yaml:"match_rules,omitempty" mapstructure:"match_rules"`
+
+	// SSHMatch lists ssh_config Match criteria under which ssh offers this account's key for
+	// its platform even while another account is active, e.g. exec "pwd | grep -q /work/".
+	// Each becomes a "Match host <domain> <criteria>" block in the managed SSH config.
+	SSHMatch []string `json:"ssh_match,omitempty" yaml:"ssh_match,omitempty" mapstructure:"ssh_match"`
+
 	// IsDefault indicates if this is the default account
 	IsDefault bool `json:"is_default" yaml:"is_default" mapstructure:"is_default"`
 
@@ -71,6 +85,13 @@ type Account struct {
 	// LastValidation tracks when the account was last validated
 	LastValidation *time.Time `json:"last_validation,omitempty" yaml:"last_validation,omitempty" mapstructure:"last_validation"`
 
+	// LastConnectivityTest tracks when a live SSH connection test last succeeded
+	LastConnectivityTest *time.Time `json:"last_connectivity_test,omitempty" yaml:"last_connectivity_test,omitempty" mapstructure:"last_connectivity_test"`
+
+	// LastConnectivityTestKey identifies the SSH key the last connection test used, so a
+	// test made with a replaced key isn't reused
+	LastConnectivityTestKey string `json:"last_connectivity_test_key,omitempty" yaml:"last_connectivity_test_key,omitempty" mapstructure:"last_connectivity_test_key"`
+
 	// ValidationErrors tracks any validation errors encountered
 	ValidationErrors []string `json:"validation_errors,omitempty" yaml:"validation_errors,omitempty" mapstructure:"validation_errors"`
 
@@ -213,8 +234,26 @@ type Config struct {
 	// AutoDetect enables automatic account detection based on folder configuration
 	AutoDetect bool `json:"auto_detect" yaml:"auto_detect" mapstructure:"auto_detect"`
 
-	// ConfigVersion for future migrations
-	ConfigVersion string `json:"config_version" yaml:"config_version" mapstructure:"config_version"`
+	// ConnectivityTestTTL is how long a successful SSH connection test is reused before
+	// validation dials the platform again (e.g. "15m"). Defaults to DefaultConnectivityTestTTL.
+	ConnectivityTestTTL time.Duration `json:"connectivity_test_ttl,omitempty" yaml:"connectivity_test_ttl,omitempty" mapstructure:"connectivity_test_ttl"`
+
+	// TokenStorage selects where account tokens are kept: "file" (encrypted files in the
+	// config directory, the default) or "keychain" (the OS credential store)
+	TokenStorage string `json:"token_storage,omitempty" yaml:"token_storage,omitempty" mapstructure:"token_storage"`
+
+	// AllowMultipleKeys keeps the keys of other accounts in the SSH agent on switch, for
+	// workflows that need several keys loaded at once. Only IdentitiesOnly in ~/.ssh/config
+	// then keeps ssh from offering another account's key, so the wrong identity is one
+	// misconfigured Host entry away.
+	AllowMultipleKeys bool `json:"allow_multiple_keys,omitempty" yaml:"allow_multiple_keys,omitempty" mapstructure:"allow_multiple_keys"`
+
+	// SSHConfig controls the optional directives of the Host blocks gitshift writes to
+	// ~/.ssh/config
+	SSHConfig SSHConfigSettings `json:"ssh_config,omitempty" yaml:"ssh_config,omitempty" mapstructure:"ssh_config"`
+
+	// Version is the schema version of the config file, used to run migrations on load
+	Version int `json:"version" yaml:"version" mapstructure:"version"`
 }
 
 // ProjectConfig represents the project-specific configuration
@@ -283,6 +322,41 @@ func NewIsolatedAccount(alias, name, email, sshKeyPath, githubUsername string) *
 	return account
 }
 
+// CurrentConfigVersion is the config schema version written by this build of gitshift
+const CurrentConfigVersion = 1
+
+// DefaultConnectivityTestTTL is used when the configuration doesn't set ConnectivityTestTTL
+const DefaultConnectivityTestTTL = 15 * time.Minute
+
+// GetConnectivityTestTTL returns the connectivity test TTL with fallback to the default
+func (c *Config) GetConnectivityTestTTL() time.Duration {
+	if c.ConnectivityTestTTL <= 0 {
+		return DefaultConnectivityTestTTL
+	}
+	return c.ConnectivityTestTTL
+}
+
+// SSHConfigSettings holds the optional directives of gitshift-managed SSH Host blocks. Unset
+// settings default to enabled.
+type SSHConfigSettings struct {
+	// AddKeysToAgent adds a key to the running ssh-agent the first time ssh uses it
+	AddKeysToAgent *bool `json:"add_keys_to_agent,omitempty" yaml:"add_keys_to_agent,omitempty" mapstructure:"add_keys_to_agent"`
+
+	// UseKeychain stores key passphrases in the macOS keychain. It only applies on macOS;
+	// ssh on other platforms rejects the directive, so it is never written there.
+	UseKeychain *bool `json:"use_keychain,omitempty" yaml:"use_keychain,omitempty" mapstructure:"use_keychain"`
+}
+
+// AddKeysToAgentEnabled returns the AddKeysToAgent setting with fallback to enabled
+func (s SSHConfigSettings) AddKeysToAgentEnabled() bool {
+	return s.AddKeysToAgent == nil || *s.AddKeysToAgent
+}
+
+// UseKeychainEnabled returns the UseKeychain setting with fallback to enabled
+func (s SSHConfigSettings) UseKeychainEnabled() bool {
+	return s.UseKeychain == nil || *s.UseKeychain
+}
+
 // NewConfig creates a new configuration with default values
 func NewConfig() *Config {
 	return &Config{
@@ -290,7 +364,7 @@ func NewConfig() *Config {
 		PendingAccounts: make(map[string]*PendingAccount),
 		GlobalGitConfig: true, // Always use global Git config by default
 		AutoDetect:      true,
-		ConfigVersion:   "1.0.0",
+		Version:         CurrentConfigVersion,
 	}
 }
 
@@ -318,62 +392,50 @@ func (a *Account) Validate() error {
 	}
 
 	// If we have email, validate its format
-	if a.Email != "" && !isValidEmail(a.Email) {
-		return ErrInvalidEmailFormat
+	if a.Email != "" {
+		if err := ValidateEmail(a.Email); err != nil {
+			return err
+		}
 	}
 
-	// If we have GitHub username, validate its format
-	if a.GitHubUsername != "" && !isValidGitHubUsername(a.GitHubUsername) {
-		return ErrInvalidGitHubUsernameFormat
+	// Usernames follow the rules of the account's platform
+	for _, username := range []string{a.GitHubUsername, a.Username} {
+		if username != "" {
+			if err := ValidateUsername(a.GetPlatform(), username); err != nil {
+				return err
+			}
+		}
 	}
 
-	return nil
-}
-
-// MarkAsUsed updates the LastUsed timestamp
-func (a *Account) MarkAsUsed() {
-	now := time.Now()
-	a.LastUsed = &now
-}
-
-// isValidEmail validates email format
-func isValidEmail(email string) bool {
-	if email == "" {
-		return false
+	if a.Platform != "" && !IsKnownPlatform(a.Platform) {
+		return fmt.Errorf("%w: unknown platform %q, expected github, gitlab, bitbucket or custom", ErrInvalidConfig, a.Platform)
 	}
-
-	// Basic email validation regex
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	return emailRegex.MatchString(email)
-}
-
-// isValidGitHubUsername validates GitHub username format
-func isValidGitHubUsername(username string) bool {
-	if username == "" {
-		return false
+	if a.GetDomain() == "" {
+		return ErrMissingDomain
 	}
-
-	// GitHub username rules:
-	// - Can only contain alphanumeric characters and hyphens
-	// - Cannot start or end with a hyphen
-	// - Cannot have consecutive hyphens
-	// - Maximum 39 characters
-
-	if len(username) > 39 || len(username) < 1 {
-		return false
+	if a.APIEndpoint != "" {
+		if err := ValidateAPIEndpoint(a.APIEndpoint); err != nil {
+			return err
+		}
 	}
 
-	if strings.HasPrefix(username, "-") || strings.HasSuffix(username, "-") {
-		return false
+	for _, rule := range a.MatchRules {
+		if err := ValidateMatchRule(rule); err != nil {
+			return err
+		}
 	}
-
-	if strings.Contains(username, "--") {
-		return false
+	for _, criteria := range a.SSHMatch {
+		if err := ValidateSSHMatch(criteria); err != nil {
+			return err
+		}
 	}
 
-	// Must contain only alphanumeric characters and hyphens
-	usernameRegex := regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
-	return usernameRegex.MatchString(username)
+	return nil
+}
+
+// MarkAsUsed records that the account was switched to at usedAt
+func (a *Account) MarkAsUsed(usedAt time.Time) {
+	a.LastUsed = &usedAt
 }
 
 // NewPendingAccount creates a new pending account
@@ -436,6 +498,27 @@ func (a *Account) SetLastValidation(validationTime time.Time, errors []string) {
 	}
 }
 
+// MarkConnectivityTested records a successful SSH connection test
+func (a *Account) MarkConnectivityTested(testTime time.Time) {
+	a.LastConnectivityTest = &testTime
+}
+
+// HasRecentConnectivityTest checks if an SSH connection test succeeded within the given TTL
+// of now
+func (a *Account) HasRecentConnectivityTest(now time.Time, ttl time.Duration) bool {
+	next := a.NextConnectivityTest(ttl)
+	return !next.IsZero() && now.Before(next)
+}
+
+// NextConnectivityTest returns when the last successful SSH connection test stops being
+// reused, or the zero time when the account was never tested
+func (a *Account) NextConnectivityTest(ttl time.Duration) time.Time {
+	if a.LastConnectivityTest == nil {
+		return time.Time{}
+	}
+	return a.LastConnectivityTest.Add(ttl)
+}
+
 // IsValidationRequired checks if account validation is required
 func (a *Account) IsValidationRequired() bool {
 	if a.LastValidation == nil {
@@ -514,6 +597,32 @@ func (a *Account) GetDomain() string {
 	}
 }
 
+// GetAPIEndpoint returns the platform API base URL: APIEndpoint when set, otherwise the
+// standard endpoint for the platform and domain. Custom platforms have no default.
+func (a *Account) GetAPIEndpoint() string {
+	if a.APIEndpoint != "" {
+		return strings.TrimSuffix(a.APIEndpoint, "/")
+	}
+
+	domain := a.GetDomain()
+	switch a.GetPlatform() {
+	case "github":
+		if domain == "github.com" {
+			return "https://api.github.com"
+		}
+		return "https://" + domain + "/api/v3" // GitHub Enterprise Server
+	case "gitlab":
+		return "https://" + domain + "/api/v4"
+	case "bitbucket":
+		if domain == "bitbucket.org" {
+			return "https://api.bitbucket.org/2.0"
+		}
+		return "https://" + domain + "/rest/api/1.0" // Bitbucket Data Center
+	default:
+		return ""
+	}
+}
+
 // GetUsername returns the username, falling back to GitHubUsername for backward compatibility
 func (a *Account) GetUsername() string {
 	if a.Username != "" {
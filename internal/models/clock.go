@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Clock tells the current time. Code that compares stored timestamps against the current
+// time takes a Clock, so tests can move time forward instead of sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock backed by time.Now
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Length limits from RFC 5321
+const (
+	maxEmailLocalLength  = 64
+	maxEmailDomainLength = 255
+	maxDomainLabelLength = 63
+)
+
+// emailLocalSpecials are the characters RFC 5322 allows in an unquoted local part besides
+// letters, digits and dots
+const emailLocalSpecials = "!#$%&'*+/=?^_`{|}~-"
+
+// ValidateEmail checks that email is an address Git platforms accept: a dot-atom local part
+// (unicode letters allowed, as in RFC 6531, plus-addressing included) and a domain with at
+// least two labels, so "user@localhost" is rejected. Quoted local parts and IP literals are
+// not supported. Errors wrap ErrInvalidEmailFormat.
+func ValidateEmail(email string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %q %s", ErrInvalidEmailFormat, email, reason)
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return invalid("has no @")
+	}
+	local, domain := email[:at], email[at+1:]
+
+	switch {
+	case local == "":
+		return invalid("has an empty local part")
+	case len(local) > maxEmailLocalLength:
+		return invalid(fmt.Sprintf("has a local part longer than %d bytes", maxEmailLocalLength))
+	case strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, ".."):
+		return invalid("has a misplaced dot in the local part")
+	}
+	for _, r := range local {
+		if r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(emailLocalSpecials, r) {
+			return invalid(fmt.Sprintf("contains %q in the local part", r))
+		}
+	}
+
+	if domain == "" {
+		return invalid("has an empty domain")
+	}
+	if len(domain) > maxEmailDomainLength {
+		return invalid(fmt.Sprintf("has a domain longer than %d bytes", maxEmailDomainLength))
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return invalid("has no top-level domain")
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > maxDomainLabelLength {
+			return invalid("has an invalid domain")
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return invalid("has a domain label starting or ending with a hyphen")
+		}
+		for _, r := range label {
+			if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return invalid(fmt.Sprintf("contains %q in the domain", r))
+			}
+		}
+	}
+
+	tld := labels[len(labels)-1]
+	if len([]rune(tld)) < 2 || strings.IndexFunc(tld, unicode.IsLetter) < 0 {
+		return invalid("has an invalid top-level domain")
+	}
+	return nil
+}
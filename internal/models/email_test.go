@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{"dev@example.com", true},
+		{"first.last@mail.example.co.uk", true},
+		{"dev+github@example.com", true},
+		{"dev+work+2025@example.com", true},
+		{"o'brien@example.ie", true},
+		{"josé@example.com", true},
+		{"用户@例子.广告", true},
+		{"dev@xn--bcher-kva.example", true},
+		{"12345+dev@users.noreply.github.com", true},
+
+		{"", false},
+		{"dev", false},
+		{"dev@localhost", false},
+		{"dev@example", false},
+		{"dev@example.c", false},
+		{"dev@192.168.0.1", false},
+		{"@example.com", false},
+		{"dev@", false},
+		{".dev@example.com", false},
+		{"dev.@example.com", false},
+		{"de..v@example.com", false},
+		{"dev@example..com", false},
+		{"dev@-example.com", false},
+		{"dev@exa_mple.com", false},
+		{"de v@example.com", false},
+		{"dev@example.com ", false},
+		{"dev@@example.com", false},
+		{strings.Repeat("a", 65) + "@example.com", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateEmail(tt.email)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateEmail(%q) error = %v, want valid = %v", tt.email, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidEmailFormat) {
+			t.Errorf("ValidateEmail(%q) error = %v, want it to wrap ErrInvalidEmailFormat", tt.email, err)
+		}
+	}
+}
+
+func TestAccountValidate_UsesValidateEmail(t *testing.T) {
+	account := &Account{Alias: "work", Name: "Dev", Email: "dev@localhost"}
+	if err := account.Validate(); !errors.Is(err, ErrInvalidEmailFormat) {
+		t.Errorf("Validate() error = %v, want ErrInvalidEmailFormat", err)
+	}
+
+	account.Email = "dev+work@example.com"
+	if err := account.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
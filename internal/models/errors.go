@@ -1,6 +1,9 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Common errors for the application
 var (
@@ -11,6 +14,9 @@ var (
 	ErrInvalidGitHubUsername       = errors.New("GitHub username cannot be empty")
 	ErrInvalidEmailFormat          = errors.New("invalid email format")
 	ErrInvalidGitHubUsernameFormat = errors.New("invalid GitHub username format")
+	ErrInvalidUsernameFormat       = errors.New("invalid username format")
+	ErrMissingDomain               = errors.New("custom platform accounts need a domain")
+	ErrInvalidAPIEndpoint          = errors.New("invalid API endpoint")
 
 	// Account management errors
 	ErrAccountNotFound  = errors.New("account not found")
@@ -36,3 +42,70 @@ var (
 	ErrNotInProject         = errors.New("not in a project directory")
 	ErrProjectConfigInvalid = errors.New("invalid project configuration")
 )
+
+// ErrorCategory groups user-facing errors by the area the user has to look at
+type ErrorCategory string
+
+const (
+	CategoryConfig ErrorCategory = "config"
+	CategoryGitHub ErrorCategory = "github"
+	CategoryGitLab ErrorCategory = "gitlab"
+	CategorySSH    ErrorCategory = "ssh"
+)
+
+// UserError is an error meant to be shown to the user together with a hint on how to
+// resolve it
+type UserError struct {
+	Category   ErrorCategory
+	Message    string
+	Suggestion string
+	Err        error
+}
+
+// NewUserError creates a UserError wrapping err, which may be nil
+func NewUserError(category ErrorCategory, message, suggestion string, err error) *UserError {
+	return &UserError{
+		Category:   category,
+		Message:    message,
+		Suggestion: suggestion,
+		Err:        err,
+	}
+}
+
+func (e *UserError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
+
+// NewProjectConfigError reports a project config file that can't be used. The error wraps
+// ErrProjectConfigInvalid and cause.
+func NewProjectConfigError(path string, cause error) *UserError {
+	return NewUserError(
+		CategoryConfig,
+		"cannot use project config "+path,
+		"Fix or remove "+path+"; 'gitshift list' shows the configured accounts",
+		fmt.Errorf("%w: %w", ErrProjectConfigInvalid, cause),
+	)
+}
+
+// ExitError makes gitshift exit with Code instead of the generic failure status of 1, for
+// commands whose exit status tells scripts more than success or failure. Err is printed
+// like any other error.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
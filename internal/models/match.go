@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RemoteMatch is an account whose MatchRules matched a Git remote
+type RemoteMatch struct {
+	Account *Account
+	Rule    string
+
+	segments int // path segments in the rule; more segments is more specific
+	literals int // characters outside wildcards; breaks ties between rules of equal depth
+}
+
+// ValidateMatchRule checks that a match rule has the form host[/owner[/repo]] with valid
+// glob patterns, e.g. "github.com/acme-*" or "gitlab.company.com"
+func ValidateMatchRule(rule string) error {
+	segments := strings.Split(strings.TrimRight(rule, "/"), "/")
+	if segments[0] == "" {
+		return fmt.Errorf("match rule %q has no host", rule)
+	}
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("match rule %q has an invalid pattern %q: %w", rule, segment, err)
+		}
+	}
+	return nil
+}
+
+// ValidateSSHMatch checks that SSH Match criteria fit on a single ssh_config line. ssh
+// itself reports criteria it doesn't understand when it reads the config.
+func ValidateSSHMatch(criteria string) error {
+	switch {
+	case strings.TrimSpace(criteria) == "":
+		return fmt.Errorf("ssh_match criteria are empty")
+	case strings.ContainsAny(criteria, "\r\n"):
+		return fmt.Errorf("ssh_match criteria %q span several lines", criteria)
+	}
+	return nil
+}
+
+// MatchRemote returns the most specific of the account's MatchRules that matches the remote
+// host and repository path (e.g. "github.com" and "acme-corp/api"), or nil when none does.
+// Host and owner are compared case-insensitively, as the platforms treat them.
+func (a *Account) MatchRemote(host, repoPath string) *RemoteMatch {
+	repoSegments := strings.Split(strings.Trim(strings.TrimSuffix(repoPath, ".git"), "/"), "/")
+
+	var best *RemoteMatch
+	for _, rule := range a.MatchRules {
+		ruleSegments := strings.Split(strings.TrimRight(rule, "/"), "/")
+		if len(ruleSegments)-1 > len(repoSegments) {
+			continue
+		}
+		if matched, _ := path.Match(strings.ToLower(ruleSegments[0]), strings.ToLower(host)); !matched {
+			continue
+		}
+
+		matched := true
+		for i, segment := range ruleSegments[1:] {
+			if ok, _ := path.Match(strings.ToLower(segment), strings.ToLower(repoSegments[i])); !ok {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		candidate := &RemoteMatch{
+			Account:  a,
+			Rule:     rule,
+			segments: len(ruleSegments),
+			literals: len(rule) - strings.Count(rule, "*") - strings.Count(rule, "?"),
+		}
+		if best == nil || candidate.moreSpecificThan(best) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// moreSpecificThan orders matches by rule depth, then by the number of literal characters
+func (m *RemoteMatch) moreSpecificThan(other *RemoteMatch) bool {
+	if m.segments != other.segments {
+		return m.segments > other.segments
+	}
+	return m.literals > other.literals
+}
+
+// SelectAccountForRemote picks the account whose MatchRules best match a remote. The most
+// specific rule wins; when several accounts match equally well, the default account is
+// preferred, then the alphabetically first alias. The accounts that tied with the selected
+// one are returned as well so callers can report the ambiguity.
+func SelectAccountForRemote(accounts []*Account, host, repoPath string) (*RemoteMatch, []*Account) {
+	var matches []*RemoteMatch
+	for _, account := range accounts {
+		if match := account.MatchRemote(host, repoPath); match != nil {
+			matches = append(matches, match)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.moreSpecificThan(b) || b.moreSpecificThan(a) {
+			return a.moreSpecificThan(b)
+		}
+		if a.Account.IsDefault != b.Account.IsDefault {
+			return a.Account.IsDefault
+		}
+		return a.Account.Alias < b.Account.Alias
+	})
+
+	var tied []*Account
+	for _, match := range matches[1:] {
+		if !matches[0].moreSpecificThan(match) {
+			tied = append(tied, match.Account)
+		}
+	}
+	return matches[0], tied
+}
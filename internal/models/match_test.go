@@ -0,0 +1,72 @@
+package models
+
+import "testing"
+
+func TestSelectAccountForRemote(t *testing.T) {
+	personal := &Account{Alias: "personal", MatchRules: []string{"github.com"}}
+	work := &Account{Alias: "work", MatchRules: []string{"github.com/acme-*", "gitlab.acme.com"}}
+	api := &Account{Alias: "api", MatchRules: []string{"github.com/acme-corp/api"}}
+	accounts := []*Account{personal, work, api}
+
+	tests := []struct {
+		host, repoPath string
+		want           string
+	}{
+		{"github.com", "octocat/hello", "personal"},
+		{"github.com", "Acme-Corp/web.git", "work"},
+		{"github.com", "acme-corp/api", "api"},
+		{"gitlab.acme.com", "platform/infra", "work"},
+		{"bitbucket.org", "acme-corp/api", ""},
+	}
+
+	for _, tt := range tests {
+		match, _ := SelectAccountForRemote(accounts, tt.host, tt.repoPath)
+		got := ""
+		if match != nil {
+			got = match.Account.Alias
+		}
+		if got != tt.want {
+			t.Errorf("SelectAccountForRemote(%s, %s) = %q, want %q", tt.host, tt.repoPath, got, tt.want)
+		}
+	}
+}
+
+func TestSelectAccountForRemote_TieBreak(t *testing.T) {
+	beta := &Account{Alias: "beta", MatchRules: []string{"github.com/acme"}}
+	alpha := &Account{Alias: "alpha", MatchRules: []string{"github.com/acme"}}
+
+	match, tied := SelectAccountForRemote([]*Account{beta, alpha}, "github.com", "acme/api")
+	if match.Account.Alias != "alpha" || len(tied) != 1 || tied[0].Alias != "beta" {
+		t.Errorf("tie = %s (tied %v), want alpha with beta tied", match.Account.Alias, tied)
+	}
+
+	beta.IsDefault = true
+	match, _ = SelectAccountForRemote([]*Account{beta, alpha}, "github.com", "acme/api")
+	if match.Account.Alias != "beta" {
+		t.Errorf("tie = %s, want the default account beta", match.Account.Alias)
+	}
+}
+
+func TestValidateMatchRule(t *testing.T) {
+	for _, rule := range []string{"github.com", "github.com/acme-*", "*.acme.com/team/?pi"} {
+		if err := ValidateMatchRule(rule); err != nil {
+			t.Errorf("ValidateMatchRule(%q) = %v, want nil", rule, err)
+		}
+	}
+	for _, rule := range []string{"", "/acme", "github.com/[acme"} {
+		if err := ValidateMatchRule(rule); err == nil {
+			t.Errorf("ValidateMatchRule(%q) = nil, want an error", rule)
+		}
+	}
+}
+
+func TestValidateSSHMatch(t *testing.T) {
+	if err := ValidateSSHMatch(`exec "pwd | grep -q /work/"`); err != nil {
+		t.Errorf("ValidateSSHMatch() = %v, want nil", err)
+	}
+	for _, criteria := range []string{"", "  ", "exec true\nHost *"} {
+		if err := ValidateSSHMatch(criteria); err == nil {
+			t.Errorf("ValidateSSHMatch(%q) = nil, want an error", criteria)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Platforms is the list of supported values of Account.Platform
+var Platforms = []string{"github", "gitlab", "bitbucket", "custom"}
+
+// IsKnownPlatform reports whether platform is one of Platforms
+func IsKnownPlatform(platform string) bool {
+	for _, known := range Platforms {
+		if platform == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAPIEndpoint checks that endpoint is an absolute http(s) URL without query or
+// fragment, e.g. "https://git.internal.corp/api/v4". Errors wrap ErrInvalidAPIEndpoint.
+func ValidateAPIEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAPIEndpoint, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("%w: %q must start with https:// or http://", ErrInvalidAPIEndpoint, endpoint)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%w: %q has no host", ErrInvalidAPIEndpoint, endpoint)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("%w: %q may not have a query or fragment", ErrInvalidAPIEndpoint, endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountGetAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		account Account
+		want    string
+	}{
+		{Account{}, "https://api.github.com"},
+		{Account{Domain: "github.corp.com"}, "https://github.corp.com/api/v3"},
+		{Account{Platform: "gitlab", Domain: "git.internal.corp"}, "https://git.internal.corp/api/v4"},
+		{Account{Platform: "bitbucket"}, "https://api.bitbucket.org/2.0"},
+		{Account{Platform: "custom", Domain: "git.internal.corp"}, ""},
+		{Account{Platform: "custom", Domain: "git.internal.corp", APIEndpoint: "https://git.internal.corp/api/v1/"}, "https://git.internal.corp/api/v1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.account.GetAPIEndpoint(); got != tt.want {
+			t.Errorf("GetAPIEndpoint() for %+v = %q, want %q", tt.account, got, tt.want)
+		}
+	}
+}
+
+func TestAccountValidate_CustomPlatform(t *testing.T) {
+	account := &Account{Alias: "corp", Name: "Dev", Email: "dev@corp.com", Platform: "custom"}
+	if err := account.Validate(); !errors.Is(err, ErrMissingDomain) {
+		t.Errorf("Validate() without a domain error = %v, want ErrMissingDomain", err)
+	}
+
+	account.Domain = "git.internal.corp"
+	account.APIEndpoint = "git.internal.corp/api"
+	if err := account.Validate(); !errors.Is(err, ErrInvalidAPIEndpoint) {
+		t.Errorf("Validate() with a relative endpoint error = %v, want ErrInvalidAPIEndpoint", err)
+	}
+
+	account.APIEndpoint = "https://git.internal.corp/api/v1"
+	if err := account.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
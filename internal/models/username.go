@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// UsernameError explains why a username breaks its platform's rules
+type UsernameError struct {
+	Platform string
+	Username string
+	Reason   string // e.g. "GitHub usernames may not contain underscores"
+}
+
+func (e *UsernameError) Error() string {
+	return fmt.Sprintf("invalid username %q: %s", e.Username, e.Reason)
+}
+
+// Unwrap returns ErrInvalidGitHubUsernameFormat for GitHub and ErrInvalidUsernameFormat
+// for the other platforms
+func (e *UsernameError) Unwrap() error {
+	if e.Platform == "github" {
+		return ErrInvalidGitHubUsernameFormat
+	}
+	return ErrInvalidUsernameFormat
+}
+
+// usernameValidators holds the username rules of each known platform; other platforms
+// use validateCustomUsername
+var usernameValidators = map[string]func(string) string{
+	"github":    validateGitHubUsername,
+	"gitlab":    validateGitLabUsername,
+	"bitbucket": validateBitbucketUsername,
+}
+
+// ValidateUsername checks a username against the rules of the platform (github, gitlab,
+// bitbucket). Custom platforms only reject what no platform allows, such as whitespace.
+// Errors are *UsernameError.
+func ValidateUsername(platform, username string) error {
+	validate, ok := usernameValidators[platform]
+	if !ok {
+		validate = validateCustomUsername
+	}
+	if username == "" {
+		return &UsernameError{Platform: platform, Username: username, Reason: "usernames may not be empty"}
+	}
+	if reason := validate(username); reason != "" {
+		return &UsernameError{Platform: platform, Username: username, Reason: reason}
+	}
+	return nil
+}
+
+// validateGitHubUsername returns why username isn't a valid GitHub username, or ""
+func validateGitHubUsername(username string) string {
+	switch {
+	case len(username) > 39:
+		return "GitHub usernames may be at most 39 characters long"
+	case strings.Contains(username, "_"):
+		return "GitHub usernames may not contain underscores"
+	case strings.Contains(username, "."):
+		return "GitHub usernames may not contain dots"
+	case strings.HasPrefix(username, "-") || strings.HasSuffix(username, "-"):
+		return "GitHub usernames may not start or end with a hyphen"
+	case strings.Contains(username, "--"):
+		return "GitHub usernames may not contain consecutive hyphens"
+	}
+	if r, found := findRune(username, func(r rune) bool { return r == '-' || isASCIIAlnum(r) }); found {
+		return fmt.Sprintf("GitHub usernames may only contain letters, digits and hyphens, not %q", r)
+	}
+	return ""
+}
+
+// validateGitLabUsername returns why username isn't a valid GitLab username, or ""
+func validateGitLabUsername(username string) string {
+	lower := strings.ToLower(username)
+	switch {
+	case len(username) < 2:
+		return "GitLab usernames must be at least 2 characters long"
+	case len(username) > 255:
+		return "GitLab usernames may be at most 255 characters long"
+	case !isASCIIAlnum(rune(username[0])) && username[0] != '_':
+		return "GitLab usernames must start with a letter, digit or underscore"
+	case strings.HasSuffix(username, ".") || strings.HasSuffix(lower, ".git") || strings.HasSuffix(lower, ".atom"):
+		return "GitLab usernames may not end with '.', '.git' or '.atom'"
+	}
+	if r, found := findRune(username, func(r rune) bool { return isASCIIAlnum(r) || strings.ContainsRune("_-.", r) }); found {
+		return fmt.Sprintf("GitLab usernames may only contain letters, digits, '_', '-' and '.', not %q", r)
+	}
+	return ""
+}
+
+// validateBitbucketUsername returns why username isn't a valid Bitbucket username, or ""
+func validateBitbucketUsername(username string) string {
+	if len(username) > 30 {
+		return "Bitbucket usernames may be at most 30 characters long"
+	}
+	if r, found := findRune(username, func(r rune) bool { return isASCIIAlnum(r) || r == '_' || r == '-' }); found {
+		return fmt.Sprintf("Bitbucket usernames may only contain letters, digits, '_' and '-', not %q", r)
+	}
+	return ""
+}
+
+// validateCustomUsername only rejects characters that can't appear in an SSH or HTTPS
+// remote's user part, since self-hosted platforms have their own rules
+func validateCustomUsername(username string) string {
+	if len(username) > 255 {
+		return "usernames may be at most 255 characters long"
+	}
+	if r, found := findRune(username, func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.IsControl(r) && !strings.ContainsRune("/:@", r)
+	}); found {
+		return fmt.Sprintf("usernames may not contain %q", r)
+	}
+	return ""
+}
+
+// findRune returns the first rune of s that allowed rejects
+func findRune(s string, allowed func(rune) bool) (rune, bool) {
+	for _, r := range s {
+		if !allowed(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+func isASCIIAlnum(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
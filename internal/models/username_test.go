@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		platform string
+		username string
+		reason   string // substring of the expected error, "" when valid
+	}{
+		{"github", "octo-cat", ""},
+		{"github", "octo_cat", "may not contain underscores"},
+		{"github", "-octocat", "may not start or end with a hyphen"},
+		{"github", "octo--cat", "consecutive hyphens"},
+		{"github", strings.Repeat("a", 40), "at most 39 characters"},
+		{"github", "octo.cat", "may not contain dots"},
+
+		{"gitlab", "octo_cat.dev", ""},
+		{"gitlab", "_octo", ""},
+		{"gitlab", "-octo", "must start with"},
+		{"gitlab", "octo.git", "may not end with"},
+		{"gitlab", "a", "at least 2 characters"},
+		{"gitlab", "octo+cat", "may only contain"},
+
+		{"bitbucket", "octo_cat-1", ""},
+		{"bitbucket", "octo.cat", "may only contain"},
+		{"bitbucket", strings.Repeat("a", 31), "at most 30 characters"},
+
+		{"custom", "Octo.Cat_+1", ""},
+		{"custom", "octo cat", "may not contain"},
+		{"custom", "octo@cat", "may not contain"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateUsername(tt.platform, tt.username)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("ValidateUsername(%q, %q) error = %v, want nil", tt.platform, tt.username, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("ValidateUsername(%q, %q) error = %v, want it to mention %q", tt.platform, tt.username, err, tt.reason)
+		}
+	}
+}
+
+func TestAccountValidate_UsesPlatformUsernameRules(t *testing.T) {
+	account := &Account{Alias: "work", GitHubUsername: "octo_cat"}
+	if err := account.Validate(); !errors.Is(err, ErrInvalidGitHubUsernameFormat) {
+		t.Errorf("Validate() error = %v, want ErrInvalidGitHubUsernameFormat", err)
+	}
+
+	account.Platform = "gitlab"
+	if err := account.Validate(); err != nil {
+		t.Errorf("Validate() of a GitLab account error = %v, want nil", err)
+	}
+}
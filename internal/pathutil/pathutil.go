@@ -0,0 +1,83 @@
+// Package pathutil resolves the file paths users write in the configuration and on the
+// command line, such as SSH key paths, to the absolute paths gitshift works with.
+package pathutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Expand returns path as an absolute, clean path: it is expanded by ExpandUser and a
+// relative path is taken from the current directory. An empty path stays empty.
+func Expand(path string) string {
+	path = ExpandUser(path)
+	if path == "" {
+		return ""
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// ExpandUser replaces $VAR and ${VAR} by the environment variables that are set (others
+// are left as written, so they show up in error messages) and a leading "~" or "~/" by
+// the user's home directory, which is left alone when it is unknown. Unlike Expand, a
+// relative path stays relative.
+func ExpandUser(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	path = os.Expand(path, func(name string) string {
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return "${" + name + "}"
+	})
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// NotExistError reports a path that doesn't exist once expanded, naming it both as it was
+// written and as it was expanded
+type NotExistError struct {
+	Path     string // as written
+	Expanded string
+}
+
+func (e *NotExistError) Error() string {
+	if e.Path == e.Expanded {
+		return fmt.Sprintf("%s does not exist", e.Path)
+	}
+	return fmt.Sprintf("%s (expanded to %s) does not exist", e.Path, e.Expanded)
+}
+
+// Unwrap makes errors.Is(err, fs.ErrNotExist) hold
+func (e *NotExistError) Unwrap() error {
+	return fs.ErrNotExist
+}
+
+// Stat is os.Stat of the expanded path. When nothing is there, the error is a
+// *NotExistError; other errors name the expanded path.
+func Stat(path string) (os.FileInfo, error) {
+	expanded := Expand(path)
+	info, err := os.Stat(expanded)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &NotExistError{Path: path, Expanded: expanded}
+	}
+	return info, err
+}
@@ -0,0 +1,93 @@
+package pathutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KEYS", "/srv/keys")
+	t.Setenv("UNSET_FOR_TEST", "")
+	os.Unsetenv("UNSET_FOR_TEST")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "~", want: home},
+		{path: "~/.ssh/id_ed25519", want: filepath.Join(home, ".ssh", "id_ed25519")},
+		{path: "$HOME/.ssh/id_ed25519", want: filepath.Join(home, ".ssh", "id_ed25519")},
+		{path: "${HOME}/.ssh//id_work", want: filepath.Join(home, ".ssh", "id_work")},
+		{path: "$KEYS/id_work", want: "/srv/keys/id_work"},
+		{path: "/keys/../keys/id_work", want: "/keys/id_work"},
+		{path: "keys/id_work", want: filepath.Join(cwd, "keys", "id_work")},
+		{path: "./id_work", want: filepath.Join(cwd, "id_work")},
+		// Only the current user's home is known
+		{path: "~dev/.ssh/id_work", want: filepath.Join(cwd, "~dev", ".ssh", "id_work")},
+		// A variable that isn't set is left as written rather than dropped
+		{path: "/keys/$UNSET_FOR_TEST/id_work", want: "/keys/${UNSET_FOR_TEST}/id_work"},
+	}
+	for _, tt := range tests {
+		if got := Expand(tt.path); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// Expanding an expanded path changes nothing
+	for _, tt := range tests {
+		if got := Expand(tt.want); got != tt.want {
+			t.Errorf("Expand(%q) of an expanded path = %q", tt.want, got)
+		}
+	}
+}
+
+func TestExpandUser_KeepsRelativePaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if got, want := ExpandUser("$HOME/.config"), filepath.Join(home, ".config"); got != want {
+		t.Errorf("ExpandUser($HOME/.config) = %q, want %q", got, want)
+	}
+	if got := ExpandUser("relative/dir"); got != "relative/dir" {
+		t.Errorf("ExpandUser(relative/dir) = %q, want it unchanged", got)
+	}
+}
+
+func TestStat_NamesBothFormsOfAMissingPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	key := filepath.Join(home, "id_work")
+	if err := os.WriteFile(key, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Stat("$HOME/id_work"); err != nil {
+		t.Errorf("Stat($HOME/id_work) of an existing key = %v", err)
+	}
+
+	_, err := Stat("$HOME/id_gone")
+	var notExist *NotExistError
+	if !errors.As(err, &notExist) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Stat() of a missing key = %v, want a NotExistError", err)
+	}
+	if want := "$HOME/id_gone (expanded to " + filepath.Join(home, "id_gone") + ") does not exist"; err.Error() != want {
+		t.Errorf("Stat() error = %q, want %q", err, want)
+	}
+
+	// An absolute path is only named once
+	_, err = Stat(filepath.Join(home, "id_gone"))
+	if want := filepath.Join(home, "id_gone") + " does not exist"; err == nil || err.Error() != want {
+		t.Errorf("Stat() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,115 @@
+// Package picker lets the user choose an account on the terminal when a command wasn't
+// told which one to use. The accounts are listed with their name, email and platform;
+// typing part of any of these narrows the list, typing a number picks an account.
+package picker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// ErrCancelled is returned when the user quit the picker without choosing an account
+var ErrCancelled = errors.New("no account selected")
+
+// Picker asks for an account on in and writes the list and prompts to out
+type Picker struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New returns a picker reading the user's answers from in and writing to out
+func New(in io.Reader, out io.Writer) *Picker {
+	return &Picker{in: bufio.NewReader(in), out: out}
+}
+
+// Pick lists accounts under title and returns the one the user chose. An empty answer
+// shows the whole list again; "q" or the end of input cancels with ErrCancelled.
+func (p *Picker) Pick(title string, accounts []*models.Account) (*models.Account, error) {
+	if len(accounts) == 0 {
+		return nil, models.ErrNoAccountsFound
+	}
+
+	shown := accounts
+	fmt.Fprintf(p.out, "%s\n", title)
+	for {
+		p.list(shown)
+		fmt.Fprintf(p.out, "Number, or text to filter by (q to quit): ")
+
+		answer, err := p.in.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if err != nil && answer == "" {
+			fmt.Fprintln(p.out)
+			return nil, ErrCancelled
+		}
+
+		switch {
+		case answer == "":
+			shown = accounts
+		case answer == "q" || answer == "quit":
+			return nil, ErrCancelled
+		default:
+			if n, convErr := strconv.Atoi(answer); convErr == nil {
+				if n >= 1 && n <= len(shown) {
+					return shown[n-1], nil
+				}
+				fmt.Fprintf(p.out, "❌ Choose a number between 1 and %d\n", len(shown))
+				continue
+			}
+
+			filtered := Filter(accounts, answer)
+			switch len(filtered) {
+			case 0:
+				fmt.Fprintf(p.out, "❌ No account matches %q\n", answer)
+			case 1:
+				return filtered[0], nil
+			default:
+				shown = filtered
+			}
+		}
+	}
+}
+
+// list prints the accounts numbered from 1
+func (p *Picker) list(accounts []*models.Account) {
+	for i, account := range accounts {
+		fmt.Fprintf(p.out, "  %d. %-15s %s <%s>  %s\n", i+1, account.Alias, account.Name, account.Email, account.GetPlatform())
+	}
+}
+
+// Filter returns the accounts whose alias, name, email or platform fuzzily matches query:
+// the characters of the query appear in that order, case-insensitively, e.g. "wgh" matches
+// the alias "work-github". Accounts matching on the alias come first.
+func Filter(accounts []*models.Account, query string) []*models.Account {
+	query = strings.ToLower(query)
+
+	var byAlias, byOther []*models.Account
+	for _, account := range accounts {
+		switch {
+		case fuzzyMatch(account.Alias, query):
+			byAlias = append(byAlias, account)
+		case fuzzyMatch(account.Name, query), fuzzyMatch(account.Email, query), fuzzyMatch(account.GetPlatform(), query):
+			byOther = append(byOther, account)
+		}
+	}
+	return append(byAlias, byOther...)
+}
+
+// fuzzyMatch reports whether the characters of the lowercase query appear in s in order
+func fuzzyMatch(s, query string) bool {
+	remaining := []rune(query)
+	for _, r := range strings.ToLower(s) {
+		if len(remaining) == 0 {
+			break
+		}
+		if r == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
@@ -0,0 +1,85 @@
+package picker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func testAccounts() []*models.Account {
+	return []*models.Account{
+		{Alias: "work-github", Name: "Dev", Email: "dev@acme.com", Platform: "github"},
+		{Alias: "work-gitlab", Name: "Dev", Email: "dev@acme.com", Platform: "gitlab"},
+		{Alias: "personal", Name: "Dev Home", Email: "dev@home.org", Platform: "github"},
+	}
+}
+
+func aliases(accounts []*models.Account) string {
+	var names []string
+	for _, account := range accounts {
+		names = append(names, account.Alias)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"wgh", "work-github"},
+		{"WORK", "work-github,work-gitlab"},
+		{"home.org", "personal"},
+		{"gitlab", "work-gitlab"},
+		// Accounts matching on the alias come before those matching elsewhere
+		{"g", "work-github,work-gitlab,personal"},
+		{"xyz", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := aliases(Filter(testAccounts(), tt.query)); got != tt.want {
+				t.Errorf("Filter(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "number", input: "3\n", want: "personal"},
+		{name: "unique filter", input: "pers\n", want: "personal"},
+		{name: "filter then number", input: "work\n2\n", want: "work-gitlab"},
+		{name: "invalid number then retry", input: "7\n1\n", want: "work-github"},
+		{name: "no match then retry", input: "xyz\nwgl\n", want: "work-gitlab"},
+		{name: "quit", input: "q\n", wantErr: ErrCancelled},
+		{name: "end of input", input: "", wantErr: ErrCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			account, err := New(strings.NewReader(tt.input), &out).Pick("Choose an account:", testAccounts())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Pick() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && account.Alias != tt.want {
+				t.Errorf("Pick() = %s, want %s", account.Alias, tt.want)
+			}
+			if !strings.Contains(out.String(), "dev@home.org") {
+				t.Errorf("accounts were not listed:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestPick_NoAccounts(t *testing.T) {
+	if _, err := New(strings.NewReader("1\n"), &strings.Builder{}).Pick("Choose:", nil); !errors.Is(err, models.ErrNoAccountsFound) {
+		t.Errorf("Pick() error = %v, want ErrNoAccountsFound", err)
+	}
+}
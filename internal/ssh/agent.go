@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// AgentStatus is the state of the SSH agent SSH_AUTH_SOCK points to
+type AgentStatus int
+
+const (
+	// AgentNotRunning means SSH_AUTH_SOCK is not set
+	AgentNotRunning AgentStatus = iota
+	// AgentStale means SSH_AUTH_SOCK points to a socket no agent listens on anymore, or
+	// to no socket at all; a new agent has to be started
+	AgentStale
+	// AgentUnreachable means the socket exists but the agent can't be talked to, e.g.
+	// because of its permissions
+	AgentUnreachable
+	// AgentNoIdentities means the agent is running without any key loaded
+	AgentNoIdentities
+	// AgentRunning means the agent is running with at least one key loaded
+	AgentRunning
+)
+
+func (s AgentStatus) String() string {
+	switch s {
+	case AgentNotRunning:
+		return "not running"
+	case AgentStale:
+		return "stale"
+	case AgentUnreachable:
+		return "unreachable"
+	case AgentNoIdentities:
+		return "no identities"
+	case AgentRunning:
+		return "running"
+	}
+	return fmt.Sprintf("AgentStatus(%d)", int(s))
+}
+
+// Usable reports whether keys can be listed, loaded and removed in the agent
+func (s AgentStatus) Usable() bool {
+	return s == AgentNoIdentities || s == AgentRunning
+}
+
+// GetAgentStatus tells apart the ways the agent in SSH_AUTH_SOCK can be unavailable. The
+// socket is dialed before ssh-add runs, since ssh-add reports a dead agent the same way as
+// a missing one. The error explains any status other than AgentRunning and
+// AgentNoIdentities.
+func (m *Manager) GetAgentStatus(ctx context.Context) (AgentStatus, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return AgentNotRunning, errors.New("SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.DialTimeout("unix", socket, agentDialTimeout)
+	switch {
+	case err == nil:
+		conn.Close()
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, os.ErrNotExist):
+		return AgentStale, fmt.Errorf("SSH_AUTH_SOCK points to %s, but the agent behind it is gone", socket)
+	default:
+		return AgentUnreachable, fmt.Errorf("cannot connect to the SSH agent at %s: %w", socket, err)
+	}
+
+	output, err := m.runner.CombinedOutput(ctx, "ssh-add", "-l")
+	switch {
+	case err == nil:
+		return AgentRunning, nil
+	case strings.Contains(string(output), "The agent has no identities"):
+		return AgentNoIdentities, nil
+	default:
+		return AgentUnreachable, fmt.Errorf("the SSH agent at %s is not responding: %w", socket, err)
+	}
+}
+
+// IsAgentRunning reports whether the agent in SSH_AUTH_SOCK accepts requests, with or
+// without keys loaded
+func (m *Manager) IsAgentRunning(ctx context.Context) bool {
+	status, _ := m.GetAgentStatus(ctx)
+	return status.Usable()
+}
+
+// IdentitiesOnly reports whether ssh only offers the configured IdentityFile keys to host,
+// rather than every key in the agent, as resolved by ssh -G from ~/.ssh/config
+func (m *Manager) IdentitiesOnly(ctx context.Context, host string) (bool, error) {
+	output, err := m.runner.CombinedOutput(ctx, "ssh", "-F", m.configPath, "-G", host)
+	if err != nil {
+		message, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		return false, fmt.Errorf("failed to resolve the SSH config for %s: %w: %s", host, err, message)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if keyword, value, ok := strings.Cut(strings.TrimSpace(line), " "); ok && keyword == "identitiesonly" {
+			return value == "yes", nil
+		}
+	}
+	return false, nil
+}
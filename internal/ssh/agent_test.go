@@ -0,0 +1,69 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetAgentStatus(t *testing.T) {
+	dir := t.TempDir()
+	live := filepath.Join(dir, "live.sock")
+	dead := filepath.Join(dir, "dead.sock")
+	listenUnix(t, live, false)
+	listenUnix(t, dead, true)
+
+	exitStatus := errors.New("exit status 1")
+	tests := []struct {
+		name   string
+		socket string
+		sshAdd fakeOutput
+		want   AgentStatus
+	}{
+		{name: "unset", socket: "", want: AgentNotRunning},
+		{name: "refused", socket: dead, want: AgentStale},
+		{name: "missing socket", socket: filepath.Join(dir, "gone.sock"), want: AgentStale},
+		{name: "no identities", socket: live, sshAdd: fakeOutput{output: "The agent has no identities.\n", err: exitStatus}, want: AgentNoIdentities},
+		{name: "keys loaded", socket: live, sshAdd: fakeOutput{output: "256 SHA256:sBNGdLe3fTvJBZw/8HDxY/3B0iRQKzfJ7UMcD0DqQ4U dev@example.com (ED25519)\n"}, want: AgentRunning},
+		{name: "agent error", socket: live, sshAdd: fakeOutput{output: "error fetching identities: communication with agent failed\n", err: exitStatus}, want: AgentUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SSH_AUTH_SOCK", tt.socket)
+			m := newTestManager(t)
+			// ssh-add must not run when the socket already tells the agent is gone
+			m.runner = fakeRunner{}
+			if tt.socket == live {
+				m.runner = fakeRunner{"ssh-add -l": tt.sshAdd}
+			}
+
+			status, err := m.GetAgentStatus(context.Background())
+			if status != tt.want {
+				t.Errorf("GetAgentStatus() = %v (%v), want %v", status, err, tt.want)
+			}
+			if (err == nil) != status.Usable() {
+				t.Errorf("GetAgentStatus() error = %v for status %v", err, status)
+			}
+		})
+	}
+}
+
+func TestIdentitiesOnly(t *testing.T) {
+	m := newTestManager(t)
+	resolve := "ssh -F " + m.configPath + " -G "
+	m.runner = fakeRunner{
+		resolve + "github.com":  {output: "user git\nhostname github.com\nidentitiesonly yes\nidentityfile ~/.ssh/id_work\n"},
+		resolve + "gitlab.com":  {output: "user git\nhostname gitlab.com\nidentitiesonly no\n"},
+		resolve + "example.com": {output: "Bad configuration option: usekeychain\n", err: errors.New("exit status 255")},
+	}
+
+	for host, want := range map[string]bool{"github.com": true, "gitlab.com": false} {
+		if got, err := m.IdentitiesOnly(context.Background(), host); err != nil || got != want {
+			t.Errorf("IdentitiesOnly(%s) = %v, %v, want %v", host, got, err, want)
+		}
+	}
+	if _, err := m.IdentitiesOnly(context.Background(), "example.com"); err == nil {
+		t.Error("IdentitiesOnly() succeeded although ssh -G failed")
+	}
+}
@@ -0,0 +1,201 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+const (
+	// configBackupInfix is inserted between the config path and the timestamp of a backup,
+	// e.g. ~/.ssh/config.gitshift-backup-20250102-150405.000000000
+	configBackupInfix = ".gitshift-backup-"
+	// configBackupTimeFormat sorts lexically and keeps backups taken within a second distinct
+	configBackupTimeFormat = "20060102-150405.000000000"
+	// legacyConfigBackupSuffix is the single backup file written by older gitshift versions
+	legacyConfigBackupSuffix = ".backup"
+	// maxConfigBackups is the number of timestamped backups kept before the oldest are pruned
+	maxConfigBackups = 20
+)
+
+// ConfigBackup describes a backup of the SSH config file
+type ConfigBackup struct {
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// ListConfigBackups returns the available SSH config backups, newest first
+func (m *Manager) ListConfigBackups() ([]ConfigBackup, error) {
+	matches, err := filepath.Glob(m.configPath + configBackupInfix + "*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSH config backups: %w", err)
+	}
+	matches = append(matches, m.configPath+legacyConfigBackupSuffix)
+
+	var backups []ConfigBackup
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		createdAt := info.ModTime()
+		if strings.HasPrefix(path, m.configPath+configBackupInfix) {
+			stamp := strings.TrimPrefix(path, m.configPath+configBackupInfix)
+			if parsed, err := time.ParseInLocation(configBackupTimeFormat, stamp, time.UTC); err == nil {
+				createdAt = parsed
+			}
+		}
+
+		backups = append(backups, ConfigBackup{
+			Path:      path,
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// RestoreConfig replaces the SSH config with the contents of a backup. The current config is
+// backed up first, so a restore can itself be undone.
+func (m *Manager) RestoreConfig(ctx context.Context, backupPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !m.isConfigBackupPath(backupPath) {
+		return fmt.Errorf("%s is not a gitshift SSH config backup", backupPath)
+	}
+
+	// Re-check the backup now: it may have been deleted or edited since it was listed
+	info, err := os.Lstat(backupPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("backup %s no longer exists", backupPath)
+		}
+		return fmt.Errorf("failed to inspect backup %s: %w", backupPath, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("backup %s is not a regular file", backupPath)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("backup %s is writable by other users (mode %04o), refusing to restore it", backupPath, info.Mode().Perm())
+	}
+
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", backupPath, err)
+	}
+	if err := validateConfigSyntax(string(content)); err != nil {
+		return fmt.Errorf("backup %s is not a valid SSH config: %w", backupPath, err)
+	}
+	if err := m.checkConfigSyntax(ctx, string(content), backupPath); err != nil {
+		return models.NewUserError(
+			models.CategorySSH,
+			fmt.Sprintf("ssh rejected backup %s, %s was left unchanged", backupPath, m.configPath),
+			"Pick another backup, or fix the directive ssh rejects in a copy of this one",
+			err,
+		)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Never overwrite the current config without keeping a copy of it
+	if _, err := m.backupSSHConfig(); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(m.configPath, content, 0600); err != nil {
+		return fmt.Errorf("failed to restore SSH config: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file, so enforce it explicitly
+	if err := os.Chmod(m.configPath, 0600); err != nil {
+		return fmt.Errorf("failed to set SSH config permissions: %w", err)
+	}
+
+	return nil
+}
+
+// backupSSHConfig copies the current SSH config to a new timestamped backup and returns its
+// path. It returns an empty path when there is no config to back up.
+func (m *Manager) backupSSHConfig() (string, error) {
+	content, err := os.ReadFile(m.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read SSH config for backup: %w", err)
+	}
+
+	backupPath := m.configPath + configBackupInfix + time.Now().UTC().Format(configBackupTimeFormat)
+	if err := os.WriteFile(backupPath, content, 0600); err != nil {
+		return "", fmt.Errorf("failed to backup SSH config: %w", err)
+	}
+
+	m.pruneConfigBackups()
+
+	return backupPath, nil
+}
+
+// pruneConfigBackups removes the oldest timestamped backups beyond maxConfigBackups
+func (m *Manager) pruneConfigBackups() {
+	matches, err := filepath.Glob(m.configPath + configBackupInfix + "*")
+	if err != nil || len(matches) <= maxConfigBackups {
+		return
+	}
+
+	// Timestamps sort lexically, so the oldest backups come first
+	sort.Strings(matches)
+	for _, path := range matches[:len(matches)-maxConfigBackups] {
+		_ = os.Remove(path)
+	}
+}
+
+// isConfigBackupPath reports whether path names a backup of this manager's SSH config
+func (m *Manager) isConfigBackupPath(path string) bool {
+	cleaned := filepath.Clean(path)
+	if cleaned == m.configPath+legacyConfigBackupSuffix {
+		return true
+	}
+	stamp, ok := strings.CutPrefix(cleaned, m.configPath+configBackupInfix)
+	return ok && stamp != "" && !strings.ContainsRune(stamp, filepath.Separator)
+}
+
+// validateConfigSyntax performs a light syntax check of SSH config content: every
+// non-comment line must be a keyword followed by a value
+func validateConfigSyntax(content string) error {
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		keyword, value, found := strings.Cut(trimmed, "=")
+		if !found || strings.ContainsAny(keyword, " \t") {
+			fields := strings.Fields(trimmed)
+			if len(fields) < 2 {
+				return fmt.Errorf("line %d: missing value for %q", i+1, trimmed)
+			}
+			keyword, value = fields[0], strings.Join(fields[1:], " ")
+		}
+
+		if strings.TrimSpace(keyword) == "" || strings.TrimSpace(value) == "" {
+			return fmt.Errorf("line %d: malformed directive %q", i+1, trimmed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+func TestRestoreConfig(t *testing.T) {
+	m := newTestManager(t)
+
+	if err := os.WriteFile(m.configPath, []byte(userSSHConfig), 0600); err != nil {
+		t.Fatalf("failed to write SSH config: %v", err)
+	}
+	if err := m.UpdateSSHConfig("work", "/keys/id_ed25519_work", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig failed: %v", err)
+	}
+
+	backups, err := m.ListConfigBackups()
+	if err != nil {
+		t.Fatalf("ListConfigBackups failed: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup after update, got %d", len(backups))
+	}
+
+	if err := m.RestoreConfig(context.Background(), backups[0].Path); err != nil {
+		t.Fatalf("RestoreConfig failed: %v", err)
+	}
+
+	restored, err := os.ReadFile(m.configPath)
+	if err != nil {
+		t.Fatalf("failed to read restored config: %v", err)
+	}
+	if string(restored) != userSSHConfig {
+		t.Errorf("restored config differs from the original:\n%s", restored)
+	}
+
+	// The managed config that was overwritten must have been backed up, newest first
+	backups, err = m.ListConfigBackups()
+	if err != nil {
+		t.Fatalf("ListConfigBackups failed: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups after restore, got %d", len(backups))
+	}
+	newest, err := os.ReadFile(backups[0].Path)
+	if err != nil {
+		t.Fatalf("failed to read newest backup: %v", err)
+	}
+	if !strings.Contains(string(newest), managedBlockBegin) {
+		t.Errorf("newest backup should hold the managed config that was replaced:\n%s", newest)
+	}
+}
+
+func TestRestoreConfig_RejectsMissingOrUnsafeBackups(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	missing := m.configPath + configBackupInfix + "20250102-150405.000000000"
+	if err := m.RestoreConfig(ctx, missing); err == nil || !strings.Contains(err.Error(), "no longer exists") {
+		t.Errorf("expected missing backup error, got %v", err)
+	}
+
+	if err := m.RestoreConfig(ctx, "/etc/passwd"); err == nil {
+		t.Errorf("expected an error restoring a file that is not a backup")
+	}
+
+	writable := m.configPath + configBackupInfix + "20250102-150406.000000000"
+	if err := os.WriteFile(writable, []byte(userSSHConfig), 0600); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+	if err := os.Chmod(writable, 0666); err != nil {
+		t.Fatalf("failed to chmod backup: %v", err)
+	}
+	if err := m.RestoreConfig(ctx, writable); err == nil {
+		t.Errorf("expected an error restoring a world-writable backup")
+	}
+
+	if err := os.Chmod(writable, 0600); err != nil {
+		t.Fatalf("failed to chmod backup: %v", err)
+	}
+	if err := os.WriteFile(writable, []byte("Host\n"), 0600); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+	if err := m.RestoreConfig(ctx, writable); err == nil {
+		t.Errorf("expected an error restoring a malformed backup")
+	}
+
+	if _, err := os.Stat(m.configPath); !os.IsNotExist(err) {
+		t.Errorf("failed restores must not create an SSH config")
+	}
+}
+
+func TestRestoreConfig_RejectsBackupSSHCannotParse(t *testing.T) {
+	m := newTestManager(t)
+	current := "Host github.com\n    IdentityFile ~/.ssh/id_work\n"
+	if err := os.WriteFile(m.configPath, []byte(current), 0600); err != nil {
+		t.Fatalf("failed to write SSH config: %v", err)
+	}
+	backup := m.configPath + configBackupInfix + "20250102-150405.000000000"
+	if err := os.WriteFile(backup, []byte("Host github.com\n    UseKeychain yes\n"), 0600); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+	m.runner = runnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(args[2] + ": line 2: Bad configuration option: usekeychain\n"), errors.New("exit status 255")
+	})
+
+	err := m.RestoreConfig(context.Background(), backup)
+	var userErr *models.UserError
+	if !errors.As(err, &userErr) || userErr.Category != models.CategorySSH {
+		t.Fatalf("RestoreConfig() error = %v, want an SSH UserError", err)
+	}
+	if !strings.Contains(err.Error(), backup+": line 2: Bad configuration option") {
+		t.Errorf("error %q doesn't point at the backup", err)
+	}
+
+	content, _ := os.ReadFile(m.configPath)
+	if string(content) != current {
+		t.Errorf("config was replaced by a backup ssh rejects:\n%s", content)
+	}
+}
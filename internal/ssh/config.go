@@ -0,0 +1,328 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/sshconfig"
+)
+
+const (
+	// managedBlockBegin and managedBlockEnd delimit the host block gitshift owns for a
+	// platform domain, e.g. "# BEGIN gitshift github.com" ... "# END gitshift github.com"
+	managedBlockBegin = "# BEGIN gitshift"
+	managedBlockEnd   = "# END gitshift"
+
+	// legacyManagedHeader marks configs written by older gitshift versions, which rewrote
+	// the whole file instead of using delimited blocks
+	legacyManagedHeader = "# gitshift Managed Config - DO NOT EDIT MANUALLY"
+	legacyManagedNotice = "# This file is automatically generated by gitshift"
+)
+
+const (
+	// configCheckHost is the host ssh -G resolves when checking a config. It matches no
+	// real host, so the exec criteria of gitshift's Match blocks, which follow a host
+	// criterion, are never run.
+	configCheckHost = "gitshift-config-check.invalid"
+
+	// configCheckTimeout bounds the ssh -G run; it only parses the config
+	configCheckTimeout = 10 * time.Second
+)
+
+// MatchIdentity is the key of another account on a domain. ssh offers it through the
+// account's <domain>-<alias> host alias, and for the bare domain too whenever Criteria
+// hold, e.g. exec "pwd | grep -q /work/". Criteria is empty for accounts only reachable
+// through their host alias.
+type MatchIdentity struct {
+	Account  string
+	Criteria string
+	KeyPath  string
+}
+
+// BlockOptions selects the optional directives of the managed Host block
+type BlockOptions struct {
+	// AddKeysToAgent adds the key to the running ssh-agent the first time ssh uses it
+	AddKeysToAgent bool
+	// UseKeychain stores the key's passphrase in the macOS keychain. Only Apple's ssh
+	// understands it; everywhere else it makes ssh reject the whole config.
+	UseKeychain bool
+}
+
+// DefaultBlockOptions returns the options used when none are configured
+func DefaultBlockOptions() BlockOptions {
+	return BlockOptions{AddKeysToAgent: true, UseKeychain: true}
+}
+
+// BlockOptionsFor returns the options selected in the ssh_config settings
+func BlockOptionsFor(settings models.SSHConfigSettings) BlockOptions {
+	return BlockOptions{
+		AddKeysToAgent: settings.AddKeysToAgentEnabled(),
+		UseKeychain:    settings.UseKeychainEnabled(),
+	}
+}
+
+// MatchIdentities returns the identities of the accounts with an SSH key other than target
+// on its domain: one per ssh_match criteria, or one without criteria for an account that
+// has none. They are sorted by alias so the generated config is stable.
+func MatchIdentities(accounts []*models.Account, target *models.Account) []MatchIdentity {
+	var others []*models.Account
+	for _, account := range accounts {
+		if account.Alias != target.Alias && account.SSHKeyPath != "" &&
+			strings.EqualFold(account.GetDomain(), target.GetDomain()) {
+			others = append(others, account)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Alias < others[j].Alias })
+
+	var matches []MatchIdentity
+	for _, account := range others {
+		if len(account.SSHMatch) == 0 {
+			matches = append(matches, MatchIdentity{Account: account.Alias, KeyPath: account.SSHKeyPath})
+		}
+		for _, criteria := range account.SSHMatch {
+			matches = append(matches, MatchIdentity{Account: account.Alias, Criteria: criteria, KeyPath: account.SSHKeyPath})
+		}
+	}
+	return matches
+}
+
+// buildManagedBlock renders the delimited host block for an account on a platform domain.
+// Match blocks for matches with criteria come first: IdentityFile accumulates across
+// blocks and ssh offers keys in the order it read them, so a matching key is tried before
+// the active account's. Every account in matches also gets a Host block of its own for its
+// <domain>-<alias> host alias, so remotes pinned to it resolve whichever account is active.
+// Without an accountAlias only those are written. Key paths are written expanded, since
+// ssh doesn't resolve $HOME or relative paths the way gitshift does.
+func buildManagedBlock(accountAlias, keyPath, domain string, matches []MatchIdentity, opts BlockOptions) string {
+	// Determine platform name for comment
+	platformName := "Git hosting"
+	switch domain {
+	case "github.com":
+		platformName = "GitHub"
+	case "gitlab.com":
+		platformName = "GitLab"
+	case "bitbucket.org":
+		platformName = "Bitbucket"
+	}
+
+	var block strings.Builder
+	fmt.Fprintf(&block, "%s %s\n", managedBlockBegin, domain)
+	for _, match := range matches {
+		if match.Criteria != "" {
+			fmt.Fprintf(&block, "# %s account when it matches: %s\nMatch host %s %s\n    IdentityFile %s\n    IdentitiesOnly yes\n",
+				match.Account, match.Criteria, domain, match.Criteria, pathutil.Expand(match.KeyPath))
+		}
+	}
+
+	// The active account's block answers both to the bare domain and to its host alias
+	if accountAlias != "" {
+		fmt.Fprintf(&block, "# %s account: %s\n", platformName, accountAlias)
+		writeHostBlock(&block, domain+" "+HostAlias(domain, accountAlias), domain, keyPath, opts)
+	}
+	written := map[string]bool{accountAlias: true}
+	for _, match := range matches {
+		if written[match.Account] {
+			continue
+		}
+		written[match.Account] = true
+		fmt.Fprintf(&block, "# %s account: %s\n", platformName, match.Account)
+		writeHostBlock(&block, HostAlias(domain, match.Account), domain, match.KeyPath, opts)
+	}
+
+	fmt.Fprintf(&block, "%s %s\n", managedBlockEnd, domain)
+	return block.String()
+}
+
+// writeHostBlock writes a Host block for patterns that connects to domain with only keyPath
+func writeHostBlock(block *strings.Builder, patterns, domain, keyPath string, opts BlockOptions) {
+	fmt.Fprintf(block, "Host %s\n    HostName %s\n    User git\n    IdentityFile %s\n    IdentitiesOnly yes\n",
+		patterns, domain, pathutil.Expand(keyPath))
+	if opts.AddKeysToAgent {
+		block.WriteString("    AddKeysToAgent yes\n")
+	}
+	if opts.UseKeychain {
+		block.WriteString("    UseKeychain yes\n")
+	}
+}
+
+// managedBlockAccount returns the account the managed block for domain was written for
+// and the key it uses for the bare domain. found is false when config has no block for
+// the domain; alias is "" when the block only holds host aliases.
+func managedBlockAccount(config, domain string) (alias, keyPath string, found bool) {
+	begin := managedBlockBegin + " " + domain
+	end := managedBlockEnd + " " + domain
+
+	var block []string
+	for _, line := range strings.SplitAfter(config, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !found {
+			found = trimmed == begin
+			continue
+		}
+		if trimmed == end {
+			break
+		}
+		block = append(block, line)
+	}
+
+	for _, host := range sshconfig.Parse(strings.Join(block, "")).Blocks {
+		if len(host.Patterns) == 2 && strings.EqualFold(host.Patterns[0], domain) {
+			alias = strings.TrimPrefix(host.Patterns[1], domain+"-")
+			return alias, host.Value("identityfile"), found
+		}
+	}
+	return "", "", found
+}
+
+// checkConfigSyntax has ssh parse config with ssh -G -F, so directives this ssh doesn't
+// support, like UseKeychain outside macOS, are caught before the config is installed. The
+// returned error is ssh's parser output, with the file named name. Without an ssh binary
+// there is nothing to check against and the config is accepted.
+func (m *Manager) checkConfigSyntax(ctx context.Context, config, name string) error {
+	// Write next to the real config so the file sits on the same filesystem and permissions
+	tmpFile, err := os.CreateTemp(filepath.Dir(m.configPath), ".config.gitshift-check-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary SSH config: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	if _, err := tmpFile.WriteString(config); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write temporary SSH config: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary SSH config: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, configCheckTimeout)
+	defer cancel()
+	output, err := m.runner.CombinedOutput(ctx, "ssh", "-G", "-F", tmpFile.Name(), configCheckHost)
+	if err == nil || errors.Is(err, exec.ErrNotFound) {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("ssh -G did not finish: %w", ctxErr)
+	}
+
+	message := strings.TrimSpace(strings.ReplaceAll(string(output), tmpFile.Name(), name))
+	if message == "" {
+		return err
+	}
+	return errors.New(message)
+}
+
+// replaceManagedBlock swaps the managed block for domain inside config with block.
+// When config has no block for the domain yet, the block is inserted ahead of the first
+// Host/Match section so it takes precedence over user entries while global directives at
+// the top of the file stay global. Content outside the block is never modified.
+func replaceManagedBlock(config, domain, block string) string {
+	config = stripLegacyManagedConfig(config)
+
+	lines := strings.SplitAfter(config, "\n")
+	begin := managedBlockBegin + " " + domain
+	end := managedBlockEnd + " " + domain
+
+	start := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 && trimmed == begin {
+			start = i
+			continue
+		}
+		if start >= 0 && trimmed == end {
+			return strings.Join(lines[:start], "") + block + strings.Join(lines[i+1:], "")
+		}
+	}
+
+	// A begin marker without its end marker means the block was damaged by hand; drop the
+	// dangling marker and insert a fresh block rather than guessing where it ended
+	if start >= 0 {
+		lines = append(lines[:start:start], lines[start+1:]...)
+	}
+
+	insertAt := len(lines)
+	for i, line := range lines {
+		if isSectionStart(line) {
+			insertAt = i
+			break
+		}
+	}
+	// Keep comments that introduce the first section attached to it
+	for insertAt > 0 && insertAt < len(lines) {
+		previous := strings.TrimSpace(lines[insertAt-1])
+		if !strings.HasPrefix(previous, "#") || strings.HasPrefix(previous, managedBlockEnd) {
+			break
+		}
+		insertAt--
+	}
+
+	before := strings.Join(lines[:insertAt], "")
+	after := strings.Join(lines[insertAt:], "")
+
+	if before != "" && !strings.HasSuffix(before, "\n") {
+		before += "\n"
+	}
+	if before != "" && !strings.HasSuffix(before, "\n\n") {
+		before += "\n"
+	}
+	if after != "" {
+		block += "\n"
+	}
+
+	return before + block + after
+}
+
+// isSectionStart reports whether an ssh_config line opens a Host or Match section
+func isSectionStart(line string) bool {
+	keyword, _ := sshconfig.SplitDirective(line)
+	return keyword == "host" || keyword == "match"
+}
+
+// stripLegacyManagedConfig removes the header and undelimited host blocks written by
+// older gitshift versions so they don't shadow the new managed blocks. Configs without
+// the legacy header are returned unchanged.
+func stripLegacyManagedConfig(config string) string {
+	if !strings.Contains(config, legacyManagedHeader) {
+		return config
+	}
+
+	lines := strings.SplitAfter(config, "\n")
+	var kept []string
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		if trimmed == legacyManagedHeader || trimmed == legacyManagedNotice {
+			// The header was followed by a blank separator line
+			if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" && trimmed == legacyManagedNotice {
+				i++
+			}
+			continue
+		}
+
+		// Legacy blocks look like "# GitHub account: work" followed by a Host line and
+		// run until the next blank line
+		if strings.HasPrefix(trimmed, "# ") && strings.Contains(trimmed, " account: ") &&
+			i+1 < len(lines) && isSectionStart(lines[i+1]) {
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+				i++
+			}
+			if i+1 < len(lines) {
+				i++ // consume the blank separator
+			}
+			continue
+		}
+
+		kept = append(kept, lines[i])
+	}
+
+	return strings.Join(kept, "")
+}
@@ -0,0 +1,439 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/sshconfig"
+)
+
+const userSSHConfig = `# Personal settings
+Include config.d/*
+ServerAliveInterval 60
+
+# Bastion for internal hosts
+Host *.internal
+    User deploy
+    ProxyJump bastion.example.com
+
+Match host build-* exec "test -f ~/.ssh/ci"
+    IdentityFile ~/.ssh/ci
+    StrictHostKeyChecking no
+
+Host github.com
+    IdentityFile ~/.ssh/id_old
+`
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	homeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(homeDir, ".ssh"), 0700); err != nil {
+		t.Fatalf("failed to create .ssh dir: %v", err)
+	}
+	return &Manager{
+		homeDir:      homeDir,
+		configPath:   filepath.Join(homeDir, ".ssh", "config"),
+		runner:       execrunner.RealCmdRunner{},
+		blockOptions: DefaultBlockOptions(),
+		goos:         "linux",
+		out:          io.Discard,
+	}
+}
+
+// withoutManagedBlocks returns config with every gitshift-managed block removed
+func withoutManagedBlocks(config string) string {
+	var kept []string
+	inBlock := false
+	for _, line := range strings.SplitAfter(config, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, managedBlockBegin):
+			inBlock = true
+		case strings.HasPrefix(trimmed, managedBlockEnd):
+			inBlock = false
+		case !inBlock:
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+func TestUpdateSSHConfig_PreservesUserConfig(t *testing.T) {
+	m := newTestManager(t)
+	if err := os.WriteFile(m.configPath, []byte(userSSHConfig), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if err := m.UpdateSSHConfig("work", "/keys/id_ed25519_work", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+	if err := m.UpdateSSHConfig("gl", "/keys/id_ed25519_gl", "gitlab.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+	first, _ := os.ReadFile(m.configPath)
+
+	// Switching accounts again must only touch the managed block
+	if err := m.UpdateSSHConfig("personal", "/keys/id_ed25519_personal", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+	if err := m.UpdateSSHConfig("work", "/keys/id_ed25519_work", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+	second, _ := os.ReadFile(m.configPath)
+
+	if string(first) != string(second) {
+		t.Errorf("UpdateSSHConfig() is not idempotent:\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+
+	config := string(second)
+	for _, want := range []string{
+		"Include config.d/*",
+		"ProxyJump bastion.example.com",
+		`Match host build-* exec "test -f ~/.ssh/ci"`,
+		"IdentityFile /keys/id_ed25519_work",
+		"Host gitlab.com gitlab.com-gl",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config is missing %q:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "id_ed25519_personal") {
+		t.Errorf("config still references the previous account's key:\n%s", config)
+	}
+
+	// Global directives must stay ahead of the managed blocks so they remain global
+	if strings.Index(config, "ServerAliveInterval 60") > strings.Index(config, managedBlockBegin) {
+		t.Errorf("managed block was inserted before global directives:\n%s", config)
+	}
+
+	// Removing the managed blocks must give back the user's file (modulo separator lines)
+	stripped := regexp.MustCompile(`\n{3,}`).ReplaceAllString(withoutManagedBlocks(config), "\n\n")
+	if stripped != userSSHConfig {
+		t.Errorf("user config was modified:\ngot:\n%s\nwant:\n%s", stripped, userSSHConfig)
+	}
+}
+
+func TestUpdateSSHConfig_MigratesLegacyConfig(t *testing.T) {
+	m := newTestManager(t)
+	legacy := legacyManagedHeader + "\n" + legacyManagedNotice + "\n\n" +
+		"Host *.internal\n    ProxyJump bastion\n\n" +
+		"# GitHub account: old\nHost github.com\n    HostName github.com\n    IdentityFile /keys/old\n\n"
+	if err := os.WriteFile(m.configPath, []byte(legacy), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if err := m.UpdateSSHConfig("work", "/keys/work", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(m.configPath)
+	config := string(content)
+	if strings.Contains(config, legacyManagedHeader) || strings.Contains(config, "/keys/old") {
+		t.Errorf("legacy managed config was not removed:\n%s", config)
+	}
+	if !strings.Contains(config, "ProxyJump bastion") {
+		t.Errorf("user host block was dropped:\n%s", config)
+	}
+}
+
+func TestFixConfig_CollapsesDuplicatesOfManagedHosts(t *testing.T) {
+	m := newTestManager(t)
+
+	config := `ServerAliveInterval 60
+
+Host github.com
+    IdentityFile ~/.ssh/id_rsa
+    IdentityFile ~/.ssh/id_other
+
+# personal
+Host github.com gh-personal
+    IdentityFile ~/.ssh/id_personal
+
+Host *
+    AddKeysToAgent yes
+
+Host *
+    Compression yes
+`
+	if err := os.WriteFile(m.configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("failed to write SSH config: %v", err)
+	}
+	if err := m.UpdateSSHConfig("work", "/keys/id_ed25519_work", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig failed: %v", err)
+	}
+
+	validation, err := m.ValidateConfig()
+	if err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	// Two shadowing github.com entries, the duplicated Host * and the conflicting IdentityFiles
+	if len(validation.Issues) != 4 {
+		t.Fatalf("expected 4 issues, got %+v", validation.Issues)
+	}
+
+	validation, err = m.FixConfig()
+	if err != nil {
+		t.Fatalf("FixConfig failed: %v", err)
+	}
+
+	// Duplicates between user sections can't be resolved automatically; several Host *
+	// blocks are common and only a warning
+	if len(validation.Issues) != 1 || validation.Issues[0].Host != "*" || validation.Issues[0].Fixable || !validation.Issues[0].Warning {
+		t.Errorf("expected only the Host * duplicate to remain as a warning, got %+v", validation.Issues)
+	}
+	if !validation.IsValid() {
+		t.Errorf("a duplicated Host * made the config invalid: %+v", validation.Problems())
+	}
+
+	content, err := os.ReadFile(m.configPath)
+	if err != nil {
+		t.Fatalf("failed to read SSH config: %v", err)
+	}
+	fixed := string(content)
+
+	if strings.Contains(fixed, "id_rsa") || strings.Contains(fixed, "id_other") {
+		t.Errorf("duplicate github.com section was not removed:\n%s", fixed)
+	}
+	if !strings.Contains(fixed, "# personal\nHost gh-personal\n    IdentityFile ~/.ssh/id_personal\n") {
+		t.Errorf("non-duplicate patterns of a shared section must be kept:\n%s", fixed)
+	}
+	if !strings.Contains(fixed, "Host github.com github.com-work") {
+		t.Errorf("managed block was removed:\n%s", fixed)
+	}
+	if strings.Contains(fixed, "\n\n\n") {
+		t.Errorf("removing a section left consecutive blank lines:\n%s", fixed)
+	}
+}
+
+func TestUpdateSSHConfig_WritesMatchBlocks(t *testing.T) {
+	m := newTestManager(t)
+	userConfig := "Host *.internal\n    ProxyJump bastion\n\nMatch host build-* exec \"test -f ~/.ssh/ci\"\n    IdentityFile ~/.ssh/ci\n"
+	if err := os.WriteFile(m.configPath, []byte(userConfig), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	matches := []MatchIdentity{{Account: "work", Criteria: `exec "pwd | grep -q /src/work/"`, KeyPath: "/keys/id_ed25519_work"}}
+	if err := m.UpdateSSHConfig("personal", "/keys/id_ed25519_personal", "github.com", matches...); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+	content, _ := os.ReadFile(m.configPath)
+	config := string(content)
+
+	// The Match block must come before the Host block so its key is offered first
+	parsed := sshconfig.Parse(config)
+	var order []string
+	for _, block := range parsed.Blocks {
+		if block.IsMatch() {
+			order = append(order, block.Criteria)
+		} else {
+			order = append(order, strings.Join(block.Patterns, " "))
+		}
+	}
+	matchAt, hostAt := -1, -1
+	for i, block := range order {
+		switch block {
+		case `host github.com exec "pwd | grep -q /src/work/"`:
+			matchAt = i
+		case "github.com github.com-personal":
+			hostAt = i
+		}
+	}
+	if matchAt < 0 || hostAt < 0 || matchAt > hostAt {
+		t.Fatalf("Match block missing or after the Host block, blocks = %q", order)
+	}
+	if identity := parsed.Blocks[matchAt].Value("IdentityFile"); identity != "/keys/id_ed25519_work" {
+		t.Errorf("Match block IdentityFile = %q", identity)
+	}
+
+	// The generated Match block is not a problem for the validator, and the user's own Match
+	// block survives a fix
+	if issues := findConfigIssues(config); len(issues) != 0 {
+		t.Errorf("findConfigIssues() = %+v, want none", issues)
+	}
+	if fixed := collapseDuplicateHosts(config); fixed != config {
+		t.Errorf("collapseDuplicateHosts() changed a config without duplicates:\n%s", fixed)
+	}
+
+	// Switching without matches drops the generated Match block but keeps the user's
+	if err := m.UpdateSSHConfig("personal", "/keys/id_ed25519_personal", "github.com"); err != nil {
+		t.Fatalf("UpdateSSHConfig() error = %v", err)
+	}
+	content, _ = os.ReadFile(m.configPath)
+	if strings.Contains(string(content), "/src/work/") {
+		t.Errorf("Match block was not removed:\n%s", content)
+	}
+	if !strings.Contains(string(content), `Match host build-* exec "test -f ~/.ssh/ci"`) {
+		t.Errorf("user Match block was dropped:\n%s", content)
+	}
+}
+
+func TestPreviewSSHConfig_UseKeychainOnlyOnMacOS(t *testing.T) {
+	tests := []struct {
+		goos         string
+		opts         BlockOptions
+		wantAgent    bool
+		wantKeychain bool
+	}{
+		{goos: "darwin", opts: DefaultBlockOptions(), wantAgent: true, wantKeychain: true},
+		{goos: "linux", opts: DefaultBlockOptions(), wantAgent: true},
+		{goos: "windows", opts: DefaultBlockOptions(), wantAgent: true},
+		{goos: "darwin", opts: BlockOptions{AddKeysToAgent: true}, wantAgent: true},
+		{goos: "darwin", opts: BlockOptions{UseKeychain: true}, wantKeychain: true},
+	}
+
+	for _, tt := range tests {
+		m := newTestManager(t)
+		m.goos = tt.goos
+		m.SetBlockOptions(tt.opts)
+
+		preview, err := m.PreviewSSHConfig("work", "/keys/id_ed25519_work", "github.com")
+		if err != nil {
+			t.Fatalf("PreviewSSHConfig() error = %v", err)
+		}
+		blocks := sshconfig.Parse(preview.Block).Blocks
+		if len(blocks) != 1 {
+			t.Fatalf("%s %+v: managed block has %d sections, want 1", tt.goos, tt.opts, len(blocks))
+		}
+		if got := blocks[0].Value("AddKeysToAgent") == "yes"; got != tt.wantAgent {
+			t.Errorf("%s %+v: AddKeysToAgent written = %v, want %v", tt.goos, tt.opts, got, tt.wantAgent)
+		}
+		if got := blocks[0].Value("UseKeychain") == "yes"; got != tt.wantKeychain {
+			t.Errorf("%s %+v: UseKeychain written = %v, want %v", tt.goos, tt.opts, got, tt.wantKeychain)
+		}
+	}
+}
+
+// runnerFunc adapts a function to execrunner.CmdRunner
+type runnerFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+func (f runnerFunc) CombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return f(ctx, name, args...)
+}
+
+func (f runnerFunc) InteractiveCombinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return f(ctx, name, args...)
+}
+
+func TestUpdateSSHConfig_RejectedConfigIsNotWritten(t *testing.T) {
+	m := newTestManager(t)
+	if err := os.WriteFile(m.configPath, []byte(userSSHConfig), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var checked string
+	m.runner = runnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if name != "ssh" || len(args) != 4 || args[0] != "-G" || args[1] != "-F" {
+			return nil, errors.New("unexpected command")
+		}
+		content, err := os.ReadFile(args[2])
+		if err != nil {
+			return nil, err
+		}
+		checked = string(content)
+		return []byte(args[2] + ": line 14: Bad configuration option: usekeychain\n"), errors.New("exit status 255")
+	})
+
+	err := m.UpdateSSHConfig("work", "/keys/id_ed25519_work", "github.com")
+	var userErr *models.UserError
+	if !errors.As(err, &userErr) || userErr.Category != models.CategorySSH {
+		t.Fatalf("UpdateSSHConfig() error = %v, want an SSH UserError", err)
+	}
+	if !strings.Contains(err.Error(), m.configPath+" (gitshift): line 14: Bad configuration option") {
+		t.Errorf("error %q doesn't point at the config ssh rejected", err)
+	}
+	if !strings.Contains(checked, HostAlias("github.com", "work")) {
+		t.Errorf("ssh checked %q, want the updated config", checked)
+	}
+
+	content, _ := os.ReadFile(m.configPath)
+	if string(content) != userSSHConfig {
+		t.Errorf("config was changed despite the rejection:\n%s", content)
+	}
+	backups, err := m.ListConfigBackups()
+	if err != nil {
+		t.Fatalf("ListConfigBackups() error = %v", err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("rejected update left %d backup(s)", len(backups))
+	}
+	entries, _ := os.ReadDir(filepath.Dir(m.configPath))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".config.gitshift-check-") {
+			t.Errorf("temporary config %s was left behind", entry.Name())
+		}
+	}
+}
+
+func TestRefreshHostAliases(t *testing.T) {
+	m := newTestManager(t)
+	accounts := []*models.Account{
+		{Alias: "work", Platform: "github", SSHKeyPath: "/keys/id_work"},
+		{Alias: "personal", Platform: "github", SSHKeyPath: "/keys/id_personal"},
+		{Alias: "lab", Platform: "gitlab", SSHKeyPath: "/keys/id_lab"},
+	}
+	hostKeys := func() map[string]string {
+		content, _ := os.ReadFile(m.configPath)
+		keys := make(map[string]string)
+		for _, block := range sshconfig.Parse(string(content)).Blocks {
+			for _, pattern := range block.Patterns {
+				keys[pattern] = block.Value("identityfile")
+			}
+		}
+		return keys
+	}
+
+	// Without a managed block for the domain nothing is written
+	if err := m.RefreshHostAliases("github.com", accounts); err != nil {
+		t.Fatalf("RefreshHostAliases() error = %v", err)
+	}
+	if _, err := os.Stat(m.configPath); !os.IsNotExist(err) {
+		t.Fatalf("RefreshHostAliases() wrote a config without a managed block: %v", err)
+	}
+
+	if err := m.UpdateSSHConfig("work", "/keys/id_work", "github.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RefreshHostAliases("github.com", accounts); err != nil {
+		t.Fatalf("RefreshHostAliases() error = %v", err)
+	}
+	want := map[string]string{"github.com": "/keys/id_work", "github.com-work": "/keys/id_work", "github.com-personal": "/keys/id_personal"}
+	if got := hostKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("host keys after adding personal = %v, want %v", got, want)
+	}
+
+	// The active account's new key replaces the old one; a removed account loses its alias
+	accounts[0].SSHKeyPath = "/keys/id_work_2"
+	if err := m.RefreshHostAliases("github.com", accounts[:1]); err != nil {
+		t.Fatalf("RefreshHostAliases() error = %v", err)
+	}
+	want = map[string]string{"github.com": "/keys/id_work_2", "github.com-work": "/keys/id_work_2"}
+	if got := hostKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("host keys after removing personal = %v, want %v", got, want)
+	}
+
+	// Removing the active account drops the bare domain but keeps the others' aliases
+	if err := m.RefreshHostAliases("github.com", accounts[1:]); err != nil {
+		t.Fatalf("RefreshHostAliases() error = %v", err)
+	}
+	want = map[string]string{"github.com-personal": "/keys/id_personal"}
+	if got := hostKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("host keys after removing work = %v, want %v", got, want)
+	}
+
+	// Without any account left on the domain the block goes away
+	if err := m.RefreshHostAliases("github.com", accounts[2:]); err != nil {
+		t.Fatalf("RefreshHostAliases() error = %v", err)
+	}
+	if content, _ := os.ReadFile(m.configPath); strings.Contains(string(content), managedBlockBegin) {
+		t.Errorf("managed block left without accounts:\n%s", content)
+	}
+}
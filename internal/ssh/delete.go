@@ -0,0 +1,118 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+)
+
+// KeyInUseError is returned by DeleteKey when configured accounts still use the key
+type KeyInUseError struct {
+	KeyPath  string
+	Accounts []string
+}
+
+func (e *KeyInUseError) Error() string {
+	return fmt.Sprintf("SSH key %s is still used by account(s) %s", e.KeyPath, strings.Join(e.Accounts, ", "))
+}
+
+// DeleteKeyOptions controls how DeleteKey removes a key pair
+type DeleteKeyOptions struct {
+	// UsedBy lists the accounts configured with the key; DeleteKey refuses to delete a key
+	// that is in use unless Force is set
+	UsedBy []string
+	Force  bool
+
+	// Permanent unlinks the key pair instead of moving it to the trash
+	Permanent bool
+}
+
+// TrashDir returns the directory deleted keys are moved to, one new timestamped
+// subdirectory per deletion
+func (m *Manager) TrashDir() string {
+	return filepath.Join(m.homeDir, ".ssh", "gitshift", "trash")
+}
+
+// DeleteKey removes the private key at keyPath and its .pub file. Unless opts.Permanent is
+// set, the files are moved to a new <TrashDir>/<timestamp>-<suffix>/ directory, which is returned
+// so the key can be restored.
+func (m *Manager) DeleteKey(keyPath string, opts DeleteKeyOptions) (string, error) {
+	if len(opts.UsedBy) > 0 && !opts.Force {
+		return "", &KeyInUseError{KeyPath: keyPath, Accounts: opts.UsedBy}
+	}
+
+	keyPath = pathutil.Expand(keyPath)
+	var files []string
+	for _, path := range []string{keyPath, keyPath + ".pub"} {
+		if _, err := os.Lstat(path); err == nil {
+			files = append(files, path)
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to inspect %s: %w", path, err)
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no SSH key found at %s", keyPath)
+	}
+
+	if opts.Permanent {
+		for _, path := range files {
+			if err := os.Remove(path); err != nil {
+				return "", fmt.Errorf("failed to delete %s: %w", path, err)
+			}
+		}
+		return "", nil
+	}
+
+	if err := os.MkdirAll(m.TrashDir(), 0700); err != nil {
+		return "", fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	// The random suffix keeps deletions within the same clock tick from sharing a directory
+	// and overwriting each other's files
+	trashDir, err := os.MkdirTemp(m.TrashDir(), time.Now().UTC().Format(configBackupTimeFormat)+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	for _, path := range files {
+		if err := moveFile(path, filepath.Join(trashDir, filepath.Base(path))); err != nil {
+			return "", fmt.Errorf("failed to move %s to the trash: %w", path, err)
+		}
+	}
+	return trashDir, nil
+}
+
+// moveFile renames src to dst, copying it when they are on different file systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestKeyPair(t *testing.T, m *Manager, name string) string {
+	t.Helper()
+	keyPath := filepath.Join(m.homeDir, ".ssh", name)
+	if err := os.WriteFile(keyPath, []byte("private"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath+".pub", []byte("public"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath
+}
+
+func TestDeleteKey_MovesKeyPairToTrash(t *testing.T) {
+	m := newTestManager(t)
+	keyPath := writeTestKeyPair(t, m, "id_ed25519_work")
+
+	trashDir, err := m.DeleteKey(keyPath, DeleteKeyOptions{})
+	if err != nil {
+		t.Fatalf("DeleteKey() error = %v", err)
+	}
+	if filepath.Dir(trashDir) != m.TrashDir() {
+		t.Errorf("DeleteKey() moved the key to %s, want a directory in %s", trashDir, m.TrashDir())
+	}
+
+	for _, name := range []string{"id_ed25519_work", "id_ed25519_work.pub"} {
+		if _, err := os.Stat(filepath.Join(m.homeDir, ".ssh", name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after DeleteKey()", name)
+		}
+		info, err := os.Stat(filepath.Join(trashDir, name))
+		if err != nil {
+			t.Errorf("%s is missing from the trash: %v", name, err)
+			continue
+		}
+		if name == "id_ed25519_work" && info.Mode().Perm() != 0600 {
+			t.Errorf("trashed private key has mode %04o, want 0600", info.Mode().Perm())
+		}
+	}
+}
+
+func TestDeleteKey_KeepsEarlierDeletionsOfTheSameName(t *testing.T) {
+	m := newTestManager(t)
+
+	var trashDirs []string
+	for _, content := range []string{"first", "second"} {
+		keyPath := writeTestKeyPair(t, m, "id_ed25519_work")
+		if err := os.WriteFile(keyPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		trashDir, err := m.DeleteKey(keyPath, DeleteKeyOptions{})
+		if err != nil {
+			t.Fatalf("DeleteKey() error = %v", err)
+		}
+		trashDirs = append(trashDirs, trashDir)
+	}
+
+	if trashDirs[0] == trashDirs[1] {
+		t.Fatalf("both deletions were moved to %s", trashDirs[0])
+	}
+	for i, want := range []string{"first", "second"} {
+		if got, err := os.ReadFile(filepath.Join(trashDirs[i], "id_ed25519_work")); err != nil || string(got) != want {
+			t.Errorf("trashed key %d = %q, %v; want %q", i, got, err, want)
+		}
+	}
+}
+
+func TestDeleteKey_RefusesKeyInUse(t *testing.T) {
+	m := newTestManager(t)
+	keyPath := writeTestKeyPair(t, m, "id_ed25519_shared")
+
+	_, err := m.DeleteKey(keyPath, DeleteKeyOptions{UsedBy: []string{"oss", "work"}})
+	var inUse *KeyInUseError
+	if !errors.As(err, &inUse) {
+		t.Fatalf("DeleteKey() error = %v, want a KeyInUseError", err)
+	}
+	if len(inUse.Accounts) != 2 || inUse.Accounts[0] != "oss" {
+		t.Errorf("KeyInUseError.Accounts = %v", inUse.Accounts)
+	}
+	if _, err := os.Stat(keyPath); err != nil {
+		t.Errorf("key in use was deleted: %v", err)
+	}
+
+	if _, err := m.DeleteKey(keyPath, DeleteKeyOptions{UsedBy: []string{"work"}, Force: true, Permanent: true}); err != nil {
+		t.Fatalf("DeleteKey() with Force error = %v", err)
+	}
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Error("forced permanent delete left the key in place")
+	}
+}
+
+func TestDeleteKey_MissingKey(t *testing.T) {
+	m := newTestManager(t)
+	if _, err := m.DeleteKey(filepath.Join(m.homeDir, ".ssh", "id_missing"), DeleteKeyOptions{}); err == nil {
+		t.Error("DeleteKey() of a missing key succeeded")
+	}
+}
@@ -0,0 +1,149 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffLine is one line of a line-based diff: ' ' unchanged, '-' removed or '+' added
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff returns a unified diff turning from into to, labelled with fromName and
+// toName, or "" when they are equal. Configs are small, so a plain LCS table is enough.
+func unifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// fromLine and toLine count the lines of each side before lines[i]
+	fromLine, toLine := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			fromLine++
+			toLine++
+			i++
+			continue
+		}
+
+		// Grow the hunk until the unchanged run after a change is long enough to split
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].kind == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*diffContext {
+				end += min(diffContext, run-end)
+				break
+			}
+			end = run
+		}
+
+		hunkFromStart, hunkToStart := fromLine-(i-start), toLine-(i-start)
+		fromCount, toCount := 0, 0
+		for _, line := range lines[start:end] {
+			if line.kind != '+' {
+				fromCount++
+			}
+			if line.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunkFromStart, fromCount), hunkRange(hunkToStart, toCount))
+		for _, line := range lines[start:end] {
+			out.WriteByte(line.kind)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+
+		for _, line := range lines[i:end] {
+			if line.kind != '+' {
+				fromLine++
+			}
+			if line.kind != '-' {
+				toLine++
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start,count of a hunk side; an empty side names the line before it
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLines computes a minimal line diff from a longest common subsequence table
+func diffLines(from, to []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, diffLine{' ', from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', from[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		lines = append(lines, diffLine{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		lines = append(lines, diffLine{'+', to[j]})
+	}
+	return lines
+}
+
+// splitLines splits content into lines without their newlines
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,50 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(s ...string) string { return strings.Join(s, "\n") + "\n" }
+
+	// Expected hunks were produced with diff -u
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{
+			name: "separate hunks",
+			from: lines("a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m"),
+			to:   lines("a", "B", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n"),
+			want: lines("@@ -1,5 +1,5 @@", " a", "-b", "+B", " c", " d", " e",
+				"@@ -11,3 +11,4 @@", " k", " l", " m", "+n"),
+		},
+		{
+			name: "merged hunk",
+			from: lines("a", "b", "c", "d", "e", "f", "g", "h"),
+			to:   lines("a", "c", "d", "e", "f", "g", "X", "h"),
+			want: lines("@@ -1,8 +1,8 @@", " a", "-b", " c", " d", " e", " f", " g", "+X", " h"),
+		},
+		{
+			name: "new file",
+			from: "",
+			to:   lines("x"),
+			want: lines("@@ -0,0 +1 @@", "+x"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := "--- old\n+++ new\n" + tt.want
+			if got := unifiedDiff("old", "new", tt.from, tt.to); got != want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	if got := unifiedDiff("old", "new", "same\n", "same\n"); got != "" {
+		t.Errorf("unifiedDiff() of equal content = %q, want empty", got)
+	}
+}
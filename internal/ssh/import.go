@@ -0,0 +1,113 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+)
+
+// ImportKeyOptions controls how ImportKey takes over an existing key pair
+type ImportKeyOptions struct {
+	// OtherAccounts are the other configured accounts. A key belongs to a single account,
+	// so ImportKey refuses a key that one of them already uses (see AccountsUsingKey).
+	OtherAccounts []*models.Account
+}
+
+// ImportedKey is a key pair ImportKey checked and prepared for an account
+type ImportedKey struct {
+	Path          string // expanded path of the private key
+	PublicKeyPath string
+	Info          *KeyInfo
+	Email         string   // the public key's comment, when it is an email address
+	FixedModes    []string // files whose permissions ImportKey corrected
+}
+
+// ImportKey checks a key pair created outside gitshift so it can be attached to an
+// account: the private key and its .pub file must exist, ssh-keygen must be able to read
+// the public key and no other account may use it. The private key is then made 0600 and
+// the public key 0644. keyPath may name either file of the pair.
+func (m *Manager) ImportKey(ctx context.Context, keyPath string, opts ImportKeyOptions) (*ImportedKey, error) {
+	keyPath = strings.TrimSuffix(keyPath, ".pub")
+	if _, err := pathutil.Stat(keyPath); err != nil {
+		return nil, fmt.Errorf("SSH key %w", err)
+	}
+	key := &ImportedKey{Path: pathutil.Expand(keyPath)}
+	key.PublicKeyPath = key.Path + ".pub"
+
+	if !IsPrivateKeyFile(key.Path) {
+		return nil, fmt.Errorf("%s is not an SSH private key", key.Path)
+	}
+	if _, err := os.Stat(key.PublicKeyPath); err != nil {
+		return nil, fmt.Errorf("no public key next to %s; create it with 'ssh-keygen -y -f %s > %s'", key.Path, key.Path, key.PublicKeyPath)
+	}
+
+	info, err := m.ValidateKey(ctx, key.PublicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	key.Info = info
+
+	if usedBy := m.AccountsUsingKey(ctx, key.Path, opts.OtherAccounts); len(usedBy) > 0 {
+		return nil, &KeyInUseError{KeyPath: key.Path, Accounts: usedBy}
+	}
+
+	for path, mode := range map[string]os.FileMode{key.Path: 0600, key.PublicKeyPath: 0644} {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if fileInfo.Mode().Perm() == mode {
+			continue
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return nil, fmt.Errorf("failed to fix the permissions of %s: %w", path, err)
+		}
+		key.FixedModes = append(key.FixedModes, path)
+	}
+	sort.Strings(key.FixedModes)
+
+	if models.ValidateEmail(info.Comment) == nil {
+		key.Email = info.Comment
+	}
+	return key, nil
+}
+
+// AccountsUsingKey returns the sorted aliases of the accounts whose key is the one at
+// keyPath: the same file, a copy with the same fingerprint, or an agent-only key with that
+// fingerprint. Keys ssh-keygen can't read only match by path.
+func (m *Manager) AccountsUsingKey(ctx context.Context, keyPath string, accounts []*models.Account) []string {
+	keyPath = pathutil.Expand(keyPath)
+	fingerprint := m.keyFingerprint(ctx, keyPath)
+
+	var usedBy []string
+	for _, account := range accounts {
+		var uses bool
+		switch {
+		case account.SSHKeyPath != "":
+			path := pathutil.Expand(account.SSHKeyPath)
+			uses = path == keyPath || (fingerprint != "" && m.keyFingerprint(ctx, path) == fingerprint)
+		case account.SSHKeyFingerprint != "":
+			uses = fingerprint != "" && sha256Fingerprint(account.SSHKeyFingerprint) == fingerprint
+		}
+		if uses {
+			usedBy = append(usedBy, account.Alias)
+		}
+	}
+	sort.Strings(usedBy)
+	return usedBy
+}
+
+// keyFingerprint returns the fingerprint of the key pair at keyPath, read from its .pub
+// file, or "" when ssh-keygen can't read it
+func (m *Manager) keyFingerprint(ctx context.Context, keyPath string) string {
+	info, err := m.ValidateKey(ctx, keyPath+".pub")
+	if err != nil {
+		return ""
+	}
+	return info.Fingerprint
+}
@@ -0,0 +1,139 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// nonKeyFiles are the files ssh keeps in ~/.ssh that are never keys
+var nonKeyFiles = []string{"known_hosts", "config", "authorized_keys", "environment", "rc"}
+
+// KeyFile is an SSH key pair found on disk
+type KeyFile struct {
+	Path          string
+	PublicKeyPath string
+	Info          *KeyInfo // nil when ssh-keygen couldn't read the key
+	Err           error    // why Info is nil
+}
+
+// maxKeyValidators bounds how many ssh-keygen processes ListKeys runs at once
+const maxKeyValidators = 8
+
+// ListKeys returns the key pairs in ~/.ssh, sorted by path, with the details ssh-keygen
+// reports for each. Keys are validated concurrently. Keys ssh-keygen can't read are still
+// listed, with Err set; the error names the key it is about.
+func (m *Manager) ListKeys(ctx context.Context) ([]KeyFile, error) {
+	paths, err := FindKeyPairs(filepath.Join(m.homeDir, ".ssh"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Each worker writes only its own slot, so the order of paths is kept
+	keys := make([]KeyFile, len(paths))
+	sem := make(chan struct{}, maxKeyValidators)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			key := KeyFile{Path: path, PublicKeyPath: path + ".pub"}
+			key.Info, key.Err = m.ValidateKey(ctx, key.PublicKeyPath)
+			keys[i] = key
+		}(i, path)
+	}
+	wg.Wait()
+	return keys, nil
+}
+
+// DetectDuplicateKeys returns the key pairs in ~/.ssh that are the same key under
+// different names, such as copies made by hand, by fingerprint. Only fingerprints shared
+// by several files are returned, each with its sorted paths; keys ssh-keygen can't read
+// are left out.
+func (m *Manager) DetectDuplicateKeys(ctx context.Context) (map[string][]string, error) {
+	keys, err := m.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string][]string)
+	for _, key := range keys {
+		if key.Info != nil {
+			paths[key.Info.Fingerprint] = append(paths[key.Info.Fingerprint], key.Path)
+		}
+	}
+	for fingerprint, keyPaths := range paths {
+		if len(keyPaths) < 2 {
+			delete(paths, fingerprint)
+		}
+	}
+	return paths, nil
+}
+
+// FindKeyPairs returns the private keys in dir that have a .pub sibling, sorted. Keys are
+// recognised by their PEM header rather than their name, so ~/.ssh/work_github and
+// ~/.ssh/personal.key are found as well as ~/.ssh/id_ed25519. A missing dir has no keys.
+func FindKeyPairs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH directory: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasSuffix(name, ".pub") || isNonKeyFile(name) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path + ".pub"); err != nil {
+			continue
+		}
+		if IsPrivateKeyFile(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// IsPrivateKeyFile reports whether the file starts with a private key PEM header, in the
+// OpenSSH format or the older PEM and PKCS#8 formats
+func IsPrivateKeyFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadSlice('\n')
+	if err != nil && len(line) == 0 {
+		return false
+	}
+	line = bytes.TrimSpace(line)
+	return bytes.HasPrefix(line, []byte("-----BEGIN ")) && bytes.HasSuffix(line, []byte("PRIVATE KEY-----"))
+}
+
+// isNonKeyFile reports whether name is one of ssh's own files or a backup of one, e.g.
+// known_hosts.old
+func isNonKeyFile(name string) bool {
+	for _, nonKey := range nonKeyFiles {
+		if name == nonKey || strings.HasPrefix(name, nonKey+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -1,101 +1,529 @@
 package ssh
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/audit"
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 // Manager handles SSH configuration and key management
 type Manager struct {
 	homeDir    string
 	configPath string
+
+	// runner runs ssh-add, ssh-keygen and ssh when they don't need the terminal. Commands
+	// that may prompt, like ssh-add of a key with a passphrase, run directly.
+	runner execrunner.CmdRunner
+
+	// blockOptions selects the optional directives of the managed Host block, and goos is
+	// the platform they are written for
+	blockOptions BlockOptions
+	goos         string
+
+	// auditLog records switches and key loads when set
+	auditLog *audit.Log
+
+	// out receives the progress and warnings of switches, os.Stdout by default
+	out io.Writer
 }
 
 // NewManager creates a new SSH manager
 func NewManager() *Manager {
+	return NewManagerWithRunner(execrunner.RealCmdRunner{})
+}
+
+// NewManagerWithRunner creates an SSH manager that runs external commands with runner
+func NewManagerWithRunner(runner execrunner.CmdRunner) *Manager {
 	homeDir := os.Getenv("HOME")
 	if homeDir == "" {
 		homeDir = "~"
 	}
 
 	return &Manager{
-		homeDir:    homeDir,
-		configPath: filepath.Join(homeDir, ".ssh", "config"),
+		homeDir:      homeDir,
+		configPath:   filepath.Join(homeDir, ".ssh", "config"),
+		runner:       runner,
+		blockOptions: DefaultBlockOptions(),
+		goos:         runtime.GOOS,
+		out:          os.Stdout,
+	}
+}
+
+// SetBlockOptions sets the optional directives written into the managed Host block.
+// UseKeychain is dropped on platforms other than macOS.
+func (m *Manager) SetBlockOptions(opts BlockOptions) {
+	m.blockOptions = opts
+}
+
+// SetOutput sets where switches report their progress and warnings; io.Discard silences them
+func (m *Manager) SetOutput(w io.Writer) {
+	m.out = w
+}
+
+// SetAuditLog makes switches record their outcome and key loads in log
+func (m *Manager) SetAuditLog(log *audit.Log) {
+	m.auditLog = log
+}
+
+// recordAudit appends an entry to the audit log, if one is set. A log that can't be
+// written is reported but doesn't fail the operation being audited.
+func (m *Manager) recordAudit(event, accountAlias, keyFingerprint string, err error) {
+	if m.auditLog == nil {
+		return
+	}
+	entry := audit.Entry{Event: event, Account: accountAlias, KeyFingerprint: keyFingerprint, Outcome: audit.Outcome(err)}
+	if recordErr := m.auditLog.Record(entry); recordErr != nil {
+		fmt.Fprintf(m.out, "⚠️  Warning: failed to write audit log: %v\n", recordErr)
 	}
 }
 
-// SwitchToAccount switches SSH configuration to use the specified account with improved isolation
+// auditFingerprint returns the fingerprint of keyPath for the audit log, or "" when
+// auditing is off or the key can't be read
+func (m *Manager) auditFingerprint(keyPath string) string {
+	if m.auditLog == nil {
+		return ""
+	}
+	info, err := m.ValidateKey(context.Background(), keyPath)
+	if err != nil {
+		return ""
+	}
+	return info.Fingerprint
+}
+
+// managedBlockOptions returns the block options that apply on the manager's platform
+func (m *Manager) managedBlockOptions() BlockOptions {
+	opts := m.blockOptions
+	if m.goos != "darwin" {
+		opts.UseKeychain = false
+	}
+	return opts
+}
+
+// SwitchToAccount switches SSH configuration to use the specified GitHub account
+// Deprecated: Use SwitchToAccountOnPlatform with the account's domain instead
 func (m *Manager) SwitchToAccount(accountAlias, keyPath string) error {
-	// 1. Validate key exists and fix permissions
-	if _, err := os.Stat(keyPath); err != nil {
-		return fmt.Errorf("SSH key not found at %s: %w", keyPath, err)
+	return m.SwitchToAccountOnPlatform(accountAlias, keyPath, "github.com")
+}
+
+// SwitchOptions controls how SwitchToAccountWithOptions applies an account switch
+type SwitchOptions struct {
+	// DryRun computes the switch without touching ~/.ssh/config, the shell config or the agent
+	DryRun bool
+	// SkipConnectivityTest skips the live SSH connection test after switching
+	SkipConnectivityTest bool
+	// Matches are the keys of other accounts on the domain that ssh offers first when their
+	// Match criteria hold
+	Matches []MatchIdentity
+	// KeepAgentKeys loads the account's key without removing the other keys from the agent,
+	// relying on IdentitiesOnly to pick the right one
+	KeepAgentKeys bool
+	// AgentKey says that keyPath is the public half of a key only the SSH agent holds (see
+	// WriteAgentPublicKey). The agent is left as it is, since clearing it would drop the key.
+	AgentKey bool
+}
+
+// SwitchPlan describes the changes an account switch makes (or would make, in dry-run mode)
+type SwitchPlan struct {
+	SSHConfigPath   string
+	SSHConfig       string
+	ShellConfigPath string
+	AgentOperations []string
+}
+
+// SwitchToAccountOnPlatform switches SSH configuration to use the specified account on the
+// given platform domain (github.com, gitlab.com, bitbucket.org or a self-hosted domain)
+func (m *Manager) SwitchToAccountOnPlatform(accountAlias, keyPath, domain string) error {
+	_, err := m.SwitchToAccountWithOptions(context.Background(), accountAlias, keyPath, domain, SwitchOptions{})
+	return err
+}
+
+// SwitchToAccountWithOptions switches SSH configuration to the specified account and returns
+// the plan it applied. With opts.DryRun set, the plan is printed and returned without making
+// any filesystem or agent changes. The agent and ssh commands it runs are killed when ctx
+// is cancelled.
+func (m *Manager) SwitchToAccountWithOptions(ctx context.Context, accountAlias, keyPath, domain string, opts SwitchOptions) (plan *SwitchPlan, err error) {
+	writtenKeyPath := keyPath
+	keyPath = pathutil.Expand(keyPath)
+	if !opts.DryRun {
+		defer func() {
+			m.recordAudit(audit.EventSwitch, accountAlias, m.auditFingerprint(keyPath), err)
+		}()
+	}
+
+	if domain == "" {
+		return nil, fmt.Errorf("no platform domain configured for account '%s'", accountAlias)
+	}
+
+	// 1. Validate key exists
+	info, err := pathutil.Stat(writtenKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("SSH key %w", err)
+	}
+
+	sshConfig, err := m.renderSSHConfig(accountAlias, keyPath, domain, opts.Matches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SSH config: %w", err)
+	}
+
+	plan = &SwitchPlan{
+		SSHConfigPath: m.configPath,
+		SSHConfig:     sshConfig,
+	}
+	if !opts.AgentKey {
+		if !opts.KeepAgentKeys {
+			plan.AgentOperations = append(plan.AgentOperations, "ssh-add -D")
+		}
+		plan.AgentOperations = append(plan.AgentOperations, fmt.Sprintf("ssh-add %s", keyPath))
+	}
+	if _, shellConfigPath, err := m.detectShell(); err == nil {
+		plan.ShellConfigPath = shellConfigPath
+	}
+
+	if opts.DryRun {
+		printSwitchPlan(m.out, plan)
+		return plan, nil
 	}
 
 	// Fix key permissions if needed
-	if info, err := os.Stat(keyPath); err == nil {
-		if info.Mode().Perm() != 0600 {
-			if err := os.Chmod(keyPath, 0600); err != nil {
-				return fmt.Errorf("failed to fix SSH key permissions: %w", err)
-			}
+	if !opts.AgentKey && info.Mode().Perm() != 0600 {
+		if err := os.Chmod(keyPath, 0600); err != nil {
+			return nil, fmt.Errorf("failed to fix SSH key permissions: %w", err)
 		}
 	}
 
 	// 2. Update SSH config with improved isolation
-	if err := m.updateGitHubSSHConfigV2(accountAlias, keyPath); err != nil {
-		return fmt.Errorf("failed to update SSH config: %w", err)
+	if err := m.UpdateSSHConfig(accountAlias, keyPath, domain, opts.Matches...); err != nil {
+		return nil, fmt.Errorf("failed to update SSH config: %w", err)
 	}
 
-	// 3. Clear SSH agent and load only the required key
-	if err := m.clearSSHAgent(); err != nil {
-		// Don't fail if SSH agent operations fail
-		fmt.Printf("⚠️  Warning: SSH agent clear failed: %v\n", err)
-	}
+	// 3. Clear SSH agent and load only the required key, unless the agent already holds the
+	// only copy of it or there is no agent to talk to; the SSH config is enough then
+	if opts.AgentKey {
+		fmt.Fprintf(m.out, "ℹ️  The SSH agent holds the key; agent left unchanged\n")
+	} else if status, agentErr := m.GetAgentStatus(ctx); !status.Usable() {
+		fmt.Fprintf(m.out, "⚠️  Warning: skipping SSH agent key loading: %v\n", agentErr)
+		if status == AgentStale || status == AgentNotRunning {
+			fmt.Fprintf(m.out, "   💡 Start a new agent with: eval \"$(ssh-agent -s)\"\n")
+		}
+	} else {
+		if !opts.KeepAgentKeys {
+			if err := m.clearSSHAgent(ctx); err != nil {
+				// Don't fail if SSH agent operations fail
+				fmt.Fprintf(m.out, "⚠️  Warning: SSH agent clear failed: %v\n", err)
+			}
+		} else if only, err := m.IdentitiesOnly(ctx, domain); err == nil && !only {
+			fmt.Fprintf(m.out, "⚠️  Warning: other keys stay loaded (allow_multiple_keys), but IdentitiesOnly is not in effect for %s, so ssh may offer another account's key\n", domain)
+		}
 
-	// 4. Add only the specific key to agent
-	if err := m.addKeyToAgent(keyPath); err != nil {
-		// Don't fail if SSH agent operations fail, SSH config should be enough
-		fmt.Printf("⚠️  Warning: SSH agent key loading failed: %v\n", err)
+		// 4. Add only the specific key to agent
+		keyLoadErr := m.addKeyToAgent(ctx, keyPath)
+		m.recordAudit(audit.EventKeyLoad, accountAlias, m.auditFingerprint(keyPath), keyLoadErr)
+		if keyLoadErr != nil {
+			// Don't fail if SSH agent operations fail, SSH config should be enough
+			fmt.Fprintf(m.out, "⚠️  Warning: SSH agent key loading failed: %v\n", keyLoadErr)
+		}
 	}
 
 	// 5. Update shell configuration with GIT_SSH_COMMAND
 	if err := m.updateShellConfig(accountAlias, keyPath); err != nil {
 		// Don't fail the entire operation if shell config update fails
-		fmt.Printf("⚠️  Warning: failed to update shell configuration: %v\n", err)
+		fmt.Fprintf(m.out, "⚠️  Warning: failed to update shell configuration: %v\n", err)
 	} else {
-		fmt.Printf("✅ Shell configuration updated for account: %s\n", accountAlias)
+		fmt.Fprintf(m.out, "✅ Shell configuration updated for account: %s\n", accountAlias)
 	}
 
 	// 6. Test the connection (don't fail on error)
-	if err := m.TestConnection(); err != nil {
-		fmt.Printf("⚠️  Warning: SSH connection test failed: %v\n", err)
+	if !opts.SkipConnectivityTest {
+		if err := m.TestConnectionToPlatform(ctx, domain); err != nil {
+			fmt.Fprintf(m.out, "⚠️  Warning: SSH connection test failed: %v\n", err)
+		}
 	}
 
-	return nil
+	return plan, nil
 }
 
-// GetLoadedKeys returns the list of currently loaded SSH keys
-func (m *Manager) GetLoadedKeys() ([]string, error) {
-	cmd := exec.Command("ssh-add", "-l")
-	output, err := cmd.CombinedOutput()
+// printSwitchPlan prints the changes a dry-run switch would make
+func printSwitchPlan(w io.Writer, plan *SwitchPlan) {
+	fmt.Fprintf(w, "🔍 Dry run: no changes will be made\n")
+	fmt.Fprintf(w, "\n📄 New SSH config (%s):\n", plan.SSHConfigPath)
+	fmt.Fprintln(w, strings.TrimRight(plan.SSHConfig, "\n"))
+	fmt.Fprintf(w, "\n🔑 SSH agent operations:\n")
+	for _, op := range plan.AgentOperations {
+		fmt.Fprintf(w, "   • %s\n", op)
+	}
+	if plan.ShellConfigPath != "" {
+		fmt.Fprintf(w, "\n📝 GIT_SSH_COMMAND would be set in: %s\n", plan.ShellConfigPath)
+	}
+}
+
+// LoadedKey is a key held by the SSH agent, as reported by ssh-add -l
+type LoadedKey = KeyInfo
+
+// GetLoadedKeys returns the keys currently loaded in the SSH agent
+func (m *Manager) GetLoadedKeys(ctx context.Context) ([]LoadedKey, error) {
+	output, err := m.runner.CombinedOutput(ctx, "ssh-add", "-l")
 	if err != nil {
 		if strings.Contains(string(output), "The agent has no identities") {
-			return []string{}, nil
+			return []LoadedKey{}, nil
 		}
 		return nil, fmt.Errorf("failed to list SSH keys: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var keys []string
-	for _, line := range lines {
-		if parts := strings.Fields(line); len(parts) >= 3 {
-			keys = append(keys, strings.TrimSpace(parts[2]))
+	return parseLoadedKeys(string(output)), nil
+}
+
+// parseLoadedKeys parses ssh-add -l output, skipping lines that are not key fingerprints
+func parseLoadedKeys(output string) []LoadedKey {
+	var keys []LoadedKey
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if info, err := ParseKeyFingerprint(strings.TrimSpace(line)); err == nil {
+			keys = append(keys, *info)
+		}
+	}
+	return keys
+}
+
+// IsKeyLoaded reports whether the key at keyPath is loaded in the SSH agent. Keys are
+// compared by fingerprint, since comments are free text and may mention other keys' paths.
+func (m *Manager) IsKeyLoaded(ctx context.Context, keyPath string) (bool, error) {
+	info, err := m.ValidateKey(ctx, keyPath)
+	if err != nil {
+		return false, err
+	}
+
+	keys, err := m.GetLoadedKeys(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range keys {
+		if key.Fingerprint == info.Fingerprint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FindAgentKey returns the key with the given SHA256 fingerprint from the SSH agent, as
+// reported by ssh-add -l
+func (m *Manager) FindAgentKey(ctx context.Context, fingerprint string) (*LoadedKey, error) {
+	fingerprint = sha256Fingerprint(fingerprint)
+	keys, err := m.GetLoadedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.Fingerprint == fingerprint {
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("no key with fingerprint %s is loaded in the SSH agent", fingerprint)
+}
+
+// UnloadKeyByFingerprint removes the agent key with the given SHA256 fingerprint. ssh-add -d
+// needs the public key, so it is looked up with ssh-add -L and passed via a temporary file.
+func (m *Manager) UnloadKeyByFingerprint(ctx context.Context, fingerprint string) error {
+	publicKey, err := m.agentPublicKey(ctx, fingerprint)
+	if err != nil {
+		return err
+	}
+	return m.removePublicKeyFromAgent(ctx, publicKey)
+}
+
+// agentPublicKey returns the public key, in authorized_keys format, of the agent key with
+// the given SHA256 fingerprint
+func (m *Manager) agentPublicKey(ctx context.Context, fingerprint string) (string, error) {
+	fingerprint = sha256Fingerprint(fingerprint)
+
+	output, err := m.runner.CombinedOutput(ctx, "ssh-add", "-L")
+	if err != nil {
+		if strings.Contains(string(output), "The agent has no identities") {
+			return "", fmt.Errorf("no key with fingerprint %s is loaded in the SSH agent", fingerprint)
+		}
+		return "", fmt.Errorf("failed to list SSH agent keys: %w", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if keyFingerprint, err := publicKeyFingerprint(line); err == nil && keyFingerprint == fingerprint {
+			return line, nil
 		}
 	}
-	return keys, nil
+
+	return "", fmt.Errorf("no key with fingerprint %s is loaded in the SSH agent", fingerprint)
+}
+
+// WriteAgentPublicKey writes the public half of the agent key with the given SHA256
+// fingerprint to ~/.ssh/gitshift_agent_<alias>.pub and returns the file's path. Named by
+// IdentityFile or ssh -i, the file makes ssh sign with the matching agent key, so it stands
+// in for the private key file an agent-only account doesn't have.
+func (m *Manager) WriteAgentPublicKey(ctx context.Context, accountAlias, fingerprint string) (string, error) {
+	publicKey, err := m.agentPublicKey(ctx, fingerprint)
+	if err != nil {
+		return "", err
+	}
+
+	sshDir := filepath.Join(m.homeDir, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create SSH directory: %w", err)
+	}
+	path := filepath.Join(sshDir, fmt.Sprintf("gitshift_agent_%s.pub", accountAlias))
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(publicKey)+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write the agent's public key: %w", err)
+	}
+	return path, nil
+}
+
+// sha256Fingerprint adds the "SHA256:" prefix ssh prints to a bare fingerprint
+func sha256Fingerprint(fingerprint string) string {
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		return "SHA256:" + fingerprint
+	}
+	return fingerprint
+}
+
+// removePublicKeyFromAgent removes the key matching an authorized_keys-format public key
+func (m *Manager) removePublicKeyFromAgent(ctx context.Context, publicKey string) error {
+	tmpFile, err := os.CreateTemp("", "gitshift-unload-*.pub")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary public key file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(strings.TrimSpace(publicKey) + "\n"); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temporary public key file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary public key file: %w", err)
+	}
+
+	output, err := m.runner.CombinedOutput(ctx, "ssh-add", "-d", tmpFile.Name())
+	if err != nil {
+		return fmt.Errorf("ssh-add -d failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
+	}
+	return nil
+}
+
+// publicKeyFingerprint computes the SHA256 fingerprint of an authorized_keys-format public
+// key line, in the form printed by ssh-keygen -l
+func publicKeyFingerprint(publicKey string) (string, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("unexpected public key format: %q", publicKey)
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid public key encoding: %w", err)
+	}
+
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// KeyInfo holds the details reported by ssh-keygen -l for a key
+type KeyInfo struct {
+	Bits        int
+	Fingerprint string
+	Comment     string
+	Type        string // ed25519, rsa, ecdsa, dsa, ed25519-sk, ecdsa-sk
+}
+
+// MinRSAKeyBits is the smallest RSA key size gitshift considers strong enough
+const MinRSAKeyBits = 3072
+
+// Weakness explains why the key is too weak to keep using, or returns "" for a strong key.
+// DSA keys are rejected outright since OpenSSH and the major platforms no longer accept
+// them.
+func (k *KeyInfo) Weakness() string {
+	switch {
+	case k.Type == "dsa":
+		return "DSA keys are deprecated and no longer accepted by OpenSSH or Git platforms"
+	case k.Type == "rsa" && k.Bits < MinRSAKeyBits:
+		return fmt.Sprintf("%d-bit RSA keys are too weak, at least %d bits are recommended", k.Bits, MinRSAKeyBits)
+	}
+	return ""
+}
+
+// ValidateKey inspects a key with ssh-keygen -l and returns its size, fingerprint and type
+func (m *Manager) ValidateKey(ctx context.Context, keyPath string) (*KeyInfo, error) {
+	keyPath = pathutil.Expand(keyPath)
+	output, err := m.runner.CombinedOutput(ctx, "ssh-keygen", "-lf", keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("ssh-keygen -l failed for %s: %w\nOutput: %s", keyPath, err, redact.Secrets(string(output)))
+	}
+
+	info, err := ParseKeyFingerprint(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH key %s: %w", keyPath, err)
+	}
+	return info, nil
+}
+
+// ParseKeyFingerprint parses a single line of ssh-keygen -l (or ssh-add -l) output, e.g.
+// "256 SHA256:abc... user@example.com (ED25519-SK)"
+func ParseKeyFingerprint(line string) (*KeyInfo, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected fingerprint format: %q", line)
+	}
+
+	bits, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected key size in fingerprint: %q", line)
+	}
+
+	info := &KeyInfo{
+		Bits:        bits,
+		Fingerprint: fields[1],
+	}
+
+	// The key type is the trailing parenthesised token; everything in between is the comment
+	last := fields[len(fields)-1]
+	commentFields := fields[2:]
+	if strings.HasPrefix(last, "(") && strings.HasSuffix(last, ")") {
+		info.Type = keyTypeFromLabel(strings.Trim(last, "()"))
+		commentFields = fields[2 : len(fields)-1]
+	}
+	info.Comment = strings.Join(commentFields, " ")
+
+	return info, nil
+}
+
+// keyTypeFromLabel maps the type label printed by ssh-keygen to gitshift's key type names
+func keyTypeFromLabel(label string) string {
+	switch strings.ToUpper(label) {
+	case "ED25519":
+		return "ed25519"
+	case "ED25519-SK":
+		return "ed25519-sk"
+	case "ECDSA":
+		return "ecdsa"
+	case "ECDSA-SK":
+		return "ecdsa-sk"
+	case "RSA":
+		return "rsa"
+	case "DSA":
+		return "dsa"
+	default:
+		return strings.ToLower(label)
+	}
 }
 
 // detectShell detects the user's shell and returns shell type and config file path
@@ -206,8 +634,8 @@ func (m *Manager) updateShellConfig(accountAlias, keyPath string) error {
 	}
 
 	// Print helpful message about what was done
-	fmt.Printf("📝 Updated %s config: %s\n", shellType, configPath)
-	fmt.Printf("💡 Run 'source %s' or restart your terminal to apply changes\n", configPath)
+	fmt.Fprintf(m.out, "📝 Updated %s config: %s\n", shellType, configPath)
+	fmt.Fprintf(m.out, "💡 Run 'source %s' or restart your terminal to apply changes\n", configPath)
 
 	return nil
 }
@@ -247,9 +675,8 @@ func (m *Manager) SourceShellConfig() error {
 }
 
 // clearSSHAgent removes all keys from the SSH agent
-func (m *Manager) clearSSHAgent() error {
-	cmd := exec.Command("ssh-add", "-D")
-	output, err := cmd.CombinedOutput()
+func (m *Manager) clearSSHAgent(ctx context.Context) error {
+	output, err := m.runner.CombinedOutput(ctx, "ssh-add", "-D")
 	if err != nil {
 		// It's OK if there are no keys to remove
 		if strings.Contains(string(output), "no identities") {
@@ -260,199 +687,379 @@ func (m *Manager) clearSSHAgent() error {
 	return nil
 }
 
-// addKeyToAgent adds a specific key to the SSH agent
-func (m *Manager) addKeyToAgent(keyPath string) error {
-	cmd := exec.Command("ssh-add", keyPath)
-	output, err := cmd.CombinedOutput()
+// addKeyToAgent adds a specific key to the SSH agent. ssh-add asks for the passphrase of
+// an encrypted key on the terminal, so it runs in the foreground.
+func (m *Manager) addKeyToAgent(ctx context.Context, keyPath string) error {
+	keyPath = pathutil.Expand(keyPath)
+	output, err := m.runner.InteractiveCombinedOutput(ctx, "ssh-add", keyPath)
 	if err != nil {
-		return fmt.Errorf("ssh-add %s failed: %w\nOutput: %s", keyPath, err, string(output))
+		return fmt.Errorf("ssh-add %s failed: %w\nOutput: %s", keyPath, err, redact.Secrets(string(output)))
 	}
 	return nil
 }
 
 // TestConnection tests the SSH connection to GitHub (deprecated, use TestConnectionToPlatform)
 func (m *Manager) TestConnection() error {
-	return m.TestConnectionToPlatform("github.com")
+	return m.TestConnectionToPlatform(context.Background(), "github.com")
 }
 
 // TestConnectionToPlatform tests the SSH connection to a specific platform domain
-func (m *Manager) TestConnectionToPlatform(domain string) error {
-	// Test SSH connection to the specified domain
-	testCmd := exec.Command("ssh", "-T", fmt.Sprintf("git@%s", domain))
-	output, err := testCmd.CombinedOutput()
+func (m *Manager) TestConnectionToPlatform(ctx context.Context, domain string) error {
+	if domain == "" {
+		return fmt.Errorf("no platform domain to test the SSH connection against")
+	}
+
+	// Test SSH connection to the specified domain; BatchMode makes an unknown host key or an
+	// encrypted key fail the test instead of prompting
+	output, err := m.runner.CombinedOutput(ctx, "ssh", "-o", "BatchMode=yes", "-T", fmt.Sprintf("git@%s", domain))
 	outputStr := string(output)
 
-	// Different platforms return different success messages
-	// GitHub: "successfully authenticated"
-	// GitLab: "Welcome to GitLab"
-	// Both return non-zero exit codes despite successful authentication
-	successIndicators := []string{
-		"successfully authenticated",
-		"Welcome to GitLab",
-		"logged in as",
-		"authenticated",
+	// Platforms exit non-zero despite successful authentication
+	if err == nil || AuthSucceeded(outputStr, err) {
+		return nil
 	}
 
-	for _, indicator := range successIndicators {
-		if strings.Contains(outputStr, indicator) {
-			return nil
-		}
+	return fmt.Errorf("SSH connection test to %s failed: %w\nOutput: %s", domain, err, redact.Secrets(outputStr))
+}
+
+// TestKeyConnection tests the SSH connection to domain with only the key at keyPath. The
+// SSH config is ignored apart from the way to the host (see routeOptions) and
+// IdentitiesOnly keeps ssh from offering other keys the agent holds, so success proves that
+// this key is accepted. It returns the account name the platform greeted, which is empty
+// for platforms gitshift doesn't recognise.
+func (m *Manager) TestKeyConnection(ctx context.Context, domain, keyPath string) (string, error) {
+	keyPath = pathutil.Expand(keyPath)
+	if domain == "" {
+		return "", fmt.Errorf("no platform domain to test the SSH connection against")
 	}
 
-	// If no error and output suggests success
-	if err == nil {
-		return nil
+	return m.testIdentityConnection(ctx, domain, keyPath, keyPath)
+}
+
+// TestAgentKeyConnection is TestKeyConnection for a key only the SSH agent holds, named by
+// its SHA256 fingerprint. The agent's public key is written to a temporary file for -i,
+// which makes ssh sign with the matching agent key and offer no other.
+func (m *Manager) TestAgentKeyConnection(ctx context.Context, domain, fingerprint string) (string, error) {
+	if domain == "" {
+		return "", fmt.Errorf("no platform domain to test the SSH connection against")
+	}
+	publicKey, err := m.agentPublicKey(ctx, fingerprint)
+	if err != nil {
+		return "", err
 	}
 
-	return fmt.Errorf("SSH connection test to %s failed: %w\nOutput: %s", domain, err, outputStr)
+	tmpFile, err := os.CreateTemp("", "gitshift-agent-*.pub")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary public key file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.WriteString(strings.TrimSpace(publicKey) + "\n"); err != nil {
+		tmpFile.Close()
+		return "", fmt.Errorf("failed to write temporary public key file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary public key file: %w", err)
+	}
+
+	return m.testIdentityConnection(ctx, domain, tmpFile.Name(), "the agent key "+sha256Fingerprint(fingerprint))
 }
 
-// updateGitHubSSHConfigV2 updates the SSH config with improved multi-account isolation
-// Deprecated: Use UpdateSSHConfig with platform domain instead
-func (m *Manager) updateGitHubSSHConfigV2(accountAlias, keyPath string) error {
-	return m.UpdateSSHConfig(accountAlias, keyPath, "github.com")
+// testIdentityConnection runs ssh -T against domain offering only identityFile; keyName
+// names the key in errors
+func (m *Manager) testIdentityConnection(ctx context.Context, domain, identityFile, keyName string) (string, error) {
+	args := []string{
+		"-F", "none",
+		"-o", "ConnectTimeout=10",
+		"-o", "BatchMode=yes",
+		"-o", "IdentitiesOnly=yes",
+		"-i", identityFile,
+	}
+	args = append(args, m.routeOptions(ctx, domain)...)
+	output, err := m.runner.CombinedOutput(ctx, "ssh", append(args, "-T", fmt.Sprintf("git@%s", domain))...)
+	outputStr := string(output)
+
+	if AuthSucceeded(outputStr, err) {
+		return AuthenticatedUser(outputStr), nil
+	}
+	return "", fmt.Errorf("SSH connection test to %s with %s failed: %v\nOutput: %s", domain, keyName, err, redact.Secrets(outputStr))
 }
 
-// UpdateSSHConfig updates the SSH config for a specific platform domain
-func (m *Manager) UpdateSSHConfig(accountAlias, keyPath, domain string) error {
-	// Ensure SSH directory exists
-	sshDir := filepath.Dir(m.configPath)
-	if err := os.MkdirAll(sshDir, 0700); err != nil {
-		return fmt.Errorf("failed to create SSH directory: %w", err)
+// HostName returns the host ssh connects to for host, as resolved by ssh -G from
+// ~/.ssh/config. A host without a HostName setting resolves to itself.
+func (m *Manager) HostName(ctx context.Context, host string) (string, error) {
+	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
+		return host, nil
+	}
+	output, err := m.runner.CombinedOutput(ctx, "ssh", "-F", m.configPath, "-G", host)
+	if err != nil {
+		message, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		return "", fmt.Errorf("failed to resolve the SSH config for %s: %w: %s", host, err, message)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if keyword, value, ok := strings.Cut(strings.TrimSpace(line), " "); ok && keyword == "hostname" {
+			return value, nil
+		}
 	}
+	return host, nil
+}
 
-	// Read current SSH config (if exists)
-	existingContent := ""
-	if content, err := os.ReadFile(m.configPath); err == nil {
-		if !strings.Contains(string(content), "# gitshift Managed Config") {
-			// Backup existing config if it's not already managed by gitshift
-			backupPath := m.configPath + ".backup"
-			if err := os.WriteFile(backupPath, content, 0600); err != nil {
-				return fmt.Errorf("failed to backup SSH config: %w", err)
+// routeOptions returns the ssh options that carry the way to domain over from the SSH
+// config to a run with -F none: its HostName, Port and ProxyCommand or ProxyJump, so a
+// key can be tested behind a bastion or on port 443. A ProxyJump becomes a ProxyCommand
+// running ssh with the default config, so the jump hosts keep their own settings. Nothing
+// is carried over when ssh -G can't resolve the config.
+func (m *Manager) routeOptions(ctx context.Context, domain string) []string {
+	output, err := m.runner.CombinedOutput(ctx, "ssh", "-F", m.configPath, "-G", domain)
+	if err != nil {
+		return nil
+	}
+	settings := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if keyword, value, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			if _, seen := settings[keyword]; !seen {
+				settings[keyword] = value
 			}
 		}
-		existingContent = string(content)
 	}
 
-	// Build the new config
-	newConfig := m.buildIsolatedSSHConfigForPlatform(accountAlias, keyPath, domain, existingContent)
+	var options []string
+	if hostName := settings["hostname"]; hostName != "" && !strings.EqualFold(hostName, domain) {
+		options = append(options, "-o", "HostName="+hostName)
+	}
+	if port := settings["port"]; port != "" && port != "22" {
+		options = append(options, "-p", port)
+	}
+	switch proxyCommand, proxyJump := settings["proxycommand"], settings["proxyjump"]; {
+	case proxyCommand != "" && proxyCommand != "none":
+		options = append(options, "-o", "ProxyCommand="+proxyCommand)
+	case proxyJump != "" && proxyJump != "none":
+		options = append(options, "-o", "ProxyCommand="+jumpCommand(proxyJump))
+	}
+	return options
+}
 
-	// Write the updated config
-	if err := os.WriteFile(m.configPath, []byte(newConfig), 0600); err != nil {
-		return fmt.Errorf("failed to write SSH config: %w", err)
+// jumpCommand returns a ProxyCommand that reaches the target through the comma-separated
+// ProxyJump hosts jumps
+func jumpCommand(jumps string) string {
+	hops := strings.Split(jumps, ",")
+	command := "ssh"
+	if len(hops) > 1 {
+		command += " -J " + strings.Join(hops[:len(hops)-1], ",")
 	}
+	return command + " -W [%h]:%p " + hops[len(hops)-1]
+}
 
-	return nil
+// sshFailureStatus is the exit status of ssh itself failing to connect or authenticate, as
+// opposed to the status of the session on the server
+const sshFailureStatus = 255
+
+// authSuccessIndicators match the greetings platforms print on successful ssh -T
+// authentication. They ignore case since the wording differs between platforms, versions
+// and GitHub Enterprise installations.
+var authSuccessIndicators = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)successfully authenticated`),                   // GitHub, GitHub Enterprise, Gitea, Gogs
+	regexp.MustCompile(`(?i)welcome to gitlab`),                            // GitLab
+	regexp.MustCompile(`(?i)logged in as|authenticated via (an )?ssh key`), // Bitbucket
+	regexp.MustCompile(`(?i)shell access is not supported`),                // Azure DevOps
 }
 
-// buildIsolatedSSHConfig creates an SSH config with proper multi-account isolation
-// Deprecated: Use buildIsolatedSSHConfigForPlatform instead
-func (m *Manager) buildIsolatedSSHConfig(accountAlias, keyPath, existingConfig string) string {
-	return m.buildIsolatedSSHConfigForPlatform(accountAlias, keyPath, "github.com", existingConfig)
+// authDeniedPattern matches ssh giving up after the server refused every key offered, such
+// as "git@github.com: Permission denied (publickey)." or "(publickey,password)"
+var authDeniedPattern = regexp.MustCompile(`Permission denied \([^)]*publickey[^)]*\)`)
+
+// AuthSucceeded reports whether an ssh -T run that printed output and returned err
+// authenticated. Platforms close the session with exit status 1 after their greeting, so
+// that is a success when the greeting is recognised. Exit status 255 is ssh itself failing,
+// which is never a success, and neither is a refused key, whatever else the output says.
+func AuthSucceeded(output string, err error) bool {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == sshFailureStatus {
+		return false
+	}
+	if AuthDenied(output) {
+		return false
+	}
+	for _, indicator := range authSuccessIndicators {
+		if indicator.MatchString(output) {
+			return true
+		}
+	}
+	return false
 }
 
-// buildIsolatedSSHConfigForPlatform creates an SSH config for a specific platform
-func (m *Manager) buildIsolatedSSHConfigForPlatform(accountAlias, keyPath, domain, existingConfig string) string {
-	// Start with the gitshift header
-	config := "# gitshift Managed Config - DO NOT EDIT MANUALLY\n"
-	config += "# This file is automatically generated by gitshift\n\n"
+// AuthDenied reports whether ssh -T output shows that the server refused the keys offered
+func AuthDenied(output string) bool {
+	return authDeniedPattern.MatchString(output)
+}
 
-	// Preserve non-platform configurations
-	config += m.preserveNonPlatformConfig(existingConfig, domain)
+// authBannerPatterns match the greeting printed by ssh -T on successful authentication,
+// capturing the account name
+var authBannerPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?mi)^Hi ([A-Za-z0-9_-]+)! You(?:'|’)ve successfully authenticated`), // GitHub, including enterprise managed users
+	regexp.MustCompile(`(?m)^Welcome to GitLab, @([A-Za-z0-9_.-]+)!`),                        // GitLab
+}
 
-	// Determine platform name for comment
-	platformName := "Git hosting"
-	switch domain {
-	case "github.com":
-		platformName = "GitHub"
-	case "gitlab.com":
-		platformName = "GitLab"
-	case "bitbucket.org":
-		platformName = "Bitbucket"
+// AuthenticatedUser extracts the account name from ssh -T output. It returns an empty
+// string unless the output contains a recognised greeting.
+func AuthenticatedUser(output string) string {
+	for _, pattern := range authBannerPatterns {
+		if match := pattern.FindStringSubmatch(output); match != nil {
+			return match[1]
+		}
 	}
+	return ""
+}
 
-	// Add platform host configuration
-	config += fmt.Sprintf(`# %s account: %s
-Host %s
-    HostName %s
-    User git
-    IdentityFile %s
-    IdentitiesOnly yes
-    AddKeysToAgent yes
-    UseKeychain yes
+// updateGitHubSSHConfigV2 updates the SSH config with improved multi-account isolation
+// Deprecated: Use UpdateSSHConfig with platform domain instead
+func (m *Manager) updateGitHubSSHConfigV2(accountAlias, keyPath string) error {
+	return m.UpdateSSHConfig(accountAlias, keyPath, "github.com")
+}
 
-`, platformName, accountAlias, domain, domain, keyPath)
+// UpdateSSHConfig updates the SSH config for a specific platform domain, writing a Match
+// block for each of matches with criteria ahead of the account's Host block, and a Host
+// block for the host alias of every account in matches after it.
+// Only the gitshift-managed block for that domain is replaced; everything else in the
+// file is left byte-for-byte intact. The new config is checked with ssh first and left
+// unwritten when ssh rejects it.
+func (m *Manager) UpdateSSHConfig(accountAlias, keyPath, domain string, matches ...MatchIdentity) error {
+	// Ensure SSH directory exists
+	sshDir := filepath.Dir(m.configPath)
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		return fmt.Errorf("failed to create SSH directory: %w", err)
+	}
 
-	return config
+	newConfig, err := m.renderSSHConfig(accountAlias, keyPath, domain, matches)
+	if err != nil {
+		return err
+	}
+	return m.writeSSHConfig(newConfig)
 }
 
-// preserveNonGitHubConfig extracts and preserves non-GitHub host configurations
-// Deprecated: Use preserveNonPlatformConfig instead
-func (m *Manager) preserveNonGitHubConfig(existingConfig string) string {
-	return m.preserveNonPlatformConfig(existingConfig, "github.com")
-}
+// RefreshHostAliases rewrites the managed block for domain with the host aliases and Match
+// blocks of accounts, keeping the account the block was last switched to, so remotes pinned
+// to an account resolve after accounts are added, removed or changed. A domain without a
+// managed block is left alone; the first switch to one of its accounts writes it.
+func (m *Manager) RefreshHostAliases(domain string, accounts []*models.Account) error {
+	content, err := os.ReadFile(m.configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+	alias, keyPath, found := managedBlockAccount(string(content), domain)
+	if !found {
+		return nil
+	}
 
-// preserveNonPlatformConfig extracts and preserves configurations not related to the specified domain
-func (m *Manager) preserveNonPlatformConfig(existingConfig, targetDomain string) string {
-	if existingConfig == "" {
-		return ""
+	// The bare domain follows the active account's key when it changed. An account whose
+	// key is only in the agent keeps the public key file written at the switch.
+	active := &models.Account{Domain: domain}
+	for _, account := range accounts {
+		if account.Alias == alias && strings.EqualFold(account.GetDomain(), domain) {
+			active = account
+		}
+	}
+	if active.Alias == "" {
+		keyPath = ""
+	} else if active.SSHKeyPath != "" {
+		keyPath = active.SSHKeyPath
 	}
 
-	var preserved []string
-	lines := strings.Split(existingConfig, "\n")
-	inTargetSection := false
-	skipUntilNextHost := false
+	matches := MatchIdentities(accounts, active)
+	block := ""
+	if active.Alias != "" || len(matches) > 0 {
+		block = buildManagedBlock(active.Alias, keyPath, domain, matches, m.managedBlockOptions())
+	}
+	return m.writeSSHConfig(replaceManagedBlock(string(content), domain, block))
+}
 
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
+// writeSSHConfig installs newConfig as the SSH config after ssh accepted it, backing up the
+// config it replaces
+func (m *Manager) writeSSHConfig(newConfig string) error {
+	content, err := os.ReadFile(m.configPath)
+	exists := err == nil
+	if exists && string(content) == newConfig {
+		return nil
+	}
 
-		// Check if we're entering a target platform host section
-		if strings.HasPrefix(line, "Host ") {
-			hostLine := strings.TrimSpace(strings.TrimPrefix(line, "Host"))
-			hosts := strings.Fields(hostLine)
+	// ssh reports problems by line of the updated config, so label it the way
+	// ConfigPreview.Diff does
+	if err := m.checkConfigSyntax(context.Background(), newConfig, m.configPath+" (gitshift)"); err != nil {
+		return models.NewUserError(
+			models.CategorySSH,
+			fmt.Sprintf("ssh rejected the updated SSH config, %s was left unchanged", m.configPath),
+			"Review the change with 'gitshift ssh-config print --diff' and fix or remove the directive ssh rejects",
+			err,
+		)
+	}
 
-			// Check if any of the hosts matches the target domain
-			isTargetHost := false
-			for _, host := range hosts {
-				if host == targetDomain || strings.Contains(host, targetDomain) {
-					isTargetHost = true
-					break
-				}
-			}
+	// Backup the existing config before changing it
+	if exists {
+		if _, err := m.backupSSHConfig(); err != nil {
+			return err
+		}
+	}
 
-			if isTargetHost {
-				inTargetSection = true
-				skipUntilNextHost = true
-				continue
-			}
+	// Write the updated config
+	if err := os.WriteFile(m.configPath, []byte(newConfig), 0600); err != nil {
+		return fmt.Errorf("failed to write SSH config: %w", err)
+	}
 
-			// If we were in a target section and found a new host, we're done
-			if inTargetSection {
-				inTargetSection = false
-			}
+	return nil
+}
 
-			// If we were skipping until next host, stop skipping
-			if skipUntilNextHost {
-				skipUntilNextHost = false
-			}
-		}
+// ConfigPreview is the SSH config a switch would write, next to the config on disk
+type ConfigPreview struct {
+	Path    string
+	Current string // "" when the file doesn't exist yet
+	Updated string
+	Block   string // the managed block for the domain
+}
 
-		// Skip lines in target sections or when we're in skip mode
-		if inTargetSection || skipUntilNextHost {
-			continue
-		}
+// PreviewSSHConfig renders the SSH config UpdateSSHConfig would write for the account,
+// without writing anything
+func (m *Manager) PreviewSSHConfig(accountAlias, keyPath, domain string, matches ...MatchIdentity) (*ConfigPreview, error) {
+	current, err := os.ReadFile(m.configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read SSH config: %w", err)
+	}
 
-		// Preserve all other lines
-		preserved = append(preserved, lines[i])
+	updated, err := m.renderSSHConfig(accountAlias, keyPath, domain, matches)
+	if err != nil {
+		return nil, err
 	}
+	return &ConfigPreview{
+		Path:    m.configPath,
+		Current: string(current),
+		Updated: updated,
+		Block:   buildManagedBlock(accountAlias, keyPath, domain, matches, m.managedBlockOptions()),
+	}, nil
+}
 
-	if len(preserved) > 0 {
-		// Ensure there's a blank line before adding platform config
-		if preserved[len(preserved)-1] != "" {
-			preserved = append(preserved, "")
-		}
-		return strings.Join(preserved, "\n") + "\n"
+// Diff returns a unified diff from the current config to the updated one, or "" when the
+// switch wouldn't change the file
+func (p *ConfigPreview) Diff() string {
+	return unifiedDiff(p.Path, p.Path+" (gitshift)", p.Current, p.Updated)
+}
+
+// renderSSHConfig returns the SSH config content with the account's block spliced in,
+// without writing anything
+func (m *Manager) renderSSHConfig(accountAlias, keyPath, domain string, matches []MatchIdentity) (string, error) {
+	existingContent := ""
+	content, err := os.ReadFile(m.configPath)
+	if err == nil {
+		existingContent = string(content)
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read SSH config: %w", err)
 	}
 
-	return ""
+	block := buildManagedBlock(accountAlias, keyPath, domain, matches, m.managedBlockOptions())
+	return replaceManagedBlock(existingContent, domain, block), nil
+}
+
+// HostAlias returns the SSH host alias gitshift generates for an account, e.g. gitlab.com-work
+func HostAlias(domain, accountAlias string) string {
+	return fmt.Sprintf("%s-%s", domain, accountAlias)
 }
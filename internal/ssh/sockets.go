@@ -0,0 +1,82 @@
+package ssh
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// agentDialTimeout bounds the connection attempt that tells a live agent socket from a dead one
+const agentDialTimeout = time.Second
+
+// FindStaleAgentSockets returns the sockets in dirs that no agent listens on anymore, e.g.
+// left behind by an isolated agent that crashed. A socket is stale when connecting to it is
+// refused; sockets that can't be probed for another reason, such as permissions, are left
+// alone. Paths in keep are never reported, and missing dirs are skipped.
+func FindStaleAgentSockets(dirs, keep []string) ([]string, error) {
+	kept := make(map[string]bool, len(keep))
+	for _, path := range keep {
+		if path != "" {
+			kept[filepath.Clean(path)] = true
+		}
+	}
+
+	var stale []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.Type()&os.ModeSocket == 0 || kept[path] {
+				continue
+			}
+			if agentSocketDead(path) {
+				stale = append(stale, path)
+			}
+		}
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
+
+// RemoveStaleAgentSockets removes the sockets FindStaleAgentSockets reports and returns the
+// ones it removed. Each socket is probed again right before it is removed, so an agent that
+// started on it in the meantime keeps it.
+func RemoveStaleAgentSockets(dirs, keep []string) ([]string, error) {
+	stale, err := FindStaleAgentSockets(dirs, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, path := range stale {
+		if !agentSocketDead(path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// agentSocketDead reports whether connecting to the socket at path is refused, which means
+// the process that created it is gone
+func agentSocketDead(path string) bool {
+	conn, err := net.DialTimeout("unix", path, agentDialTimeout)
+	if err == nil {
+		conn.Close()
+		return false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,62 @@
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// listenUnix creates a socket at path; with dead set, its listener is closed again
+// without removing the socket file, like an agent that crashed
+func listenUnix(t *testing.T, path string, dead bool) {
+	t.Helper()
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dead {
+		listener.SetUnlinkOnClose(false)
+		listener.Close()
+		return
+	}
+	t.Cleanup(func() { listener.Close() })
+}
+
+func TestRemoveStaleAgentSockets(t *testing.T) {
+	dir := t.TempDir()
+	live := filepath.Join(dir, "live.sock")
+	dead := filepath.Join(dir, "dead.sock")
+	active := filepath.Join(dir, "active.sock")
+	listenUnix(t, live, false)
+	listenUnix(t, dead, true)
+	listenUnix(t, active, true)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a socket"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := []string{dir, filepath.Join(dir, "missing")}
+	keep := []string{active}
+
+	stale, err := FindStaleAgentSockets(dirs, keep)
+	if err != nil {
+		t.Fatalf("FindStaleAgentSockets() error = %v", err)
+	}
+	if want := []string{dead}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("FindStaleAgentSockets() = %v, want %v", stale, want)
+	}
+
+	removed, err := RemoveStaleAgentSockets(dirs, keep)
+	if err != nil {
+		t.Fatalf("RemoveStaleAgentSockets() error = %v", err)
+	}
+	if want := []string{dead}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveStaleAgentSockets() = %v, want %v", removed, want)
+	}
+	for path, wantExists := range map[string]bool{live: true, dead: false, active: true} {
+		if _, err := os.Stat(path); (err == nil) != wantExists {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, wantExists)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/sshconfig"
+)
+
+// SSHConfigValidation is the result of validating the SSH config file
+type SSHConfigValidation struct {
+	ConfigPath  string
+	Exists      bool
+	Permissions os.FileMode
+	Issues      []SSHConfigIssue
+}
+
+// SSHConfigIssue describes a single problem found in the SSH config
+type SSHConfigIssue struct {
+	Line    int // 1-based line number, 0 when the issue is not tied to a line
+	Host    string
+	Message string
+	Fixable bool // whether FixConfig can repair the issue
+	Warning bool // the config works as ssh documents it, but may not do what was meant
+}
+
+// IsValid returns true when no issues other than warnings were found
+func (v *SSHConfigValidation) IsValid() bool {
+	return len(v.Problems()) == 0
+}
+
+// Problems returns the issues that are not warnings
+func (v *SSHConfigValidation) Problems() []SSHConfigIssue {
+	var problems []SSHConfigIssue
+	for _, issue := range v.Issues {
+		if !issue.Warning {
+			problems = append(problems, issue)
+		}
+	}
+	return problems
+}
+
+// HasFixableIssues returns true when FixConfig would change something
+func (v *SSHConfigValidation) HasFixableIssues() bool {
+	for _, issue := range v.Issues {
+		if issue.Fixable {
+			return true
+		}
+	}
+	return false
+}
+
+// hostSection is a Host section of an SSH config and whether it lies inside a
+// gitshift-managed block
+type hostSection struct {
+	sshconfig.HostBlock
+	managed bool
+}
+
+// ValidateConfig checks that the SSH config exists with safe permissions and looks for
+// Host patterns defined more than once and Host sections with conflicting IdentityFile
+// directives.
+func (m *Manager) ValidateConfig() (*SSHConfigValidation, error) {
+	validation := &SSHConfigValidation{ConfigPath: m.configPath}
+
+	info, err := os.Stat(m.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			validation.Issues = append(validation.Issues, SSHConfigIssue{
+				Message: "SSH config does not exist",
+			})
+			return validation, nil
+		}
+		return nil, fmt.Errorf("failed to inspect SSH config: %w", err)
+	}
+	validation.Exists = true
+	validation.Permissions = info.Mode().Perm()
+
+	if validation.Permissions&0022 != 0 {
+		validation.Issues = append(validation.Issues, SSHConfigIssue{
+			Message: fmt.Sprintf("SSH config is writable by other users (mode %04o); ssh refuses to use it, expected 0600", validation.Permissions),
+			Fixable: true,
+		})
+	}
+
+	content, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	validation.Issues = append(validation.Issues, findConfigIssues(string(content))...)
+	return validation, nil
+}
+
+// FixConfig repairs the fixable issues reported by ValidateConfig: permissions are reset to
+// 0600 and duplicated Host patterns are removed from user sections in favour of the
+// gitshift-managed block. The config is backed up before it is rewritten. The returned
+// validation reflects the repaired config.
+func (m *Manager) FixConfig() (*SSHConfigValidation, error) {
+	content, err := os.ReadFile(m.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m.ValidateConfig()
+		}
+		return nil, fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	fixed := collapseDuplicateHosts(string(content))
+	if fixed != string(content) {
+		if _, err := m.backupSSHConfig(); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(m.configPath, []byte(fixed), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write SSH config: %w", err)
+		}
+	}
+
+	if info, err := os.Stat(m.configPath); err == nil && info.Mode().Perm() != 0600 {
+		if err := os.Chmod(m.configPath, 0600); err != nil {
+			return nil, fmt.Errorf("failed to fix SSH config permissions: %w", err)
+		}
+	}
+
+	return m.ValidateConfig()
+}
+
+// findConfigIssues reports duplicate Host patterns and conflicting IdentityFile directives
+func findConfigIssues(config string) []SSHConfigIssue {
+	sections, _ := parseHostSections(config)
+
+	var issues []SSHConfigIssue
+	for _, pattern := range duplicatePatterns(sections) {
+		occurrences := sectionsWithPattern(sections, pattern)
+
+		managedLine := 0
+		for _, section := range occurrences {
+			if section.managed {
+				managedLine = section.Start + 1
+				break
+			}
+		}
+
+		// Without a managed entry there is no way to know which definition the user meant.
+		// Several Host * blocks are a common way to add defaults, and ssh applies all of
+		// them to every host, so they only get a warning.
+		if managedLine == 0 {
+			for _, section := range occurrences[1:] {
+				issue := SSHConfigIssue{
+					Line:    section.Start + 1,
+					Host:    pattern,
+					Message: fmt.Sprintf("Host %q is already defined on line %d; ssh merges both blocks, so IdentityFile entries add up and only the first value of other options applies", pattern, occurrences[0].Start+1),
+				}
+				if pattern == "*" {
+					issue.Message = fmt.Sprintf("Host * is also defined on line %d; ssh applies both blocks to every host, and only the first value of each option", occurrences[0].Start+1)
+					issue.Warning = true
+				}
+				issues = append(issues, issue)
+			}
+			continue
+		}
+
+		for _, section := range occurrences {
+			if section.managed {
+				continue
+			}
+			message := fmt.Sprintf("Host %q duplicates the gitshift-managed entry on line %d", pattern, managedLine)
+			if section.Start+1 < managedLine {
+				message = fmt.Sprintf("Host %q shadows the gitshift-managed entry on line %d", pattern, managedLine)
+			}
+			issues = append(issues, SSHConfigIssue{
+				Line:    section.Start + 1,
+				Host:    pattern,
+				Message: message,
+				Fixable: true,
+			})
+		}
+	}
+
+	for _, section := range sections {
+		identityFiles := section.Lookup("identityfile")
+		seen := make(map[string]bool)
+		for _, identity := range identityFiles {
+			seen[identity.Value] = true
+		}
+		if len(seen) > 1 {
+			issues = append(issues, SSHConfigIssue{
+				Line:    identityFiles[1].Line + 1,
+				Host:    strings.Join(section.Patterns, " "),
+				Message: fmt.Sprintf("Host section has %d different IdentityFile directives", len(seen)),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues
+}
+
+// collapseDuplicateHosts removes Host patterns that are also defined by a gitshift-managed
+// block from user sections. Sections left without any pattern are dropped entirely.
+// Duplicates between user sections are left alone since there is no way to know which one
+// the user meant.
+func collapseDuplicateHosts(config string) string {
+	sections, lines := parseHostSections(config)
+
+	managedPatterns := make(map[string]bool)
+	for _, section := range sections {
+		if section.managed {
+			for _, pattern := range section.Patterns {
+				managedPatterns[strings.ToLower(pattern)] = true
+			}
+		}
+	}
+
+	drop := make(map[int]bool)
+	for _, section := range sections {
+		if section.managed {
+			continue
+		}
+
+		var remaining []string
+		for _, pattern := range section.Patterns {
+			if !managedPatterns[strings.ToLower(pattern)] {
+				remaining = append(remaining, pattern)
+			}
+		}
+		if len(remaining) == len(section.Patterns) {
+			continue
+		}
+
+		if len(remaining) > 0 {
+			lines[section.Start] = rewriteHostLine(lines[section.Start], remaining)
+			continue
+		}
+
+		// Keep blank lines and comments that introduce the next section, including the
+		// begin marker of a managed block
+		end := section.End
+		for end > section.Start+1 {
+			trimmed := strings.TrimSpace(lines[end-1])
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				break
+			}
+			end--
+		}
+		// Avoid leaving two blank separators where the section used to be
+		if section.Start > 0 && end < len(lines) &&
+			strings.TrimSpace(lines[section.Start-1]) == "" && strings.TrimSpace(lines[end]) == "" {
+			end++
+		}
+		for i := section.Start; i < end; i++ {
+			drop[i] = true
+		}
+	}
+
+	var kept []string
+	for i, line := range lines {
+		if !drop[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// parseHostSections parses an SSH config into its Host sections, marking those inside a
+// gitshift-managed block, and returns the config's lines. Match sections are left out.
+func parseHostSections(config string) ([]hostSection, []string) {
+	parsed := sshconfig.Parse(config)
+
+	// managed[i] reports whether line i lies between a begin and an end marker
+	managed := make([]bool, len(parsed.Lines))
+	inManagedBlock := false
+	for i, line := range parsed.Lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, managedBlockBegin):
+			inManagedBlock = true
+		case strings.HasPrefix(trimmed, managedBlockEnd):
+			inManagedBlock = false
+		}
+		managed[i] = inManagedBlock
+	}
+
+	var sections []hostSection
+	for _, block := range parsed.Blocks {
+		if block.IsMatch() {
+			continue
+		}
+		sections = append(sections, hostSection{HostBlock: block, managed: managed[block.Start]})
+	}
+	return sections, parsed.Lines
+}
+
+// duplicatePatterns returns the Host patterns defined by more than one section, in order of
+// first appearance
+func duplicatePatterns(sections []hostSection) []string {
+	counts := make(map[string]int)
+	var order []string
+	for _, section := range sections {
+		for _, pattern := range section.Patterns {
+			key := strings.ToLower(pattern)
+			if counts[key] == 0 {
+				order = append(order, pattern)
+			}
+			counts[key]++
+		}
+	}
+
+	var duplicates []string
+	for _, pattern := range order {
+		if counts[strings.ToLower(pattern)] > 1 {
+			duplicates = append(duplicates, pattern)
+		}
+	}
+	return duplicates
+}
+
+// sectionsWithPattern returns the sections that define pattern, in file order
+func sectionsWithPattern(sections []hostSection, pattern string) []hostSection {
+	var matches []hostSection
+	for _, section := range sections {
+		for _, candidate := range section.Patterns {
+			if strings.EqualFold(candidate, pattern) {
+				matches = append(matches, section)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// rewriteHostLine replaces the patterns of a Host line, preserving its indentation
+func rewriteHostLine(line string, patterns []string) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	return indent + "Host " + strings.Join(patterns, " ") + "\n"
+}
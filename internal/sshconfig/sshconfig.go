@@ -0,0 +1,230 @@
+// Package sshconfig parses OpenSSH client config files into their Host and Match blocks.
+// Parsing keeps the raw lines and records where each block and directive sits, so callers
+// can rewrite a config without disturbing what they don't touch.
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxIncludeDepth mirrors the nesting limit ssh itself applies to Include directives
+const maxIncludeDepth = 16
+
+// Directive is a single keyword/value line of an SSH config
+type Directive struct {
+	Keyword string // lowercased, e.g. "identityfile"
+	Value   string
+	Line    int // 0-based index into the file's lines
+}
+
+// HostBlock is a Host or Match section. It spans Lines[Start:End] of its file, from its
+// Host or Match line up to the next section, including trailing comments and blank lines.
+type HostBlock struct {
+	Keyword    string   // "host" or "match"
+	Patterns   []string // patterns of a Host line, nil for Match blocks
+	Criteria   string   // criteria of a Match line
+	Directives []Directive
+	Start      int
+	End        int
+	SourceFile string // file the block was read from, set by Load
+}
+
+// IsMatch reports whether the block is a Match section
+func (b *HostBlock) IsMatch() bool {
+	return b.Keyword == "match"
+}
+
+// Lookup returns the block's directives with the given keyword, in file order
+func (b *HostBlock) Lookup(keyword string) []Directive {
+	keyword = strings.ToLower(keyword)
+	var directives []Directive
+	for _, directive := range b.Directives {
+		if directive.Keyword == keyword {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// Value returns the value of the first directive with the given keyword, which is the one
+// ssh uses, or "" when the block doesn't set it
+func (b *HostBlock) Value(keyword string) string {
+	if directives := b.Lookup(keyword); len(directives) > 0 {
+		return directives[0].Value
+	}
+	return ""
+}
+
+// Config is a parsed SSH config file
+type Config struct {
+	Lines  []string    // the file's lines, each with its trailing newline
+	Global []Directive // directives before the first Host or Match line
+	Blocks []HostBlock
+}
+
+// Parse parses the content of an SSH config file. Include directives are returned like any
+// other directive; Load follows them.
+func Parse(content string) *Config {
+	config := &Config{Lines: strings.SplitAfter(content, "\n")}
+
+	var current *HostBlock
+	closeCurrent := func(end int) {
+		if current != nil {
+			current.End = end
+			config.Blocks = append(config.Blocks, *current)
+			current = nil
+		}
+	}
+
+	for i, line := range config.Lines {
+		keyword, value := SplitDirective(line)
+		switch keyword {
+		case "":
+			continue
+		case "host":
+			closeCurrent(i)
+			current = &HostBlock{Keyword: keyword, Patterns: strings.Fields(value), Start: i}
+		case "match":
+			closeCurrent(i)
+			current = &HostBlock{Keyword: keyword, Criteria: value, Start: i}
+		default:
+			directive := Directive{Keyword: keyword, Value: value, Line: i}
+			if current != nil {
+				current.Directives = append(current.Directives, directive)
+			} else {
+				config.Global = append(config.Global, directive)
+			}
+		}
+	}
+	closeCurrent(len(config.Lines))
+
+	return config
+}
+
+// ParseFile reads and parses the SSH config at path
+func ParseFile(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(content)), nil
+}
+
+// Load parses the SSH config at path and the files its Include directives name, returning
+// the blocks in the order ssh reads them. Relative Include paths are resolved against
+// ~/.ssh under homeDir, as ssh does for the user config, and include cycles are skipped.
+func Load(path, homeDir string) ([]HostBlock, error) {
+	l := &loader{homeDir: homeDir, visited: make(map[string]bool)}
+	return l.load(path, 0)
+}
+
+type loader struct {
+	homeDir string
+	visited map[string]bool
+}
+
+func (l *loader) load(path string, depth int) ([]HostBlock, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("too many nested Include directives at %s", path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if l.visited[absPath] {
+		return nil, nil
+	}
+	l.visited[absPath] = true
+
+	config, err := ParseFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []HostBlock
+	appendIncludes := func(directives []Directive) error {
+		for _, directive := range directives {
+			if directive.Keyword != "include" {
+				continue
+			}
+			for _, pattern := range strings.Fields(directive.Value) {
+				for _, included := range l.resolveInclude(pattern) {
+					includedBlocks, err := l.load(included, depth+1)
+					if err != nil {
+						return err
+					}
+					blocks = append(blocks, includedBlocks...)
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := appendIncludes(config.Global); err != nil {
+		return nil, err
+	}
+	for _, block := range config.Blocks {
+		block.SourceFile = absPath
+		blocks = append(blocks, block)
+		if err := appendIncludes(block.Directives); err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// resolveInclude expands an Include argument to the regular files it matches
+func (l *loader) resolveInclude(pattern string) []string {
+	pattern = ExpandPath(pattern, l.homeDir)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(l.homeDir, ".ssh", pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+			files = append(files, match)
+		}
+	}
+	return files
+}
+
+// SplitDirective splits an SSH config line into its lowercased keyword and value. Keywords
+// may be separated from their value by whitespace or "=". It returns an empty keyword for
+// blank lines and comments.
+func SplitDirective(line string) (keyword, value string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), ""
+	}
+
+	value = strings.TrimLeft(line[end:], " \t")
+	value = strings.TrimPrefix(value, "=")
+	return strings.ToLower(line[:end]), strings.TrimSpace(value)
+}
+
+// ExpandPath strips the quotes around a path argument and expands a leading ~ to homeDir
+func ExpandPath(path, homeDir string) string {
+	path = strings.Trim(path, `"`)
+	if path == "~" {
+		return homeDir
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir, path[2:])
+	}
+	return path
+}
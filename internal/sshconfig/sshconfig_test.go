@@ -0,0 +1,145 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testConfig = `# Defaults
+ServerAliveInterval 60
+Include config.d/*
+
+Host github.com gh-work
+    HostName=github.com
+    IdentityFile ~/.ssh/id_work
+    IdentityFile ~/.ssh/id_fallback
+
+# CI runners
+Match host build-* exec "test -f ~/.ssh/ci"
+    IdentityFile ~/.ssh/ci
+
+Host=*
+  AddKeysToAgent yes
+`
+
+func TestParse(t *testing.T) {
+	config := Parse(testConfig)
+
+	wantGlobal := []Directive{
+		{Keyword: "serveraliveinterval", Value: "60", Line: 1},
+		{Keyword: "include", Value: "config.d/*", Line: 2},
+	}
+	if !reflect.DeepEqual(config.Global, wantGlobal) {
+		t.Errorf("Global = %+v, want %+v", config.Global, wantGlobal)
+	}
+
+	if len(config.Blocks) != 3 {
+		t.Fatalf("Parse() returned %d blocks, want 3: %+v", len(config.Blocks), config.Blocks)
+	}
+
+	work := config.Blocks[0]
+	if !reflect.DeepEqual(work.Patterns, []string{"github.com", "gh-work"}) || work.IsMatch() {
+		t.Errorf("first block = %+v", work)
+	}
+	if work.Start != 4 || work.End != 10 {
+		t.Errorf("first block spans lines %d-%d, want 4-10 up to the Match line", work.Start, work.End)
+	}
+	if got := work.Value("HostName"); got != "github.com" {
+		t.Errorf("Value(HostName) = %q, want github.com", got)
+	}
+	if got := work.Value("identityfile"); got != "~/.ssh/id_work" {
+		t.Errorf("Value(identityfile) = %q, want the first IdentityFile", got)
+	}
+	if identities := work.Lookup("IdentityFile"); len(identities) != 2 || identities[1].Line != 7 {
+		t.Errorf("Lookup(IdentityFile) = %+v", identities)
+	}
+
+	match := config.Blocks[1]
+	if !match.IsMatch() || match.Patterns != nil || match.Criteria != `host build-* exec "test -f ~/.ssh/ci"` {
+		t.Errorf("Match block = %+v", match)
+	}
+
+	if all := config.Blocks[2]; !reflect.DeepEqual(all.Patterns, []string{"*"}) || all.End != len(config.Lines) {
+		t.Errorf("last block = %+v", all)
+	}
+}
+
+func TestSplitDirective(t *testing.T) {
+	tests := []struct {
+		line    string
+		keyword string
+		value   string
+	}{
+		{"    IdentityFile ~/.ssh/id_work\n", "identityfile", "~/.ssh/id_work"},
+		{"HostName=github.com", "hostname", "github.com"},
+		{"User = git", "user", "git"},
+		{"\tIdentitiesOnly\tyes", "identitiesonly", "yes"},
+		{"# Host commented-out", "", ""},
+		{"   ", "", ""},
+	}
+	for _, tt := range tests {
+		keyword, value := SplitDirective(tt.line)
+		if keyword != tt.keyword || value != tt.value {
+			t.Errorf("SplitDirective(%q) = %q, %q, want %q, %q", tt.line, keyword, value, tt.keyword, tt.value)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_FollowsIncludesInPlace(t *testing.T) {
+	homeDir := t.TempDir()
+	sshDir := filepath.Join(homeDir, ".ssh")
+
+	writeConfig(t, filepath.Join(sshDir, "config"), `Include config.d/*
+
+Host first
+    Include ~/.ssh/extra
+
+Host last
+`)
+	// Including the top-level config again exercises the cycle guard
+	writeConfig(t, filepath.Join(sshDir, "config.d", "work"), "Include ../config\nHost from-glob\n")
+	writeConfig(t, filepath.Join(sshDir, "extra"), "Host from-block\n")
+
+	blocks, err := Load(filepath.Join(sshDir, "config"), homeDir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var order []string
+	for _, block := range blocks {
+		order = append(order, block.Patterns[0])
+	}
+	if want := []string{"from-glob", "first", "from-block", "last"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Load() block order = %v, want %v", order, want)
+	}
+	if want := filepath.Join(sshDir, "config.d", "work"); blocks[0].SourceFile != want {
+		t.Errorf("SourceFile = %s, want %s", blocks[0].SourceFile, want)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	tests := map[string]string{
+		"~":                   "/home/dev",
+		"~/.ssh/id_work":      "/home/dev/.ssh/id_work",
+		`"~/.ssh/with space"`: "/home/dev/.ssh/with space",
+		"/etc/ssh/key":        "/etc/ssh/key",
+		"~other/key":          "~other/key",
+	}
+	for path, want := range tests {
+		if got := ExpandPath(path, "/home/dev"); got != want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
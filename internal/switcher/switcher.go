@@ -0,0 +1,353 @@
+// Package switcher applies an account: its SSH config and agent key, the Git identity,
+// SSH command and signing settings, and the current account of the gitshift
+// configuration. The switch command and the pkg/gitshift API both switch through it.
+package switcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/techishthoughts/gitshift/internal/audit"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/pkg/redact"
+)
+
+// Options controls how Switch applies an account
+type Options struct {
+	// Force continues past failed steps, reporting them to Out
+	Force bool
+	// Offline skips live SSH connection tests
+	Offline bool
+	// RepoConfig also sets the identity and SSH command in the Git repository of the
+	// working directory, if there is one
+	RepoConfig bool
+	// GitHubCLI switches the GitHub CLI login to the account as well
+	GitHubCLI bool
+	// Verify checks the applied configuration unless Force is set; a failure is reported
+	// to Out, not returned
+	Verify func(ctx context.Context, account *models.Account) error
+	// AuditLog records the switch and the key load when set
+	AuditLog *audit.Log
+	// Out receives progress and warnings; nil discards them
+	Out io.Writer
+}
+
+// Switch makes account the active account of configManager. Failures of the SSH and Git
+// steps are returned unless opts.Force is set; failures of the GPG, GitHub CLI and
+// verification steps are only reported.
+//
+// The SSH step writes the managed Host block for the account's domain, loads its key into
+// the agent and exports GIT_SSH_COMMAND for the key in the user's shell rc file. For a key
+// only the agent holds, the block and the SSH commands name the key's public half instead
+// and the agent is left alone.
+func Switch(ctx context.Context, configManager *config.Manager, account *models.Account, opts Options) error {
+	out := opts.Out
+	if out == nil {
+		out = io.Discard
+	}
+	force := opts.Force
+
+	// 1. Switch SSH configuration if SSH key is configured
+	keyPath, err := switchSSH(ctx, configManager, account, opts, out)
+	if err != nil {
+		return err
+	}
+
+	// 2. Update Git configuration
+	fmt.Fprintf(out, "🔧 Updating Git configuration...\n")
+	if err := updateGitConfig(ctx, account, keyPath, opts.RepoConfig); err != nil {
+		if force {
+			fmt.Fprintf(out, "⚠️  Git config update failed: %v (continuing due to --force)\n", err)
+		} else {
+			return fmt.Errorf("failed to update Git configuration: %w", err)
+		}
+	} else {
+		fmt.Fprintf(out, "✅ Git configuration updated\n")
+	}
+
+	// 2.5 Update GPG configuration if account has GPG key
+	if account.HasGPGKey() {
+		fmt.Fprintf(out, "🔐 Configuring GPG signing...\n")
+		if err := updateGPGConfig(ctx, account); err != nil {
+			if force {
+				fmt.Fprintf(out, "⚠️  GPG config update failed: %v (continuing due to --force)\n", err)
+			} else {
+				fmt.Fprintf(out, "⚠️  GPG config update failed: %v\n", err)
+				fmt.Fprintf(out, "   Git configuration updated but GPG signing may not work\n")
+			}
+		} else {
+			if account.IsGPGEnabled() {
+				fmt.Fprintf(out, "✅ GPG signing enabled (key: %s)\n", account.GPGKeyID)
+			} else {
+				fmt.Fprintf(out, "ℹ️  GPG key configured but automatic signing is disabled\n")
+				fmt.Fprintf(out, "   To enable: gitshift gpg-keygen %s --enable\n", account.Alias)
+			}
+		}
+	} else {
+		// No GPG key, disable signing
+		fmt.Fprintf(out, "🔓 Disabling GPG signing (no GPG key configured)...\n")
+		if err := disableGPGSigning(ctx); err != nil {
+			fmt.Fprintf(out, "⚠️  Failed to disable GPG signing: %v\n", err)
+		}
+	}
+
+	// 3. Update current account in gitshift config
+	fmt.Fprintf(out, "📝 Updating gitshift configuration...\n")
+	if err := configManager.SetCurrentAccount(account.Alias); err != nil {
+		return fmt.Errorf("failed to set current account: %w", err)
+	}
+	fmt.Fprintf(out, "✅ gitshift configuration updated\n")
+
+	// 4. Update GitHub token if using GitHub CLI
+	if opts.GitHubCLI {
+		fmt.Fprintf(out, "🔐 Switching GitHub CLI authentication...\n")
+		if err := switchGitHubCLI(ctx, account.Alias); err != nil {
+			if force {
+				fmt.Fprintf(out, "⚠️  GitHub CLI switch failed: %v (continuing due to --force)\n", err)
+			} else {
+				fmt.Fprintf(out, "⚠️  GitHub CLI switch failed: %v\n", err)
+				fmt.Fprintf(out, "   You may need to authenticate manually: gh auth login\n")
+			}
+		} else {
+			fmt.Fprintf(out, "✅ GitHub CLI authentication updated\n")
+		}
+	}
+
+	// 5. Test the setup (unless forcing)
+	if opts.Verify != nil && !force {
+		fmt.Fprintf(out, "🧪 Testing configuration...\n")
+		if err := opts.Verify(ctx, account); err != nil {
+			fmt.Fprintf(out, "⚠️  Configuration test failed: %v\n", err)
+			fmt.Fprintf(out, "   The switch completed but there may be issues\n")
+		} else {
+			fmt.Fprintf(out, "✅ Configuration test passed\n")
+		}
+	}
+
+	if err := configManager.RecordAccountUsed(account.Alias); err != nil {
+		fmt.Fprintf(out, "⚠️  Failed to record the use of account '%s': %v\n", account.Alias, err)
+	}
+	return nil
+}
+
+// SSHOptions returns the options the SSH manager switches account with, for a switch or
+// its dry run
+func SSHOptions(configManager *config.Manager, account *models.Account) ssh.SwitchOptions {
+	return ssh.SwitchOptions{
+		Matches:       ssh.MatchIdentities(configManager.ListAccounts(), account),
+		KeepAgentKeys: configManager.GetConfig().AllowMultipleKeys,
+	}
+}
+
+// NewSSHManager returns an SSH manager that writes the managed Host block with the
+// directives selected in the ssh_config settings
+func NewSSHManager(configManager *config.Manager) *ssh.Manager {
+	sshManager := ssh.NewManager()
+	sshManager.SetBlockOptions(ssh.BlockOptionsFor(configManager.GetConfig().SSHConfig))
+	return sshManager
+}
+
+// RefreshHostAliases updates the host aliases in the managed SSH config blocks of the
+// accounts' domains, after the accounts were added, removed or changed
+func RefreshHostAliases(configManager *config.Manager, accounts ...*models.Account) error {
+	sshManager := NewSSHManager(configManager)
+	refreshed := make(map[string]bool)
+	for _, account := range accounts {
+		domain := account.GetDomain()
+		if domain == "" || refreshed[strings.ToLower(domain)] {
+			continue
+		}
+		refreshed[strings.ToLower(domain)] = true
+		if err := sshManager.RefreshHostAliases(domain, configManager.ListAccounts()); err != nil {
+			return fmt.Errorf("failed to refresh the SSH host aliases for %s: %w", domain, err)
+		}
+	}
+	return nil
+}
+
+// switchSSH points the SSH config and agent at the account's key and returns the key file
+// Git's SSH command should name: the account's key, the public half of its agent key, or
+// "" for an account without a key
+func switchSSH(ctx context.Context, configManager *config.Manager, account *models.Account, opts Options, out io.Writer) (string, error) {
+	if account.SSHKeyPath == "" && account.SSHKeyFingerprint == "" {
+		fmt.Fprintf(out, "ℹ️  No SSH key configured for this account\n")
+		fmt.Fprintf(out, "   Consider running: gitshift ssh-keys generate %s\n", account.Alias)
+		return "", nil
+	}
+
+	sshManager := NewSSHManager(configManager)
+	sshManager.SetOutput(out)
+	if opts.AuditLog != nil {
+		sshManager.SetAuditLog(opts.AuditLog)
+	}
+	switchOpts := SSHOptions(configManager, account)
+	switchOpts.SkipConnectivityTest = opts.Offline
+
+	keyPath := account.SSHKeyPath
+	if keyPath == "" {
+		// The managed Host block and core.sshCommand name the agent key's public half,
+		// which makes ssh sign with the agent key
+		fmt.Fprintf(out, "🔑 Switching SSH configuration to the agent key %s...\n", account.SSHKeyFingerprint)
+		var err error
+		if keyPath, err = sshManager.WriteAgentPublicKey(ctx, account.Alias, account.SSHKeyFingerprint); err != nil {
+			if opts.Force {
+				fmt.Fprintf(out, "⚠️  SSH switch failed: %v (continuing due to --force)\n", err)
+				return "", nil
+			}
+			return "", fmt.Errorf("SSH switch failed: %w", err)
+		}
+		switchOpts.AgentKey = true
+	} else {
+		if _, err := pathutil.Stat(keyPath); err != nil {
+			if opts.Force {
+				fmt.Fprintf(out, "⚠️  SSH key %v, skipping SSH switch (--force enabled)\n", err)
+				return keyPath, nil
+			}
+			return "", fmt.Errorf("SSH key %w", err)
+		}
+		fmt.Fprintf(out, "🔑 Switching SSH configuration with proper isolation...\n")
+	}
+
+	if _, err := sshManager.SwitchToAccountWithOptions(ctx, account.Alias, keyPath, account.GetDomain(), switchOpts); err != nil {
+		if opts.Force {
+			fmt.Fprintf(out, "⚠️  SSH switch failed: %v (continuing due to --force)\n", err)
+			return keyPath, nil
+		}
+		return "", fmt.Errorf("SSH switch failed: %w", err)
+	}
+
+	fmt.Fprintf(out, "✅ SSH configuration updated with complete isolation\n")
+	fmt.Fprintf(out, "   • SSH config configured for account: %s\n", account.Alias)
+	if switchOpts.AgentKey {
+		fmt.Fprintf(out, "   • SSH agent key used through: %s\n", keyPath)
+	} else {
+		fmt.Fprintf(out, "   • SSH agent cleared and key loaded: %s\n", keyPath)
+	}
+	if !opts.Offline {
+		fmt.Fprintf(out, "   • SSH connection tested successfully\n")
+	}
+	return keyPath, nil
+}
+
+// isGitRepo checks if the current directory is a Git repository
+func isGitRepo(ctx context.Context) bool {
+	cmd := execrunner.CommandContext(ctx, "git", "rev-parse", "--git-dir")
+	return cmd.Run() == nil
+}
+
+// updateGitConfig updates the global Git user configuration, and the repository's too when
+// repo is set and the working directory is in one. core.sshCommand offers only keyPath, or
+// is removed for an account without a key so another account's key isn't used.
+func updateGitConfig(ctx context.Context, account *models.Account, keyPath string, repo bool) error {
+	scopes := []string{"--global"}
+	if repo && isGitRepo(ctx) {
+		scopes = append(scopes, "--local")
+	}
+
+	for _, scope := range scopes {
+		scopeName := strings.TrimPrefix(scope, "--")
+		if account.Name != "" {
+			cmd := execrunner.CommandContext(ctx, "git", "config", scope, "user.name", account.Name)
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("failed to set %s git user.name: %w", scopeName, err)
+			}
+		}
+
+		if account.Email != "" {
+			cmd := execrunner.CommandContext(ctx, "git", "config", scope, "user.email", account.Email)
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("failed to set %s git user.email: %w", scopeName, err)
+			}
+		}
+
+		// Set SSH command to use the account's SSH key for proper isolation
+		if keyPath != "" {
+			cmd := execrunner.CommandContext(ctx, "git", "config", scope, "core.sshCommand", SSHCommand(keyPath))
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("failed to set %s git core.sshCommand: %w", scopeName, err)
+			}
+		} else {
+			cmd := execrunner.CommandContext(ctx, "git", "config", scope, "--unset", "core.sshCommand")
+			_ = cmd.Run() // Ignore error if the command was not set
+		}
+	}
+
+	return nil
+}
+
+// SSHCommand returns the core.sshCommand a switch writes to make Git offer only keyPath
+func SSHCommand(keyPath string) string {
+	return fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", pathutil.Expand(keyPath))
+}
+
+// switchGitHubCLI switches the GitHub CLI authentication
+func switchGitHubCLI(ctx context.Context, accountAlias string) error {
+	// Check if gh CLI is available
+	if _, err := exec.LookPath("gh"); err != nil {
+		return fmt.Errorf("GitHub CLI not found")
+	}
+
+	// Try to switch to the account
+	cmd := execrunner.CommandContext(ctx, "gh", "auth", "switch", "--user", accountAlias)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// If the account doesn't exist in gh auth, that's OK
+		if strings.Contains(string(output), "not found") {
+			return nil
+		}
+		return fmt.Errorf("gh auth switch failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
+	}
+
+	return nil
+}
+
+// updateGPGConfig updates Git GPG signing configuration for the account
+func updateGPGConfig(ctx context.Context, account *models.Account) error {
+	if !account.HasGPGKey() {
+		return fmt.Errorf("account has no GPG key configured")
+	}
+
+	// Set the signing key
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "user.signingkey", account.GPGKeyID)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to set user.signingkey: %w", err)
+	}
+
+	// Enable or disable automatic signing based on account preference, keeping the key
+	// configured either way
+	sign := "false"
+	if account.IsGPGEnabled() {
+		sign = "true"
+	}
+	for _, key := range []string{"commit.gpgsign", "tag.gpgsign"} {
+		cmd = execrunner.CommandContext(ctx, "git", "config", "--global", key, sign)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// disableGPGSigning disables GPG signing in Git configuration
+func disableGPGSigning(ctx context.Context) error {
+	// Unset the signing key
+	cmd := execrunner.CommandContext(ctx, "git", "config", "--global", "--unset", "user.signingkey")
+	_ = cmd.Run() // Ignore error if key was not set
+
+	for _, key := range []string{"commit.gpgsign", "tag.gpgsign"} {
+		cmd = execrunner.CommandContext(ctx, "git", "config", "--global", key, "false")
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to disable %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
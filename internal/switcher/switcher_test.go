@@ -0,0 +1,257 @@
+package switcher
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/sshconfig"
+)
+
+// newTestManager loads content as the gitshift configuration of a fresh home without an
+// SSH agent, and returns the manager and the home directory
+func newTestManager(t *testing.T, content string) (*config.Manager, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	dir := filepath.Join(home, ".config", "gitshift")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configManager := config.NewManager()
+	if err := configManager.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return configManager, home
+}
+
+// writeKey writes a placeholder private key at ~/.ssh/name
+func writeKey(t *testing.T, home, name string) string {
+	t.Helper()
+	keyPath := filepath.Join(home, ".ssh", name)
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("placeholder\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath
+}
+
+// globalGitValue returns key from the global Git config, or "" when it isn't set
+func globalGitValue(t *testing.T, key string) string {
+	t.Helper()
+	output, _ := exec.Command("git", "config", "--global", "--get", key).Output()
+	return strings.TrimSpace(string(output))
+}
+
+func TestSwitch_AppliesAccount(t *testing.T) {
+	configManager, home := newTestManager(t, `accounts:
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    ssh_key_path: ~/.ssh/id_work
+`)
+	keyPath := writeKey(t, home, "id_work")
+
+	account, err := configManager.GetAccount("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Switch(context.Background(), configManager, account, Options{Offline: true}); err != nil {
+		t.Fatalf("Switch() error = %v", err)
+	}
+
+	if got := globalGitValue(t, "user.email"); got != "dev@work.com" {
+		t.Errorf("user.email = %q, want dev@work.com", got)
+	}
+	if got := globalGitValue(t, "core.sshCommand"); got != "ssh -i "+keyPath+" -o IdentitiesOnly=yes" {
+		t.Errorf("core.sshCommand = %q, want the account's key", got)
+	}
+	sshConfig, err := os.ReadFile(filepath.Join(home, ".ssh", "config"))
+	if err != nil || !strings.Contains(string(sshConfig), "IdentityFile "+keyPath) {
+		t.Errorf("~/.ssh/config = %q, %v; want the account's key", sshConfig, err)
+	}
+	if current := configManager.GetConfig().CurrentAccount; current != "work" {
+		t.Errorf("current account = %q, want work", current)
+	}
+	if account, _ := configManager.GetAccount("work"); account.LastUsed == nil {
+		t.Error("LastUsed not recorded")
+	}
+}
+
+func TestSwitch_KeepsOtherAccountsHostAliases(t *testing.T) {
+	configManager, home := newTestManager(t, `accounts:
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    ssh_key_path: ~/.ssh/id_work
+  personal:
+    alias: personal
+    name: Dev
+    email: dev@home.org
+    ssh_key_path: ~/.ssh/id_personal
+`)
+	workKey := writeKey(t, home, "id_work")
+	personalKey := writeKey(t, home, "id_personal")
+	ctx := context.Background()
+
+	for _, alias := range []string{"work", "personal"} {
+		account, err := configManager.GetAccount(alias)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Switch(ctx, configManager, account, Options{Offline: true}); err != nil {
+			t.Fatalf("Switch(%s) error = %v", alias, err)
+		}
+	}
+
+	configPath := filepath.Join(home, ".ssh", "config")
+	sshConfig, _ := os.ReadFile(configPath)
+	for host, want := range map[string]string{"github.com": personalKey, "github.com-personal": personalKey, "github.com-work": workKey} {
+		if got := hostIdentityFiles(string(sshConfig), host); len(got) != 1 || got[0] != want {
+			t.Errorf("%s keys = %q, want %s:\n%s", host, got, want, sshConfig)
+		}
+	}
+
+	// ssh itself resolves the inactive account's alias to the platform with its key
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return
+	}
+	output, err := exec.Command("ssh", "-G", "-F", configPath, "github.com-work").Output()
+	if err != nil {
+		t.Fatalf("ssh -G github.com-work failed: %v", err)
+	}
+	for _, want := range []string{"hostname github.com", "identityfile " + workKey} {
+		if !strings.Contains(string(output), want+"\n") {
+			t.Errorf("ssh -G github.com-work doesn't resolve to %q:\n%s", want, output)
+		}
+	}
+}
+
+// hostIdentityFiles returns the IdentityFile values of the Host blocks in config that name
+// pattern, in file order
+func hostIdentityFiles(config, pattern string) []string {
+	var files []string
+	for _, block := range sshconfig.Parse(config).Blocks {
+		for _, p := range block.Patterns {
+			if p == pattern {
+				files = append(files, block.Value("identityfile"))
+				break
+			}
+		}
+	}
+	return files
+}
+
+// startAgent starts an ssh-agent for the test and points SSH_AUTH_SOCK at it
+func startAgent(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-agent"); err != nil {
+		t.Skip("ssh-agent not installed")
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	agent := exec.Command("ssh-agent", "-D", "-a", socket)
+	if err := agent.Start(); err != nil {
+		t.Fatalf("failed to start ssh-agent: %v", err)
+	}
+	t.Cleanup(func() {
+		agent.Process.Kill()
+		agent.Wait()
+	})
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			t.Setenv("SSH_AUTH_SOCK", socket)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("ssh-agent didn't create its socket")
+}
+
+// generateKey writes a new ed25519 key pair without a passphrase and returns the path of
+// its private key
+func generateKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	keyPath := filepath.Join(dir, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", keyPath).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %v\n%s", err, output)
+	}
+	return keyPath
+}
+
+func TestSwitch_FromFileKeyToAgentOnlyKey(t *testing.T) {
+	configManager, home := newTestManager(t, "accounts: {}\n")
+	startAgent(t)
+
+	workKey := generateKey(t, filepath.Join(home, ".ssh"), "id_work")
+	// The token key lives outside ~/.ssh and is only ever handed to the agent
+	tokenKey := generateKey(t, t.TempDir(), "id_token")
+	output, err := exec.Command("ssh-keygen", "-lf", tokenKey).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := strings.Fields(string(output))[1]
+
+	accounts := []*models.Account{
+		{Alias: "work", Name: "Dev", Email: "dev@work.com", SSHKeyPath: workKey},
+		{Alias: "token", Name: "Dev", Email: "dev@token.org", SSHKeyFingerprint: fingerprint},
+	}
+	for _, account := range accounts {
+		if err := configManager.AddAccount(account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	if err := Switch(ctx, configManager, accounts[0], Options{Offline: true}); err != nil {
+		t.Fatalf("Switch() to the file key account error = %v", err)
+	}
+	if output, err := exec.Command("ssh-add", tokenKey).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-add failed: %v\n%s", err, output)
+	}
+	if err := Switch(ctx, configManager, accounts[1], Options{Offline: true}); err != nil {
+		t.Fatalf("Switch() to the agent-only account error = %v", err)
+	}
+
+	agentKeyFile := filepath.Join(home, ".ssh", "gitshift_agent_token.pub")
+	publicKey, err := os.ReadFile(agentKeyFile)
+	want, _ := os.ReadFile(tokenKey + ".pub")
+	if err != nil || string(publicKey) != string(want) {
+		t.Errorf("agent public key file = %q, %v; want %q", publicKey, err, want)
+	}
+	if got := globalGitValue(t, "core.sshCommand"); got != "ssh -i "+agentKeyFile+" -o IdentitiesOnly=yes" {
+		t.Errorf("core.sshCommand = %q, want the agent key's public half", got)
+	}
+	sshConfig, _ := os.ReadFile(filepath.Join(home, ".ssh", "config"))
+	if got := hostIdentityFiles(string(sshConfig), "github.com"); len(got) != 1 || got[0] != agentKeyFile {
+		t.Errorf("~/.ssh/config github.com keys = %q, want only the agent key:\n%s", got, sshConfig)
+	}
+	bashrc, _ := os.ReadFile(filepath.Join(home, ".bashrc"))
+	if !strings.Contains(string(bashrc), "ssh -i "+agentKeyFile) || strings.Contains(string(bashrc), workKey) {
+		t.Errorf("~/.bashrc GIT_SSH_COMMAND doesn't name the agent key:\n%s", bashrc)
+	}
+	// The agent holds the only copy of the key, so it must not have been cleared
+	if output, err := exec.Command("ssh-add", "-l").CombinedOutput(); err != nil || !strings.Contains(string(output), fingerprint) {
+		t.Errorf("ssh-add -l = %q, %v; want the agent key still loaded", output, err)
+	}
+}
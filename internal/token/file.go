@@ -0,0 +1,215 @@
+package token
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	keyFileName     = ".key"
+	tokenFileSuffix = ".token"
+	keySize         = 32 // AES-256
+)
+
+// FileStore keeps each token AES-GCM encrypted in its own file. The key is generated on
+// first use and kept next to the tokens, readable only by the user, so tokens are never
+// written to disk in plain text and don't leak through backups of a single file.
+type FileStore struct {
+	dir string
+}
+
+// encryptedToken is the on-disk format of a token file
+type encryptedToken struct {
+	UpdatedAt  time.Time `json:"updated_at"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// NewFileStore creates a file store keeping its tokens in dir
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Backend returns BackendFile
+func (s *FileStore) Backend() string {
+	return BackendFile
+}
+
+// Get decrypts and returns the account's token
+func (s *FileStore) Get(alias string) (string, error) {
+	stored, err := s.read(alias)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return "", err
+	}
+	if len(stored.Nonce) != gcm.NonceSize() {
+		return "", fmt.Errorf("token file %s is corrupted", s.tokenPath(alias))
+	}
+
+	plaintext, err := gcm.Open(nil, stored.Nonce, stored.Ciphertext, []byte(alias))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token for '%s': %w", alias, err)
+	}
+	return string(plaintext), nil
+}
+
+// Set encrypts and stores the account's token
+func (s *FileStore) Set(alias, token string) error {
+	if err := validateAlias(alias); err != nil {
+		return err
+	}
+
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The alias is authenticated data, so a token file can't be renamed to another account
+	data, err := json.Marshal(encryptedToken{
+		UpdatedAt:  time.Now(),
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(token), []byte(alias)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	tmpPath := s.tokenPath(alias) + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.tokenPath(alias)); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write token: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the account's token file
+func (s *FileStore) Delete(alias string) error {
+	if err := validateAlias(alias); err != nil {
+		return err
+	}
+	if err := os.Remove(s.tokenPath(alias)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+	return nil
+}
+
+// Metadata returns where the account's token is stored and when it was set
+func (s *FileStore) Metadata(alias string) (*Metadata, error) {
+	stored, err := s.read(alias)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		Alias:     alias,
+		Backend:   BackendFile,
+		Location:  s.tokenPath(alias),
+		UpdatedAt: stored.UpdatedAt,
+	}, nil
+}
+
+// read loads the account's token file without decrypting it
+func (s *FileStore) read(alias string) (*encryptedToken, error) {
+	if err := validateAlias(alias); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.tokenPath(alias))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to read token: %w", err)
+	}
+
+	var stored encryptedToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("token file %s is corrupted: %w", s.tokenPath(alias), err)
+	}
+	return &stored, nil
+}
+
+// cipher returns the AES-GCM cipher for the store's key, generating the key when create is
+// set and there is none yet
+func (s *FileStore) cipher(create bool) (cipher.AEAD, error) {
+	keyPath := filepath.Join(s.dir, keyFileName)
+
+	key, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) && create {
+		key, err = s.generateKey(keyPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token encryption key: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("token encryption key %s is corrupted", keyPath)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// generateKey creates the store directory and a new random key readable only by the user
+func (s *FileStore) generateKey(keyPath string) ([]byte, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	// O_EXCL so two processes never generate different keys for the same store
+	f, err := os.OpenFile(keyPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return os.ReadFile(keyPath)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Path returns the file the account's token is, or would be, stored in
+func (s *FileStore) Path(alias string) string {
+	return s.tokenPath(alias)
+}
+
+func (s *FileStore) tokenPath(alias string) string {
+	return filepath.Join(s.dir, alias+tokenFileSuffix)
+}
+
+// validateAlias rejects aliases that would escape the store directory
+func validateAlias(alias string) error {
+	if alias == "" || alias == "." || alias == ".." || filepath.Base(alias) != alias {
+		return fmt.Errorf("invalid account alias '%s'", alias)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tokens")
+	store := NewFileStore(dir)
+
+	if _, err := store.Get("work"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("Get() before Set error = %v, want ErrTokenNotFound", err)
+	}
+
+	if err := store.Set("work", "ghp_secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err := store.Get("work")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "ghp_secret" {
+		t.Errorf("Get() = %q, want %q", got, "ghp_secret")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "work.token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ghp_secret") {
+		t.Error("token file contains the token in plain text")
+	}
+
+	metadata, err := store.Metadata("work")
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if metadata.Backend != BackendFile || metadata.UpdatedAt.IsZero() {
+		t.Errorf("Metadata() = %+v, want file backend with an update time", metadata)
+	}
+
+	if err := store.Delete("work"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get("work"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Get() after Delete error = %v, want ErrTokenNotFound", err)
+	}
+	if err := store.Delete("work"); err != nil {
+		t.Errorf("Delete() of a missing token error = %v", err)
+	}
+}
+
+func TestFileStore_TokenBoundToAlias(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileStore(dir)
+	if err := store.Set("work", "ghp_secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// A token file copied to another account must not decrypt
+	if err := os.Rename(filepath.Join(dir, "work.token"), filepath.Join(dir, "personal.token")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("personal"); err == nil {
+		t.Error("Get() of a token moved to another alias succeeded")
+	}
+}
+
+func TestFileStore_RejectsPathAliases(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	for _, alias := range []string{"", "..", "../escape", "a/b"} {
+		if err := store.Set(alias, "token"); err == nil {
+			t.Errorf("Set(%q) succeeded, want an error", alias)
+		}
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	for backend, want := range map[string]string{"": BackendFile, "file": BackendFile, "keychain": BackendKeychain} {
+		store, err := NewStore(backend, t.TempDir())
+		if err != nil {
+			t.Fatalf("NewStore(%q) error = %v", backend, err)
+		}
+		if store.Backend() != want {
+			t.Errorf("NewStore(%q).Backend() = %q, want %q", backend, store.Backend(), want)
+		}
+	}
+
+	if _, err := NewStore("vault", t.TempDir()); err == nil {
+		t.Error("NewStore(\"vault\") succeeded, want an error")
+	}
+}
@@ -0,0 +1,66 @@
+package token
+
+import "fmt"
+
+// keychainService is the service name tokens are stored under in the OS credential store
+const keychainService = "gitshift"
+
+// KeychainStore keeps tokens in the OS credential store: the macOS Keychain, the Secret
+// Service on Linux (through secret-tool) or the Windows Credential Manager. TokenStore
+// methods take no context, so the security and secret-tool commands are never cancelled.
+type KeychainStore struct {
+	service string
+}
+
+// NewKeychainStore creates a store backed by the OS credential store
+func NewKeychainStore() *KeychainStore {
+	return &KeychainStore{service: keychainService}
+}
+
+// Backend returns BackendKeychain
+func (s *KeychainStore) Backend() string {
+	return BackendKeychain
+}
+
+// Get returns the account's token from the credential store
+func (s *KeychainStore) Get(alias string) (string, error) {
+	if err := validateAlias(alias); err != nil {
+		return "", err
+	}
+	return keychainGet(s.service, alias)
+}
+
+// Set stores the account's token in the credential store
+func (s *KeychainStore) Set(alias, token string) error {
+	if err := validateAlias(alias); err != nil {
+		return err
+	}
+	return keychainSet(s.service, alias, token)
+}
+
+// Delete removes the account's token from the credential store
+func (s *KeychainStore) Delete(alias string) error {
+	if err := validateAlias(alias); err != nil {
+		return err
+	}
+	return keychainDelete(s.service, alias)
+}
+
+// Metadata returns where the account's token is stored and, where the credential store
+// records it, when it was last changed. Only the item's attributes are read, so the token
+// isn't decrypted and a locked keychain isn't unlocked.
+func (s *KeychainStore) Metadata(alias string) (*Metadata, error) {
+	if err := validateAlias(alias); err != nil {
+		return nil, err
+	}
+	updatedAt, err := keychainStat(s.service, alias)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		Alias:     alias,
+		Backend:   BackendKeychain,
+		Location:  fmt.Sprintf("%s (service '%s')", keychainName, s.service),
+		UpdatedAt: updatedAt,
+	}, nil
+}
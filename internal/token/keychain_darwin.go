@@ -0,0 +1,80 @@
+//go:build darwin
+
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+)
+
+const keychainName = "macOS Keychain"
+
+// errSecItemNotFound is the exit status of the security tool when no item matches
+const errSecItemNotFound = 44
+
+func keychainGet(service, account string) (string, error) {
+	output, err := execrunner.CommandContext(context.Background(), "security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		if isItemNotFound(err) {
+			return "", ErrTokenNotFound
+		}
+		return "", fmt.Errorf("failed to read token from the macOS Keychain: %w", err)
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// modificationDate matches the mdat attribute security prints for an item, e.g.
+// "mdat"<timedate>=0x3230...  "20240102030405Z\000"
+var modificationDate = regexp.MustCompile(`"mdat"<timedate>=\S+\s+"(\d{14})Z`)
+
+// keychainStat returns when the item was last changed. Without -w, security only prints
+// the item's attributes, which doesn't need the Keychain unlocked.
+func keychainStat(service, account string) (time.Time, error) {
+	output, err := execrunner.CommandContext(context.Background(), "security", "find-generic-password", "-s", service, "-a", account).Output()
+	if err != nil {
+		if isItemNotFound(err) {
+			return time.Time{}, ErrTokenNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to read token attributes from the macOS Keychain: %w", err)
+	}
+	if match := modificationDate.FindSubmatch(output); match != nil {
+		if updatedAt, err := time.Parse("20060102150405", string(match[1])); err == nil {
+			return updatedAt, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func keychainSet(service, account, secret string) error {
+	// The token is passed on stdin through the interactive mode so it never shows up in
+	// the process list
+	line, err := securityAddCommand(service, account, secret)
+	if err != nil {
+		return err
+	}
+	cmd := execrunner.CommandContext(context.Background(), "security", "-i")
+	cmd.Stdin = strings.NewReader(line)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store token in the macOS Keychain: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func keychainDelete(service, account string) error {
+	if err := execrunner.CommandContext(context.Background(), "security", "delete-generic-password", "-s", service, "-a", account).Run(); err != nil && !isItemNotFound(err) {
+		return fmt.Errorf("failed to delete token from the macOS Keychain: %w", err)
+	}
+	return nil
+}
+
+func isItemNotFound(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound
+}
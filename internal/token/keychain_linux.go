@@ -0,0 +1,114 @@
+//go:build linux
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/execrunner"
+)
+
+const keychainName = "Secret Service"
+
+// secretTool returns the path of secret-tool, the libsecret CLI used to reach the Secret
+// Service
+func secretTool() (string, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return "", fmt.Errorf("secret-tool not found; install libsecret-tools or set 'token_storage: file'")
+	}
+	return path, nil
+}
+
+func keychainGet(service, account string) (string, error) {
+	tool, err := secretTool()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := execrunner.CommandContext(context.Background(), tool, "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		// lookup exits 1 without output when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(output) == 0 && len(exitErr.Stderr) == 0 {
+			return "", ErrTokenNotFound
+		}
+		return "", fmt.Errorf("failed to read token from the Secret Service: %w", err)
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// keychainStat returns when the item was last changed. secret-tool search doesn't unlock
+// locked collections; the secret it prints for unlocked items is ignored.
+func keychainStat(service, account string) (time.Time, error) {
+	tool, err := secretTool()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	output, err := execrunner.CommandContext(context.Background(), tool, "search", "service", service, "account", account).CombinedOutput()
+	updatedAt, found := parseSecretToolSearch(string(output))
+	switch {
+	case found:
+		return updatedAt, nil
+	case err == nil || len(bytes.TrimSpace(output)) == 0:
+		// search prints nothing, and may exit 1, when nothing matches
+		return time.Time{}, ErrTokenNotFound
+	default:
+		return time.Time{}, fmt.Errorf("failed to read token attributes from the Secret Service: %w", err)
+	}
+}
+
+// parseSecretToolSearch reports whether secret-tool search output lists an item, and when
+// the first one was modified, zero when it doesn't say
+func parseSecretToolSearch(output string) (time.Time, bool) {
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[/") {
+			if found {
+				break
+			}
+			found = true
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "modified = "); ok && found {
+			if updatedAt, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+				return updatedAt, true
+			}
+		}
+	}
+	return time.Time{}, found
+}
+
+func keychainSet(service, account, secret string) error {
+	tool, err := secretTool()
+	if err != nil {
+		return err
+	}
+
+	cmd := execrunner.CommandContext(context.Background(), tool, "store", "--label", fmt.Sprintf("%s token (%s)", service, account), "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store token in the Secret Service: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func keychainDelete(service, account string) error {
+	tool, err := secretTool()
+	if err != nil {
+		return err
+	}
+
+	if output, err := execrunner.CommandContext(context.Background(), tool, "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete token from the Secret Service: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSecretToolSearch(t *testing.T) {
+	output := `[/org/freedesktop/secrets/collection/login/7]
+label = gitshift token (work)
+secret = ghp_secret
+created = 2024-01-02 03:04:05
+modified = 2024-03-04 05:06:07
+schema = org.freedesktop.Secret.Generic
+attribute.service = gitshift
+attribute.account = work
+`
+	updatedAt, found := parseSecretToolSearch(output)
+	want := time.Date(2024, 3, 4, 5, 6, 7, 0, time.Local)
+	if !found || !updatedAt.Equal(want) {
+		t.Errorf("parseSecretToolSearch() = %v, %v; want %v, true", updatedAt, found, want)
+	}
+
+	// An item listed without a modification date is still found
+	if updatedAt, found := parseSecretToolSearch("[/org/freedesktop/secrets/collection/login/7]\nattribute.account = work\n"); !found || !updatedAt.IsZero() {
+		t.Errorf("parseSecretToolSearch() of an item without dates = %v, %v; want a zero time, true", updatedAt, found)
+	}
+	if _, found := parseSecretToolSearch(""); found {
+		t.Error("parseSecretToolSearch() found an item in empty output")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !darwin && !linux && !windows
+
+package token
+
+import (
+	"errors"
+	"time"
+)
+
+const keychainName = "OS keychain"
+
+var errKeychainUnsupported = errors.New("the keychain token backend is not supported on this platform; set 'token_storage: file'")
+
+func keychainGet(service, account string) (string, error) {
+	return "", errKeychainUnsupported
+}
+
+func keychainStat(service, account string) (time.Time, error) {
+	return time.Time{}, errKeychainUnsupported
+}
+
+func keychainSet(service, account, secret string) error {
+	return errKeychainUnsupported
+}
+
+func keychainDelete(service, account string) error {
+	return errKeychainUnsupported
+}
@@ -0,0 +1,23 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// securityAddCommand builds the add-generic-password line fed to `security -i`. The
+// interactive mode splits its input on whitespace and groups double-quoted text, so every
+// field is quoted, and fields the quoting can't carry are refused rather than letting an
+// alias with a space or a quote add options of its own.
+func securityAddCommand(service, account, secret string) (string, error) {
+	for _, field := range []struct{ name, value string }{
+		{"service", service},
+		{"account", account},
+		{"token", secret},
+	} {
+		if strings.ContainsAny(field.value, "\"\\\n\r") {
+			return "", fmt.Errorf("%s contains characters the macOS Keychain backend cannot store", field.name)
+		}
+	}
+	return fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", service, account, secret), nil
+}
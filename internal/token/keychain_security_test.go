@@ -0,0 +1,31 @@
+package token
+
+import "testing"
+
+func TestSecurityAddCommand(t *testing.T) {
+	tests := []struct {
+		service, account, secret string
+		want                     string
+	}{
+		{"gitshift", "work", "ghp_secret", "add-generic-password -U -s \"gitshift\" -a \"work\" -w \"ghp_secret\"\n"},
+		// Spaces and option-like text stay inside their field
+		{"gitshift", "my work -w x", "ghp secret", "add-generic-password -U -s \"gitshift\" -a \"my work -w x\" -w \"ghp secret\"\n"},
+	}
+	for _, tt := range tests {
+		got, err := securityAddCommand(tt.service, tt.account, tt.secret)
+		if err != nil || got != tt.want {
+			t.Errorf("securityAddCommand(%q, %q, %q) = %q, %v; want %q", tt.service, tt.account, tt.secret, got, err, tt.want)
+		}
+	}
+
+	for _, fields := range [][3]string{
+		{"gitshift", `work" -w "x`, "ghp_secret"},
+		{"gitshift", `work\`, "ghp_secret"},
+		{"gitshift", "work\nadd-generic-password", "ghp_secret"},
+		{"gitshift", "work", `ghp"secret`},
+	} {
+		if got, err := securityAddCommand(fields[0], fields[1], fields[2]); err == nil {
+			t.Errorf("securityAddCommand(%q) = %q, want an error", fields, got)
+		}
+	}
+}
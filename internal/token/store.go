@@ -0,0 +1,58 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Token storage backends selectable with the token_storage config setting
+const (
+	BackendFile     = "file"
+	BackendKeychain = "keychain"
+)
+
+// ErrTokenNotFound is returned when no token is stored for an account
+var ErrTokenNotFound = errors.New("no token stored for account")
+
+// TokenStore stores one platform token per account alias
+type TokenStore interface {
+	// Get returns the token stored for the account, or ErrTokenNotFound
+	Get(alias string) (string, error)
+
+	// Set stores the token for the account, replacing any previous one
+	Set(alias, token string) error
+
+	// Delete removes the account's token. Deleting a missing token is not an error.
+	Delete(alias string) error
+
+	// Metadata describes where the account's token is stored without decrypting it, or
+	// returns ErrTokenNotFound
+	Metadata(alias string) (*Metadata, error)
+
+	// Backend returns the backend name, BackendFile or BackendKeychain
+	Backend() string
+}
+
+// Metadata describes a stored token
+type Metadata struct {
+	Alias    string
+	Backend  string
+	Location string // file path or keychain service the token lives in
+
+	// UpdatedAt is when the token was last set, zero when the backend doesn't record it
+	UpdatedAt time.Time
+}
+
+// NewStore returns the store for the given backend. An empty backend selects the
+// encrypted file store in dir.
+func NewStore(backend, dir string) (TokenStore, error) {
+	switch backend {
+	case "", BackendFile:
+		return NewFileStore(dir), nil
+	case BackendKeychain:
+		return NewKeychainStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown token storage backend '%s', expected '%s' or '%s'", backend, BackendFile, BackendKeychain)
+	}
+}
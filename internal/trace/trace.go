@@ -0,0 +1,100 @@
+// Package trace tags what one gitshift invocation does with an ID, so the steps of a
+// switch, the commands it runs and the API requests it makes can be told apart from those
+// of other invocations. The ID travels on the context; with --trace the steps are printed.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// tracer is what the context carries for an invocation
+type tracer struct {
+	id    string
+	start time.Time
+
+	// out receives the trace lines; nil when tracing is off
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewID returns a random trace ID
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithID returns a context carrying id for the invocation
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &tracer{id: id, start: time.Now()})
+}
+
+// ID returns the trace ID of the context, or "" when it has none
+func ID(ctx context.Context) string {
+	if t := fromContext(ctx); t != nil {
+		return t.id
+	}
+	return ""
+}
+
+// Enable makes Printf write the trace lines of the context's invocation to out. A context
+// without a trace ID is given one.
+func Enable(ctx context.Context, out io.Writer) context.Context {
+	t := fromContext(ctx)
+	if t == nil {
+		ctx = WithID(ctx, NewID())
+		t = fromContext(ctx)
+	}
+	t.mu.Lock()
+	t.out = out
+	t.mu.Unlock()
+	return ctx
+}
+
+// Enabled reports whether trace lines of the context are printed
+func Enabled(ctx context.Context) bool {
+	t := fromContext(ctx)
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.out != nil
+}
+
+// Printf writes a trace line for component, e.g. "exec" or "switch", when tracing is
+// enabled. Lines carry the trace ID and the time since the invocation started:
+//
+//	[trace 3f9a1c2e8b7d6054 +0.012s] exec: ssh-add -l
+func Printf(ctx context.Context, component, format string, args ...interface{}) {
+	t := fromContext(ctx)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.out == nil {
+		return
+	}
+	message := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	fmt.Fprintf(t.out, "[trace %s +%.3fs] %s: %s\n", t.id, time.Since(t.start).Seconds(), component, message)
+}
+
+func fromContext(ctx context.Context) *tracer {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(contextKey{}).(*tracer)
+	return t
+}
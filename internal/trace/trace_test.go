@@ -0,0 +1,49 @@
+package trace
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPrintf_OnlyWritesWhenEnabled(t *testing.T) {
+	ctx := WithID(context.Background(), "abc123")
+
+	// Nothing to write to yet
+	Printf(ctx, "switch", "not printed")
+	if Enabled(ctx) {
+		t.Fatal("Enabled() = true before Enable")
+	}
+
+	var out strings.Builder
+	ctx = Enable(ctx, &out)
+	Printf(ctx, "exec", "ssh-add %s\n", "-l")
+
+	got := out.String()
+	if !strings.HasPrefix(got, "[trace abc123 +") || !strings.HasSuffix(got, "] exec: ssh-add -l\n") {
+		t.Errorf("unexpected trace line %q", got)
+	}
+	if strings.Contains(got, "not printed") {
+		t.Errorf("line written before tracing was enabled: %q", got)
+	}
+}
+
+func TestEnable_KeepsExistingID(t *testing.T) {
+	ctx := WithID(context.Background(), "abc123")
+	ctx = Enable(ctx, &strings.Builder{})
+	if id := ID(ctx); id != "abc123" {
+		t.Errorf("ID() = %q, want abc123", id)
+	}
+
+	fresh := Enable(context.Background(), &strings.Builder{})
+	if id := ID(fresh); len(id) != 16 {
+		t.Errorf("ID() = %q, want a 16 character ID", id)
+	}
+}
+
+func TestPrintf_WithoutIDIsNoop(t *testing.T) {
+	Printf(context.Background(), "exec", "nothing happens")
+	if ID(context.Background()) != "" {
+		t.Error("ID() of a plain context should be empty")
+	}
+}
@@ -1,15 +1,27 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/techishthoughts/gitshift/cmd"
+	"github.com/techishthoughts/gitshift/internal/models"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		var userErr *models.UserError
+		if errors.As(err, &userErr) && userErr.Suggestion != "" {
+			fmt.Fprintf(os.Stderr, "💡 %s\n", userErr.Suggestion)
+		}
+
+		var exitErr *models.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -8,6 +8,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 // AuthStatus represents the authentication status of a GitHub user.
@@ -96,7 +98,7 @@ func execGhCommand(ctx context.Context, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "gh", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("gh command failed: %w\nOutput: %s", err, string(output))
+		return "", fmt.Errorf("gh command failed: %w\nOutput: %s", err, redact.Secrets(string(output)))
 	}
 	return string(output), nil
 }
@@ -9,18 +9,54 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	ghapi "github.com/cli/go-gh/v2/pkg/api"
+	"github.com/techishthoughts/gitshift/pkg/retry"
 )
 
+const (
+	// maxRetries is how many times a request is attempted before giving up
+	maxRetries = 3
+
+	// baseRetryDelay is the first backoff delay, doubled on every further retry
+	baseRetryDelay = time.Second
+
+	// maxRateLimitWait is the longest gitshift waits for a rate limit to reset before
+	// giving up with a RateLimitError
+	maxRateLimitWait = time.Minute
+)
+
+// RateLimitError is returned when GitHub's rate limit is exhausted and doesn't reset soon
+// enough to wait for it.
+type RateLimitError struct {
+	Reset time.Time // zero when GitHub didn't say when the limit resets
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return "GitHub API rate limit exceeded"
+	}
+	return fmt.Sprintf("GitHub API rate limit exceeded, it resets at %s (in %s)",
+		e.Reset.Local().Format("15:04:05"), time.Until(e.Reset).Round(time.Second))
+}
+
 // Client wraps the GitHub API client with additional functionality.
 type Client struct {
-	REST      *ghapi.RESTClient
-	logger    *slog.Logger
+	REST    *ghapi.RESTClient
+	logger  *slog.Logger
+	baseURL string // API base URL overriding the one derived from the host
+	observe RequestObserver
+
+	// maxAttempts overrides how many times a request is attempted; zero means maxRetries
+	maxAttempts int
+
 	mu        sync.Mutex
 	rateLimit struct {
 		Limit     int
@@ -39,6 +75,33 @@ func WithLogger(logger *slog.Logger) ClientOption {
 	}
 }
 
+// RequestObserver is told about every API request: the context it was made with, its method
+// and path, the HTTP status code (0 when no response arrived) and how long it took.
+type RequestObserver func(ctx context.Context, method, path string, status int, duration time.Duration)
+
+// WithRequestObserver reports every API request, including retries, to observe.
+func WithRequestObserver(observe RequestObserver) ClientOption {
+	return func(c *Client) {
+		c.observe = observe
+	}
+}
+
+// WithMaxAttempts sets how many times a request is attempted before giving up, including
+// the first attempt. Only network errors, server errors and rate limits are retried.
+func WithMaxAttempts(attempts int) ClientOption {
+	return func(c *Client) {
+		c.maxAttempts = max(attempts, 1)
+	}
+}
+
+// WithAPIEndpoint sends requests to the given API base URL (e.g.
+// "https://git.internal.corp/api/v3") instead of the one go-gh derives from the host.
+func WithAPIEndpoint(endpoint string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
 // NewClient creates a new GitHub API client.
 func NewClient(opts ...ClientOption) (*Client, error) {
 	restClient, err := ghapi.DefaultRESTClient()
@@ -81,6 +144,29 @@ func WithToken(token string, opts ...ClientOption) (*Client, error) {
 	return c, nil
 }
 
+// WithTokenForHost creates a new client for the given host (e.g. a GitHub Enterprise
+// domain) authenticated with the specified token.
+func WithTokenForHost(host, token string, opts ...ClientOption) (*Client, error) {
+	client, err := ghapi.NewRESTClient(ghapi.ClientOptions{
+		Host:      host,
+		AuthToken: token,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authenticated client: %w", err)
+	}
+
+	c := &Client{
+		REST:   client,
+		logger: slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
 // CheckRateLimit checks the current rate limit status.
 func (c *Client) CheckRateLimit() (*RateLimit, error) {
 	var rateLimit struct {
@@ -93,7 +179,7 @@ func (c *Client) CheckRateLimit() (*RateLimit, error) {
 		} `json:"resources"`
 	}
 
-	err := c.REST.Get("rate_limit", &rateLimit)
+	err := c.doWithRetry(context.Background(), "GET", "rate_limit", nil, &rateLimit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rate limit: %w", err)
 	}
@@ -107,9 +193,9 @@ func (c *Client) CheckRateLimit() (*RateLimit, error) {
 
 // RateLimit represents GitHub API rate limit information.
 type RateLimit struct {
-	Limit     int
-	Remaining int
-	Reset     int
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+	Reset     int `json:"reset"` // Unix time the limit resets at
 }
 
 // IsAuthenticated checks if the client is properly authenticated.
@@ -119,9 +205,10 @@ func (c *Client) IsAuthenticated() (bool, error) {
 		Login string `json:"login"`
 	}
 
-	err := c.REST.Get("user", &user)
+	err := c.doWithRetry(context.Background(), "GET", "user", nil, &user)
 	if err != nil {
-		if apiErr, ok := err.(*ghapi.HTTPError); ok && apiErr.StatusCode == http.StatusUnauthorized {
+		var apiErr *ghapi.HTTPError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
 			return false, nil
 		}
 	}
@@ -146,90 +233,248 @@ func (c *Client) GetAuthenticatedUser(ctx context.Context) (string, error) {
 	return user.Login, nil
 }
 
-// doWithRetry executes a request with retry logic and rate limiting.
+// doWithRetry executes a request with retry logic and rate limiting. Every GitHub API call
+// goes through it so the rate limit seen in response headers is always up to date. POSTs
+// are only retried when rate limited.
 func (c *Client) doWithRetry(ctx context.Context, method, path string, body, result interface{}) error {
-	const maxRetries = 3
-	var lastErr error
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(time.Second * time.Duration(1<<uint(attempt-1))): // Exponential backoff
-			}
+	_, err := c.request(ctx, method, path, body, result)
+	return err
+}
+
+// request is doWithRetry that also returns the headers of the successful response.
+func (c *Client) request(ctx context.Context, method, path string, body, result interface{}) (http.Header, error) {
+	var jsonBody []byte
+	if body != nil {
+		var err error
+		if jsonBody, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
+	}
+
+	isRetryable := func(err error) bool { return rateLimited(err) || isRetryableError(err) }
+	if method == http.MethodPost {
+		// A POST that failed with a server or network error may have been applied anyway,
+		// so only rate-limited ones, which GitHub rejects before doing anything, are retried
+		isRetryable = rateLimited
+	}
 
-		// Check rate limits before making the request
+	policy := retry.Policy{
+		MaxAttempts: c.attempts(),
+		Backoff:     c.retryDelay,
+		IsRetryable: isRetryable,
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			c.logger.WarnContext(ctx, "Request failed, retrying...",
+				"attempt", attempt,
+				"error", err,
+				"path", path,
+				"wait", wait,
+			)
+		},
+	}
+	var header http.Header
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		// Don't spend a request we know will be rejected
 		if err := c.checkRateLimit(ctx); err != nil {
-			c.logger.WarnContext(ctx, "Rate limit check failed", "error", err)
+			return err
 		}
 
-		// Make the request
+		var bodyReader io.Reader
+		if jsonBody != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
 		var err error
-		switch method {
-		case "GET":
-			err = c.REST.Get(path, result)
-		case "POST", "PUT":
-			// Convert body to JSON if it's not nil
-			var bodyReader io.Reader
-			if body != nil {
-				jsonBody, jsonErr := json.Marshal(body)
-				if jsonErr != nil {
-					return fmt.Errorf("failed to marshal request body: %w", jsonErr)
-				}
-				bodyReader = bytes.NewReader(jsonBody)
+		header, err = c.do(ctx, method, path, bodyReader, result)
+		if rateLimited(err) {
+			if reset, ok := c.rateLimitReset(err); ok && time.Until(reset) > maxRateLimitWait {
+				return &RateLimitError{Reset: reset}
 			}
-			err = c.REST.DoWithContext(ctx, method, path, bodyReader, result)
-		case "DELETE":
-			err = c.REST.Delete(path, result)
-		default:
-			err = fmt.Errorf("unsupported HTTP method: %s", method)
 		}
+		return err
+	})
 
-		if err == nil {
-			return nil
+	var attemptsErr *retry.AttemptsError
+	if errors.As(err, &attemptsErr) && rateLimited(attemptsErr.Err) {
+		reset, _ := c.rateLimitReset(attemptsErr.Err)
+		return nil, &RateLimitError{Reset: reset}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// nextPageLink matches the rel="next" URL of a Link header
+var nextPageLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// getAllPages GETs path and every further page GitHub links to with rel="next", and
+// returns the items of all pages.
+func getAllPages[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	var items []T
+	for path != "" {
+		var page []T
+		header, err := c.request(ctx, http.MethodGet, path, nil, &page)
+		if err != nil {
+			return nil, err
 		}
+		items = append(items, page...)
 
-		// Check if error is retryable
-		if !isRetryableError(err) {
-			return err
+		path = ""
+		if match := nextPageLink.FindStringSubmatch(header.Get("Link")); match != nil {
+			path = match[1]
 		}
+	}
+	return items, nil
+}
+
+// attempts returns how many times a request is attempted
+func (c *Client) attempts() int {
+	if c.maxAttempts > 0 {
+		return c.maxAttempts
+	}
+	return maxRetries
+}
 
-		lastErr = err
-		c.logger.WarnContext(ctx, "Request failed, retrying...",
-			"attempt", attempt+1,
-			"error", err,
-			"path", path,
-		)
+// do issues a single request, records the rate limit headers of the response and decodes
+// its JSON body into result. It returns the response headers.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, result interface{}) (http.Header, error) {
+	if c.baseURL != "" && !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		path = c.baseURL + "/" + strings.TrimPrefix(path, "/")
+	}
+	start := time.Now()
+	resp, err := c.REST.RequestWithContext(ctx, method, path, body)
+	if err != nil {
+		status := 0
+		var httpErr *ghapi.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.StatusCode
+			c.updateRateLimit(httpErr.Headers)
+		}
+		c.observeRequest(ctx, method, path, status, start)
+		return nil, err
 	}
+	defer resp.Body.Close()
+	c.observeRequest(ctx, method, path, resp.StatusCode, start)
+	c.updateRateLimit(resp.Header)
 
-	return fmt.Errorf("after %d attempts, last error: %w", maxRetries, lastErr)
+	if resp.StatusCode == http.StatusNoContent || result == nil {
+		return resp.Header, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header, json.Unmarshal(data, result)
 }
 
-// checkRateLimit checks the current rate limit status and sleeps if needed.
+// observeRequest reports a request that started at start to the request observer, if any
+func (c *Client) observeRequest(ctx context.Context, method, path string, status int, start time.Time) {
+	if c.observe != nil {
+		c.observe(ctx, method, path, status, time.Since(start))
+	}
+}
+
+// checkRateLimit waits for the rate limit to reset when the last response reported it as
+// exhausted, or fails with a RateLimitError when the reset is too far away.
 func (c *Client) checkRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	exhausted := c.rateLimit.Limit > 0 && c.rateLimit.Remaining <= 0 && time.Now().Before(c.rateLimit.Reset)
+	reset := c.rateLimit.Reset
+	c.mu.Unlock()
+
+	if !exhausted {
+		return nil
+	}
+
+	sleepTime := time.Until(reset)
+	if sleepTime > maxRateLimitWait {
+		return &RateLimitError{Reset: reset}
+	}
+	c.logger.InfoContext(ctx, "Rate limit reached, sleeping until reset",
+		"reset_in", sleepTime,
+	)
+	return sleepContext(ctx, sleepTime)
+}
+
+// updateRateLimit records the rate limit reported by GitHub's X-RateLimit-* headers.
+func (c *Client) updateRateLimit(header http.Header) {
+	limit, limitErr := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if limitErr != nil || remainingErr != nil || resetErr != nil {
+		return
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.rateLimit.Limit = limit
+	c.rateLimit.Remaining = remaining
+	c.rateLimit.Reset = time.Unix(reset, 0)
+}
+
+// RateLimitStatus returns the rate limit reported by the last API response. ok is false
+// when no response carried rate limit headers yet.
+func (c *Client) RateLimitStatus() (rateLimit RateLimit, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rateLimit.Limit == 0 {
+		return RateLimit{}, false
+	}
+	return RateLimit{
+		Limit:     c.rateLimit.Limit,
+		Remaining: c.rateLimit.Remaining,
+		Reset:     int(c.rateLimit.Reset.Unix()),
+	}, true
+}
 
-	now := time.Now()
-	if now.Before(c.rateLimit.Reset) && c.rateLimit.Remaining <= 0 {
-		sleepTime := time.Until(c.rateLimit.Reset)
-		c.logger.InfoContext(ctx, "Rate limit reached, sleeping until reset",
-			"reset_in", sleepTime,
-		)
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(sleepTime):
-			// Continue after rate limit reset
+// rateLimitReset works out when a rate-limited request may be retried, from the
+// Retry-After header used by secondary rate limits or the primary limit's reset time.
+func (c *Client) rateLimitReset(err error) (time.Time, bool) {
+	var httpErr *ghapi.HTTPError
+	if errors.As(err, &httpErr) {
+		if seconds, convErr := strconv.Atoi(httpErr.Headers.Get("Retry-After")); convErr == nil {
+			return time.Now().Add(time.Duration(seconds) * time.Second), true
 		}
 	}
 
-	// Update rate limit status from headers if available
-	// Note: This is a simplified example. In a real implementation,
-	// you would parse the rate limit headers from the response.
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rateLimit.Remaining <= 0 && time.Now().Before(c.rateLimit.Reset) {
+		return c.rateLimit.Reset, true
+	}
+	return time.Time{}, false
+}
+
+// retryBackoff is exponential backoff from baseRetryDelay with up to 50% jitter
+var retryBackoff = retry.Exponential(baseRetryDelay, 0.5)
+
+// retryDelay returns how long to wait before retry number attempt after err: until the
+// rate limit resets when it is known, otherwise exponential backoff with jitter.
+func (c *Client) retryDelay(attempt int, err error) time.Duration {
+	if rateLimited(err) {
+		if reset, ok := c.rateLimitReset(err); ok {
+			return time.Until(reset)
+		}
+	}
+	return retryBackoff(attempt, err)
+}
+
+// rateLimited reports whether err is GitHub rejecting a request because of the primary or
+// a secondary rate limit. GitHub uses 403 for both, with 429 for some secondary limits.
+func rateLimited(err error) bool {
+	var httpErr *ghapi.HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	switch httpErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return httpErr.Headers.Get("Retry-After") != "" ||
+			httpErr.Headers.Get("X-RateLimit-Remaining") == "0" ||
+			strings.Contains(strings.ToLower(httpErr.Message), "rate limit")
+	}
+	return false
 }
 
 // isRetryableError checks if an error is retryable.
@@ -238,31 +483,58 @@ func isRetryableError(err error) bool {
 		return false
 	}
 
-	// Check for network errors or rate limiting
+	// Check for network timeouts and resets
 	if errors.Is(err, context.DeadlineExceeded) ||
-		errors.Is(err, context.Canceled) ||
-		strings.Contains(err.Error(), "rate limit") ||
 		strings.Contains(err.Error(), "connection reset") ||
 		strings.Contains(err.Error(), "timeout") {
 		return true
 	}
 
 	// Check for HTTP status codes that are safe to retry
-	if apiErr, ok := err.(interface{ StatusCode() int }); ok {
-		statusCode := apiErr.StatusCode()
-		return statusCode >= 500 || statusCode == 429 // 5xx or Too Many Requests
+	var httpErr *ghapi.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
 	}
 
-	return false
+	// DNS failures, refused connections and other network errors
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
 
-// VerifySSHKey verifies if an SSH key is added to the authenticated user's account.
-func (c *Client) VerifySSHKey(ctx context.Context, publicKey string) (bool, error) {
-	var keys []struct {
-		Key string `json:"key"`
+// IsTransientError reports whether err is a network or server error that may go away on its
+// own, as opposed to a request GitHub rejected.
+func IsTransientError(err error) bool {
+	return isRetryableError(err)
+}
+
+// IsAuthError reports whether GitHub rejected the token: a 401, or a 403 that isn't a rate
+// limit.
+func IsAuthError(err error) bool {
+	var httpErr *ghapi.HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.StatusCode == http.StatusUnauthorized ||
+		(httpErr.StatusCode == http.StatusForbidden && !rateLimited(err))
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
 	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
 
-	if err := c.doWithRetry(ctx, "GET", "user/keys", nil, &keys); err != nil {
+// VerifySSHKey verifies if an SSH key is added to the authenticated user's account.
+func (c *Client) VerifySSHKey(ctx context.Context, publicKey string) (bool, error) {
+	keys, err := getAllPages[SSHKey](ctx, c, "user/keys?per_page=100")
+	if err != nil {
 		return false, fmt.Errorf("failed to get SSH keys: %w", err)
 	}
 
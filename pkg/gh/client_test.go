@@ -0,0 +1,310 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	ghapi "github.com/cli/go-gh/v2/pkg/api"
+)
+
+// stubTransport replies to each request with the next canned response
+type stubTransport struct {
+	responses []*http.Response
+	requests  int
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := s.responses[s.requests]
+	s.requests++
+	resp.Request = req
+	return resp, nil
+}
+
+func stubResponse(status int, body string, header map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	for key, value := range header {
+		resp.Header.Set(key, value)
+	}
+	return resp
+}
+
+func newStubClient(t *testing.T, transport *stubTransport) *Client {
+	t.Helper()
+	rest, err := ghapi.NewRESTClient(ghapi.ClientOptions{Host: "github.com", AuthToken: "token", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{REST: rest, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func TestDoWithRetry_RecordsRateLimitHeaders(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	transport := &stubTransport{responses: []*http.Response{
+		stubResponse(http.StatusOK, `{"login":"octocat"}`, map[string]string{
+			"X-RateLimit-Limit":     "5000",
+			"X-RateLimit-Remaining": "4999",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset, 10),
+		}),
+	}}
+	client := newStubClient(t, transport)
+
+	if _, err := client.GetAuthenticatedUser(context.Background()); err != nil {
+		t.Fatalf("GetAuthenticatedUser() error = %v", err)
+	}
+
+	rateLimit, ok := client.RateLimitStatus()
+	if !ok {
+		t.Fatal("RateLimitStatus() reported no rate limit")
+	}
+	if rateLimit.Limit != 5000 || rateLimit.Remaining != 4999 || int64(rateLimit.Reset) != reset {
+		t.Errorf("RateLimitStatus() = %+v", rateLimit)
+	}
+}
+
+func TestDoWithRetry_HonorsRetryAfter(t *testing.T) {
+	transport := &stubTransport{responses: []*http.Response{
+		stubResponse(http.StatusForbidden, `{"message":"You have exceeded a secondary rate limit"}`, map[string]string{"Retry-After": "0"}),
+		stubResponse(http.StatusOK, `{"login":"octocat"}`, nil),
+	}}
+	client := newStubClient(t, transport)
+
+	login, err := client.GetAuthenticatedUser(context.Background())
+	if err != nil {
+		t.Fatalf("GetAuthenticatedUser() error = %v", err)
+	}
+	if login != "octocat" || transport.requests != 2 {
+		t.Errorf("login = %q after %d requests, want octocat after 2", login, transport.requests)
+	}
+}
+
+func TestDoWithRetry_AbortsWhenResetIsFarAway(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Unix()
+	transport := &stubTransport{responses: []*http.Response{
+		stubResponse(http.StatusForbidden, `{"message":"API rate limit exceeded"}`, map[string]string{
+			"X-RateLimit-Limit":     "60",
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset, 10),
+		}),
+	}}
+	client := newStubClient(t, transport)
+
+	_, err := client.GetAuthenticatedUser(context.Background())
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("GetAuthenticatedUser() error = %v, want a RateLimitError", err)
+	}
+	if rateLimitErr.Reset.Unix() != reset {
+		t.Errorf("Reset = %v, want %v", rateLimitErr.Reset.Unix(), reset)
+	}
+	if transport.requests != 1 {
+		t.Errorf("made %d requests, want 1", transport.requests)
+	}
+
+	// The exhausted limit is remembered, so the next call doesn't hit the API at all
+	if _, err := client.GetAuthenticatedUser(context.Background()); !errors.As(err, &rateLimitErr) {
+		t.Errorf("second call error = %v, want a RateLimitError", err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("made %d requests, want 1", transport.requests)
+	}
+}
+
+func TestRetryDelay_BackoffWithJitter(t *testing.T) {
+	client := &Client{}
+	for attempt := 1; attempt <= 3; attempt++ {
+		base := baseRetryDelay << uint(attempt-1)
+		delay := client.retryDelay(attempt, errors.New("connection reset"))
+		if delay < base || delay > base+base/2 {
+			t.Errorf("retryDelay(attempt %d) = %v, want within [%v, %v]", attempt, delay, base, base+base/2)
+		}
+	}
+}
+
+func TestWithAPIEndpoint_PrefixesRequests(t *testing.T) {
+	transport := &stubTransport{responses: []*http.Response{
+		stubResponse(http.StatusOK, `{"login":"octocat"}`, nil),
+	}}
+	client := newStubClient(t, transport)
+	WithAPIEndpoint("https://git.internal.corp/api/v3/")(client)
+
+	if _, err := client.GetAuthenticatedUser(context.Background()); err != nil {
+		t.Fatalf("GetAuthenticatedUser() error = %v", err)
+	}
+	if got := transport.responses[0].Request.URL.String(); got != "https://git.internal.corp/api/v3/user" {
+		t.Errorf("request URL = %s, want https://git.internal.corp/api/v3/user", got)
+	}
+}
+
+func TestWithRequestObserver_SeesEveryAttempt(t *testing.T) {
+	transport := &stubTransport{responses: []*http.Response{
+		stubResponse(http.StatusForbidden, `{"message":"You have exceeded a secondary rate limit"}`, map[string]string{"Retry-After": "0"}),
+		stubResponse(http.StatusOK, `{"login":"octocat"}`, nil),
+	}}
+	client := newStubClient(t, transport)
+	var statuses []int
+	WithRequestObserver(func(ctx context.Context, method, path string, status int, duration time.Duration) {
+		if method != http.MethodGet || duration < 0 {
+			t.Errorf("observed %s request taking %s", method, duration)
+		}
+		statuses = append(statuses, status)
+	})(client)
+
+	if _, err := client.GetAuthenticatedUser(context.Background()); err != nil {
+		t.Fatalf("GetAuthenticatedUser() error = %v", err)
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusForbidden || statuses[1] != http.StatusOK {
+		t.Errorf("observed statuses %v, want [403 200]", statuses)
+	}
+}
+
+// unreachableTransport fails every request the way a refused connection does
+type unreachableTransport struct {
+	requests int
+}
+
+func (u *unreachableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u.requests++
+	return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+func TestWithMaxAttempts_RetriesNetworkErrors(t *testing.T) {
+	transport := &unreachableTransport{}
+	rest, err := ghapi.NewRESTClient(ghapi.ClientOptions{Host: "github.com", AuthToken: "token", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{REST: rest, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	WithMaxAttempts(1)(client)
+
+	_, err = client.GetAuthenticatedUser(context.Background())
+	if err == nil {
+		t.Fatal("GetAuthenticatedUser() succeeded")
+	}
+	if transport.requests != 1 {
+		t.Errorf("made %d requests, want 1", transport.requests)
+	}
+	if !IsTransientError(err) || IsAuthError(err) {
+		t.Errorf("a refused connection should be transient, not an auth error: %v", err)
+	}
+}
+
+func TestAddSSHKey_RetriesOnlyRateLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRequests int
+	}{
+		{"server error", http.StatusBadGateway, 1},
+		{"rate limited", http.StatusTooManyRequests, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &stubTransport{responses: []*http.Response{
+				stubResponse(tt.status, `{"message":"try again"}`, map[string]string{"Retry-After": "0"}),
+				stubResponse(http.StatusCreated, `{"id":7,"key":"ssh-ed25519 AAAA","title":"laptop"}`, nil),
+			}}
+			client := newStubClient(t, transport)
+
+			_, err := client.AddSSHKey(context.Background(), "laptop", "ssh-ed25519 AAAA")
+			if transport.requests != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", transport.requests, tt.wantRequests)
+			}
+			if (err == nil) != (tt.wantRequests > 1) {
+				t.Errorf("AddSSHKey() error = %v", err)
+			}
+		})
+	}
+
+	// A POST that never got an answer may have been applied, so it isn't sent again
+	transport := &unreachableTransport{}
+	rest, err := ghapi.NewRESTClient(ghapi.ClientOptions{Host: "github.com", AuthToken: "token", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{REST: rest, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if _, err := client.AddSSHKey(context.Background(), "laptop", "ssh-ed25519 AAAA"); err == nil {
+		t.Fatal("AddSSHKey() succeeded")
+	}
+	if transport.requests != 1 {
+		t.Errorf("made %d requests for a refused connection, want 1", transport.requests)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+		want   bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, nil, true},
+		{"forbidden", http.StatusForbidden, nil, true},
+		{"rate limited", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, false},
+		{"server error", http.StatusInternalServerError, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.header {
+				header.Set(key, value)
+			}
+			err := &ghapi.HTTPError{StatusCode: tt.status, Headers: header}
+			if got := IsAuthError(err); got != tt.want {
+				t.Errorf("IsAuthError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSSHKeys_FollowsPagination(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/keys" {
+			t.Errorf("path = %s, want /user/keys", r.URL.Path)
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			if got := r.URL.Query().Get("per_page"); got != "100" {
+				t.Errorf("per_page = %q, want 100", got)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/user/keys?per_page=100&page=2>; rel="next", <%s/user/keys?per_page=100&page=2>; rel="last"`, server.URL, server.URL))
+			fmt.Fprint(w, `[{"id":1,"key":"ssh-ed25519 AAAA1","title":"laptop"}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/user/keys?per_page=100&page=1>; rel="prev", <%s/user/keys?per_page=100&page=1>; rel="first"`, server.URL, server.URL))
+			fmt.Fprint(w, `[{"id":2,"key":"ssh-ed25519 AAAA2","title":"desktop"}]`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	rest, err := ghapi.NewRESTClient(ghapi.ClientOptions{Host: "github.com", AuthToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{REST: rest, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	WithAPIEndpoint(server.URL)(client)
+
+	keys, err := client.ListSSHKeys(context.Background())
+	if err != nil {
+		t.Fatalf("ListSSHKeys() error = %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != 1 || keys[1].ID != 2 {
+		t.Errorf("ListSSHKeys() = %+v, want the keys of both pages", keys)
+	}
+}
@@ -0,0 +1,76 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHKey is a public SSH key registered on the authenticated user's account.
+type SSHKey struct {
+	ID    int64  `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+// ListSSHKeys lists the public SSH keys of the authenticated user, following GitHub's
+// pagination.
+func (c *Client) ListSSHKeys(ctx context.Context) ([]SSHKey, error) {
+	keys, err := getAllPages[SSHKey](ctx, c, "user/keys?per_page=100")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSH keys: %w", err)
+	}
+	return keys, nil
+}
+
+// AddSSHKey registers a public SSH key on the authenticated user's account. It needs a
+// token with the write:public_key scope.
+func (c *Client) AddSSHKey(ctx context.Context, title, publicKey string) (*SSHKey, error) {
+	body := map[string]string{
+		"title": title,
+		"key":   strings.TrimSpace(publicKey),
+	}
+
+	var key SSHKey
+	if err := c.doWithRetry(ctx, "POST", "user/keys", body, &key); err != nil {
+		return nil, fmt.Errorf("failed to add SSH key: %w", err)
+	}
+	return &key, nil
+}
+
+// DeleteSSHKey removes a public SSH key from the authenticated user's account. It needs a
+// token with the admin:public_key scope.
+func (c *Client) DeleteSSHKey(ctx context.Context, id int64) error {
+	if err := c.doWithRetry(ctx, "DELETE", fmt.Sprintf("user/keys/%d", id), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete SSH key %d: %w", id, err)
+	}
+	return nil
+}
+
+// FindSSHKeyByFingerprint returns the key among keys with the same SHA256 fingerprint as
+// publicKey, or nil. Comments are ignored, so a key registered under another title still
+// matches.
+func FindSSHKeyByFingerprint(keys []SSHKey, publicKey string) (*SSHKey, error) {
+	want, err := fingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		if got, err := fingerprint(keys[i].Key); err == nil && got == want {
+			return &keys[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// fingerprint returns the SHA256 fingerprint of an authorized_keys formatted public key.
+func fingerprint(publicKey string) (string, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	return ssh.FingerprintSHA256(key), nil
+}
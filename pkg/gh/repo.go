@@ -2,9 +2,14 @@
 package gh
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
+
+	ghapi "github.com/cli/go-gh/v2/pkg/api"
 )
 
 // Repository represents a GitHub repository with its basic information and permissions.
@@ -31,10 +36,11 @@ func (c *Client) CheckRepoAccess(owner, repo string) (*Repository, error) {
 	// Try to get the repository information
 	var repoInfo Repository
 
-	err := c.REST.Get(fmt.Sprintf("repos/%s/%s", owner, repo), &repoInfo)
+	err := c.doWithRetry(context.Background(), "GET", fmt.Sprintf("repos/%s/%s", owner, repo), nil, &repoInfo)
 	if err != nil {
 		// If we get a 404, the repository either doesn't exist or we don't have access
-		if apiErr, ok := err.(interface{ StatusCode() int }); ok && apiErr.StatusCode() == 404 {
+		var apiErr *ghapi.HTTPError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
 			return nil, fmt.Errorf("repository %s/%s not found or access denied", owner, repo)
 		}
 		return nil, fmt.Errorf("failed to access repository: %w", err)
@@ -108,7 +114,7 @@ func (c *Client) GetDefaultBranch(owner, repo string) (string, error) {
 		DefaultBranch string `json:"default_branch"`
 	}
 
-	err := c.REST.Get(fmt.Sprintf("repos/%s/%s", owner, repo), &repoInfo)
+	err := c.doWithRetry(context.Background(), "GET", fmt.Sprintf("repos/%s/%s", owner, repo), nil, &repoInfo)
 	if err != nil {
 		return "", fmt.Errorf("failed to get repository info: %w", err)
 	}
@@ -0,0 +1,304 @@
+// Package gitlab provides a minimal GitLab REST API (v4) client for gitshift, covering
+// what account validation and SSH key management need.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/techishthoughts/gitshift/pkg/retry"
+)
+
+const (
+	// DefaultAPIEndpoint is the API base URL of gitlab.com
+	DefaultAPIEndpoint = "https://gitlab.com/api/v4"
+
+	// maxRetries is how many times a request is attempted before giving up
+	maxRetries = 3
+
+	// baseRetryDelay is the first backoff delay, doubled on every further retry
+	baseRetryDelay = time.Second
+
+	// maxRetryAfter is the longest gitshift waits when GitLab asks it to retry later
+	maxRetryAfter = time.Minute
+
+	// requestTimeout bounds a single request when the HTTP client has no timeout of its own
+	requestTimeout = 30 * time.Second
+)
+
+// HTTPError is returned when the GitLab API answers with a non-2xx status.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // zero when GitLab didn't send a Retry-After header
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("GitLab API returned HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("GitLab API returned HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// User is the authenticated GitLab user.
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	WebURL   string `json:"web_url"`
+}
+
+// Client talks to the GitLab API of gitlab.com or a self-hosted instance.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	// maxAttempts overrides how many times a request is attempted; zero means maxRetries
+	maxAttempts int
+}
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithLogger sets the logger for the client.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithMaxAttempts sets how many times a request is attempted before giving up, including
+// the first attempt. Only network errors, server errors and rate limits are retried.
+func WithMaxAttempts(attempts int) ClientOption {
+	return func(c *Client) {
+		c.maxAttempts = max(attempts, 1)
+	}
+}
+
+// NewClient creates a client for the API at endpoint (e.g.
+// "https://gitlab.internal.corp/api/v4"), authenticated with a personal access token.
+// An empty endpoint means gitlab.com.
+func NewClient(endpoint, token string, opts ...ClientOption) (*Client, error) {
+	if endpoint == "" {
+		endpoint = DefaultAPIEndpoint
+	}
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		return nil, fmt.Errorf("invalid GitLab API endpoint %q: must be an http(s) URL", endpoint)
+	}
+	if token == "" {
+		return nil, errors.New("a GitLab token is required")
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(endpoint, "/"),
+		token:      token,
+		httpClient: http.DefaultClient,
+		logger:     slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// GetUser returns the user the token belongs to.
+func (c *Client) GetUser(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.doWithRetry(ctx, http.MethodGet, "user", nil, &user); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+	if user.Username == "" {
+		return nil, errors.New("no authenticated user found")
+	}
+	return &user, nil
+}
+
+// TestAccess reports whether the token is accepted by the API. It returns false without
+// an error when GitLab rejects the token, and an error when the API couldn't be reached.
+func (c *Client) TestAccess(ctx context.Context) (bool, error) {
+	_, err := c.GetUser(ctx)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// doWithRetry executes a request, retrying server errors and rate-limited requests with
+// exponential backoff or the delay GitLab asks for in Retry-After. POSTs are only retried when
+// rate limited.
+func (c *Client) doWithRetry(ctx context.Context, method, path string, body, result interface{}) error {
+	var jsonBody []byte
+	if body != nil {
+		var err error
+		if jsonBody, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	isRetryable := isRetryableError
+	if method == http.MethodPost {
+		// A POST that failed with a server or network error may have been applied anyway,
+		// so only rate-limited ones, which GitLab rejects before doing anything, are retried
+		isRetryable = rateLimited
+	}
+
+	policy := retry.Policy{
+		MaxAttempts: c.attempts(),
+		Backoff:     retryDelay,
+		IsRetryable: isRetryable,
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			c.logger.WarnContext(ctx, "Request failed, retrying...",
+				"attempt", attempt,
+				"error", err,
+				"path", path,
+				"wait", wait,
+			)
+		},
+	}
+	return retry.Do(ctx, policy, func(ctx context.Context) error {
+		return c.do(ctx, method, path, jsonBody, result)
+	})
+}
+
+// attempts returns how many times a request is attempted
+func (c *Client) attempts() int {
+	if c.maxAttempts > 0 {
+		return c.maxAttempts
+	}
+	return maxRetries
+}
+
+// do issues a single request and decodes its JSON body into result
+func (c *Client) do(ctx context.Context, method, path string, body []byte, result interface{}) error {
+	if c.httpClient.Timeout == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/"+strings.TrimPrefix(path, "/"), bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newHTTPError(resp, data)
+	}
+	if resp.StatusCode == http.StatusNoContent || result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// newHTTPError builds an HTTPError from a failed response. GitLab reports errors as
+// {"message": ...} or {"error": ...}, where message may be a string or an object of
+// field errors.
+func newHTTPError(resp *http.Response, data []byte) *HTTPError {
+	httpErr := &HTTPError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		httpErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var payload struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	if json.Unmarshal(data, &payload) != nil {
+		return httpErr
+	}
+	var message string
+	switch {
+	case json.Unmarshal(payload.Message, &message) == nil:
+		httpErr.Message = message
+	case len(payload.Message) > 0:
+		httpErr.Message = string(payload.Message)
+	default:
+		httpErr.Message = payload.Error
+	}
+	return httpErr
+}
+
+// isRetryableError reports whether a request that failed with err may succeed if retried
+func isRetryableError(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+	// Network errors are worth another try, a cancelled context isn't
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// rateLimited reports whether GitLab rejected a request because of its rate limit
+func rateLimited(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsTransientError reports whether err is a network or server error that may go away on its
+// own, as opposed to a request GitLab rejected.
+func IsTransientError(err error) bool {
+	return err != nil && isRetryableError(err)
+}
+
+// IsAuthError reports whether GitLab rejected the token
+func IsAuthError(err error) bool {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden
+}
+
+// retryBackoff is exponential backoff from baseRetryDelay
+var retryBackoff = retry.Exponential(baseRetryDelay, 0)
+
+// retryDelay returns how long to wait before retry number attempt after err: what
+// Retry-After asked for, capped, or exponential backoff otherwise.
+func retryDelay(attempt int, err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return min(httpErr.RetryAfter, maxRetryAfter)
+	}
+	return retryBackoff(attempt, err)
+}
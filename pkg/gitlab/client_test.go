@@ -0,0 +1,183 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL+"/api/v4/", "glpat-test",
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestGetUser_SendsPrivateToken(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/user" {
+			t.Errorf("path = %s, want /api/v4/user", r.URL.Path)
+		}
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "glpat-test" {
+			t.Errorf("PRIVATE-TOKEN = %q", got)
+		}
+		w.Write([]byte(`{"id":42,"username":"tanuki","name":"Tanuki"}`))
+	})
+
+	user, err := client.GetUser(context.Background())
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if user.ID != 42 || user.Username != "tanuki" {
+		t.Errorf("GetUser() = %+v", user)
+	}
+}
+
+func TestTestAccess_RejectedToken(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"401 Unauthorized"}`))
+	})
+
+	ok, err := client.TestAccess(context.Background())
+	if ok || err != nil {
+		t.Errorf("TestAccess() = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestAddSSHKey_ReportsFieldErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if r.Method != http.MethodPost || body["title"] != "laptop" || body["key"] != "ssh-ed25519 AAAA" {
+			t.Errorf("unexpected request %s %v", r.Method, body)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":{"key":["has already been taken"]}}`))
+	})
+
+	_, err := client.AddSSHKey(context.Background(), "laptop", "ssh-ed25519 AAAA\n")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("AddSSHKey() error = %v, want an HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadRequest || httpErr.Message != `{"key":["has already been taken"]}` {
+		t.Errorf("HTTPError = %+v", httpErr)
+	}
+}
+
+func TestListSSHKeys_RetriesServerErrors(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id":1,"title":"laptop","key":"ssh-ed25519 AAAA"}]`))
+	})
+
+	keys, err := client.ListSSHKeys(context.Background())
+	if err != nil {
+		t.Fatalf("ListSSHKeys() error = %v", err)
+	}
+	if requests != 2 || len(keys) != 1 || keys[0].Title != "laptop" {
+		t.Errorf("ListSSHKeys() = %+v after %d requests", keys, requests)
+	}
+}
+
+func TestAddSSHKey_RetriesOnlyRateLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRequests int
+	}{
+		{"server error", http.StatusBadGateway, 1},
+		{"rate limited", http.StatusTooManyRequests, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":7,"title":"laptop","key":"ssh-ed25519 AAAA"}`))
+			})
+
+			_, err := client.AddSSHKey(context.Background(), "laptop", "ssh-ed25519 AAAA")
+			if requests != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", requests, tt.wantRequests)
+			}
+			if (err == nil) != (tt.wantRequests > 1) {
+				t.Errorf("AddSSHKey() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClient_RejectsInvalidEndpoint(t *testing.T) {
+	if _, err := NewClient("gitlab.example.com/api/v4", "token"); err == nil {
+		t.Error("NewClient() accepted an endpoint without a scheme")
+	}
+}
+
+func TestWithMaxAttempts_ClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantTransient bool
+		wantAuth      bool
+	}{
+		{"server error", http.StatusBadGateway, true, false},
+		{"rejected token", http.StatusUnauthorized, false, true},
+		{"missing scope", http.StatusForbidden, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+			client, err := NewClient(server.URL+"/api/v4", "glpat-test", WithMaxAttempts(1),
+				WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.ListSSHKeys(context.Background())
+			if err == nil {
+				t.Fatal("ListSSHKeys() succeeded")
+			}
+			if requests != 1 {
+				t.Errorf("made %d requests, want 1", requests)
+			}
+			if got := IsTransientError(err); got != tt.wantTransient {
+				t.Errorf("IsTransientError(%v) = %v, want %v", err, got, tt.wantTransient)
+			}
+			if got := IsAuthError(err); got != tt.wantAuth {
+				t.Errorf("IsAuthError(%v) = %v, want %v", err, got, tt.wantAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SSHKey is a public SSH key registered on the authenticated user's account.
+type SSHKey struct {
+	ID    int64  `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+// ListSSHKeys lists the public SSH keys of the authenticated user.
+func (c *Client) ListSSHKeys(ctx context.Context) ([]SSHKey, error) {
+	var keys []SSHKey
+	if err := c.doWithRetry(ctx, http.MethodGet, "user/keys?per_page=100", nil, &keys); err != nil {
+		return nil, fmt.Errorf("failed to list SSH keys: %w", err)
+	}
+	return keys, nil
+}
+
+// AddSSHKey registers a public SSH key on the authenticated user's account. It needs a
+// token with the api scope.
+func (c *Client) AddSSHKey(ctx context.Context, title, publicKey string) (*SSHKey, error) {
+	body := map[string]string{
+		"title": title,
+		"key":   strings.TrimSpace(publicKey),
+	}
+
+	var key SSHKey
+	if err := c.doWithRetry(ctx, http.MethodPost, "user/keys", body, &key); err != nil {
+		return nil, fmt.Errorf("failed to add SSH key: %w", err)
+	}
+	return &key, nil
+}
+
+// DeleteSSHKey removes a public SSH key from the authenticated user's account.
+func (c *Client) DeleteSSHKey(ctx context.Context, id int64) error {
+	if err := c.doWithRetry(ctx, http.MethodDelete, fmt.Sprintf("user/keys/%d", id), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete SSH key %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package gitshift lets Go programs list, validate and switch gitshift accounts without
+// running the gitshift binary. A Client works on the same configuration file, SSH config
+// and audit log as the command line, so switches made either way see each other.
+//
+// # Stability
+//
+// The exported identifiers of this package are gitshift's supported Go API: they are only
+// removed or changed incompatibly in a new major version, and new fields and methods are
+// only added. Everything else in the module, including the packages under internal/ and
+// cmd/ that a Client is built on, may change in any release. Output written to the
+// WithOutput writer is meant for people and has no stable format.
+package gitshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/techishthoughts/gitshift/internal/audit"
+	"github.com/techishthoughts/gitshift/internal/config"
+	"github.com/techishthoughts/gitshift/internal/models"
+	"github.com/techishthoughts/gitshift/internal/pathutil"
+	"github.com/techishthoughts/gitshift/internal/ssh"
+	"github.com/techishthoughts/gitshift/internal/switcher"
+)
+
+var (
+	// ErrAccountNotFound is returned for an alias no account is configured with
+	ErrAccountNotFound = models.ErrAccountNotFound
+	// ErrNoSSHKey is returned when an operation needs the account's SSH key and none is configured
+	ErrNoSSHKey = errors.New("no SSH key configured for the account")
+)
+
+// Account is a configured account as seen through the API
+type Account struct {
+	Alias      string
+	Name       string
+	Email      string
+	Platform   string // github, gitlab, bitbucket or custom
+	Domain     string // e.g. github.com, or the host of a self-hosted instance
+	Username   string // the account's username on the platform, empty when unknown
+	SSHKeyPath string // empty when the account has no SSH key
+	// SSHKeyFingerprint is the SHA256 fingerprint of a key only the SSH agent holds, used
+	// when SSHKeyPath is empty
+	SSHKeyFingerprint string
+	Default           bool // whether this is the default account
+	Current           bool // whether this is the active account
+	LastUsed          *time.Time
+}
+
+// Client switches between the accounts in the gitshift configuration of the current user
+type Client struct {
+	config *config.Manager
+	out    io.Writer
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithOutput sends the progress and warnings of SSH changes to w, which are discarded by
+// default
+func WithOutput(w io.Writer) Option {
+	return func(c *Client) {
+		c.out = w
+	}
+}
+
+// New loads the gitshift configuration, creating an empty one when there is none, and
+// returns a Client for it
+func New(opts ...Option) (*Client, error) {
+	c := &Client{config: config.NewManager(), out: io.Discard}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.config.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return c, nil
+}
+
+// ListAccounts returns the configured accounts sorted by alias
+func (c *Client) ListAccounts() []Account {
+	accounts := c.config.ListAccounts()
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Alias < accounts[j].Alias })
+
+	result := make([]Account, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, c.toAccount(account))
+	}
+	return result
+}
+
+// Account returns the account configured with alias
+func (c *Client) Account(alias string) (Account, error) {
+	account, err := c.config.GetAccount(alias)
+	if err != nil {
+		return Account{}, fmt.Errorf("account '%s': %w", alias, err)
+	}
+	return c.toAccount(account), nil
+}
+
+// SwitchOptions controls how Switch applies an account
+type SwitchOptions struct {
+	// Offline skips the SSH connection test to the account's platform
+	Offline bool
+}
+
+// Switch makes alias the active account, the way 'gitshift switch' does: the managed SSH
+// config block for its domain points to its key, the SSH agent holds its key (and only its
+// key unless allow_multiple_keys is set), and the global Git user.name, user.email,
+// core.sshCommand and GPG signing settings are the account's. Unlike 'gitshift switch', it
+// doesn't change repository-local Git config or the GitHub CLI login. The switch is
+// recorded in the audit log.
+//
+// Like the command, Switch also exports GIT_SSH_COMMAND for the account's key in the rc
+// file of the user's shell (e.g. ~/.zshrc), which the previous contents are backed up
+// next to, so that new shells use the key too. A failure to configure GPG signing is only
+// reported to the WithOutput writer.
+func (c *Client) Switch(ctx context.Context, alias string, opts SwitchOptions) error {
+	account, err := c.config.GetAccount(alias)
+	if err != nil {
+		return fmt.Errorf("account '%s': %w", alias, err)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	return switcher.Switch(ctx, c.config, account, switcher.Options{
+		Offline:  opts.Offline,
+		AuditLog: audit.NewLog(filepath.Join(config.ConfigDir(homeDir), audit.LogFileName), "gitshift (Go API)"),
+		Out:      c.out,
+	})
+}
+
+// Validate checks the account configured with alias the way 'gitshift validate --offline'
+// does: its identity fields and usernames, and that its SSH key exists, can be read and
+// isn't weak; a key given by fingerprint must be loaded in the SSH agent. It returns nil
+// for a valid account, or an error joining every problem found.
+func (c *Client) Validate(ctx context.Context, alias string) error {
+	account, err := c.config.GetAccount(alias)
+	if err != nil {
+		return fmt.Errorf("account '%s': %w", alias, err)
+	}
+
+	var problems []error
+	if err := account.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if account.SSHKeyPath != "" {
+		if _, err := pathutil.Stat(account.SSHKeyPath); err != nil {
+			problems = append(problems, fmt.Errorf("SSH key %w", err))
+		} else if info, err := ssh.NewManager().ValidateKey(ctx, account.SSHKeyPath); err != nil {
+			problems = append(problems, fmt.Errorf("SSH key %s: %w", account.SSHKeyPath, err))
+		} else if weakness := info.Weakness(); weakness != "" {
+			problems = append(problems, fmt.Errorf("weak SSH key %s (%s, %d bits): %s", account.SSHKeyPath, info.Type, info.Bits, weakness))
+		}
+	} else if account.SSHKeyFingerprint != "" {
+		if info, err := ssh.NewManager().FindAgentKey(ctx, account.SSHKeyFingerprint); err != nil {
+			problems = append(problems, fmt.Errorf("SSH key: %w", err))
+		} else if weakness := info.Weakness(); weakness != "" {
+			problems = append(problems, fmt.Errorf("weak SSH key %s (%s, %d bits): %s", info.Fingerprint, info.Type, info.Bits, weakness))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// GenerateAndInstallSSHConfig writes the managed Host block for the account configured with
+// alias into ~/.ssh/config, replacing the block for its domain and leaving the rest of the
+// file intact, and returns the block. The previous file is backed up, and nothing is
+// written when ssh rejects the new config. The SSH agent is left alone.
+func (c *Client) GenerateAndInstallSSHConfig(ctx context.Context, alias string) (string, error) {
+	account, err := c.config.GetAccount(alias)
+	if err != nil {
+		return "", fmt.Errorf("account '%s': %w", alias, err)
+	}
+	if account.SSHKeyPath == "" {
+		return "", fmt.Errorf("account '%s': %w", alias, ErrNoSSHKey)
+	}
+
+	sshManager := c.sshManager()
+	matches := ssh.MatchIdentities(c.config.ListAccounts(), account)
+	preview, err := sshManager.PreviewSSHConfig(account.Alias, account.SSHKeyPath, account.GetDomain(), matches...)
+	if err != nil {
+		return "", err
+	}
+	if err := sshManager.UpdateSSHConfig(account.Alias, account.SSHKeyPath, account.GetDomain(), matches...); err != nil {
+		return "", err
+	}
+	return preview.Block, nil
+}
+
+// sshManager returns an SSH manager that writes the Host block selected in the ssh_config
+// settings and reports to the client's output
+func (c *Client) sshManager() *ssh.Manager {
+	sshManager := switcher.NewSSHManager(c.config)
+	sshManager.SetOutput(c.out)
+	return sshManager
+}
+
+func (c *Client) toAccount(account *models.Account) Account {
+	return Account{
+		Alias:             account.Alias,
+		Name:              account.Name,
+		Email:             account.Email,
+		Platform:          account.GetPlatform(),
+		Domain:            account.GetDomain(),
+		Username:          account.GetUsername(),
+		SSHKeyPath:        account.SSHKeyPath,
+		SSHKeyFingerprint: account.SSHKeyFingerprint,
+		Default:           account.IsDefault,
+		Current:           account.Alias == c.config.GetConfig().CurrentAccount,
+		LastUsed:          account.LastUsed,
+	}
+}
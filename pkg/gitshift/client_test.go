@@ -0,0 +1,105 @@
+package gitshift
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/techishthoughts/gitshift/internal/models"
+)
+
+// newTestClient returns a client for content written as the config file of a fresh home
+func newTestClient(t *testing.T, content string) (*Client, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	dir := filepath.Join(home, ".config", "gitshift")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c, home
+}
+
+const testConfig = `version: 1
+current_account: work
+accounts:
+  work:
+    alias: work
+    name: Dev
+    email: dev@work.com
+    ssh_key_path: ~/.ssh/id_work
+  personal:
+    alias: personal
+    name: Dev
+    email: dev@home.org
+    github_username: dev
+    is_default: true
+    ssh_match:
+      - exec "pwd | grep -q /src/oss/"
+    ssh_key_path: /keys/id_personal
+`
+
+func TestClient_ListAccounts(t *testing.T) {
+	c, _ := newTestClient(t, testConfig)
+
+	accounts := c.ListAccounts()
+	if len(accounts) != 2 || accounts[0].Alias != "personal" || accounts[1].Alias != "work" {
+		t.Fatalf("ListAccounts() = %+v, want personal and work in that order", accounts)
+	}
+	personal, work := accounts[0], accounts[1]
+	if !personal.Default || personal.Current || personal.Username != "dev" || personal.Domain != "github.com" {
+		t.Errorf("ListAccounts() personal = %+v", personal)
+	}
+	if work.Default || !work.Current {
+		t.Errorf("ListAccounts() work = %+v, want the current, non-default account", work)
+	}
+
+	if _, err := c.Account("gone"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Account() of a missing alias = %v, want ErrAccountNotFound", err)
+	}
+}
+
+func TestClient_Validate(t *testing.T) {
+	c, home := newTestClient(t, testConfig)
+
+	err := c.Validate(context.Background(), "work")
+	if err == nil || !strings.Contains(err.Error(), "SSH key ~/.ssh/id_work (expanded to "+filepath.Join(home, ".ssh", "id_work")+")") {
+		t.Errorf("Validate() with a missing key = %v", err)
+	}
+	if err := c.Validate(context.Background(), "gone"); !errors.Is(err, models.ErrAccountNotFound) {
+		t.Errorf("Validate() of a missing alias = %v", err)
+	}
+}
+
+func TestClient_GenerateAndInstallSSHConfig(t *testing.T) {
+	c, home := newTestClient(t, testConfig)
+
+	block, err := c.GenerateAndInstallSSHConfig(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("GenerateAndInstallSSHConfig() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(home, ".ssh", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), block) {
+		t.Errorf("~/.ssh/config doesn't hold the returned block:\n%s", content)
+	}
+	// The other account's ssh_match criteria are part of the block
+	if !strings.Contains(block, "/keys/id_personal") || !strings.Contains(block, filepath.Join(home, ".ssh", "id_work")) {
+		t.Errorf("GenerateAndInstallSSHConfig() block = %s", block)
+	}
+}
@@ -0,0 +1,42 @@
+package gitshift_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/techishthoughts/gitshift/pkg/gitshift"
+)
+
+func Example() {
+	client, err := gitshift.New(gitshift.WithOutput(os.Stderr))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, account := range client.ListAccounts() {
+		fmt.Printf("%s <%s> on %s\n", account.Alias, account.Email, account.Domain)
+	}
+
+	ctx := context.Background()
+	if err := client.Validate(ctx, "work"); err != nil {
+		log.Fatalf("account 'work' can't be used: %v", err)
+	}
+	if err := client.Switch(ctx, "work", gitshift.SwitchOptions{Offline: true}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleClient_GenerateAndInstallSSHConfig() {
+	client, err := gitshift.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	block, err := client.GenerateAndInstallSSHConfig(context.Background(), "work")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(block)
+}
@@ -0,0 +1,29 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// endpointCheckTimeout bounds how long CheckEndpoint waits for the API to answer
+const endpointCheckTimeout = 10 * time.Second
+
+// CheckEndpoint verifies that an API endpoint answers over HTTP. Any response counts,
+// including 401 and 404, since the check runs before a token is configured.
+func CheckEndpoint(ctx context.Context, endpoint string) error {
+	ctx, cancel := context.WithTimeout(ctx, endpointCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("invalid API endpoint %s: %w", endpoint, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("API endpoint %s is not reachable: %w", endpoint, err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/techishthoughts/gitshift/pkg/gh"
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 // GitHubPlatform implements the Platform interface for GitHub
@@ -149,7 +150,7 @@ func (p *GitHubPlatform) TestSSHConnection(keyPath string) error {
 		return nil
 	}
 
-	return fmt.Errorf("SSH connection test failed: %w\nOutput: %s", err, outputStr)
+	return fmt.Errorf("SSH connection test failed: %w\nOutput: %s", err, redact.Secrets(outputStr))
 }
 
 // GetAPIClient returns a GitHub API client
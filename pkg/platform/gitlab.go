@@ -6,6 +6,8 @@ import (
 	"net/url"
 	"os/exec"
 	"strings"
+
+	"github.com/techishthoughts/gitshift/pkg/redact"
 )
 
 // GitLabPlatform implements the Platform interface for GitLab
@@ -166,7 +168,7 @@ func (p *GitLabPlatform) TestSSHConnection(keyPath string) error {
 		return nil
 	}
 
-	return fmt.Errorf("SSH connection test failed: %w\nOutput: %s", err, outputStr)
+	return fmt.Errorf("SSH connection test failed: %w\nOutput: %s", err, redact.Secrets(outputStr))
 }
 
 // GetAPIClient returns a GitLab API client
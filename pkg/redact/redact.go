@@ -0,0 +1,24 @@
+// Package redact masks credentials in text gitshift didn't write itself, such as the output
+// of ssh, git and gh, before it ends up in an error message or on the terminal.
+package redact
+
+import "regexp"
+
+// Mask replaces the secret part of a redacted credential
+const Mask = "[REDACTED]"
+
+var (
+	// tokenPattern matches GitHub personal, OAuth, user, server and refresh tokens, GitHub
+	// fine-grained tokens and GitLab personal, deploy, runner and OAuth tokens. The
+	// prefix is kept so the message still says what kind of token it was.
+	tokenPattern = regexp.MustCompile(`\b(gh[pousr]_|github_pat_|glpat-|gldt-|glrt-|gloas-)[A-Za-z0-9_\-]+`)
+
+	// pemPattern matches a PEM block, or its beginning when the output was cut short
+	pemPattern = regexp.MustCompile(`-----BEGIN ([A-Z0-9 ]+)-----[\s\S]*?(?:-----END [A-Z0-9 ]+-----|$)`)
+)
+
+// Secrets returns s with known token formats and PEM blocks masked
+func Secrets(s string) string {
+	s = pemPattern.ReplaceAllString(s, "-----BEGIN $1-----"+Mask+"-----END $1-----")
+	return tokenPattern.ReplaceAllString(s, "$1"+Mask)
+}
@@ -0,0 +1,110 @@
+// Package retry calls an operation again when it fails with an error worth retrying,
+// waiting between attempts as a Policy prescribes.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns how long to wait before retry number retry (1 before the second attempt)
+// of an operation that just failed with err
+type Backoff func(retry int, err error) time.Duration
+
+// Constant waits d before every retry
+func Constant(d time.Duration) Backoff {
+	return func(int, error) time.Duration {
+		return d
+	}
+}
+
+// Exponential waits base before the first retry and doubles the wait for every further one.
+// A random jitter of up to jitter times the wait is added, so clients that failed together
+// don't retry in lockstep.
+func Exponential(base time.Duration, jitter float64) Backoff {
+	return func(retry int, _ error) time.Duration {
+		wait := base << uint(max(retry-1, 0))
+		if spread := int64(float64(wait) * jitter); spread > 0 {
+			wait += time.Duration(rand.Int64N(spread + 1))
+		}
+		return wait
+	}
+}
+
+// Policy controls how Do retries an operation
+type Policy struct {
+	// MaxAttempts is how many times the operation is attempted, including the first; values
+	// below 1 mean a single attempt
+	MaxAttempts int
+
+	// Backoff is the wait before each retry; nil retries immediately
+	Backoff Backoff
+
+	// IsRetryable reports whether an error may go away on another attempt; nil retries
+	// every error
+	IsRetryable func(err error) bool
+
+	// OnRetry, when set, is told about each retry before the wait
+	OnRetry func(retry int, err error, wait time.Duration)
+}
+
+// AttemptsError is returned when every attempt failed with a retryable error
+type AttemptsError struct {
+	Attempts int
+	Err      error // the error of the last attempt
+}
+
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("after %d attempts, last error: %v", e.Attempts, e.Err)
+}
+
+func (e *AttemptsError) Unwrap() error {
+	return e.Err
+}
+
+// sleep waits for d or until ctx is done; tests replace it
+var sleep = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error the policy doesn't retry, or
+// policy.MaxAttempts attempts failed, in which case it returns an *AttemptsError. When ctx
+// is done while waiting to retry, Do returns the context's error.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	attempts := max(policy.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if policy.IsRetryable != nil && !policy.IsRetryable(err) {
+			return err
+		}
+		if attempt == attempts {
+			return &AttemptsError{Attempts: attempts, Err: err}
+		}
+
+		var wait time.Duration
+		if policy.Backoff != nil {
+			wait = policy.Backoff(attempt, err)
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, wait)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// recordSleeps replaces sleep for the test with one that returns at once and records the
+// waits it was asked for
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	original := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = original })
+	return &waits
+}
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		jitter   float64
+		retry    int
+		min, max time.Duration
+	}{
+		{"first retry", time.Second, 0, 1, time.Second, time.Second},
+		{"doubles", time.Second, 0, 3, 4 * time.Second, 4 * time.Second},
+		{"jitter adds up to half", time.Second, 0.5, 2, 2 * time.Second, 3 * time.Second},
+		{"retry zero is treated as the first", 100 * time.Millisecond, 0, 0, 100 * time.Millisecond, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backoff := Exponential(tt.base, tt.jitter)
+			for i := 0; i < 20; i++ {
+				if got := backoff(tt.retry, nil); got < tt.min || got > tt.max {
+					t.Fatalf("Exponential(%v, %v)(%d) = %v, want within [%v, %v]", tt.base, tt.jitter, tt.retry, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTransient := errors.New("connection reset")
+	errFatal := errors.New("401 Unauthorized")
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		failures  []error // errors of the first attempts; later attempts succeed
+		wantCalls int
+		wantWaits []time.Duration
+		wantErr   error
+		exhausted bool
+	}{
+		{
+			name:      "succeeds at once",
+			policy:    Policy{MaxAttempts: 3, Backoff: Constant(time.Second)},
+			wantCalls: 1,
+		},
+		{
+			name:      "retries with backoff until it succeeds",
+			policy:    Policy{MaxAttempts: 3, Backoff: Exponential(time.Second, 0)},
+			failures:  []error{errTransient, errTransient},
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "gives up after max attempts",
+			policy:    Policy{MaxAttempts: 2, Backoff: Constant(time.Second)},
+			failures:  []error{errTransient, errTransient, errTransient},
+			wantCalls: 2,
+			wantWaits: []time.Duration{time.Second},
+			wantErr:   errTransient,
+			exhausted: true,
+		},
+		{
+			name: "stops on an error that isn't retryable",
+			policy: Policy{MaxAttempts: 3, Backoff: Constant(time.Second),
+				IsRetryable: func(err error) bool { return err == errTransient }},
+			failures:  []error{errTransient, errFatal},
+			wantCalls: 2,
+			wantWaits: []time.Duration{time.Second},
+			wantErr:   errFatal,
+		},
+		{
+			name:      "zero attempts still tries once",
+			policy:    Policy{},
+			failures:  []error{errTransient},
+			wantCalls: 1,
+			wantErr:   errTransient,
+			exhausted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waits := recordSleeps(t)
+			calls := 0
+			err := Do(context.Background(), tt.policy, func(context.Context) error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+			if len(*waits) != len(tt.wantWaits) {
+				t.Fatalf("waited %v, want %v", *waits, tt.wantWaits)
+			}
+			for i, wait := range tt.wantWaits {
+				if (*waits)[i] != wait {
+					t.Errorf("wait %d = %v, want %v", i+1, (*waits)[i], wait)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Do() = %v, want %v", err, tt.wantErr)
+			}
+			var attemptsErr *AttemptsError
+			if got := errors.As(err, &attemptsErr); got != tt.exhausted {
+				t.Errorf("Do() returned an *AttemptsError: %v, want %v", got, tt.exhausted)
+			}
+		})
+	}
+}
+
+func TestDo_StopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	retries := 0
+	policy := Policy{
+		MaxAttempts: 5,
+		Backoff:     Constant(time.Hour),
+		OnRetry:     func(int, error, time.Duration) { retries++ },
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- Do(ctx, policy, func(context.Context) error {
+			calls++
+			return errors.New("connection reset")
+		})
+	}()
+
+	// Do is now waiting an hour before the second attempt
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Do() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Do() kept waiting after the context was cancelled")
+	}
+	if calls != 1 || retries != 1 {
+		t.Errorf("fn called %d times with %d retries, want 1 and 1", calls, retries)
+	}
+}